@@ -0,0 +1,313 @@
+package apitoken
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	roleservice "cacto-cms/app/application/role"
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/apitoken"
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/domain/role"
+	"cacto-cms/app/shared/auth"
+	"cacto-cms/app/shared/errors"
+)
+
+const (
+	// DefaultExpiry applies when a token is created without an explicit lifetime
+	DefaultExpiry = 90 * 24 * time.Hour
+	// MaxExpiryDays is the longest lifetime a token can be created with
+	MaxExpiryDays = 365
+	// lastUsedResolution limits last-used writes to one per token per interval
+	lastUsedResolution = time.Minute
+	// rejectedAuditWindow limits rejected-token audit entries to one per
+	// token (or unknown token), IP and reason per interval
+	rejectedAuditWindow = 10 * time.Minute
+	// maxRejectedTracked bounds the rejections remembered within a window;
+	// beyond it rejections are only logged
+	maxRejectedTracked = 10000
+)
+
+// Service handles business logic for API tokens
+type Service struct {
+	repo        apitoken.Repository
+	userService *userservice.Service
+	roleService *roleservice.Service
+	audit       *auditservice.Service
+
+	mu       sync.Mutex
+	rejected map[rejectionKey]*rejection
+}
+
+// rejectionKey groups rejected requests for auditing. Unknown tokens share
+// the empty prefix, so made-up tokens can't multiply the entries.
+type rejectionKey struct {
+	prefix    string
+	ipAddress string
+	reason    string
+}
+
+// rejection counts the rejected requests since the last audit entry
+type rejection struct {
+	audited    time.Time
+	suppressed int
+}
+
+// NewService creates a new API token service
+func NewService(repo apitoken.Repository, userService *userservice.Service, roleService *roleservice.Service, auditService *auditservice.Service) *Service {
+	return &Service{
+		repo:        repo,
+		userService: userService,
+		roleService: roleService,
+		audit:       auditService,
+		rejected:    make(map[rejectionKey]*rejection),
+	}
+}
+
+// CreateTokenRequest represents token creation data
+type CreateTokenRequest struct {
+	Name          string   `json:"name" validate:"required,min=2,max=100"`
+	Scopes        []string `json:"scopes" validate:"required"`
+	ExpiresInDays int      `json:"expires_in_days" validate:"min=1,max=365"` // Defaults to 90 days
+}
+
+// GetTokens retrieves all tokens
+func (s *Service) GetTokens(ctx context.Context) ([]*apitoken.Token, error) {
+	tokens, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load tokens", err)
+	}
+	return tokens, nil
+}
+
+// GetUserTokens retrieves the personal tokens of a user
+func (s *Service) GetUserTokens(ctx context.Context, userID int) ([]*apitoken.Token, error) {
+	tokens, err := s.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load tokens", err)
+	}
+	return tokens, nil
+}
+
+// CreatePersonalToken creates a token acting on behalf of a user.
+// Scopes cannot exceed the permissions of the user's role.
+// The plaintext token is returned once and never stored.
+func (s *Service) CreatePersonalToken(ctx context.Context, userID int, req *CreateTokenRequest) (*apitoken.Token, string, error) {
+	owner, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return s.createToken(ctx, apitoken.KindPersonal, &owner.ID, userID, string(owner.Role), req)
+}
+
+// CreateServiceToken creates a token for an integration that is not tied to a person.
+// Scopes cannot exceed the permissions of the creator's role.
+// The plaintext token is returned once and never stored.
+func (s *Service) CreateServiceToken(ctx context.Context, creatorID int, creatorRole string, req *CreateTokenRequest) (*apitoken.Token, string, error) {
+	return s.createToken(ctx, apitoken.KindService, nil, creatorID, creatorRole, req)
+}
+
+// createToken validates the request and stores a new token
+func (s *Service) createToken(ctx context.Context, kind apitoken.Kind, ownerID *int, creatorID int, grantorRole string, req *CreateTokenRequest) (*apitoken.Token, string, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, "", errors.NewValidation("name is required")
+	}
+
+	scopes, err := s.roleService.ValidatePermissions(ctx, req.Scopes)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(scopes) == 0 {
+		return nil, "", errors.NewValidation("at least one scope is required")
+	}
+
+	granted := s.roleService.PermissionsFor(ctx, grantorRole)
+	for _, scope := range scopes {
+		if !role.Grants(granted, scope) {
+			return nil, "", errors.NewForbidden("Scope not granted by your role: " + scope)
+		}
+	}
+
+	expiry := DefaultExpiry
+	if req.ExpiresInDays > 0 {
+		if req.ExpiresInDays > MaxExpiryDays {
+			return nil, "", errors.NewValidation("expires_in_days must be at most 365")
+		}
+		expiry = time.Duration(req.ExpiresInDays) * 24 * time.Hour
+	}
+
+	short := "pat"
+	if kind == apitoken.KindService {
+		short = "svc"
+	}
+	plaintext, prefix, hash, err := auth.GenerateAPIToken(short)
+	if err != nil {
+		return nil, "", errors.NewInternal("Failed to generate token", err)
+	}
+
+	now := time.Now().UTC()
+	t := &apitoken.Token{
+		Name:      name,
+		Kind:      kind,
+		UserID:    ownerID,
+		CreatedBy: &creatorID,
+		Prefix:    prefix,
+		TokenHash: hash,
+		Scopes:    scopes,
+		ExpiresAt: now.Add(expiry),
+		CreatedAt: now,
+	}
+
+	if err := s.repo.Create(ctx, t); err != nil {
+		return nil, "", errors.NewInternal("Failed to create token", err)
+	}
+
+	log.Printf("🔑 API token created: %s (%s, %s) by user %d", t.Prefix, t.Kind, strings.Join(scopes, " "), creatorID)
+	s.audit.Record(ctx, "token.created", "api_token", t.ID, nil, summary(t))
+	return t, plaintext, nil
+}
+
+// RevokeToken revokes any token
+func (s *Service) RevokeToken(ctx context.Context, id int) error {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return errors.Wrap(err, errors.ErrCodeNotFound, "Token not found", 404)
+	}
+
+	if err := s.repo.Revoke(ctx, id, time.Now()); err != nil {
+		return errors.NewInternal("Failed to revoke token", err)
+	}
+
+	log.Printf("🔑 API token revoked: %d", id)
+	s.audit.Record(ctx, "token.revoked", "api_token", t.ID, summary(t), nil)
+	return nil
+}
+
+// RevokeUserToken revokes one of a user's personal tokens
+func (s *Service) RevokeUserToken(ctx context.Context, userID, id int) error {
+	t, err := s.repo.FindByID(ctx, id)
+	if err != nil || !t.IsOwnedBy(userID) {
+		return errors.NewNotFound("Token not found")
+	}
+
+	return s.RevokeToken(ctx, id)
+}
+
+// AuthenticateToken validates a plaintext API token and returns who it acts for.
+// Personal tokens stop working as soon as their owner is deactivated.
+func (s *Service) AuthenticateToken(ctx context.Context, plaintext, ipAddress string) (*auth.TokenPrincipal, error) {
+	t, err := s.repo.FindByHash(ctx, auth.HashAPIToken(plaintext))
+	if err != nil {
+		s.recordRejected(ctx, nil, ipAddress, "unknown token")
+		return nil, errors.NewUnauthorized("Invalid API token")
+	}
+
+	now := time.Now()
+	if !t.IsActive(now) {
+		s.recordRejected(ctx, t, ipAddress, "expired or revoked")
+		return nil, errors.NewUnauthorized("API token expired or revoked")
+	}
+
+	principal := &auth.TokenPrincipal{
+		TokenID: t.ID,
+		Scopes:  t.Scopes,
+	}
+
+	if t.Kind == apitoken.KindPersonal {
+		if t.UserID == nil {
+			return nil, errors.NewUnauthorized("Invalid API token")
+		}
+		owner, err := s.userService.GetUserByID(ctx, *t.UserID)
+		if err != nil || !owner.IsActive {
+			s.recordRejected(ctx, t, ipAddress, "owner inactive")
+			return nil, errors.NewUnauthorized("API token owner is inactive")
+		}
+		principal.UserID = owner.ID
+		principal.Email = owner.Email
+		principal.Role = string(owner.Role)
+	}
+
+	if t.LastUsedAt == nil || now.Sub(*t.LastUsedAt) >= lastUsedResolution || t.LastUsedIP != ipAddress {
+		if err := s.repo.TouchLastUsed(ctx, t.ID, now, ipAddress); err != nil {
+			log.Printf("Failed to record API token use for %s: %v", t.Prefix, err)
+		}
+	}
+
+	return principal, nil
+}
+
+// recordRejected audits a request made with an unusable API token. Repeated
+// rejections of the same token from the same IP are audited once per
+// rejectedAuditWindow; the next entry counts the ones left out.
+func (s *Service) recordRejected(ctx context.Context, t *apitoken.Token, ipAddress, reason string) {
+	key := rejectionKey{ipAddress: ipAddress, reason: reason}
+	if t != nil {
+		key.prefix = t.Prefix
+	}
+	suppressed, ok := s.throttleRejected(key, time.Now())
+	if !ok {
+		return
+	}
+
+	actor := audit.Actor{IPAddress: ipAddress}
+	var targetID interface{}
+	after := map[string]interface{}{"reason": reason}
+	if t != nil {
+		actor.TokenID = &t.ID
+		targetID = t.ID
+		after["prefix"] = t.Prefix
+	}
+	if suppressed > 0 {
+		after["repeated"] = suppressed
+	}
+	s.audit.RecordAs(ctx, actor, "auth.token_rejected", "api_token", targetID, nil, after)
+}
+
+// throttleRejected reports whether a rejection is audited, and how many
+// rejections with the same key were left out since the last entry
+func (s *Service) throttleRejected(key rejectionKey, now time.Time) (int, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r, ok := s.rejected[key]; ok {
+		if now.Sub(r.audited) < rejectedAuditWindow {
+			r.suppressed++
+			return 0, false
+		}
+		suppressed := r.suppressed
+		r.audited, r.suppressed = now, 0
+		return suppressed, true
+	}
+
+	if len(s.rejected) >= maxRejectedTracked {
+		for k, r := range s.rejected {
+			if now.Sub(r.audited) >= rejectedAuditWindow {
+				delete(s.rejected, k)
+			}
+		}
+		if len(s.rejected) >= maxRejectedTracked {
+			log.Printf("⚠️  API token rejected (%s) from %s; audit entries throttled", key.reason, key.ipAddress)
+			return 0, false
+		}
+	}
+	s.rejected[key] = &rejection{audited: now}
+	return 0, true
+}
+
+// summary describes a token in audit entries (never includes the hash)
+func summary(t *apitoken.Token) map[string]interface{} {
+	return map[string]interface{}{
+		"name":       t.Name,
+		"kind":       t.Kind,
+		"prefix":     t.Prefix,
+		"user_id":    t.UserID,
+		"scopes":     t.Scopes,
+		"expires_at": t.ExpiresAt,
+	}
+}
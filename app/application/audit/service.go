@@ -0,0 +1,172 @@
+package audit
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/shared/errors"
+)
+
+const (
+	// DefaultPageSize is the number of entries returned per page
+	DefaultPageSize = 50
+	// MaxPageSize is the largest page that can be requested
+	MaxPageSize = 500
+	// MaxExportRows caps a single CSV export
+	MaxExportRows = 100000
+)
+
+// Service records and queries the audit log
+type Service struct {
+	repo audit.Repository
+}
+
+// NewService creates a new audit service
+func NewService(repo audit.Repository) *Service {
+	return &Service{repo: repo}
+}
+
+// Record appends an entry attributed to the actor carried by the context.
+// before and after are summaries of the target (marshalled to JSON, nil
+// for none). Failures are logged rather than returned so that a broken
+// audit write never turns a completed action into an error.
+func (s *Service) Record(ctx context.Context, action, targetType string, targetID interface{}, before, after interface{}) {
+	s.RecordAs(ctx, audit.ActorFromContext(ctx), action, targetType, targetID, before, after)
+}
+
+// RecordAs appends an entry attributed to an explicit actor, for events
+// where the request has no signed-in user yet (e.g. login)
+func (s *Service) RecordAs(ctx context.Context, actor audit.Actor, action, targetType string, targetID interface{}, before, after interface{}) {
+	e := &audit.Entry{
+		ActorID:    actor.UserID,
+		ActorEmail: actor.Email,
+		TokenID:    actor.TokenID,
+		Action:     action,
+		TargetType: targetType,
+		Before:     summarize(before),
+		After:      summarize(after),
+		IPAddress:  actor.IPAddress,
+		UserAgent:  actor.UserAgent,
+		CreatedAt:  time.Now().UTC(),
+
+		ImpersonatorID:    actor.ImpersonatorID,
+		ImpersonatorEmail: actor.ImpersonatorEmail,
+	}
+	if targetID != nil {
+		e.TargetID = fmt.Sprint(targetID)
+	}
+
+	// The change has already happened; record it even if the client has gone
+	if err := s.repo.Append(context.WithoutCancel(ctx), e); err != nil {
+		log.Printf("⚠️  Failed to write audit entry %s %s/%s: %v", action, e.TargetType, e.TargetID, err)
+	}
+}
+
+// Search returns a page of entries matching the filter and the total number of matches
+func (s *Service) Search(ctx context.Context, filter audit.Filter) ([]*audit.Entry, int, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultPageSize
+	}
+	if filter.Limit > MaxPageSize {
+		filter.Limit = MaxPageSize
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	total, err := s.repo.Count(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.NewInternal("Failed to count audit entries", err)
+	}
+
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, 0, errors.NewInternal("Failed to load audit entries", err)
+	}
+
+	return entries, total, nil
+}
+
+// Export returns all entries matching the filter (up to MaxExportRows), newest first
+func (s *Service) Export(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	filter.Limit = MaxExportRows
+	filter.Offset = 0
+
+	entries, err := s.repo.Find(ctx, filter)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load audit entries", err)
+	}
+	return entries, nil
+}
+
+// WriteCSV writes entries as CSV with a header row
+func WriteCSV(w io.Writer, entries []*audit.Entry) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{
+		"id", "created_at", "actor_id", "actor_email", "token_id", "action",
+		"target_type", "target_id", "before", "after", "ip_address", "user_agent",
+		"impersonator_id", "impersonator_email",
+	})
+
+	for _, e := range entries {
+		cw.Write([]string{
+			strconv.Itoa(e.ID),
+			e.CreatedAt.UTC().Format(time.RFC3339),
+			optionalID(e.ActorID),
+			csvSafe(e.ActorEmail),
+			optionalID(e.TokenID),
+			csvSafe(e.Action),
+			csvSafe(e.TargetType),
+			csvSafe(e.TargetID),
+			csvSafe(e.Before),
+			csvSafe(e.After),
+			csvSafe(e.IPAddress),
+			csvSafe(e.UserAgent),
+			optionalID(e.ImpersonatorID),
+			csvSafe(e.ImpersonatorEmail),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// summarize marshals a before/after summary, returning an empty string for nil
+func summarize(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	if s, ok := v.(string); ok {
+		return s
+	}
+
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// optionalID formats a nullable ID for CSV
+func optionalID(id *int) string {
+	if id == nil {
+		return ""
+	}
+	return strconv.Itoa(*id)
+}
+
+// csvSafe neutralizes values that spreadsheet applications would run as formulas
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
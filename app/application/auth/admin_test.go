@@ -0,0 +1,52 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/errors"
+)
+
+func TestLastAdminSurvivesConcurrentChanges(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	admins := []*user.User{
+		env.createUser(t, "first@example.com", user.RoleAdmin),
+		env.createUser(t, "second@example.com", user.RoleAdmin),
+	}
+
+	// Each admin is demoted while the other is deactivated: only one can win
+	var wg sync.WaitGroup
+	errs := make([]error, len(admins))
+	for i, u := range admins {
+		wg.Add(1)
+		go func(i int, u *user.User) {
+			defer wg.Done()
+			if i == 0 {
+				_, errs[i] = env.users.ChangeRole(ctx, u.ID, string(user.RoleEditor))
+			} else {
+				_, errs[i] = env.users.SetActive(ctx, u.ID, false)
+			}
+		}(i, u)
+	}
+	wg.Wait()
+
+	if n := env.countRows(t, "users", "role = ? AND is_active = 1", user.RoleAdmin); n != 1 {
+		t.Fatalf("%d active admins left (errors %v), want 1", n, errs)
+	}
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			failed++
+			if status := errors.AsAppError(err).HTTPStatus; status != http.StatusConflict {
+				t.Errorf("losing change failed with status %d (%v), want %d", status, err, http.StatusConflict)
+			}
+		}
+	}
+	if failed != 1 {
+		t.Errorf("%d changes failed, want 1", failed)
+	}
+}
@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	roleservice "cacto-cms/app/application/role"
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+	auditpersistence "cacto-cms/app/infrastructure/persistence/audit"
+	rolepersistence "cacto-cms/app/infrastructure/persistence/role"
+	userpersistence "cacto-cms/app/infrastructure/persistence/user"
+	"cacto-cms/app/shared/errors"
+)
+
+// testEnv is an auth service on a migrated database of its own
+type testEnv struct {
+	db    *sql.DB
+	auth  *Service
+	users *userservice.Service
+}
+
+// newTestEnv creates an auth service wired like the server's, on a fresh
+// database in a temporary directory
+func newTestEnv(t *testing.T) *testEnv {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "cacto.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	migrator, err := db.Migrator()
+	if err != nil {
+		t.Fatalf("migrator: %v", err)
+	}
+	if _, err := migrator.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	audit := auditservice.NewService(auditpersistence.NewRepository(db.DB))
+	roles := roleservice.NewService(rolepersistence.NewRepository(db.DB), audit)
+	users := userservice.NewService(userpersistence.NewRepository(db.DB), userpersistence.NewPasswordHistoryRepository(db.DB), database.NewUnitOfWork(db.DB), roles, audit)
+	auth := NewService(users, roles, audit,
+		userpersistence.NewLockoutRepository(db.DB), userpersistence.NewSessionRepository(db.DB),
+		"test-secret-that-is-long-enough-for-jwt", time.Hour)
+
+	return &testEnv{db: db.DB, auth: auth, users: users}
+}
+
+// createUser creates an active user with a generated password
+func (e *testEnv) createUser(t *testing.T, email string, role user.Role) *user.User {
+	t.Helper()
+
+	u, _, err := e.users.InviteUser(context.Background(), &userservice.InviteUserRequest{
+		Email: email,
+		Name:  "Test User",
+		Role:  string(role),
+	})
+	if err != nil {
+		t.Fatalf("create user %s: %v", email, err)
+	}
+	return u
+}
+
+// countRows counts the rows of a table matching a condition
+func (e *testEnv) countRows(t *testing.T, table, where string, args ...interface{}) int {
+	t.Helper()
+
+	var n int
+	if err := e.db.QueryRow("SELECT COUNT(*) FROM "+table+" WHERE "+where, args...).Scan(&n); err != nil {
+		t.Fatalf("count %s: %v", table, err)
+	}
+	return n
+}
+
+// expectError fails unless err is an application error with the given status
+func expectError(t *testing.T, err error, status int) {
+	t.Helper()
+
+	if err == nil {
+		t.Fatalf("expected an error with status %d, got none", status)
+	}
+	if got := errors.AsAppError(err).HTTPStatus; got != status {
+		t.Fatalf("expected status %d, got %d (%v)", status, got, err)
+	}
+}
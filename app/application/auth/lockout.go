@@ -0,0 +1,271 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/errors"
+)
+
+// LockoutPolicy defines per-account brute-force protection settings
+type LockoutPolicy struct {
+	FreeAttempts     int           // Failed attempts allowed before delays kick in
+	BaseDelay        time.Duration // Delay after the first throttled failure, doubled on each further failure
+	MaxDelay         time.Duration // Upper bound for the progressive delay
+	LockoutThreshold int           // Failed attempts that trigger a temporary lockout
+	LockoutDuration  time.Duration // How long a lockout lasts
+	Window           time.Duration // Failures older than this are forgotten
+}
+
+// attemptPruneInterval is how often the scheduled pruning deletes login
+// attempts that fell out of the window
+const attemptPruneInterval = 24 * time.Hour
+
+// DefaultLockoutPolicy returns the default brute-force protection settings
+func DefaultLockoutPolicy() LockoutPolicy {
+	return LockoutPolicy{
+		FreeAttempts:     3,
+		BaseDelay:        1 * time.Second,
+		MaxDelay:         30 * time.Second,
+		LockoutThreshold: 10,
+		LockoutDuration:  15 * time.Minute,
+		Window:           1 * time.Hour,
+	}
+}
+
+// delayFor returns the delay required after the given number of consecutive failures
+func (p LockoutPolicy) delayFor(failures int) time.Duration {
+	if failures < p.FreeAttempts {
+		return 0
+	}
+
+	delay := p.BaseDelay
+	for i := p.FreeAttempts; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay
+}
+
+// LoginMeta carries request details recorded with each login attempt
+type LoginMeta struct {
+	IPAddress string
+	UserAgent string
+}
+
+// normalizeEmail returns the key used to track attempts for an account
+func normalizeEmail(email string) string {
+	return user.NormalizeEmail(email)
+}
+
+// loginAttempt is an attempt recorded as failed before the credentials are
+// checked, so that concurrent attempts are counted against each other
+type loginAttempt struct {
+	id       int
+	email    string
+	meta     LoginMeta
+	failures int // Failed attempts recorded before this one
+	now      time.Time
+}
+
+// beginAttempt records a failed attempt for an account, then rejects it if
+// the account is locked or still within its progressive delay. Only the
+// attempts recorded before this one count, so a burst of concurrent guesses
+// is throttled as if they had been made one after the other. A rejected
+// attempt is removed again and does not count.
+func (s *Service) beginAttempt(ctx context.Context, email string, meta LoginMeta, now time.Time) (*loginAttempt, error) {
+	lockout, err := s.lockoutRepo.FindLatestLockout(ctx, email)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to check account lockout", err)
+	}
+
+	if lockout != nil && lockout.IsActive(now) {
+		return nil, errors.NewTooManyRequests(fmt.Sprintf(
+			"Account temporarily locked. Try again in %s", retryIn(lockout.LockedUntil.Sub(now)),
+		))
+	}
+
+	// A client hanging up must not erase a failed attempt from the count
+	record := &user.LoginAttempt{
+		Email:     email,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+		CreatedAt: now,
+	}
+	if err := s.lockoutRepo.RecordAttempt(context.WithoutCancel(ctx), record); err != nil {
+		return nil, errors.NewInternal("Failed to record login attempt", err)
+	}
+	attempt := &loginAttempt{id: record.ID, email: email, meta: meta, now: now}
+
+	// Failures before the window, the last successful login, or the last
+	// lockout/unlock do not count
+	since := now.Add(-s.lockoutPolicy.Window)
+	if lockout != nil && lockout.ResetAt().After(since) {
+		since = lockout.ResetAt()
+	}
+
+	attempts, err := s.lockoutRepo.FindRecentAttempts(ctx, email, since, attempt.id, s.lockoutPolicy.LockoutThreshold)
+	if err != nil {
+		attempt.discard(ctx, s)
+		return nil, errors.NewInternal("Failed to check login attempts", err)
+	}
+
+	var lastFailure time.Time
+	for _, a := range attempts {
+		if a.Success {
+			break
+		}
+		if attempt.failures == 0 {
+			lastFailure = a.CreatedAt
+		}
+		attempt.failures++
+	}
+
+	// Attempts that raced the lockout being taken see the failures behind it
+	if attempt.failures >= s.lockoutPolicy.LockoutThreshold {
+		attempt.discard(ctx, s)
+		return nil, errors.NewTooManyRequests(fmt.Sprintf(
+			"Account temporarily locked. Try again in %s", retryIn(s.lockoutPolicy.LockoutDuration),
+		))
+	}
+
+	if delay := s.lockoutPolicy.delayFor(attempt.failures); delay > 0 {
+		if wait := lastFailure.Add(delay).Sub(now); wait > 0 {
+			attempt.discard(ctx, s)
+			return nil, errors.NewTooManyRequests(fmt.Sprintf(
+				"Too many failed login attempts. Try again in %s", retryIn(wait),
+			))
+		}
+	}
+
+	return attempt, nil
+}
+
+// discard removes a rejected attempt, so it does not count as a failure
+func (a *loginAttempt) discard(ctx context.Context, s *Service) {
+	if err := s.lockoutRepo.DeleteAttempt(context.WithoutCancel(ctx), a.id); err != nil {
+		log.Printf("Failed to remove login attempt for %s: %v", a.email, err)
+	}
+}
+
+// succeed marks the attempt successful, which resets the failure count
+func (a *loginAttempt) succeed(ctx context.Context, s *Service) {
+	if err := s.lockoutRepo.MarkAttemptSucceeded(context.WithoutCancel(ctx), a.id); err != nil {
+		log.Printf("Failed to record login attempt for %s: %v", a.email, err)
+	}
+}
+
+// fail keeps the attempt as failed and locks the account once the threshold is reached
+func (a *loginAttempt) fail(ctx context.Context, s *Service) {
+	failures := a.failures + 1
+	if failures < s.lockoutPolicy.LockoutThreshold {
+		return
+	}
+
+	lockout := &user.Lockout{
+		Email:          a.email,
+		FailedAttempts: failures,
+		IPAddress:      a.meta.IPAddress,
+		LockedUntil:    a.now.Add(s.lockoutPolicy.LockoutDuration),
+		CreatedAt:      a.now,
+	}
+	if err := s.lockoutRepo.CreateLockout(context.WithoutCancel(ctx), lockout); err != nil {
+		log.Printf("Failed to lock account %s: %v", a.email, err)
+		return
+	}
+
+	log.Printf("🔒 Account locked: %s after %d failed attempts (last IP: %s) until %s",
+		a.email, failures, a.meta.IPAddress, lockout.LockedUntil.Format(time.RFC3339))
+	s.recordAuth(ctx, "auth.account_locked", a.email, nil, a.meta, map[string]interface{}{
+		"failed_attempts": failures,
+		"locked_until":    lockout.LockedUntil.UTC(),
+	})
+}
+
+// recordAttempt stores a login attempt, logging instead of failing the login on error
+func (s *Service) recordAttempt(ctx context.Context, email string, meta LoginMeta, success bool, now time.Time) {
+	attempt := &user.LoginAttempt{
+		Email:     email,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+		Success:   success,
+		CreatedAt: now,
+	}
+	// A client hanging up must not erase a failed attempt from the count
+	if err := s.lockoutRepo.RecordAttempt(context.WithoutCancel(ctx), attempt); err != nil {
+		log.Printf("Failed to record login attempt for %s: %v", email, err)
+	}
+}
+
+// UnlockAccount lifts an active lockout and resets the failure count for an
+// account. adminID is 0 when the unlock is made with a service token.
+func (s *Service) UnlockAccount(ctx context.Context, email string, adminID int) error {
+	email = normalizeEmail(email)
+	if email == "" {
+		return errors.NewValidation("email is required")
+	}
+
+	var unlockedBy *int
+	if adminID > 0 {
+		unlockedBy = &adminID
+	}
+
+	if err := s.lockoutRepo.Unlock(ctx, email, unlockedBy, time.Now()); err != nil {
+		return errors.NewInternal("Failed to unlock account", err)
+	}
+
+	log.Printf("🔓 Account unlocked: %s by user %d", email, adminID)
+	s.audit.Record(ctx, "auth.account_unlocked", "account", email, nil, nil)
+	return nil
+}
+
+// GetLockouts retrieves the most recent lockouts, newest first
+func (s *Service) GetLockouts(ctx context.Context, limit int) ([]*user.Lockout, error) {
+	if limit <= 0 || limit > 500 {
+		limit = 100
+	}
+
+	lockouts, err := s.lockoutRepo.FindLockouts(ctx, limit)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load lockouts", err)
+	}
+	return lockouts, nil
+}
+
+// PruneLoginAttempts deletes the attempts older than the lockout window:
+// only the failures inside it count towards delays and lockouts
+func (s *Service) PruneLoginAttempts(ctx context.Context, now time.Time) (int, error) {
+	deleted, err := s.lockoutRepo.DeleteAttemptsBefore(ctx, now.Add(-s.lockoutPolicy.Window))
+	if err != nil {
+		return 0, errors.NewInternal("Failed to prune login attempts", err)
+	}
+	return deleted, nil
+}
+
+// ScheduleAttemptPruning prunes login attempts now and then every day
+func (s *Service) ScheduleAttemptPruning() {
+	go func() {
+		for {
+			deleted, err := s.PruneLoginAttempts(context.Background(), time.Now())
+			if err != nil {
+				log.Printf("❌ Login attempt pruning failed: %v", err)
+			} else if deleted > 0 {
+				log.Printf("🔐 Pruned %d old login attempt(s)", deleted)
+			}
+			time.Sleep(attemptPruneInterval)
+		}
+	}()
+}
+
+// retryIn formats a wait duration for error messages
+func retryIn(d time.Duration) string {
+	if d < time.Second {
+		d = time.Second
+	}
+	return d.Round(time.Second).String()
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/user"
+)
+
+func TestLoginIgnoresEmailCase(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+
+	t.Run("invited user", func(t *testing.T) {
+		invited, password, err := env.users.InviteUser(ctx, &userservice.InviteUserRequest{
+			Email: " Jane@Example.com",
+			Name:  "Jane",
+			Role:  string(user.RoleEditor),
+		})
+		if err != nil {
+			t.Fatalf("InviteUser: %v", err)
+		}
+		if invited.Email != "jane@example.com" {
+			t.Errorf("stored email %q, want jane@example.com", invited.Email)
+		}
+
+		resp, err := env.auth.Login(ctx, &LoginRequest{Email: "JANE@example.COM", Password: password}, LoginMeta{})
+		if err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+		if resp.User.ID != invited.ID {
+			t.Errorf("signed in as user %d, want %d", resp.User.ID, invited.ID)
+		}
+	})
+
+	t.Run("registered user", func(t *testing.T) {
+		const password = "correct-horse-battery-staple-42"
+		registered, err := env.auth.Register(ctx, &RegisterRequest{Email: "Bob@Example.com", Password: password, Name: "Bob"})
+		if err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		if registered.Email != "bob@example.com" {
+			t.Errorf("stored email %q, want bob@example.com", registered.Email)
+		}
+
+		if _, err := env.auth.Login(ctx, &LoginRequest{Email: "bob@example.com", Password: password}, LoginMeta{}); err != nil {
+			t.Fatalf("Login: %v", err)
+		}
+
+		_, err = env.auth.Register(ctx, &RegisterRequest{Email: "BOB@example.com", Password: password, Name: "Bob"})
+		expectError(t, err, http.StatusConflict)
+	})
+
+	t.Run("emails differing in case are one account", func(t *testing.T) {
+		_, err := env.db.Exec(`INSERT INTO users (email, password_hash, name, role, is_active) VALUES (?, '', 'Jane', 'viewer', 1)`, "JANE@EXAMPLE.COM")
+		if err == nil {
+			t.Error("inserted a second account for jane@example.com")
+		}
+	})
+}
+
+func TestPruneLoginAttempts(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	now := time.Now()
+
+	for _, age := range []time.Duration{2 * time.Hour, 61 * time.Minute, 30 * time.Minute} {
+		if _, err := env.db.Exec(`INSERT INTO login_attempts (email, ip_address, user_agent, success, created_at) VALUES ('jane@example.com', '', '', 0, ?)`, now.Add(-age).UTC()); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	deleted, err := env.auth.PruneLoginAttempts(ctx, now)
+	if err != nil {
+		t.Fatalf("PruneLoginAttempts: %v", err)
+	}
+	if deleted != 2 {
+		t.Errorf("pruned %d attempts, want the 2 outside the hour window", deleted)
+	}
+	if n := env.countRows(t, "login_attempts", "1 = 1"); n != 1 {
+		t.Errorf("%d attempts left, want 1", n)
+	}
+}
@@ -0,0 +1,305 @@
+package auth
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/webauthn"
+)
+
+// MaxPasskeysPerUser limits how many passkeys one account can register
+const MaxPasskeysPerUser = 10
+
+// passkeyChallenge is a registration or sign-in ceremony in progress
+type passkeyChallenge struct {
+	userID    int // 0 for sign-in, where the user is not known yet
+	expiresAt time.Time
+}
+
+// passkeys holds the passkey setup of the auth service
+type passkeys struct {
+	rp   *webauthn.RelyingParty
+	repo user.PasskeyRepository
+
+	mu      sync.Mutex
+	pending map[string]passkeyChallenge
+}
+
+// EnablePasskeys enables WebAuthn registration and passwordless sign-in
+func (s *Service) EnablePasskeys(rp *webauthn.RelyingParty, repo user.PasskeyRepository) {
+	s.passkeys = &passkeys{
+		rp:      rp,
+		repo:    repo,
+		pending: make(map[string]passkeyChallenge),
+	}
+}
+
+// PasskeysEnabled checks if passkey sign-in is configured
+func (s *Service) PasskeysEnabled() bool {
+	return s.passkeys != nil
+}
+
+// RegisterPasskeyRequest represents the result of navigator.credentials.create
+type RegisterPasskeyRequest struct {
+	Name       string                         `json:"name" validate:"max=100"`
+	Credential *webauthn.RegistrationResponse `json:"credential" validate:"required"`
+}
+
+// GetPasskeys retrieves the passkeys of a user
+func (s *Service) GetPasskeys(ctx context.Context, userID int) ([]*user.Passkey, error) {
+	if s.passkeys == nil {
+		return []*user.Passkey{}, nil
+	}
+
+	passkeys, err := s.passkeys.repo.FindByUser(ctx, userID)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load passkeys", err)
+	}
+	return passkeys, nil
+}
+
+// BeginPasskeyRegistration starts registering a passkey for a signed-in user
+func (s *Service) BeginPasskeyRegistration(ctx context.Context, userID int) (*webauthn.CreationOptions, error) {
+	if s.passkeys == nil {
+		return nil, errors.NewNotFound("Passkeys are not enabled")
+	}
+
+	u, err := s.userService.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	existing, err := s.passkeys.repo.FindByUser(ctx, u.ID)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load passkeys", err)
+	}
+	if len(existing) >= MaxPasskeysPerUser {
+		return nil, errors.NewValidation("you cannot register more than 10 passkeys")
+	}
+
+	exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+	for _, p := range existing {
+		id, err := webauthn.Decode(p.CredentialID)
+		if err != nil {
+			continue
+		}
+		exclude = append(exclude, webauthn.NewCredentialDescriptor(id, p.Transports))
+	}
+
+	opts, err := s.passkeys.rp.BeginRegistration(webauthn.User{
+		ID:          userHandle(u.ID),
+		Name:        u.Email,
+		DisplayName: u.Name,
+	}, exclude)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to start passkey registration", err)
+	}
+
+	s.passkeys.remember(opts.Challenge, u.ID)
+	return opts, nil
+}
+
+// FinishPasskeyRegistration verifies the authenticator's response and stores the passkey
+func (s *Service) FinishPasskeyRegistration(ctx context.Context, userID int, req *RegisterPasskeyRequest) (*user.Passkey, error) {
+	if s.passkeys == nil {
+		return nil, errors.NewNotFound("Passkeys are not enabled")
+	}
+
+	challenge := req.Credential.Challenge()
+	if !s.passkeys.consume(challenge, userID) {
+		return nil, errors.NewBadRequest("Passkey registration expired, please try again")
+	}
+
+	credential, err := s.passkeys.rp.VerifyRegistration(req.Credential, challenge)
+	if err != nil {
+		log.Printf("Passkey: registration rejected for user %d: %v", userID, err)
+		return nil, errors.NewBadRequest("Passkey could not be verified")
+	}
+
+	credentialID := webauthn.Encode(credential.ID)
+	if _, err := s.passkeys.repo.FindByCredentialID(ctx, credentialID); err == nil {
+		return nil, errors.NewConflict("This passkey is already registered")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		name = "Passkey"
+	}
+
+	p := &user.Passkey{
+		UserID:         userID,
+		Name:           name,
+		CredentialID:   credentialID,
+		PublicKey:      webauthn.Encode(credential.PublicKey),
+		Algorithm:      credential.Algorithm,
+		SignCount:      credential.SignCount,
+		AAGUID:         webauthn.Encode(credential.AAGUID),
+		Transports:     credential.Transports,
+		BackupEligible: credential.BackupEligible,
+		BackedUp:       credential.BackedUp,
+		CreatedAt:      time.Now().UTC(),
+	}
+	if err := s.passkeys.repo.Create(ctx, p); err != nil {
+		return nil, errors.NewInternal("Failed to save passkey", err)
+	}
+
+	log.Printf("🔑 Passkey registered: %q for user %d", p.Name, userID)
+	s.audit.Record(ctx, "auth.passkey_registered", "passkey", p.ID, nil, passkeySummary(p))
+	return p, nil
+}
+
+// DeletePasskey removes one of a user's passkeys
+func (s *Service) DeletePasskey(ctx context.Context, userID, id int) error {
+	if s.passkeys == nil {
+		return errors.NewNotFound("Passkey not found")
+	}
+
+	p, err := s.passkeys.repo.FindByID(ctx, id)
+	if err != nil || p.UserID != userID {
+		return errors.NewNotFound("Passkey not found")
+	}
+
+	if err := s.passkeys.repo.Delete(ctx, p.ID); err != nil {
+		return errors.NewInternal("Failed to delete passkey", err)
+	}
+
+	log.Printf("🔑 Passkey deleted: %q for user %d", p.Name, userID)
+	s.audit.Record(ctx, "auth.passkey_deleted", "passkey", p.ID, passkeySummary(p), nil)
+	return nil
+}
+
+// BeginPasskeyLogin starts a passwordless sign-in. No email is needed:
+// the browser offers the passkeys it holds for this site.
+func (s *Service) BeginPasskeyLogin() (*webauthn.RequestOptions, error) {
+	if s.passkeys == nil {
+		return nil, errors.NewNotFound("Passkeys are not enabled")
+	}
+
+	opts, err := s.passkeys.rp.BeginLogin(nil)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to start passkey sign-in", err)
+	}
+
+	s.passkeys.remember(opts.Challenge, 0)
+	return opts, nil
+}
+
+// FinishPasskeyLogin verifies the signed challenge and issues a JWT. Lockouts
+// apply as for passwords, and failed signatures count as failed attempts.
+func (s *Service) FinishPasskeyLogin(ctx context.Context, assertion *webauthn.AssertionResponse, meta LoginMeta) (*LoginResponse, error) {
+	if s.passkeys == nil {
+		return nil, errors.NewNotFound("Passkeys are not enabled")
+	}
+
+	challenge := assertion.Challenge()
+	if !s.passkeys.consume(challenge, 0) {
+		return nil, errors.NewUnauthorized("Passkey sign-in expired, please try again")
+	}
+
+	p, err := s.passkeys.repo.FindByCredentialID(ctx, strings.TrimRight(assertion.RawID, "="))
+	if err != nil {
+		s.recordAuth(ctx, "auth.login_failed", "", nil, meta, map[string]interface{}{"method": "passkey", "reason": "unknown passkey"})
+		return nil, errors.NewUnauthorized("Passkey is not registered")
+	}
+
+	u, err := s.userService.GetUserByID(ctx, p.UserID)
+	if err != nil {
+		return nil, errors.NewUnauthorized("Passkey is not registered")
+	}
+	email := normalizeEmail(u.Email)
+	now := time.Now()
+
+	attempt, err := s.beginAttempt(ctx, email, meta, now)
+	if err != nil {
+		s.recordAuth(ctx, "auth.login_blocked", email, u, meta, map[string]interface{}{"method": "passkey", "reason": errors.AsAppError(err).Message})
+		return nil, err
+	}
+
+	publicKey, err := webauthn.Decode(p.PublicKey)
+	if err != nil {
+		attempt.discard(ctx, s)
+		return nil, errors.NewInternal("Stored passkey is corrupt", err)
+	}
+
+	result, err := s.passkeys.rp.VerifyAssertion(assertion, challenge, publicKey, p.SignCount)
+	if err == nil && result.UserHandle != nil && string(result.UserHandle) != string(userHandle(u.ID)) {
+		err = fmt.Errorf("user handle does not match the passkey owner")
+	}
+	if err != nil {
+		log.Printf("Passkey: sign-in rejected for %s: %v", email, err)
+		s.recordAuth(ctx, "auth.login_failed", email, u, meta, map[string]interface{}{"method": "passkey", "reason": "invalid passkey signature", "passkey_id": p.ID})
+		attempt.fail(ctx, s)
+		return nil, errors.NewUnauthorized("Passkey could not be verified")
+	}
+
+	if !u.IsActive {
+		s.recordAuth(ctx, "auth.login_failed", email, u, meta, map[string]interface{}{"method": "passkey", "reason": "inactive account"})
+		return nil, errors.NewForbidden("User account is inactive")
+	}
+
+	if err := s.passkeys.repo.RecordUse(ctx, p.ID, result.SignCount, result.BackedUp, now); err != nil {
+		log.Printf("Failed to record passkey use for %s: %v", email, err)
+	}
+	attempt.succeed(ctx, s)
+	s.recordAuth(ctx, "auth.login", email, u, meta, map[string]interface{}{"method": "passkey", "passkey_id": p.ID})
+
+	token, _, err := s.issueSession(ctx, u, meta, nil, s.jwtManager.TokenDuration())
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.userService.UpdateLastLogin(ctx, u.ID)
+	u.Permissions = s.roleService.PermissionsFor(ctx, string(u.Role))
+
+	return &LoginResponse{
+		Token: token,
+		User:  u,
+	}, nil
+}
+
+// remember stores a ceremony challenge until it is answered or expires
+func (p *passkeys) remember(challenge string, userID int) {
+	now := time.Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for key, pending := range p.pending {
+		if now.After(pending.expiresAt) {
+			delete(p.pending, key)
+		}
+	}
+	p.pending[challenge] = passkeyChallenge{userID: userID, expiresAt: now.Add(p.rp.Timeout())}
+}
+
+// consume removes a challenge and reports whether it was issued to this user
+// (0 for sign-in) and has not expired. Each challenge can be answered once.
+func (p *passkeys) consume(challenge string, userID int) bool {
+	p.mu.Lock()
+	pending, ok := p.pending[challenge]
+	delete(p.pending, challenge)
+	p.mu.Unlock()
+
+	return ok && challenge != "" && pending.userID == userID && time.Now().Before(pending.expiresAt)
+}
+
+// userHandle is the opaque WebAuthn user ID stored on the authenticator
+func userHandle(userID int) []byte {
+	return binary.BigEndian.AppendUint64(nil, uint64(userID))
+}
+
+// passkeySummary describes a passkey in audit entries (never includes the key)
+func passkeySummary(p *user.Passkey) map[string]interface{} {
+	return map[string]interface{}{
+		"name":            p.Name,
+		"user_id":         p.UserID,
+		"credential_id":   p.CredentialID,
+		"backup_eligible": p.BackupEligible,
+	}
+}
@@ -0,0 +1,282 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"cacto-cms/app/domain/user"
+	userpersistence "cacto-cms/app/infrastructure/persistence/user"
+	"cacto-cms/app/shared/webauthn"
+	"cacto-cms/app/shared/webauthn/webauthntest"
+)
+
+const (
+	testRPID   = "cms.test"
+	testOrigin = "https://cms.test"
+)
+
+// newPasskeyEnv enables passkeys for https://cms.test and creates a user
+func newPasskeyEnv(t *testing.T) (*testEnv, *user.User) {
+	t.Helper()
+
+	env := newTestEnv(t)
+	env.auth.EnablePasskeys(webauthn.NewRelyingParty(webauthn.Config{
+		RPID:    testRPID,
+		RPName:  "Cacto CMS",
+		Origins: []string{testOrigin},
+	}), userpersistence.NewPasskeyRepository(env.db))
+	return env, env.createUser(t, "jane@example.com", user.RoleEditor)
+}
+
+// registerPasskey runs the registration ceremony for a user
+func registerPasskey(t *testing.T, env *testEnv, authn *webauthntest.Authenticator, userID int) (*user.Passkey, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	opts, err := env.auth.BeginPasskeyRegistration(ctx, userID)
+	if err != nil {
+		t.Fatalf("BeginPasskeyRegistration: %v", err)
+	}
+	credential, err := authn.Register(opts)
+	if err != nil {
+		t.Fatalf("authenticator register: %v", err)
+	}
+	return env.auth.FinishPasskeyRegistration(ctx, userID, &RegisterPasskeyRequest{Name: "Laptop", Credential: credential})
+}
+
+// loginWithPasskey runs the sign-in ceremony
+func loginWithPasskey(t *testing.T, env *testEnv, authn *webauthntest.Authenticator) (*LoginResponse, error) {
+	t.Helper()
+
+	opts, err := env.auth.BeginPasskeyLogin()
+	if err != nil {
+		t.Fatalf("BeginPasskeyLogin: %v", err)
+	}
+	assertion, err := authn.Login(opts)
+	if err != nil {
+		t.Fatalf("authenticator login: %v", err)
+	}
+	return env.auth.FinishPasskeyLogin(context.Background(), assertion, LoginMeta{IPAddress: "192.0.2.1"})
+}
+
+func TestPasskeyRegistrationAndLogin(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+	authn := webauthntest.NewAuthenticator(testOrigin)
+
+	p, err := registerPasskey(t, env, authn, u.ID)
+	if err != nil {
+		t.Fatalf("FinishPasskeyRegistration: %v", err)
+	}
+	if p.UserID != u.ID || p.Name != "Laptop" || p.SignCount != 0 {
+		t.Errorf("stored passkey %+v", p)
+	}
+
+	for i := 1; i <= 2; i++ {
+		resp, err := loginWithPasskey(t, env, authn)
+		if err != nil {
+			t.Fatalf("sign-in %d: %v", i, err)
+		}
+		if resp.User.ID != u.ID || resp.Token == "" {
+			t.Fatalf("sign-in %d: signed in as user %d (token %q), want %d", i, resp.User.ID, resp.Token, u.ID)
+		}
+	}
+
+	passkeys, err := env.auth.GetPasskeys(context.Background(), u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passkeys) != 1 || passkeys[0].SignCount != 2 {
+		t.Errorf("passkeys after two sign-ins: %+v, want one with sign count 2", passkeys)
+	}
+}
+
+func TestPasskeyRegistrationRejectsExcludedCredential(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+	authn := webauthntest.NewAuthenticator(testOrigin)
+	if _, err := registerPasskey(t, env, authn, u.ID); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+
+	opts, err := env.auth.BeginPasskeyRegistration(context.Background(), u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(opts.ExcludeCredentials) != 1 {
+		t.Fatalf("%d excluded credentials, want the registered one", len(opts.ExcludeCredentials))
+	}
+	if _, err := authn.Register(opts); err == nil {
+		t.Error("authenticator registered a second passkey despite the exclude list")
+	}
+}
+
+func TestPasskeyRegistrationRejectsWrongChallenge(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("challenge never issued", func(t *testing.T) {
+		env, u := newPasskeyEnv(t)
+		opts, err := env.auth.BeginPasskeyRegistration(ctx, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.Challenge, err = webauthn.NewChallenge(); err != nil {
+			t.Fatal(err)
+		}
+		credential, err := webauthntest.NewAuthenticator(testOrigin).Register(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = env.auth.FinishPasskeyRegistration(ctx, u.ID, &RegisterPasskeyRequest{Credential: credential})
+		expectError(t, err, http.StatusBadRequest)
+	})
+
+	t.Run("challenge issued to another user", func(t *testing.T) {
+		env, u := newPasskeyEnv(t)
+		other := env.createUser(t, "mallory@example.com", user.RoleViewer)
+		opts, err := env.auth.BeginPasskeyRegistration(ctx, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		credential, err := webauthntest.NewAuthenticator(testOrigin).Register(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = env.auth.FinishPasskeyRegistration(ctx, other.ID, &RegisterPasskeyRequest{Credential: credential})
+		expectError(t, err, http.StatusBadRequest)
+		if n := env.countRows(t, "user_passkeys", "1 = 1"); n != 0 {
+			t.Errorf("%d passkeys stored, want none", n)
+		}
+	})
+
+	t.Run("challenge used twice", func(t *testing.T) {
+		env, u := newPasskeyEnv(t)
+		opts, err := env.auth.BeginPasskeyRegistration(ctx, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		authn := webauthntest.NewAuthenticator(testOrigin)
+		first, err := authn.Register(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		second, err := authn.Register(&webauthn.CreationOptions{Challenge: opts.Challenge, RP: opts.RP, User: opts.User})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := env.auth.FinishPasskeyRegistration(ctx, u.ID, &RegisterPasskeyRequest{Credential: first}); err != nil {
+			t.Fatalf("first response: %v", err)
+		}
+		_, err = env.auth.FinishPasskeyRegistration(ctx, u.ID, &RegisterPasskeyRequest{Credential: second})
+		expectError(t, err, http.StatusBadRequest)
+	})
+}
+
+func TestPasskeyRegistrationRejectsWrongOrigin(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+
+	_, err := registerPasskey(t, env, webauthntest.NewAuthenticator("https://cms.test.evil.example"), u.ID)
+	expectError(t, err, http.StatusBadRequest)
+	if n := env.countRows(t, "user_passkeys", "user_id = ?", u.ID); n != 0 {
+		t.Errorf("%d passkeys stored, want none", n)
+	}
+}
+
+func TestPasskeyLoginRejectsWrongChallenge(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+	authn := webauthntest.NewAuthenticator(testOrigin)
+	if _, err := registerPasskey(t, env, authn, u.ID); err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+	ctx := context.Background()
+
+	t.Run("challenge never issued", func(t *testing.T) {
+		opts, err := env.auth.BeginPasskeyLogin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if opts.Challenge, err = webauthn.NewChallenge(); err != nil {
+			t.Fatal(err)
+		}
+		assertion, err := authn.Login(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = env.auth.FinishPasskeyLogin(ctx, assertion, LoginMeta{})
+		expectError(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("registration challenge", func(t *testing.T) {
+		opts, err := env.auth.BeginPasskeyRegistration(ctx, u.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertion, err := authn.Login(&webauthn.RequestOptions{Challenge: opts.Challenge, RPID: testRPID})
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = env.auth.FinishPasskeyLogin(ctx, assertion, LoginMeta{})
+		expectError(t, err, http.StatusUnauthorized)
+	})
+
+	t.Run("replayed assertion", func(t *testing.T) {
+		opts, err := env.auth.BeginPasskeyLogin()
+		if err != nil {
+			t.Fatal(err)
+		}
+		assertion, err := authn.Login(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err := env.auth.FinishPasskeyLogin(ctx, assertion, LoginMeta{}); err != nil {
+			t.Fatalf("first use: %v", err)
+		}
+		_, err = env.auth.FinishPasskeyLogin(ctx, assertion, LoginMeta{})
+		expectError(t, err, http.StatusUnauthorized)
+	})
+}
+
+func TestPasskeyLoginRejectsWrongOrigin(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+	authn := webauthntest.NewAuthenticator(testOrigin)
+	if _, err := registerPasskey(t, env, authn, u.ID); err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+
+	// A phishing page relaying the ceremony reports its own origin
+	authn.Origin = "https://cms-test.example"
+	_, err := loginWithPasskey(t, env, authn)
+	expectError(t, err, http.StatusUnauthorized)
+	if n := env.countRows(t, "login_attempts", "email = ? AND success = 0", "jane@example.com"); n != 1 {
+		t.Errorf("%d failed attempts recorded, want 1", n)
+	}
+}
+
+func TestPasskeyLoginRejectsReplayedSignCount(t *testing.T) {
+	env, u := newPasskeyEnv(t)
+	authn := webauthntest.NewAuthenticator(testOrigin)
+	if _, err := registerPasskey(t, env, authn, u.ID); err != nil {
+		t.Fatalf("registration: %v", err)
+	}
+	if _, err := loginWithPasskey(t, env, authn); err != nil {
+		t.Fatalf("first sign-in: %v", err)
+	}
+
+	// A cloned authenticator signs with a counter the server has already seen
+	authn.KeepCounter = false
+	_, err := loginWithPasskey(t, env, authn)
+	expectError(t, err, http.StatusUnauthorized)
+
+	passkeys, err := env.auth.GetPasskeys(context.Background(), u.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(passkeys) != 1 || passkeys[0].SignCount != 1 {
+		t.Errorf("passkeys after the rejected sign-in: %+v, want sign count 1", passkeys)
+	}
+}
@@ -1,7 +1,13 @@
 package auth
 
 import (
+	"context"
+	"log"
+
+	auditservice "cacto-cms/app/application/audit"
+	roleservice "cacto-cms/app/application/role"
 	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/audit"
 	"cacto-cms/app/domain/user"
 	"cacto-cms/app/shared/auth"
 	"cacto-cms/app/shared/errors"
@@ -10,24 +16,50 @@ import (
 
 // Service handles authentication business logic
 type Service struct {
-	userService *userservice.Service
-	jwtManager  *auth.JWTManager
-	hasher      *auth.PasswordHasher
+	userService   *userservice.Service
+	roleService   *roleservice.Service
+	audit         *auditservice.Service
+	jwtManager    *auth.JWTManager
+	hasher        *auth.PasswordHasher
+	lockoutRepo   user.LockoutRepository
+	sessionRepo   user.SessionRepository
+	lockoutPolicy LockoutPolicy
+	dummyHash     string // Verified against when the email is unknown, to keep timing constant
+	sso           *sso   // Nil unless single sign-on is enabled
+	passkeys      *passkeys // Nil unless passkeys are enabled
+
+	impersonationDuration time.Duration
 }
 
 // NewService creates a new auth service
-func NewService(userService *userservice.Service, jwtSecret string, tokenDuration time.Duration) *Service {
+func NewService(userService *userservice.Service, roleService *roleservice.Service, auditService *auditservice.Service, lockoutRepo user.LockoutRepository, sessionRepo user.SessionRepository, jwtSecret string, tokenDuration time.Duration) *Service {
+	hasher := auth.NewPasswordHasher()
+	dummyHash, _ := hasher.HashPassword("cacto-timing-equalizer")
+
 	return &Service{
-		userService: userService,
-		jwtManager:  auth.NewJWTManager(jwtSecret, tokenDuration),
-		hasher:      auth.NewPasswordHasher(),
+		userService:   userService,
+		roleService:   roleService,
+		audit:         auditService,
+		jwtManager:    auth.NewJWTManager(jwtSecret, tokenDuration),
+		hasher:        hasher,
+		lockoutRepo:   lockoutRepo,
+		sessionRepo:   sessionRepo,
+		lockoutPolicy: DefaultLockoutPolicy(),
+		dummyHash:     dummyHash,
+
+		impersonationDuration: DefaultImpersonationDuration,
 	}
 }
 
+// SetLockoutPolicy overrides the default brute-force protection settings
+func (s *Service) SetLockoutPolicy(policy LockoutPolicy) {
+	s.lockoutPolicy = policy
+}
+
 // LoginRequest represents login request data
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required,max=1024"`
 }
 
 // LoginResponse represents login response data
@@ -36,37 +68,69 @@ type LoginResponse struct {
 	User  *user.User  `json:"user"`
 }
 
-// Login authenticates a user and returns a JWT token
-func (s *Service) Login(req *LoginRequest) (*LoginResponse, error) {
-	// Get user by email
-	u, err := s.userService.GetUserByEmail(req.Email)
+// Login authenticates a user and returns a JWT token.
+// Failed attempts are tracked per account; the response takes the same
+// time whether or not the email exists.
+func (s *Service) Login(ctx context.Context, req *LoginRequest, meta LoginMeta) (*LoginResponse, error) {
+	if !s.PasswordLoginEnabled() {
+		return nil, errors.NewForbidden("Password sign-in is disabled, use single sign-on")
+	}
+
+	email := normalizeEmail(req.Email)
+	now := time.Now()
+
+	// Reject early if the account is locked or throttled
+	attempt, err := s.beginAttempt(ctx, email, meta, now)
 	if err != nil {
-		return nil, errors.NewUnauthorized("Invalid credentials")
+		s.recordAuth(ctx, "auth.login_blocked", email, nil, meta, map[string]interface{}{"reason": errors.AsAppError(err).Message})
+		return nil, err
 	}
 
-	// Check if user is active
-	if !u.IsActive {
-		return nil, errors.NewForbidden("User account is inactive")
+	// Get user by email (unknown emails are verified against a dummy hash)
+	u, lookupErr := s.userService.GetUserByEmail(ctx, email)
+	passwordHash := s.dummyHash
+	if lookupErr == nil {
+		passwordHash = u.PasswordHash
 	}
 
 	// Verify password
-	valid, err := s.hasher.VerifyPassword(req.Password, u.PasswordHash)
+	valid, err := s.hasher.VerifyPassword(req.Password, passwordHash)
 	if err != nil {
+		attempt.discard(ctx, s)
 		return nil, errors.NewInternal("Failed to verify password", err)
 	}
 
-	if !valid {
+	if lookupErr != nil || !valid {
+		if lookupErr != nil {
+			u = nil
+		}
+		s.recordAuth(ctx, "auth.login_failed", email, u, meta, map[string]interface{}{"reason": "invalid credentials"})
+		attempt.fail(ctx, s)
 		return nil, errors.NewUnauthorized("Invalid credentials")
 	}
 
-	// Generate JWT token
-	token, err := s.jwtManager.GenerateToken(u.ID, u.Email, string(u.Role))
+	// Check if user is active (only revealed to callers who know the password)
+	if !u.IsActive {
+		s.recordAuth(ctx, "auth.login_failed", email, u, meta, map[string]interface{}{"reason": "inactive account"})
+		return nil, errors.NewForbidden("User account is inactive")
+	}
+
+	attempt.succeed(ctx, s)
+	s.recordAuth(ctx, "auth.login", email, u, meta, map[string]interface{}{"method": "password"})
+
+	// Transparently upgrade hashes created with older, weaker parameters
+	s.userService.UpgradePasswordHash(ctx, u, req.Password)
+
+	// Start a session and generate its JWT
+	token, _, err := s.issueSession(ctx, u, meta, nil, s.jwtManager.TokenDuration())
 	if err != nil {
-		return nil, errors.NewInternal("Failed to generate token", err)
+		return nil, err
 	}
 
 	// Update last login
-	_ = s.userService.UpdateLastLogin(u.ID)
+	_ = s.userService.UpdateLastLogin(ctx, u.ID)
+
+	u.Permissions = s.roleService.PermissionsFor(ctx, string(u.Role))
 
 	return &LoginResponse{
 		Token: token,
@@ -77,54 +141,82 @@ func (s *Service) Login(req *LoginRequest) (*LoginResponse, error) {
 // RegisterRequest represents registration request data
 type RegisterRequest struct {
 	Email    string `json:"email" validate:"required,email"`
-	Password string `json:"password" validate:"required,min=6"`
+	Password string `json:"password" validate:"required"` // Checked against the password policy
 	Name     string `json:"name" validate:"required,min=2"`
-	Role     string `json:"role,omitempty"`
 }
 
-// Register creates a new user account
-func (s *Service) Register(req *RegisterRequest) (*user.User, error) {
+// Register creates a new user account.
+// Self-registered users always get the viewer role; admins assign other
+// roles through user management.
+func (s *Service) Register(ctx context.Context, req *RegisterRequest) (*user.User, error) {
+	email := normalizeEmail(req.Email)
+
 	// Check if user already exists
-	existing, err := s.userService.GetUserByEmail(req.Email)
+	existing, err := s.userService.GetUserByEmail(ctx, email)
 	if err == nil && existing != nil {
 		return nil, errors.NewConflict("User with this email already exists")
 	}
 
+	if err := s.userService.ValidateNewPassword(req.Password, req.Email, req.Name); err != nil {
+		return nil, err
+	}
+
 	// Hash password
 	passwordHash, err := s.hasher.HashPassword(req.Password)
 	if err != nil {
 		return nil, errors.NewInternal("Failed to hash password", err)
 	}
 
-	// Determine role
-	role := user.RoleViewer
-	if req.Role != "" {
-		switch req.Role {
-		case "admin":
-			role = user.RoleAdmin
-		case "editor":
-			role = user.RoleEditor
-		case "author":
-			role = user.RoleAuthor
-		}
-	}
-
 	// Create user
 	newUser := &user.User{
-		Email:        req.Email,
+		Email:        email,
 		PasswordHash: passwordHash,
 		Name:         req.Name,
-		Role:         role,
+		Role:         user.RoleViewer,
 		IsActive:     true,
 	}
 
-	if err := s.userService.CreateUser(newUser); err != nil {
+	if err := s.userService.CreateUser(ctx, newUser); err != nil {
 		return nil, errors.NewInternal("Failed to create user", err)
 	}
 
+	actor := audit.ActorFromContext(ctx)
+	actor.UserID = &newUser.ID
+	actor.Email = newUser.Email
+	s.audit.RecordAs(ctx, actor, "auth.registered", "user", newUser.ID, nil, map[string]interface{}{
+		"email": newUser.Email,
+		"name":  newUser.Name,
+		"role":  newUser.Role,
+	})
+
 	return newUser, nil
 }
 
+// ChangePasswordRequest represents a password change by the signed-in user
+type ChangePasswordRequest struct {
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required"`
+}
+
+// ChangePassword changes the password of the signed-in user and signs out
+// their other sessions
+func (s *Service) ChangePassword(ctx context.Context, userID int, currentSessionID string, req *ChangePasswordRequest) error {
+	if err := s.userService.ChangePassword(ctx, userID, req.CurrentPassword, req.NewPassword); err != nil {
+		return err
+	}
+
+	keep := 0
+	if session, err := s.sessionRepo.FindBySessionID(ctx, currentSessionID); err == nil {
+		keep = session.ID
+	}
+	if err := s.sessionRepo.RevokeAllForUser(ctx, userID, keep, time.Now()); err != nil {
+		log.Printf("Failed to revoke other sessions for user %d: %v", userID, err)
+	}
+	return nil
+}
+
+
+
 // ValidateToken validates a JWT token and returns claims
 func (s *Service) ValidateToken(tokenString string) (*auth.Claims, error) {
 	claims, err := s.jwtManager.ValidateToken(tokenString)
@@ -133,3 +225,17 @@ func (s *Service) ValidateToken(tokenString string) (*auth.Claims, error) {
 	}
 	return claims, nil
 }
+
+// recordAuth audits an authentication event for an account. The attempted
+// email is recorded as the actor, with the user ID when the account exists.
+func (s *Service) recordAuth(ctx context.Context, action, email string, u *user.User, meta LoginMeta, details map[string]interface{}) {
+	actor := audit.Actor{
+		Email:     email,
+		IPAddress: meta.IPAddress,
+		UserAgent: meta.UserAgent,
+	}
+	if u != nil {
+		actor.UserID = &u.ID
+	}
+	s.audit.RecordAs(ctx, actor, action, "account", email, nil, details)
+}
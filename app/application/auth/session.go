@@ -0,0 +1,261 @@
+package auth
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/domain/role"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/auth"
+	"cacto-cms/app/shared/errors"
+)
+
+const (
+	// DefaultImpersonationDuration limits how long an admin can act as another user
+	DefaultImpersonationDuration = 30 * time.Minute
+	// ImpersonatePermission allows signing in as another user
+	ImpersonatePermission = "users:impersonate"
+	// lastSeenResolution limits last-seen writes to one per session per interval
+	lastSeenResolution = time.Minute
+	// expiredSessionRetention keeps expired sessions around briefly before they are purged
+	expiredSessionRetention = 7 * 24 * time.Hour
+)
+
+// SetImpersonationDuration overrides how long impersonation sessions last
+func (s *Service) SetImpersonationDuration(d time.Duration) {
+	s.impersonationDuration = d
+}
+
+// ImpersonationResponse represents a started impersonation
+type ImpersonationResponse struct {
+	Token        string     `json:"token"`
+	User         *user.User `json:"user"`
+	Impersonator string     `json:"impersonator"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+}
+
+// issueSession stores a new session for a user and returns its JWT.
+// impersonator is set when an admin acts as the user.
+func (s *Service) issueSession(ctx context.Context, u *user.User, meta LoginMeta, impersonator *user.User, duration time.Duration) (string, *user.Session, error) {
+	sessionID, err := auth.GenerateSessionID()
+	if err != nil {
+		return "", nil, errors.NewInternal("Failed to generate session", err)
+	}
+
+	now := time.Now().UTC()
+	session := &user.Session{
+		SessionID:  sessionID,
+		UserID:     u.ID,
+		IPAddress:  meta.IPAddress,
+		UserAgent:  meta.UserAgent,
+		CreatedAt:  now,
+		LastSeenAt: now,
+		ExpiresAt:  now.Add(duration),
+	}
+
+	claims := auth.Claims{
+		UserID:    u.ID,
+		Email:     u.Email,
+		Role:      string(u.Role),
+		SessionID: sessionID,
+	}
+	if impersonator != nil {
+		session.ImpersonatorID = &impersonator.ID
+		claims.ImpersonatorID = impersonator.ID
+		claims.ImpersonatorEmail = impersonator.Email
+	}
+
+	if err := s.sessionRepo.DeleteExpired(ctx, now.Add(-expiredSessionRetention)); err != nil {
+		log.Printf("Failed to purge expired sessions: %v", err)
+	}
+	if err := s.sessionRepo.Create(ctx, session); err != nil {
+		return "", nil, errors.NewInternal("Failed to create session", err)
+	}
+
+	token, err := s.jwtManager.GenerateToken(claims, session.ExpiresAt)
+	if err != nil {
+		return "", nil, errors.NewInternal("Failed to generate token", err)
+	}
+
+	return token, session, nil
+}
+
+// ValidateSession checks that the session behind a JWT is still active and
+// returns the claims refreshed with the user's current email and role, so
+// deactivation, role changes and revocation apply immediately.
+func (s *Service) ValidateSession(ctx context.Context, claims *auth.Claims, ipAddress, userAgent string) (*auth.Claims, error) {
+	if claims.SessionID == "" {
+		return nil, errors.NewUnauthorized("Session expired, please sign in again")
+	}
+
+	session, err := s.sessionRepo.FindBySessionID(ctx, claims.SessionID)
+	if err != nil || session.UserID != claims.UserID {
+		return nil, errors.NewUnauthorized("Session expired, please sign in again")
+	}
+
+	now := time.Now()
+	if !session.IsActive(now) {
+		return nil, errors.NewUnauthorized("Session expired, please sign in again")
+	}
+
+	u, err := s.userService.GetUserByID(ctx, session.UserID)
+	if err != nil || !u.IsActive {
+		return nil, errors.NewUnauthorized("User account is inactive")
+	}
+
+	refreshed := *claims
+	refreshed.Email = u.Email
+	refreshed.Role = string(u.Role)
+
+	// An impersonation ends as soon as the admin loses the right to impersonate
+	if session.IsImpersonation() {
+		admin, err := s.userService.GetUserByID(ctx, *session.ImpersonatorID)
+		if err != nil || !admin.IsActive || !s.roleService.HasPermission(ctx, string(admin.Role), ImpersonatePermission) {
+			return nil, errors.NewUnauthorized("Impersonation is no longer allowed")
+		}
+		refreshed.ImpersonatorID = admin.ID
+		refreshed.ImpersonatorEmail = admin.Email
+	}
+
+	if now.Sub(session.LastSeenAt) >= lastSeenResolution || session.IPAddress != ipAddress {
+		if err := s.sessionRepo.Touch(ctx, session.ID, now, ipAddress); err != nil {
+			log.Printf("Failed to record session activity for user %d: %v", session.UserID, err)
+		}
+	}
+
+	return &refreshed, nil
+}
+
+// GetSessions retrieves the active sessions of a user, marking the current one
+func (s *Service) GetSessions(ctx context.Context, userID int, currentSessionID string) ([]*user.Session, error) {
+	sessions, err := s.sessionRepo.FindActiveByUser(ctx, userID, time.Now())
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load sessions", err)
+	}
+
+	for _, session := range sessions {
+		session.Current = session.SessionID == currentSessionID
+	}
+	return sessions, nil
+}
+
+// RevokeSession revokes one of a user's own sessions
+func (s *Service) RevokeSession(ctx context.Context, userID, id int) error {
+	session, err := s.sessionRepo.FindByID(ctx, id)
+	if err != nil || session.UserID != userID {
+		return errors.NewNotFound("Session not found")
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+		return errors.NewInternal("Failed to revoke session", err)
+	}
+
+	s.audit.Record(ctx, "auth.session_revoked", "session", session.ID, map[string]interface{}{
+		"device":     session.Device(),
+		"ip_address": session.IPAddress,
+	}, nil)
+	return nil
+}
+
+// Logout revokes the session making the request
+func (s *Service) Logout(ctx context.Context, sessionID string) {
+	if sessionID == "" {
+		return
+	}
+
+	session, err := s.sessionRepo.FindBySessionID(ctx, sessionID)
+	if err != nil {
+		return
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+		log.Printf("Failed to revoke session on logout for user %d: %v", session.UserID, err)
+	}
+
+	actor := audit.ActorFromContext(ctx)
+	s.audit.Record(ctx, "auth.logout", "account", normalizeEmail(actor.Email), nil, nil)
+}
+
+// Impersonate starts a time-limited session in which an admin acts as another
+// user. Admins cannot impersonate themselves, inactive users, or users with
+// permissions they do not have themselves. sessionID is the session making
+// the request (empty for API tokens); an impersonation session cannot start
+// another one.
+func (s *Service) Impersonate(ctx context.Context, impersonatorID int, sessionID string, targetID int, meta LoginMeta) (*ImpersonationResponse, error) {
+	if sessionID != "" {
+		current, err := s.sessionRepo.FindBySessionID(ctx, sessionID)
+		if err != nil {
+			return nil, errors.NewUnauthorized("Session not found")
+		}
+		if current.IsImpersonation() {
+			return nil, errors.NewConflict("Stop the current impersonation first")
+		}
+	}
+
+	admin, err := s.userService.GetUserByID(ctx, impersonatorID)
+	if err != nil {
+		return nil, err
+	}
+	if !s.roleService.HasPermission(ctx, string(admin.Role), ImpersonatePermission) {
+		return nil, errors.NewForbidden("You are not allowed to impersonate users")
+	}
+
+	target, err := s.userService.GetUserByID(ctx, targetID)
+	if err != nil {
+		return nil, err
+	}
+	if target.ID == admin.ID {
+		return nil, errors.NewValidation("you cannot impersonate yourself")
+	}
+	if !target.IsActive {
+		return nil, errors.NewValidation("inactive users cannot be impersonated")
+	}
+
+	granted := s.roleService.PermissionsFor(ctx, string(admin.Role))
+	for _, permission := range s.roleService.PermissionsFor(ctx, string(target.Role)) {
+		if !role.Grants(granted, permission) {
+			return nil, errors.NewForbidden("Cannot impersonate a user with more permissions than you")
+		}
+	}
+
+	token, session, err := s.issueSession(ctx, target, meta, admin, s.impersonationDuration)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Printf("🎭 Impersonation started: %s as %s until %s", admin.Email, target.Email, session.ExpiresAt.Format(time.RFC3339))
+	s.audit.Record(ctx, "auth.impersonation_started", "user", target.ID, nil, map[string]interface{}{
+		"email":      target.Email,
+		"role":       target.Role,
+		"session_id": session.ID,
+		"expires_at": session.ExpiresAt,
+	})
+
+	target.Permissions = s.roleService.PermissionsFor(ctx, string(target.Role))
+	return &ImpersonationResponse{
+		Token:        token,
+		User:         target,
+		Impersonator: admin.Email,
+		ExpiresAt:    session.ExpiresAt,
+	}, nil
+}
+
+// StopImpersonation ends the impersonation session making the request
+func (s *Service) StopImpersonation(ctx context.Context, sessionID string) error {
+	session, err := s.sessionRepo.FindBySessionID(ctx, sessionID)
+	if err != nil || !session.IsImpersonation() {
+		return errors.NewBadRequest("Not impersonating a user")
+	}
+
+	if err := s.sessionRepo.Revoke(ctx, session.ID, time.Now()); err != nil {
+		return errors.NewInternal("Failed to end impersonation", err)
+	}
+
+	log.Printf("🎭 Impersonation stopped: user %d as user %d", *session.ImpersonatorID, session.UserID)
+	s.audit.Record(ctx, "auth.impersonation_stopped", "user", session.UserID, nil, map[string]interface{}{
+		"session_id": session.ID,
+	})
+	return nil
+}
@@ -0,0 +1,43 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"cacto-cms/app/domain/user"
+)
+
+func TestImpersonationCannotBeNested(t *testing.T) {
+	env := newTestEnv(t)
+	ctx := context.Background()
+	first := env.createUser(t, "first@example.com", user.RoleAdmin)
+	second := env.createUser(t, "second@example.com", user.RoleAdmin)
+	editor := env.createUser(t, "editor@example.com", user.RoleEditor)
+
+	_, own, err := env.auth.issueSession(ctx, first, LoginMeta{}, nil, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := env.auth.Impersonate(ctx, first.ID, own.SessionID, second.ID, LoginMeta{})
+	if err != nil {
+		t.Fatalf("Impersonate: %v", err)
+	}
+	claims, err := env.auth.ValidateToken(resp.Token)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Acting as the second admin, the first one can't impersonate further
+	_, err = env.auth.Impersonate(ctx, second.ID, claims.SessionID, editor.ID, LoginMeta{})
+	expectError(t, err, http.StatusConflict)
+	if n := env.countRows(t, "user_sessions", "user_id = ?", editor.ID); n != 0 {
+		t.Errorf("%d sessions issued for the editor, want none", n)
+	}
+
+	// An API token carries no session
+	if _, err := env.auth.Impersonate(ctx, first.ID, "", editor.ID, LoginMeta{}); err != nil {
+		t.Errorf("Impersonate with an API token: %v", err)
+	}
+}
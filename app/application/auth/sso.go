@@ -0,0 +1,333 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/oidc"
+)
+
+// ssoSessionTTL is how long a started sign-in may take at the identity provider
+const ssoSessionTTL = 10 * time.Minute
+
+// SSOConfig defines how identities from the OpenID Connect provider map to users
+type SSOConfig struct {
+	ProviderName         string        // Shown on the login button
+	RoleClaim            string        // ID token claim holding groups or roles (default "groups")
+	RoleMappings         []RoleMapping // First match wins, so list the most privileged first
+	DefaultRole          user.Role     // Role when no mapping matches; empty denies access
+	AutoProvision        bool          // Create users on first sign-in
+	LinkByEmail          bool          // Link existing users by verified email on first sign-in
+	DisablePasswordLogin bool          // Only allow SSO sign-in
+}
+
+// RoleMapping maps a claim value (e.g. a group name) to a role
+type RoleMapping struct {
+	Value string
+	Role  user.Role
+}
+
+// ParseRoleMappings parses "group=role,group=role" mappings
+func ParseRoleMappings(s string) ([]RoleMapping, error) {
+	mappings := make([]RoleMapping, 0)
+	for _, pair := range strings.Split(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		value, roleName, ok := strings.Cut(pair, "=")
+		if !ok || strings.TrimSpace(value) == "" || strings.TrimSpace(roleName) == "" {
+			return nil, fmt.Errorf("invalid role mapping %q, expected value=role", pair)
+		}
+		mappings = append(mappings, RoleMapping{
+			Value: strings.TrimSpace(value),
+			Role:  user.Role(strings.TrimSpace(roleName)),
+		})
+	}
+	return mappings, nil
+}
+
+// ssoSession holds the secrets of a sign-in in progress, keyed by state
+type ssoSession struct {
+	nonce     string
+	verifier  string
+	expiresAt time.Time
+}
+
+// sso holds the single sign-on setup of the auth service
+type sso struct {
+	provider   *oidc.Provider
+	identities user.IdentityRepository
+	config     SSOConfig
+
+	mu      sync.Mutex
+	pending map[string]ssoSession
+}
+
+// EnableSSO enables OpenID Connect sign-in
+func (s *Service) EnableSSO(provider *oidc.Provider, identities user.IdentityRepository, cfg SSOConfig) {
+	if cfg.RoleClaim == "" {
+		cfg.RoleClaim = "groups"
+	}
+	if cfg.ProviderName == "" {
+		cfg.ProviderName = "SSO"
+	}
+
+	s.sso = &sso{
+		provider:   provider,
+		identities: identities,
+		config:     cfg,
+		pending:    make(map[string]ssoSession),
+	}
+}
+
+// SSOEnabled checks if single sign-on is configured
+func (s *Service) SSOEnabled() bool {
+	return s.sso != nil
+}
+
+// SSOProviderName returns the label of the identity provider
+func (s *Service) SSOProviderName() string {
+	if s.sso == nil {
+		return ""
+	}
+	return s.sso.config.ProviderName
+}
+
+// PasswordLoginEnabled checks if local password sign-in is allowed
+func (s *Service) PasswordLoginEnabled() bool {
+	return s.sso == nil || !s.sso.config.DisablePasswordLogin
+}
+
+// BeginSSO starts an authorization code + PKCE sign-in. It returns the
+// provider URL to redirect to and the state the callback must present.
+func (s *Service) BeginSSO(ctx context.Context) (string, string, error) {
+	if s.sso == nil {
+		return "", "", errors.NewNotFound("Single sign-on is not configured")
+	}
+
+	state, err := oidc.RandomString()
+	if err != nil {
+		return "", "", errors.NewInternal("Failed to start sign-in", err)
+	}
+	nonce, err := oidc.RandomString()
+	if err != nil {
+		return "", "", errors.NewInternal("Failed to start sign-in", err)
+	}
+	verifier, challenge, err := oidc.NewPKCE()
+	if err != nil {
+		return "", "", errors.NewInternal("Failed to start sign-in", err)
+	}
+
+	authURL, err := s.sso.provider.AuthCodeURL(ctx, state, nonce, challenge)
+	if err != nil {
+		log.Printf("SSO: %v", err)
+		return "", "", errors.NewInternal("Identity provider is unavailable", err)
+	}
+
+	now := time.Now()
+	s.sso.mu.Lock()
+	for key, session := range s.sso.pending {
+		if now.After(session.expiresAt) {
+			delete(s.sso.pending, key)
+		}
+	}
+	s.sso.pending[state] = ssoSession{nonce: nonce, verifier: verifier, expiresAt: now.Add(ssoSessionTTL)}
+	s.sso.mu.Unlock()
+
+	return authURL, state, nil
+}
+
+// CompleteSSO finishes a sign-in: it redeems the code, verifies the ID
+// token, resolves (and optionally links or provisions) the user, syncs the
+// mapped role and issues a JWT.
+func (s *Service) CompleteSSO(ctx context.Context, state, code string, meta LoginMeta) (*LoginResponse, error) {
+	if s.sso == nil {
+		return nil, errors.NewNotFound("Single sign-on is not configured")
+	}
+
+	s.sso.mu.Lock()
+	session, ok := s.sso.pending[state]
+	delete(s.sso.pending, state)
+	s.sso.mu.Unlock()
+
+	now := time.Now()
+	if !ok || state == "" || now.After(session.expiresAt) {
+		return nil, errors.NewUnauthorized("Sign-in session expired, please try again")
+	}
+
+	rawIDToken, err := s.sso.provider.Exchange(ctx, code, session.verifier)
+	if err != nil {
+		log.Printf("SSO: code exchange failed: %v", err)
+		s.recordAuth(ctx, "auth.sso_failed", "", nil, meta, map[string]interface{}{"reason": "code exchange failed"})
+		return nil, errors.NewUnauthorized("Sign-in with the identity provider failed")
+	}
+
+	claims, err := s.sso.provider.VerifyIDToken(ctx, rawIDToken, session.nonce)
+	if err != nil {
+		log.Printf("SSO: %v", err)
+		s.recordAuth(ctx, "auth.sso_failed", "", nil, meta, map[string]interface{}{"reason": "invalid ID token"})
+		return nil, errors.NewUnauthorized("Sign-in with the identity provider failed")
+	}
+
+	subject := claims.String("sub")
+	email := normalizeEmail(claims.String("email"))
+	if subject == "" {
+		return nil, errors.NewUnauthorized("Identity provider did not return a subject")
+	}
+
+	mappedRole, err := s.mapSSORole(claims)
+	if err != nil {
+		s.recordAuth(ctx, "auth.sso_failed", email, nil, meta, map[string]interface{}{"reason": errors.AsAppError(err).Message, "subject": subject})
+		s.recordAttempt(ctx, email, meta, false, now)
+		return nil, err
+	}
+
+	u, identity, err := s.resolveSSOUser(ctx, claims, subject, email, mappedRole, meta, now)
+	if err != nil {
+		s.recordAuth(ctx, "auth.sso_failed", email, nil, meta, map[string]interface{}{"reason": errors.AsAppError(err).Message, "subject": subject})
+		s.recordAttempt(ctx, email, meta, false, now)
+		return nil, err
+	}
+
+	if !u.IsActive {
+		s.recordAuth(ctx, "auth.sso_failed", normalizeEmail(u.Email), u, meta, map[string]interface{}{"reason": "inactive account", "subject": subject})
+		s.recordAttempt(ctx, normalizeEmail(u.Email), meta, false, now)
+		return nil, errors.NewForbidden("User account is inactive")
+	}
+
+	// The identity provider is the source of truth for mapped roles
+	if mappedRole != "" && mappedRole != u.Role {
+		if updated, err := s.userService.ChangeRole(ctx, u.ID, string(mappedRole)); err != nil {
+			log.Printf("SSO: keeping role %s for %s: %v", u.Role, u.Email, err)
+		} else {
+			u = updated
+		}
+	}
+
+	if err := s.sso.identities.TouchLogin(ctx, identity.ID, email, now); err != nil {
+		log.Printf("SSO: failed to record identity login for %s: %v", u.Email, err)
+	}
+	s.recordAttempt(ctx, normalizeEmail(u.Email), meta, true, now)
+	s.recordAuth(ctx, "auth.login", normalizeEmail(u.Email), u, meta, map[string]interface{}{"method": "sso", "subject": subject})
+
+	token, _, err := s.issueSession(ctx, u, meta, nil, s.jwtManager.TokenDuration())
+	if err != nil {
+		return nil, err
+	}
+
+	_ = s.userService.UpdateLastLogin(ctx, u.ID)
+	u.Permissions = s.roleService.PermissionsFor(ctx, string(u.Role))
+
+	return &LoginResponse{
+		Token: token,
+		User:  u,
+	}, nil
+}
+
+// mapSSORole maps the role claim to a role. Without configured mappings
+// local roles are kept (an empty role is returned). With mappings, users
+// that match none get the default role or are denied.
+func (s *Service) mapSSORole(claims oidc.Claims) (user.Role, error) {
+	cfg := s.sso.config
+	if len(cfg.RoleMappings) == 0 {
+		return "", nil
+	}
+
+	values := make(map[string]bool)
+	for _, v := range claims.Strings(cfg.RoleClaim) {
+		values[v] = true
+	}
+
+	for _, m := range cfg.RoleMappings {
+		if values[m.Value] {
+			return m.Role, nil
+		}
+	}
+
+	if cfg.DefaultRole != "" {
+		return cfg.DefaultRole, nil
+	}
+	return "", errors.NewForbidden("Your account is not allowed to access the CMS")
+}
+
+// resolveSSOUser finds the user linked to the identity, linking by verified
+// email or provisioning a new user on first sign-in when enabled
+func (s *Service) resolveSSOUser(ctx context.Context, claims oidc.Claims, subject, email string, mappedRole user.Role, meta LoginMeta, now time.Time) (*user.User, *user.Identity, error) {
+	cfg := s.sso.config
+	provider := s.sso.provider.Issuer()
+
+	if identity, err := s.sso.identities.FindByProviderSubject(ctx, provider, subject); err == nil {
+		u, err := s.userService.GetUserByID(ctx, identity.UserID)
+		if err != nil {
+			return nil, nil, err
+		}
+		return u, identity, nil
+	}
+
+	var u *user.User
+	verified, _ := claims.Bool("email_verified")
+
+	if cfg.LinkByEmail && email != "" && verified {
+		if existing, err := s.userService.GetUserByEmail(ctx, email); err == nil {
+			u = existing
+			log.Printf("🔗 SSO: linked %s to %s", subject, u.Email)
+			s.recordAuth(ctx, "auth.sso_linked", email, u, meta, map[string]interface{}{"provider": provider, "subject": subject})
+		}
+	}
+
+	if u == nil {
+		if !cfg.AutoProvision {
+			return nil, nil, errors.NewForbidden("No account is linked to this identity")
+		}
+		if email == "" || !verified {
+			return nil, nil, errors.NewForbidden("Identity provider did not return a verified email")
+		}
+
+		roleName := mappedRole
+		if roleName == "" {
+			roleName = cfg.DefaultRole
+		}
+		if roleName == "" {
+			return nil, nil, errors.NewForbidden("No role is configured for new accounts")
+		}
+
+		name := claims.String("name")
+		if name == "" {
+			name = email
+		}
+
+		// Provisioned users sign in through the provider; the generated password is discarded
+		created, _, err := s.userService.InviteUser(ctx, &userservice.InviteUserRequest{
+			Email: email,
+			Name:  name,
+			Role:  string(roleName),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		u = created
+		log.Printf("👤 SSO: provisioned %s as %s", u.Email, u.Role)
+	}
+
+	identity := &user.Identity{
+		UserID:    u.ID,
+		Provider:  provider,
+		Subject:   subject,
+		Email:     email,
+		CreatedAt: now,
+	}
+	if err := s.sso.identities.Create(ctx, identity); err != nil {
+		return nil, nil, errors.NewInternal("Failed to link identity", err)
+	}
+
+	return u, identity, nil
+}
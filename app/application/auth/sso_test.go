@@ -0,0 +1,262 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"cacto-cms/app/domain/user"
+	userpersistence "cacto-cms/app/infrastructure/persistence/user"
+	"cacto-cms/app/shared/oidc"
+	"cacto-cms/app/shared/oidc/oidctest"
+)
+
+// newSSOEnv enables single sign-on against a mock identity provider
+func newSSOEnv(t *testing.T, cfg SSOConfig) (*testEnv, *oidctest.Provider) {
+	t.Helper()
+
+	idp := oidctest.NewProvider("cacto", "client-secret")
+	t.Cleanup(idp.Close)
+
+	env := newTestEnv(t)
+	env.auth.EnableSSO(oidc.NewProvider(oidc.Config{
+		Issuer:       idp.URL,
+		ClientID:     "cacto",
+		ClientSecret: "client-secret",
+		RedirectURL:  "http://cms.test/admin/sso/callback",
+		Scopes:       []string{"openid", "email", "profile"},
+	}), userpersistence.NewIdentityRepository(env.db), cfg)
+	return env, idp
+}
+
+// beginSSO starts a sign-in and follows it through the provider, returning
+// the state and code the callback receives
+func beginSSO(t *testing.T, env *testEnv, idp *oidctest.Provider) (state, code string) {
+	t.Helper()
+
+	authURL, state, err := env.auth.BeginSSO(context.Background())
+	if err != nil {
+		t.Fatalf("BeginSSO: %v", err)
+	}
+	code, returnedState, err := idp.Authorize(authURL)
+	if err != nil {
+		t.Fatalf("authorize: %v", err)
+	}
+	if returnedState != state {
+		t.Fatalf("provider returned state %q, want %q", returnedState, state)
+	}
+	return state, code
+}
+
+// signInSSO runs a complete sign-in as the provider's current user
+func signInSSO(t *testing.T, env *testEnv, idp *oidctest.Provider) (*LoginResponse, error) {
+	t.Helper()
+
+	state, code := beginSSO(t, env, idp)
+	return env.auth.CompleteSSO(context.Background(), state, code, LoginMeta{IPAddress: "192.0.2.1"})
+}
+
+func TestSSOProvisionsUserWithMappedRole(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{
+		AutoProvision: true,
+		RoleMappings: []RoleMapping{
+			{Value: "cms-admins", Role: user.RoleAdmin},
+			{Value: "cms-editors", Role: user.RoleEditor},
+		},
+	})
+	idp.SetUser(oidctest.User{Subject: "42", Email: "Jane@Example.com", EmailVerified: true, Name: "Jane", Groups: []string{"staff", "cms-editors"}})
+
+	resp, err := signInSSO(t, env, idp)
+	if err != nil {
+		t.Fatalf("CompleteSSO: %v", err)
+	}
+	if resp.Token == "" {
+		t.Error("no token issued")
+	}
+	if resp.User.Email != "jane@example.com" || resp.User.Name != "Jane" {
+		t.Errorf("provisioned %q (%q), want jane@example.com (Jane)", resp.User.Email, resp.User.Name)
+	}
+	if resp.User.Role != user.RoleEditor {
+		t.Errorf("provisioned with role %q, want %q", resp.User.Role, user.RoleEditor)
+	}
+	if n := env.countRows(t, "user_identities", "subject = ? AND user_id = ?", "42", resp.User.ID); n != 1 {
+		t.Errorf("%d identities linked, want 1", n)
+	}
+}
+
+func TestSSOSyncsMappedRoleOnEverySignIn(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{
+		AutoProvision: true,
+		RoleMappings: []RoleMapping{
+			{Value: "cms-admins", Role: user.RoleAdmin},
+			{Value: "cms-editors", Role: user.RoleEditor},
+		},
+	})
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane@example.com", EmailVerified: true, Groups: []string{"cms-editors"}})
+	first, err := signInSSO(t, env, idp)
+	if err != nil {
+		t.Fatalf("first sign-in: %v", err)
+	}
+
+	// The first matching mapping wins
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane@example.com", EmailVerified: true, Groups: []string{"cms-editors", "cms-admins"}})
+	second, err := signInSSO(t, env, idp)
+	if err != nil {
+		t.Fatalf("second sign-in: %v", err)
+	}
+	if second.User.ID != first.User.ID {
+		t.Fatalf("second sign-in resolved user %d, want %d", second.User.ID, first.User.ID)
+	}
+	if second.User.Role != user.RoleAdmin {
+		t.Errorf("role %q after the groups changed, want %q", second.User.Role, user.RoleAdmin)
+	}
+}
+
+func TestSSORoleMapping(t *testing.T) {
+	mappings := []RoleMapping{{Value: "cms-admins", Role: user.RoleAdmin}}
+
+	t.Run("unmatched groups are denied without a default role", func(t *testing.T) {
+		env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, RoleMappings: mappings})
+		idp.SetUser(oidctest.User{Subject: "7", Email: "guest@example.com", EmailVerified: true, Groups: []string{"contractors"}})
+
+		_, err := signInSSO(t, env, idp)
+		expectError(t, err, http.StatusForbidden)
+		if n := env.countRows(t, "users", "email = ?", "guest@example.com"); n != 0 {
+			t.Errorf("denied identity was provisioned")
+		}
+	})
+
+	t.Run("unmatched groups get the default role", func(t *testing.T) {
+		env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, RoleMappings: mappings, DefaultRole: user.RoleViewer})
+		idp.SetUser(oidctest.User{Subject: "7", Email: "guest@example.com", EmailVerified: true, Groups: []string{"contractors"}})
+
+		resp, err := signInSSO(t, env, idp)
+		if err != nil {
+			t.Fatalf("CompleteSSO: %v", err)
+		}
+		if resp.User.Role != user.RoleViewer {
+			t.Errorf("role %q, want %q", resp.User.Role, user.RoleViewer)
+		}
+	})
+
+	t.Run("a custom claim holds the roles", func(t *testing.T) {
+		env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, RoleClaim: "roles", RoleMappings: mappings})
+		idp.SetUser(oidctest.User{Subject: "7", Email: "ops@example.com", EmailVerified: true, Extra: map[string]interface{}{"roles": []string{"cms-admins"}}})
+
+		resp, err := signInSSO(t, env, idp)
+		if err != nil {
+			t.Fatalf("CompleteSSO: %v", err)
+		}
+		if resp.User.Role != user.RoleAdmin {
+			t.Errorf("role %q, want %q", resp.User.Role, user.RoleAdmin)
+		}
+	})
+}
+
+func TestSSOLinksExistingAccountByVerifiedEmail(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{LinkByEmail: true})
+	existing := env.createUser(t, "jane@example.com", user.RoleEditor)
+
+	idp.SetUser(oidctest.User{Subject: "42", Email: "JANE@example.com", EmailVerified: true})
+	resp, err := signInSSO(t, env, idp)
+	if err != nil {
+		t.Fatalf("CompleteSSO: %v", err)
+	}
+	if resp.User.ID != existing.ID {
+		t.Fatalf("signed in as user %d, want the existing user %d", resp.User.ID, existing.ID)
+	}
+	if resp.User.Role != user.RoleEditor {
+		t.Errorf("role %q, want the local role %q kept without mappings", resp.User.Role, user.RoleEditor)
+	}
+	if n := env.countRows(t, "audit_log", "action = ?", "auth.sso_linked"); n != 1 {
+		t.Errorf("%d auth.sso_linked audit entries, want 1", n)
+	}
+
+	// Once linked, the subject identifies the user even if the email changes
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane.doe@example.com", EmailVerified: true})
+	resp, err = signInSSO(t, env, idp)
+	if err != nil {
+		t.Fatalf("sign-in after the email changed: %v", err)
+	}
+	if resp.User.ID != existing.ID {
+		t.Errorf("signed in as user %d, want the linked user %d", resp.User.ID, existing.ID)
+	}
+}
+
+func TestSSORefusesUnverifiedEmail(t *testing.T) {
+	t.Run("no link to an existing account", func(t *testing.T) {
+		env, idp := newSSOEnv(t, SSOConfig{LinkByEmail: true})
+		env.createUser(t, "jane@example.com", user.RoleAdmin)
+		idp.SetUser(oidctest.User{Subject: "666", Email: "jane@example.com", EmailVerified: false})
+
+		_, err := signInSSO(t, env, idp)
+		expectError(t, err, http.StatusForbidden)
+		if n := env.countRows(t, "user_identities", "subject = ?", "666"); n != 0 {
+			t.Errorf("unverified email was linked")
+		}
+	})
+
+	t.Run("no provisioning", func(t *testing.T) {
+		env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, DefaultRole: user.RoleViewer})
+		idp.SetUser(oidctest.User{Subject: "666", Email: "new@example.com", EmailVerified: false})
+
+		_, err := signInSSO(t, env, idp)
+		expectError(t, err, http.StatusForbidden)
+		if n := env.countRows(t, "users", "email = ?", "new@example.com"); n != 0 {
+			t.Errorf("unverified email was provisioned")
+		}
+	})
+}
+
+func TestSSORejectsStateMismatch(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, DefaultRole: user.RoleViewer})
+	ctx := context.Background()
+	state, code := beginSSO(t, env, idp)
+
+	_, err := env.auth.CompleteSSO(ctx, "forged-state", code, LoginMeta{})
+	expectError(t, err, http.StatusUnauthorized)
+	_, err = env.auth.CompleteSSO(ctx, "", code, LoginMeta{})
+	expectError(t, err, http.StatusUnauthorized)
+
+	// The real state still completes, once
+	if _, err := env.auth.CompleteSSO(ctx, state, code, LoginMeta{}); err != nil {
+		t.Fatalf("CompleteSSO with the issued state: %v", err)
+	}
+	_, err = env.auth.CompleteSSO(ctx, state, code, LoginMeta{})
+	expectError(t, err, http.StatusUnauthorized)
+}
+
+func TestSSORequiresPKCEVerifier(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, DefaultRole: user.RoleViewer})
+	state, code := beginSSO(t, env, idp)
+
+	// A code intercepted by someone without the verifier can't be redeemed
+	verifier, _, err := oidc.NewPKCE()
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.auth.sso.mu.Lock()
+	session := env.auth.sso.pending[state]
+	session.verifier = verifier
+	env.auth.sso.pending[state] = session
+	env.auth.sso.mu.Unlock()
+
+	_, err = env.auth.CompleteSSO(context.Background(), state, code, LoginMeta{})
+	expectError(t, err, http.StatusUnauthorized)
+	if n := env.countRows(t, "users", "email = ?", "user@example.com"); n != 0 {
+		t.Errorf("user provisioned without a valid code exchange")
+	}
+}
+
+func TestSSORejectsNonceMismatch(t *testing.T) {
+	env, idp := newSSOEnv(t, SSOConfig{AutoProvision: true, DefaultRole: user.RoleViewer})
+
+	// An ID token issued for another sign-in carries that sign-in's nonce
+	idp.SetUser(oidctest.User{Subject: "42", Email: "jane@example.com", EmailVerified: true, Extra: map[string]interface{}{"nonce": "replayed-nonce"}})
+
+	_, err := signInSSO(t, env, idp)
+	expectError(t, err, http.StatusUnauthorized)
+	if n := env.countRows(t, "audit_log", "action = ?", "auth.sso_failed"); n != 1 {
+		t.Errorf("%d auth.sso_failed audit entries, want 1", n)
+	}
+}
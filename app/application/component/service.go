@@ -1,22 +1,37 @@
 package component
 
 import (
+	"context"
+	"fmt"
+
+	auditservice "cacto-cms/app/application/audit"
 	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/transaction"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/events"
 )
 
 // Service handles business logic for components
 type Service struct {
-	repo component.Repository
+	repo   component.Repository
+	uow    transaction.UnitOfWork
+	audit  *auditservice.Service
+	events *events.Bus
 }
 
 // NewService creates a new component service
-func NewService(repo component.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo component.Repository, uow transaction.UnitOfWork, auditService *auditservice.Service) *Service {
+	return &Service{repo: repo, uow: uow, audit: auditService}
+}
+
+// SetEvents publishes component changes on a bus, e.g. for the page cache
+func (s *Service) SetEvents(bus *events.Bus) {
+	s.events = bus
 }
 
 // GetComponentByID retrieves a component by ID
-func (s *Service) GetComponentByID(id int) (*component.Component, error) {
-	c, err := s.repo.FindByID(id)
+func (s *Service) GetComponentByID(ctx context.Context, id int) (*component.Component, error) {
+	c, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, err
 	}
@@ -24,8 +39,8 @@ func (s *Service) GetComponentByID(id int) (*component.Component, error) {
 }
 
 // GetComponentByName retrieves a component by name
-func (s *Service) GetComponentByName(name string) (*component.Component, error) {
-	c, err := s.repo.FindByName(name)
+func (s *Service) GetComponentByName(ctx context.Context, name string) (*component.Component, error) {
+	c, err := s.repo.FindByName(ctx, name)
 	if err != nil {
 		return nil, err
 	}
@@ -33,8 +48,8 @@ func (s *Service) GetComponentByName(name string) (*component.Component, error)
 }
 
 // GetComponentsByType retrieves components by type
-func (s *Service) GetComponentsByType(componentType component.Type) ([]*component.Component, error) {
-	components, err := s.repo.FindByType(componentType)
+func (s *Service) GetComponentsByType(ctx context.Context, componentType component.Type) ([]*component.Component, error) {
+	components, err := s.repo.FindByType(ctx, componentType)
 	if err != nil {
 		return nil, err
 	}
@@ -48,21 +63,122 @@ func (s *Service) GetComponentsByType(componentType component.Type) ([]*componen
 }
 
 // GetAllComponents retrieves all components
-func (s *Service) GetAllComponents() ([]*component.Component, error) {
-	return s.repo.FindAll()
+func (s *Service) GetAllComponents(ctx context.Context) ([]*component.Component, error) {
+	return s.repo.FindAll(ctx)
 }
 
 // CreateComponent creates a new component
-func (s *Service) CreateComponent(c *component.Component) error {
-	return s.repo.Create(c)
+func (s *Service) CreateComponent(ctx context.Context, c *component.Component) error {
+	if err := s.repo.Create(ctx, c); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "component.created", "component", c.ID, nil, summary(c))
+	return nil
 }
 
 // UpdateComponent updates an existing component
-func (s *Service) UpdateComponent(c *component.Component) error {
-	return s.repo.Update(c)
+func (s *Service) UpdateComponent(ctx context.Context, c *component.Component) error {
+	var before map[string]interface{}
+	if existing, err := s.repo.FindByID(ctx, c.ID); err == nil {
+		before = summary(existing)
+	}
+
+	if err := s.repo.Update(ctx, c); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "component.updated", "component", c.ID, before, summary(c))
+	s.changed(ctx, c.ID)
+	return nil
 }
 
-// DeleteComponent deletes a component by ID
-func (s *Service) DeleteComponent(id int) error {
-	return s.repo.Delete(id)
+// DeleteComponent moves a component to the trash. Pages keep its place in
+// their layout until it is purged.
+func (s *Service) DeleteComponent(ctx context.Context, id int) error {
+	existing, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "component.deleted", "component", id, summary(existing), nil)
+	s.changed(ctx, id)
+	return nil
+}
+
+// changed announces a component change once it is committed. New components
+// aren't announced: no page shows them yet.
+func (s *Service) changed(ctx context.Context, id int) {
+	s.uow.AfterCommit(ctx, func() {
+		s.events.Publish(events.Event{Kind: events.ComponentChanged, ID: id})
+	})
+}
+
+// ImportComponents stores a set of components matched by name: new names are
+// created, existing ones updated. Either all of them are stored or none, and
+// on return each component carries its ID.
+func (s *Service) ImportComponents(ctx context.Context, components []*component.Component) error {
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		for _, c := range components {
+			if c.Name == "" {
+				return errors.NewValidation("Component name is required")
+			}
+
+			existing, err := s.repo.FindByName(ctx, c.Name)
+			if err != nil {
+				if err := s.CreateComponent(ctx, c); err != nil {
+					return errors.NewInternal(fmt.Sprintf("Failed to create component %s", c.Name), err)
+				}
+				continue
+			}
+
+			if existing.Type != c.Type {
+				return errors.NewConflict(fmt.Sprintf("Component %s already exists with type %s", c.Name, existing.Type))
+			}
+			c.ID = existing.ID
+			if err := s.UpdateComponent(ctx, c); err != nil {
+				return errors.NewInternal(fmt.Sprintf("Failed to update component %s", c.Name), err)
+			}
+		}
+		return nil
+	})
+}
+
+// CloneComponent copies a component under a new name
+func (s *Service) CloneComponent(ctx context.Context, id int, name string) (*component.Component, error) {
+	var clone component.Component
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		source, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return errors.NewNotFound("Component not found")
+		}
+		if _, err := s.repo.FindByName(ctx, name); err == nil {
+			return errors.NewConflict(fmt.Sprintf("Component %s already exists", name))
+		}
+
+		clone = *source
+		clone.ID = 0
+		clone.Name = name
+		if err := s.CreateComponent(ctx, &clone); err != nil {
+			return errors.NewInternal("Failed to create component", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &clone, nil
+}
+
+// summary describes a component in audit entries
+func summary(c *component.Component) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  c.Type,
+		"name":  c.Name,
+		"title": c.Title,
+	}
 }
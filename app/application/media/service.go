@@ -1,27 +1,48 @@
 package media
 
 import (
+	auditservice "cacto-cms/app/application/audit"
 	"cacto-cms/app/domain/media"
 	"cacto-cms/app/shared/errors"
+	"context"
 	"mime"
 	"path/filepath"
 	"strings"
 	"time"
 )
 
+// defaultMaxFileSize is the upload limit until SetMaxFileSize changes it
+const defaultMaxFileSize = 10 * 1024 * 1024 // 10MB
+
 // Service handles business logic for media
 type Service struct {
-	repo media.Repository
+	repo        media.Repository
+	audit       *auditservice.Service
+	maxFileSize int64
 }
 
 // NewService creates a new media service
-func NewService(repo media.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo media.Repository, auditService *auditservice.Service) *Service {
+	return &Service{repo: repo, audit: auditService, maxFileSize: defaultMaxFileSize}
+}
+
+// SetMaxFileSize sets the largest upload accepted, in bytes (MAX_UPLOAD_SIZE)
+func (s *Service) SetMaxFileSize(size int64) {
+	s.maxFileSize = size
 }
 
 // GetMediaByID retrieves a media by ID
-func (s *Service) GetMediaByID(id int) (*media.Media, error) {
-	m, err := s.repo.FindByID(id)
+func (s *Service) GetMediaByID(ctx context.Context, id int) (*media.Media, error) {
+	m, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeNotFound, "Media not found", 404)
+	}
+	return m, nil
+}
+
+// GetMediaByFilename retrieves a media by its file name in the uploads directory
+func (s *Service) GetMediaByFilename(ctx context.Context, filename string) (*media.Media, error) {
+	m, err := s.repo.FindByFilename(ctx, filename)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrCodeNotFound, "Media not found", 404)
 	}
@@ -29,12 +50,12 @@ func (s *Service) GetMediaByID(id int) (*media.Media, error) {
 }
 
 // GetAllMedia retrieves all media with pagination
-func (s *Service) GetAllMedia(limit, offset int) ([]*media.Media, error) {
-	return s.repo.FindAll(limit, offset)
+func (s *Service) GetAllMedia(ctx context.Context, limit, offset int) ([]*media.Media, error) {
+	return s.repo.FindAll(ctx, limit, offset)
 }
 
 // CreateMedia creates a new media record
-func (s *Service) CreateMedia(filename, originalName, mimeType string, size int64, path, url string) (*media.Media, error) {
+func (s *Service) CreateMedia(ctx context.Context, filename, originalName, mimeType string, size int64, path, url string) (*media.Media, error) {
 	// Validate mime type
 	if mimeType == "" {
 		ext := filepath.Ext(originalName)
@@ -55,21 +76,43 @@ func (s *Service) CreateMedia(filename, originalName, mimeType string, size int6
 		CreatedAt:    time.Now(),
 	}
 
-	if err := s.repo.Create(m); err != nil {
+	if err := s.repo.Create(ctx, m); err != nil {
 		return nil, errors.NewInternal("Failed to create media", err)
 	}
 
+	s.audit.Record(ctx, "media.created", "media", m.ID, nil, summary(m))
 	return m, nil
 }
 
 // UpdateMedia updates media metadata
-func (s *Service) UpdateMedia(m *media.Media) error {
-	return s.repo.Update(m)
+func (s *Service) UpdateMedia(ctx context.Context, m *media.Media) error {
+	var before map[string]interface{}
+	if existing, err := s.repo.FindByID(ctx, m.ID); err == nil {
+		before = summary(existing)
+	}
+
+	if err := s.repo.Update(ctx, m); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "media.updated", "media", m.ID, before, summary(m))
+	return nil
 }
 
-// DeleteMedia deletes a media by ID
-func (s *Service) DeleteMedia(id int) error {
-	return s.repo.Delete(id)
+// DeleteMedia moves a media record to the trash. Its file stays in the
+// uploads directory until the trash is purged.
+func (s *Service) DeleteMedia(ctx context.Context, id int) error {
+	existing, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return errors.NewNotFound("Media not found")
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return errors.NewNotFound("Media not found")
+	}
+
+	s.audit.Record(ctx, "media.deleted", "media", id, summary(existing), nil)
+	return nil
 }
 
 // ValidateFileType validates if file type is allowed
@@ -91,8 +134,18 @@ func (s *Service) ValidateFileType(mimeType string) bool {
 	return false
 }
 
-// ValidateFileSize validates file size (max 10MB)
+// ValidateFileSize validates file size against the configured maximum
 func (s *Service) ValidateFileSize(size int64) bool {
-	const maxSize = 10 * 1024 * 1024 // 10MB
-	return size > 0 && size <= maxSize
+	return size > 0 && size <= s.maxFileSize
+}
+
+// summary describes a media file in audit entries
+func summary(m *media.Media) map[string]interface{} {
+	return map[string]interface{}{
+		"filename":      m.Filename,
+		"original_name": m.OriginalName,
+		"mime_type":     m.MimeType,
+		"size":          m.Size,
+		"alt_text":      m.AltText,
+	}
 }
@@ -1,33 +1,47 @@
 package page
 
 import (
+	"context"
 	"fmt"
 	"strings"
 	"time"
 	"unicode"
 
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/domain/component"
 	"cacto-cms/app/domain/page"
+	"cacto-cms/app/domain/transaction"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/events"
 )
 
 // Service handles business logic for pages
 type Service struct {
-	repo page.Repository
+	repo   page.Repository
+	uow    transaction.UnitOfWork
+	audit  *auditservice.Service
+	events *events.Bus
 }
 
 // NewService creates a new page service
-func NewService(repo page.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo page.Repository, uow transaction.UnitOfWork, auditService *auditservice.Service) *Service {
+	return &Service{repo: repo, uow: uow, audit: auditService}
+}
+
+// SetEvents publishes page changes on a bus, e.g. for the page cache
+func (s *Service) SetEvents(bus *events.Bus) {
+	s.events = bus
 }
 
 // GetPageBySlug retrieves a page by its slug
-func (s *Service) GetPageBySlug(slug string) (*page.Page, error) {
-	p, err := s.repo.FindBySlug(slug)
+func (s *Service) GetPageBySlug(ctx context.Context, slug string) (*page.Page, error) {
+	p, err := s.repo.FindBySlug(ctx, slug)
 	if err != nil {
 		return nil, err
 	}
 
 	// Load components
-	components, err := s.repo.GetComponents(p.ID)
+	components, err := s.repo.GetComponents(ctx, p.ID)
 	if err == nil {
 		p.Components = components
 	}
@@ -35,18 +49,23 @@ func (s *Service) GetPageBySlug(slug string) (*page.Page, error) {
 	return p, nil
 }
 
+// GetPageByID retrieves a page by its ID
+func (s *Service) GetPageByID(ctx context.Context, id int) (*page.Page, error) {
+	return s.repo.FindByID(ctx, id)
+}
+
 // GetAllPages retrieves all pages
-func (s *Service) GetAllPages() ([]*page.Page, error) {
-	return s.repo.FindAll()
+func (s *Service) GetAllPages(ctx context.Context) ([]*page.Page, error) {
+	return s.repo.FindAll(ctx)
 }
 
 // GetPublishedPages retrieves only published pages
-func (s *Service) GetPublishedPages() ([]*page.Page, error) {
-	return s.repo.FindPublished()
+func (s *Service) GetPublishedPages(ctx context.Context) ([]*page.Page, error) {
+	return s.repo.FindPublished(ctx)
 }
 
 // CreatePage creates a new page
-func (s *Service) CreatePage(p *page.Page) error {
+func (s *Service) CreatePage(ctx context.Context, p *page.Page) error {
 	// Auto-generate slug if empty
 	if p.Slug == "" {
 		p.Slug = GenerateSlug(p.Title)
@@ -62,18 +81,246 @@ func (s *Service) CreatePage(p *page.Page) error {
 		p.Status = page.StatusDraft
 	}
 
-	return s.repo.Create(p)
+	if err := s.repo.Create(ctx, p); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "page.created", "page", p.ID, nil, summary(p))
+	s.changed(ctx, p.ID)
+	return nil
 }
 
 // UpdatePage updates an existing page
-func (s *Service) UpdatePage(p *page.Page) error {
+func (s *Service) UpdatePage(ctx context.Context, p *page.Page) error {
+	var before map[string]interface{}
+	if existing, err := s.repo.FindByID(ctx, p.ID); err == nil {
+		before = summary(existing)
+	}
+
+	p.UpdatedAt = time.Now()
+	if err := s.repo.Update(ctx, p); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "page.updated", "page", p.ID, before, summary(p))
+	s.changed(ctx, p.ID)
+	return nil
+}
+
+// DeletePage moves a page to the trash, from where it can be restored
+func (s *Service) DeletePage(ctx context.Context, id int) error {
+	existing, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if err := s.repo.Delete(ctx, id); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "page.deleted", "page", id, summary(existing), nil)
+	s.changed(ctx, id)
+	return nil
+}
+
+// ClonePage copies a page and its component layout under a new slug. The
+// copy starts as a draft.
+func (s *Service) ClonePage(ctx context.Context, id int, slug, title string) (*page.Page, error) {
+	var clone *page.Page
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		source, err := s.repo.FindByID(ctx, id)
+		if err != nil {
+			return errors.NewNotFound("Page not found")
+		}
+		components, err := s.repo.GetComponents(ctx, id)
+		if err != nil {
+			return errors.NewInternal("Failed to load page components", err)
+		}
+
+		clone = &page.Page{
+			Slug:            slug,
+			Title:           title,
+			Content:         source.Content,
+			MetaTitle:       source.MetaTitle,
+			MetaDescription: source.MetaDescription,
+			MetaKeywords:    source.MetaKeywords,
+			OGImage:         source.OGImage,
+			Status:          page.StatusDraft,
+		}
+		if clone.Title == "" {
+			clone.Title = source.Title
+		}
+		if err := s.ValidateSlug(ctx, clone.Slug, 0); err != nil {
+			return errors.NewValidation(fmt.Sprintf("Invalid slug %q: %v", clone.Slug, err))
+		}
+
+		now := time.Now()
+		clone.CreatedAt, clone.UpdatedAt = now, now
+		if err := s.repo.Create(ctx, clone); err != nil {
+			return errors.NewInternal("Failed to create page", err)
+		}
+		if err := s.repo.SetComponents(ctx, clone.ID, components); err != nil {
+			return errors.NewInternal("Failed to copy page layout", err)
+		}
+		clone.Components = components
+
+		after := summary(clone)
+		after["cloned_from"] = source.ID
+		after["components"] = componentIDs(components)
+		s.audit.Record(ctx, "page.cloned", "page", clone.ID, nil, after)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return clone, nil
+}
+
+// ReplaceLayout replaces the components of a page, in order. Either the
+// whole new layout is stored or the old one is kept. Components that stay
+// on the page keep their overrides.
+func (s *Service) ReplaceLayout(ctx context.Context, pageID int, componentIDs []int) error {
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		p, err := s.repo.FindByID(ctx, pageID)
+		if err != nil {
+			return errors.NewNotFound("Page not found")
+		}
+		return s.replaceLayout(ctx, p, componentIDs)
+	})
+}
+
+// ImportPage stores a page with its component layout: a new page is
+// created, one with the same slug is updated in place.
+func (s *Service) ImportPage(ctx context.Context, p *page.Page, componentIDs []int) error {
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		// An empty slug is the home page
+		if p.Slug != "" && p.Slug != GenerateSlug(p.Slug) {
+			return errors.NewValidation(fmt.Sprintf("Invalid slug %q: slug contains invalid characters", p.Slug))
+		}
+
+		existing, err := s.repo.FindBySlug(ctx, p.Slug)
+		if err != nil {
+			if s.slugInTrash(ctx, p.Slug) {
+				return errors.NewConflict(fmt.Sprintf("A page in the trash has the slug %q; restore or purge it first", p.Slug))
+			}
+			if err := s.CreatePage(ctx, p); err != nil {
+				return errors.NewInternal("Failed to create page", err)
+			}
+		} else {
+			p.ID = existing.ID
+			if err := s.UpdatePage(ctx, p); err != nil {
+				return errors.NewInternal("Failed to update page", err)
+			}
+		}
+		return s.replaceLayout(ctx, p, componentIDs)
+	})
+}
+
+// replaceLayout stores the layout of a page inside the caller's transaction
+func (s *Service) replaceLayout(ctx context.Context, p *page.Page, ids []int) error {
+	current, err := s.repo.GetComponents(ctx, p.ID)
+	if err != nil {
+		return errors.NewInternal("Failed to load page components", err)
+	}
+	if err := s.repo.SetComponents(ctx, p.ID, keepOverrides(current, ids)); err != nil {
+		return errors.NewInternal("Failed to replace page layout", err)
+	}
+
+	// Touch the page so caches keyed on its update time see the new layout
 	p.UpdatedAt = time.Now()
-	return s.repo.Update(p)
+	if err := s.repo.Update(ctx, p); err != nil {
+		return errors.NewInternal("Failed to update page", err)
+	}
+
+	s.audit.Record(ctx, "page.layout_replaced", "page", p.ID,
+		map[string]interface{}{"components": componentIDs(current)},
+		map[string]interface{}{"components": ids})
+	s.changed(ctx, p.ID)
+	return nil
 }
 
-// DeletePage deletes a page by ID
-func (s *Service) DeletePage(id int) error {
-	return s.repo.Delete(id)
+// OverrideComponent replaces the overrides of a component placed on a page,
+// changing it on that page only. Nil overrides show the shared component again.
+func (s *Service) OverrideComponent(ctx context.Context, pageID, placementID int, overrides *component.Overrides) error {
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		p, err := s.repo.FindByID(ctx, pageID)
+		if err != nil {
+			return errors.NewNotFound("Page not found")
+		}
+		layout, err := s.repo.GetComponents(ctx, pageID)
+		if err != nil {
+			return errors.NewInternal("Failed to load page components", err)
+		}
+		var placement *page.Placement
+		for i := range layout {
+			if layout[i].PlacementID == placementID {
+				placement = &layout[i]
+			}
+		}
+		if placement == nil {
+			return errors.NewNotFound(fmt.Sprintf("Placement %d is not on this page", placementID))
+		}
+
+		if err := s.repo.SetOverrides(ctx, pageID, placementID, overrides); err != nil {
+			return errors.NewInternal("Failed to store the overrides", err)
+		}
+		p.UpdatedAt = time.Now()
+		if err := s.repo.Update(ctx, p); err != nil {
+			return errors.NewInternal("Failed to update page", err)
+		}
+
+		s.audit.Record(ctx, "page.component_overridden", "page", pageID,
+			map[string]interface{}{"placement_id": placementID, "component_id": placement.ID, "overrides": placement.Overrides},
+			map[string]interface{}{"placement_id": placementID, "component_id": placement.ID, "overrides": overrides})
+		s.changed(ctx, pageID)
+		return nil
+	})
+}
+
+// changed announces a page change once it is committed
+func (s *Service) changed(ctx context.Context, id int) {
+	s.uow.AfterCommit(ctx, func() {
+		s.events.Publish(events.Event{Kind: events.PageChanged, ID: id})
+	})
+}
+
+// keepOverrides builds a layout of components, giving each the overrides it
+// had in the current layout: the n-th placement of a component takes those
+// of its n-th placement before
+func keepOverrides(current []page.Placement, ids []int) []page.Placement {
+	previous := make(map[int][]*component.Overrides)
+	for _, pl := range current {
+		previous[pl.ID] = append(previous[pl.ID], pl.Overrides)
+	}
+
+	layout := make([]page.Placement, 0, len(ids))
+	for _, id := range ids {
+		pl := page.Placement{Component: component.Component{ID: id}}
+		if overrides := previous[id]; len(overrides) > 0 {
+			pl.Overrides, previous[id] = overrides[0], overrides[1:]
+		}
+		layout = append(layout, pl)
+	}
+	return layout
+}
+
+// componentIDs lists the IDs of a layout in order
+func componentIDs(components []page.Placement) []int {
+	ids := make([]int, 0, len(components))
+	for _, c := range components {
+		ids = append(ids, c.ID)
+	}
+	return ids
+}
+
+// summary describes a page in audit entries (content is summarized by length)
+func summary(p *page.Page) map[string]interface{} {
+	return map[string]interface{}{
+		"slug":           p.Slug,
+		"title":          p.Title,
+		"status":         p.Status,
+		"content_length": len(p.Content),
+	}
 }
 
 // GenerateSlug creates a URL-friendly slug from text
@@ -109,7 +356,7 @@ func GenerateSlug(text string) string {
 }
 
 // ValidateSlug checks if slug is valid and unique
-func (s *Service) ValidateSlug(slug string, excludeID int) error {
+func (s *Service) ValidateSlug(ctx context.Context, slug string, excludeID int) error {
 	if slug == "" {
 		return fmt.Errorf("slug cannot be empty")
 	}
@@ -119,10 +366,27 @@ func (s *Service) ValidateSlug(slug string, excludeID int) error {
 	}
 
 	// Check uniqueness
-	existing, err := s.repo.FindBySlug(slug)
+	existing, err := s.repo.FindBySlug(ctx, slug)
 	if err == nil && existing.ID != excludeID {
 		return fmt.Errorf("slug already exists")
 	}
+	if err != nil && s.slugInTrash(ctx, slug) {
+		return fmt.Errorf("a page in the trash has this slug; restore or purge it first")
+	}
 
 	return nil
 }
+
+// slugInTrash checks if a page in the trash still holds a slug
+func (s *Service) slugInTrash(ctx context.Context, slug string) bool {
+	deleted, err := s.repo.FindDeleted(ctx)
+	if err != nil {
+		return false
+	}
+	for _, p := range deleted {
+		if p.Slug == slug {
+			return true
+		}
+	}
+	return false
+}
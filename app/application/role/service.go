@@ -0,0 +1,262 @@
+package role
+
+import (
+	"context"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/domain/role"
+	"cacto-cms/app/shared/errors"
+)
+
+// roleNamePattern restricts role names to lowercase slugs
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_-]{1,49}$`)
+
+// Service handles business logic for roles and permissions.
+// Resolved permissions are cached per role and invalidated on every write.
+type Service struct {
+	repo  role.Repository
+	audit *auditservice.Service
+
+	mu    sync.RWMutex
+	cache map[string][]string
+}
+
+// NewService creates a new role service
+func NewService(repo role.Repository, auditService *auditservice.Service) *Service {
+	return &Service{
+		repo:  repo,
+		audit: auditService,
+		cache: make(map[string][]string),
+	}
+}
+
+// CreateRoleRequest represents role creation data
+type CreateRoleRequest struct {
+	Name        string   `json:"name" validate:"required,min=2,max=50"`
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// UpdateRoleRequest represents role update data (role names are immutable)
+type UpdateRoleRequest struct {
+	Description string   `json:"description" validate:"max=255"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles retrieves all roles
+func (s *Service) GetRoles(ctx context.Context) ([]*role.Role, error) {
+	roles, err := s.repo.FindAll(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load roles", err)
+	}
+	return roles, nil
+}
+
+// GetRoleByID retrieves a role by ID
+func (s *Service) GetRoleByID(ctx context.Context, id int) (*role.Role, error) {
+	r, err := s.repo.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.Wrap(err, errors.ErrCodeNotFound, "Role not found", 404)
+	}
+	return r, nil
+}
+
+// GetPermissions retrieves the permission catalog
+func (s *Service) GetPermissions(ctx context.Context) ([]*role.Permission, error) {
+	permissions, err := s.repo.FindPermissions(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load permissions", err)
+	}
+	return permissions, nil
+}
+
+// RoleExists checks if a role with the given name exists
+func (s *Service) RoleExists(ctx context.Context, name string) bool {
+	_, err := s.repo.FindByName(ctx, name)
+	return err == nil
+}
+
+// CreateRole creates a custom role
+func (s *Service) CreateRole(ctx context.Context, req *CreateRoleRequest) (*role.Role, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.NewValidation("name must start with a letter and contain only lowercase letters, digits, '-' or '_'")
+	}
+
+	if s.RoleExists(ctx, name) {
+		return nil, errors.NewConflict("Role with this name already exists")
+	}
+
+	permissions, err := s.ValidatePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	r := &role.Role{
+		Name:        name,
+		Description: req.Description,
+		Permissions: permissions,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+
+	if err := s.repo.Create(ctx, r); err != nil {
+		return nil, errors.NewInternal("Failed to create role", err)
+	}
+	if err := s.repo.SetPermissions(ctx, r.ID, permissions); err != nil {
+		return nil, errors.NewInternal("Failed to save role permissions", err)
+	}
+
+	s.invalidate()
+	s.audit.Record(ctx, "role.created", "role", r.ID, nil, summary(r))
+	return r, nil
+}
+
+// UpdateRole updates a role's description and permissions
+func (s *Service) UpdateRole(ctx context.Context, id int, req *UpdateRoleRequest) (*role.Role, error) {
+	r, err := s.GetRoleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// The admin role always keeps full access so the system cannot be locked out
+	if r.Name == "admin" && !role.Grants(req.Permissions, role.Wildcard) {
+		return nil, errors.NewForbidden("The admin role must keep the '*' permission")
+	}
+
+	permissions, err := s.ValidatePermissions(ctx, req.Permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	before := summary(r)
+	r.Description = req.Description
+	r.Permissions = permissions
+	r.UpdatedAt = time.Now()
+
+	if err := s.repo.Update(ctx, r); err != nil {
+		return nil, errors.NewInternal("Failed to update role", err)
+	}
+	if err := s.repo.SetPermissions(ctx, r.ID, permissions); err != nil {
+		return nil, errors.NewInternal("Failed to save role permissions", err)
+	}
+
+	s.invalidate()
+	s.audit.Record(ctx, "role.updated", "role", r.ID, before, summary(r))
+	return r, nil
+}
+
+// DeleteRole deletes a custom role that is not assigned to any user
+func (s *Service) DeleteRole(ctx context.Context, id int) error {
+	r, err := s.GetRoleByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	if r.IsSystem {
+		return errors.NewForbidden("Default roles cannot be deleted")
+	}
+
+	count, err := s.repo.CountUsers(ctx, r.Name)
+	if err != nil {
+		return errors.NewInternal("Failed to check role usage", err)
+	}
+	if count > 0 {
+		return errors.NewConflict("Role is still assigned to users")
+	}
+
+	if err := s.repo.Delete(ctx, r.ID); err != nil {
+		return errors.NewInternal("Failed to delete role", err)
+	}
+
+	s.invalidate()
+	s.audit.Record(ctx, "role.deleted", "role", r.ID, summary(r), nil)
+	return nil
+}
+
+// PermissionsFor returns the permissions granted to a role name (cached).
+// Unknown roles have no permissions.
+func (s *Service) PermissionsFor(ctx context.Context, roleName string) []string {
+	s.mu.RLock()
+	permissions, ok := s.cache[roleName]
+	s.mu.RUnlock()
+	if ok {
+		return permissions
+	}
+
+	r, err := s.repo.FindByName(ctx, roleName)
+	if err != nil {
+		// Don't cache lookup failures; the role may be created later
+		return nil
+	}
+
+	s.mu.Lock()
+	s.cache[roleName] = r.Permissions
+	s.mu.Unlock()
+
+	return r.Permissions
+}
+
+// HasPermission checks if a role grants a permission
+func (s *Service) HasPermission(ctx context.Context, roleName, permission string) bool {
+	return role.Grants(s.PermissionsFor(ctx, roleName), permission)
+}
+
+// ValidatePermissions checks permissions against the catalog and returns them sorted and deduplicated
+func (s *Service) ValidatePermissions(ctx context.Context, requested []string) ([]string, error) {
+	catalog, err := s.repo.FindPermissions(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load permissions", err)
+	}
+
+	known := make(map[string]bool, len(catalog))
+	resources := make(map[string]bool)
+	for _, p := range catalog {
+		known[p.Name] = true
+		if resource, _, ok := strings.Cut(p.Name, ":"); ok {
+			resources[resource] = true
+		}
+	}
+
+	seen := make(map[string]bool, len(requested))
+	permissions := make([]string, 0, len(requested))
+	for _, p := range requested {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+
+		resource, action, _ := strings.Cut(p, ":")
+		if !known[p] && !(action == "*" && resources[resource]) {
+			return nil, errors.NewValidation("unknown permission: " + p)
+		}
+
+		seen[p] = true
+		permissions = append(permissions, p)
+	}
+
+	sort.Strings(permissions)
+	return permissions, nil
+}
+
+// invalidate clears the permission cache
+func (s *Service) invalidate() {
+	s.mu.Lock()
+	s.cache = make(map[string][]string)
+	s.mu.Unlock()
+}
+
+// summary describes a role in audit entries
+func summary(r *role.Role) map[string]interface{} {
+	return map[string]interface{}{
+		"name":        r.Name,
+		"description": r.Description,
+		"permissions": r.Permissions,
+	}
+}
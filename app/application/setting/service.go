@@ -0,0 +1,185 @@
+package setting
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/domain/setting"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/events"
+)
+
+// cacheTTL bounds how long values are served from memory. Writes through
+// the service clear the cache at once; the TTL picks up writes made by
+// other processes, such as artisan content:import.
+const cacheTTL = time.Minute
+
+// Defaults are the values used for settings the database doesn't hold
+type Defaults struct {
+	SiteName        string
+	SiteDescription string
+}
+
+// UpdateSettingsRequest holds the settings an admin can change
+type UpdateSettingsRequest struct {
+	SiteName        string `json:"site_name" validate:"required,max=100"`
+	SiteDescription string `json:"site_description" validate:"max=300"`
+	SitemapEnabled  bool   `json:"sitemap_enabled"`
+}
+
+// Service reads and writes site settings. Database values are layered over
+// the defaults from configuration and cached.
+type Service struct {
+	repo     setting.Repository
+	audit    *auditservice.Service
+	defaults map[string]string
+	events   *events.Bus
+
+	mu       sync.RWMutex
+	values   map[string]string // nil until loaded
+	loadedAt time.Time
+}
+
+// NewService creates a new settings service
+func NewService(repo setting.Repository, auditService *auditservice.Service, defaults Defaults) *Service {
+	return &Service{
+		repo:  repo,
+		audit: auditService,
+		defaults: map[string]string{
+			setting.KeySiteName:        defaults.SiteName,
+			setting.KeySiteDescription: defaults.SiteDescription,
+			setting.KeySitemapEnabled:  "true",
+		},
+	}
+}
+
+// SetEvents publishes settings changes on a bus, e.g. for the page cache
+func (s *Service) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// Get returns the value of a setting: the stored one, or the default when
+// nothing (or an empty value) is stored
+func (s *Service) Get(ctx context.Context, key string) string {
+	if value := s.load(ctx)[key]; value != "" {
+		return value
+	}
+	return s.defaults[key]
+}
+
+// All returns the effective value of every known and stored setting
+func (s *Service) All(ctx context.Context) map[string]string {
+	all := make(map[string]string, len(s.defaults))
+	for key := range s.defaults {
+		all[key] = s.Get(ctx, key)
+	}
+	for key := range s.load(ctx) {
+		all[key] = s.Get(ctx, key)
+	}
+	return all
+}
+
+// SiteName returns the name of the site
+func (s *Service) SiteName(ctx context.Context) string {
+	return s.Get(ctx, setting.KeySiteName)
+}
+
+// SiteDescription returns the default description of the site
+func (s *Service) SiteDescription(ctx context.Context) string {
+	return s.Get(ctx, setting.KeySiteDescription)
+}
+
+// SitemapEnabled reports whether a sitemap is published
+func (s *Service) SitemapEnabled(ctx context.Context) bool {
+	enabled, err := strconv.ParseBool(s.Get(ctx, setting.KeySitemapEnabled))
+	return err != nil || enabled
+}
+
+// SitemapLastGenerated returns when the sitemap was last written, zero if never
+func (s *Service) SitemapLastGenerated(ctx context.Context) time.Time {
+	t, _ := time.Parse(time.RFC3339, s.Get(ctx, setting.KeySitemapLastGenerated))
+	return t
+}
+
+// MarkSitemapGenerated records when the sitemap was written
+func (s *Service) MarkSitemapGenerated(ctx context.Context, at time.Time) error {
+	return s.save(ctx, map[string]string{setting.KeySitemapLastGenerated: at.UTC().Format(time.RFC3339)})
+}
+
+// UpdateSettings stores the settings an admin changed
+func (s *Service) UpdateSettings(ctx context.Context, req *UpdateSettingsRequest) error {
+	values := map[string]string{
+		setting.KeySiteName:        strings.TrimSpace(req.SiteName),
+		setting.KeySiteDescription: strings.TrimSpace(req.SiteDescription),
+		setting.KeySitemapEnabled:  strconv.FormatBool(req.SitemapEnabled),
+	}
+	if values[setting.KeySiteName] == "" {
+		return errors.NewValidation("Site name is required")
+	}
+
+	before := make(map[string]interface{})
+	after := make(map[string]interface{})
+	for key, value := range values {
+		if current := s.Get(ctx, key); current != value {
+			before[key] = current
+			after[key] = value
+		}
+	}
+	if len(after) == 0 {
+		return nil
+	}
+
+	if err := s.save(ctx, values); err != nil {
+		return errors.NewInternal("Failed to save settings", err)
+	}
+
+	s.audit.Record(ctx, "settings.updated", "settings", nil, before, after)
+	s.events.Publish(events.Event{Kind: events.SettingsChanged})
+	return nil
+}
+
+// Invalidate drops the cached values, so the next read goes to the database
+func (s *Service) Invalidate() {
+	s.mu.Lock()
+	s.values = nil
+	s.mu.Unlock()
+}
+
+// save writes values and clears the cache
+func (s *Service) save(ctx context.Context, values map[string]string) error {
+	err := s.repo.Save(ctx, values)
+	s.Invalidate()
+	return err
+}
+
+// load returns the stored values, reading them when the cache is empty or
+// stale. When the database can't be read the defaults are used, uncached.
+func (s *Service) load(ctx context.Context) map[string]string {
+	s.mu.RLock()
+	values, loadedAt := s.values, s.loadedAt
+	s.mu.RUnlock()
+	if values != nil && time.Since(loadedAt) < cacheTTL {
+		return values
+	}
+
+	settings, err := s.repo.FindAll(ctx)
+	if err != nil {
+		log.Printf("⚠️  Failed to load settings, using defaults: %v", err)
+		return map[string]string{}
+	}
+
+	values = make(map[string]string, len(settings))
+	for _, st := range settings {
+		values[st.Key] = st.Value
+	}
+
+	s.mu.Lock()
+	s.values, s.loadedAt = values, time.Now()
+	s.mu.Unlock()
+	return values
+}
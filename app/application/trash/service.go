@@ -0,0 +1,329 @@
+package trash
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/media"
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/events"
+)
+
+// purgeInterval is how often the scheduled purge looks for expired items
+const purgeInterval = time.Hour
+
+// Contents lists what is in the trash
+type Contents struct {
+	Pages      []*page.Page           `json:"pages"`
+	Components []*component.Component `json:"components"`
+	Media      []*media.Media         `json:"media"`
+	Retention  time.Duration          `json:"-"`
+}
+
+// Purged counts the items a purge removed
+type Purged struct {
+	Pages      int `json:"pages"`
+	Components int `json:"components"`
+	Media      int `json:"media"`
+}
+
+// Service restores and purges deleted pages, components and media
+type Service struct {
+	pages      page.Repository
+	components component.Repository
+	media      media.Repository
+	uploadDir  string
+	audit      *auditservice.Service
+	events     *events.Bus
+	retention  time.Duration
+}
+
+// NewService creates a new trash service. Items are purged once they have
+// been in the trash for the retention period; purging media removes their
+// files from uploadDir.
+func NewService(pages page.Repository, components component.Repository, mediaRepo media.Repository, uploadDir string, auditService *auditservice.Service, retention time.Duration) *Service {
+	return &Service{
+		pages:      pages,
+		components: components,
+		media:      mediaRepo,
+		uploadDir:  uploadDir,
+		audit:      auditService,
+		retention:  retention,
+	}
+}
+
+// SetEvents publishes restored pages and components on a bus, e.g. for the
+// page cache
+func (s *Service) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// Retention returns how long items stay in the trash
+func (s *Service) Retention() time.Duration {
+	return s.retention
+}
+
+// Contents returns the pages, components and media in the trash
+func (s *Service) Contents(ctx context.Context) (*Contents, error) {
+	pages, err := s.pages.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted pages", err)
+	}
+	components, err := s.components.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted components", err)
+	}
+	deletedMedia, err := s.media.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted media", err)
+	}
+	return &Contents{Pages: pages, Components: components, Media: deletedMedia, Retention: s.retention}, nil
+}
+
+// RestorePage takes a page out of the trash with its component layout
+func (s *Service) RestorePage(ctx context.Context, id int) (*page.Page, error) {
+	if err := s.pages.Restore(ctx, id); err != nil {
+		return nil, errors.NewNotFound("Page not in the trash")
+	}
+	p, err := s.pages.FindByID(ctx, id)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load restored page", err)
+	}
+
+	s.audit.Record(ctx, "page.restored", "page", id, nil, pageSummary(p))
+	s.events.Publish(events.Event{Kind: events.PageChanged, ID: id})
+	return p, nil
+}
+
+// RestoreComponent takes a component out of the trash. It reappears on the
+// pages it was placed on, in the same positions.
+func (s *Service) RestoreComponent(ctx context.Context, id int) (*component.Component, error) {
+	deleted, err := s.deletedComponent(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.components.FindByName(ctx, deleted.Name); err == nil {
+		return nil, errors.NewConflict(fmt.Sprintf("Another component is named %q; rename it before restoring this one", deleted.Name))
+	}
+
+	if err := s.components.Restore(ctx, id); err != nil {
+		return nil, errors.NewNotFound("Component not in the trash")
+	}
+	deleted.DeletedAt = nil
+
+	s.audit.Record(ctx, "component.restored", "component", id, nil, componentSummary(deleted))
+	s.events.Publish(events.Event{Kind: events.ComponentChanged, ID: id})
+	return deleted, nil
+}
+
+// RestoreMedia takes a media file out of the trash
+func (s *Service) RestoreMedia(ctx context.Context, id int) (*media.Media, error) {
+	deleted, err := s.deletedMedia(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := s.media.FindByFilename(ctx, deleted.Filename); err == nil {
+		return nil, errors.NewConflict(fmt.Sprintf("Another media record uses the file %q", deleted.Filename))
+	}
+
+	if err := s.media.Restore(ctx, id); err != nil {
+		return nil, errors.NewNotFound("Media not in the trash")
+	}
+	deleted.DeletedAt = nil
+
+	s.audit.Record(ctx, "media.restored", "media", id, nil, mediaSummary(deleted))
+	return deleted, nil
+}
+
+// PurgePage permanently deletes a page in the trash
+func (s *Service) PurgePage(ctx context.Context, id int) error {
+	deleted, err := s.deletedPage(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.pages.Purge(ctx, id); err != nil {
+		return errors.NewInternal("Failed to purge page", err)
+	}
+
+	s.audit.Record(ctx, "page.purged", "page", id, pageSummary(deleted), nil)
+	return nil
+}
+
+// PurgeComponent permanently deletes a component in the trash and its
+// placements on pages
+func (s *Service) PurgeComponent(ctx context.Context, id int) error {
+	deleted, err := s.deletedComponent(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.components.Purge(ctx, id); err != nil {
+		return errors.NewInternal("Failed to purge component", err)
+	}
+
+	s.audit.Record(ctx, "component.purged", "component", id, componentSummary(deleted), nil)
+	return nil
+}
+
+// PurgeMedia permanently deletes a media record in the trash and its file
+func (s *Service) PurgeMedia(ctx context.Context, id int) error {
+	deleted, err := s.deletedMedia(ctx, id)
+	if err != nil {
+		return err
+	}
+	return s.purgeMedia(ctx, deleted)
+}
+
+// purgeMedia deletes a media record, then its file unless another record
+// still uses it. A file that can't be removed is logged; the record is gone.
+func (s *Service) purgeMedia(ctx context.Context, m *media.Media) error {
+	if err := s.media.Purge(ctx, m.ID); err != nil {
+		return errors.NewInternal("Failed to purge media", err)
+	}
+	s.audit.Record(ctx, "media.purged", "media", m.ID, mediaSummary(m), nil)
+
+	if _, err := s.media.FindByFilename(ctx, m.Filename); err == nil {
+		return nil
+	}
+	if filepath.Base(m.Filename) != m.Filename || m.Filename == "." || m.Filename == ".." {
+		log.Printf("⚠️  Media #%d has an unsafe file name %q, file left in place", m.ID, m.Filename)
+		return nil
+	}
+	if err := os.Remove(filepath.Join(s.uploadDir, m.Filename)); err != nil && !os.IsNotExist(err) {
+		log.Printf("⚠️  Failed to remove the file of purged media #%d: %v", m.ID, err)
+	}
+	return nil
+}
+
+// PurgeExpired permanently deletes the items that have been in the trash for
+// longer than the retention period
+func (s *Service) PurgeExpired(ctx context.Context) (Purged, error) {
+	return s.PurgeDeletedBefore(ctx, time.Now().Add(-s.retention))
+}
+
+// PurgeDeletedBefore permanently deletes the items moved to the trash before
+// a time
+func (s *Service) PurgeDeletedBefore(ctx context.Context, before time.Time) (Purged, error) {
+	var purged Purged
+	var err error
+	if purged.Pages, err = s.pages.PurgeDeletedBefore(ctx, before); err != nil {
+		return purged, errors.NewInternal("Failed to purge deleted pages", err)
+	}
+	if purged.Components, err = s.components.PurgeDeletedBefore(ctx, before); err != nil {
+		return purged, errors.NewInternal("Failed to purge deleted components", err)
+	}
+	deletedMedia, err := s.media.FindDeleted(ctx)
+	if err != nil {
+		return purged, errors.NewInternal("Failed to load deleted media", err)
+	}
+	for _, m := range deletedMedia {
+		if !m.DeletedAt.Before(before) {
+			continue
+		}
+		if err := s.purgeMedia(ctx, m); err != nil {
+			return purged, err
+		}
+		purged.Media++
+	}
+
+	if purged.Pages+purged.Components+purged.Media > 0 {
+		s.audit.Record(ctx, "trash.purged", "", nil, nil, map[string]interface{}{
+			"pages":          purged.Pages,
+			"components":     purged.Components,
+			"media":          purged.Media,
+			"deleted_before": before.UTC().Format(time.RFC3339),
+		})
+	}
+	return purged, nil
+}
+
+// Schedule purges expired items now and then every hour
+func (s *Service) Schedule() {
+	go func() {
+		for {
+			purged, err := s.PurgeExpired(context.Background())
+			if err != nil {
+				log.Printf("❌ Trash purge failed: %v", err)
+			} else if purged.Pages+purged.Components+purged.Media > 0 {
+				log.Printf("🗑️  Purged %d page(s), %d component(s) and %d media file(s) from the trash",
+					purged.Pages, purged.Components, purged.Media)
+			}
+			time.Sleep(purgeInterval)
+		}
+	}()
+}
+
+// deletedPage finds a page in the trash
+func (s *Service) deletedPage(ctx context.Context, id int) (*page.Page, error) {
+	pages, err := s.pages.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted pages", err)
+	}
+	for _, p := range pages {
+		if p.ID == id {
+			return p, nil
+		}
+	}
+	return nil, errors.NewNotFound("Page not in the trash")
+}
+
+// deletedComponent finds a component in the trash
+func (s *Service) deletedComponent(ctx context.Context, id int) (*component.Component, error) {
+	components, err := s.components.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted components", err)
+	}
+	for _, c := range components {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return nil, errors.NewNotFound("Component not in the trash")
+}
+
+// deletedMedia finds a media record in the trash
+func (s *Service) deletedMedia(ctx context.Context, id int) (*media.Media, error) {
+	deleted, err := s.media.FindDeleted(ctx)
+	if err != nil {
+		return nil, errors.NewInternal("Failed to load deleted media", err)
+	}
+	for _, m := range deleted {
+		if m.ID == id {
+			return m, nil
+		}
+	}
+	return nil, errors.NewNotFound("Media not in the trash")
+}
+
+// pageSummary describes a page in audit entries
+func pageSummary(p *page.Page) map[string]interface{} {
+	return map[string]interface{}{
+		"slug":   p.Slug,
+		"title":  p.Title,
+		"status": p.Status,
+	}
+}
+
+// componentSummary describes a component in audit entries
+func componentSummary(c *component.Component) map[string]interface{} {
+	return map[string]interface{}{
+		"type":  c.Type,
+		"name":  c.Name,
+		"title": c.Title,
+	}
+}
+
+// mediaSummary describes a media file in audit entries
+func mediaSummary(m *media.Media) map[string]interface{} {
+	return map[string]interface{}{
+		"filename":      m.Filename,
+		"original_name": m.OriginalName,
+		"mime_type":     m.MimeType,
+	}
+}
@@ -0,0 +1,108 @@
+package user
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/errors"
+)
+
+// ValidateNewPassword checks a password against the password policy
+func (s *Service) ValidateNewPassword(password, email, name string) error {
+	if err := s.policy.Validate(password, email, name); err != nil {
+		return errors.NewValidation(err.Error())
+	}
+	return nil
+}
+
+// ChangePassword changes a user's own password after verifying the current one
+func (s *Service) ChangePassword(ctx context.Context, id int, currentPassword, newPassword string) error {
+	u, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	valid, err := s.hasher.VerifyPassword(currentPassword, u.PasswordHash)
+	if err != nil || !valid {
+		return errors.NewValidation("current password is incorrect")
+	}
+
+	if err := s.setPassword(ctx, u, newPassword); err != nil {
+		return err
+	}
+
+	s.audit.Record(ctx, "user.password_changed", "user", u.ID, nil, map[string]interface{}{"email": u.Email})
+	return nil
+}
+
+// UpgradePasswordHash rehashes a verified password when its stored hash was
+// created with weaker parameters than the current ones
+func (s *Service) UpgradePasswordHash(ctx context.Context, u *user.User, password string) {
+	if !s.hasher.NeedsRehash(u.PasswordHash) {
+		return
+	}
+
+	hash, err := s.hasher.HashPassword(password)
+	if err != nil {
+		log.Printf("Failed to rehash password for %s: %v", u.Email, err)
+		return
+	}
+
+	u.PasswordHash = hash
+	if err := s.UpdateUser(ctx, u); err != nil {
+		log.Printf("Failed to store upgraded password hash for %s: %v", u.Email, err)
+		return
+	}
+
+	log.Printf("🔁 Password hash upgraded for %s", u.Email)
+}
+
+// setPassword applies the password policy, rejects recently used passwords
+// and stores the new password
+func (s *Service) setPassword(ctx context.Context, u *user.User, password string) error {
+	if err := s.ValidateNewPassword(password, u.Email, u.Name); err != nil {
+		return err
+	}
+
+	if s.policy.HistorySize > 0 {
+		previous, err := s.historyRepo.FindRecent(ctx, u.ID, s.policy.HistorySize-1)
+		if err != nil {
+			return errors.NewInternal("Failed to check password history", err)
+		}
+
+		for _, hash := range append([]string{u.PasswordHash}, previous...) {
+			if reused, _ := s.hasher.VerifyPassword(password, hash); reused {
+				return errors.NewValidation("password was used recently, choose a different one")
+			}
+		}
+	}
+
+	return s.replacePasswordHash(ctx, u, password)
+}
+
+// replacePasswordHash hashes and stores a new password, moving the old hash
+// into the password history
+func (s *Service) replacePasswordHash(ctx context.Context, u *user.User, password string) error {
+	hash, err := s.hasher.HashPassword(password)
+	if err != nil {
+		return errors.NewInternal("Failed to hash password", err)
+	}
+
+	previous := u.PasswordHash
+	u.PasswordHash = hash
+	if err := s.UpdateUser(ctx, u); err != nil {
+		return errors.NewInternal("Failed to update user", err)
+	}
+
+	if s.policy.HistorySize > 0 && previous != "" {
+		if err := s.historyRepo.Add(ctx, u.ID, previous, time.Now()); err != nil {
+			log.Printf("Failed to record password history for %s: %v", u.Email, err)
+		} else if err := s.historyRepo.Prune(ctx, u.ID, s.policy.HistorySize-1); err != nil {
+			log.Printf("Failed to prune password history for %s: %v", u.Email, err)
+		}
+	}
+
+	return nil
+}
@@ -1,24 +1,50 @@
 package user
 
 import (
+	"context"
+
+	auditservice "cacto-cms/app/application/audit"
+	roleservice "cacto-cms/app/application/role"
+	"cacto-cms/app/domain/transaction"
 	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/auth"
 	"cacto-cms/app/shared/errors"
+	"strings"
 	"time"
 )
 
 // Service handles business logic for users
 type Service struct {
-	repo user.Repository
+	repo        user.Repository
+	historyRepo user.PasswordHistoryRepository
+	uow         transaction.UnitOfWork
+	roleService *roleservice.Service
+	audit       *auditservice.Service
+	hasher      *auth.PasswordHasher
+	policy      *auth.PasswordPolicy
 }
 
 // NewService creates a new user service
-func NewService(repo user.Repository) *Service {
-	return &Service{repo: repo}
+func NewService(repo user.Repository, historyRepo user.PasswordHistoryRepository, uow transaction.UnitOfWork, roleService *roleservice.Service, auditService *auditservice.Service) *Service {
+	return &Service{
+		repo:        repo,
+		historyRepo: historyRepo,
+		uow:         uow,
+		roleService: roleService,
+		audit:       auditService,
+		hasher:      auth.NewPasswordHasher(),
+		policy:      auth.DefaultPasswordPolicy(),
+	}
+}
+
+// SetPasswordPolicy overrides the default password policy
+func (s *Service) SetPasswordPolicy(policy *auth.PasswordPolicy) {
+	s.policy = policy
 }
 
 // GetUserByID retrieves a user by ID
-func (s *Service) GetUserByID(id int) (*user.User, error) {
-	u, err := s.repo.FindByID(id)
+func (s *Service) GetUserByID(ctx context.Context, id int) (*user.User, error) {
+	u, err := s.repo.FindByID(ctx, id)
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrCodeNotFound, "User not found", 404)
 	}
@@ -26,8 +52,8 @@ func (s *Service) GetUserByID(id int) (*user.User, error) {
 }
 
 // GetUserByEmail retrieves a user by email
-func (s *Service) GetUserByEmail(email string) (*user.User, error) {
-	u, err := s.repo.FindByEmail(email)
+func (s *Service) GetUserByEmail(ctx context.Context, email string) (*user.User, error) {
+	u, err := s.repo.FindByEmail(ctx, user.NormalizeEmail(email))
 	if err != nil {
 		return nil, errors.Wrap(err, errors.ErrCodeNotFound, "User not found", 404)
 	}
@@ -35,14 +61,16 @@ func (s *Service) GetUserByEmail(email string) (*user.User, error) {
 }
 
 // GetAllUsers retrieves all users
-func (s *Service) GetAllUsers() ([]*user.User, error) {
-	return s.repo.FindAll()
+func (s *Service) GetAllUsers(ctx context.Context) ([]*user.User, error) {
+	return s.repo.FindAll(ctx)
 }
 
 // CreateUser creates a new user
-func (s *Service) CreateUser(u *user.User) error {
+func (s *Service) CreateUser(ctx context.Context, u *user.User) error {
+	u.Email = user.NormalizeEmail(u.Email)
+
 	// Check if email already exists
-	existing, err := s.repo.FindByEmail(u.Email)
+	existing, err := s.repo.FindByEmail(ctx, u.Email)
 	if err == nil && existing != nil {
 		return errors.NewConflict("User with this email already exists")
 	}
@@ -58,21 +86,241 @@ func (s *Service) CreateUser(u *user.User) error {
 	u.UpdatedAt = now
 	u.IsActive = true
 
-	return s.repo.Create(u)
+	return s.repo.Create(ctx, u)
 }
 
 // UpdateUser updates an existing user
-func (s *Service) UpdateUser(u *user.User) error {
+func (s *Service) UpdateUser(ctx context.Context, u *user.User) error {
+	u.Email = user.NormalizeEmail(u.Email)
 	u.UpdatedAt = time.Now()
-	return s.repo.Update(u)
+	return s.repo.Update(ctx, u)
 }
 
-// DeleteUser deletes a user by ID
-func (s *Service) DeleteUser(id int) error {
-	return s.repo.Delete(id)
+// DeleteUser deletes a user by ID (the last active admin cannot be deleted)
+func (s *Service) DeleteUser(ctx context.Context, id int) error {
+	return s.uow.WithTx(ctx, func(ctx context.Context) error {
+		u, err := s.GetUserByID(ctx, id)
+		if err != nil {
+			return err
+		}
+
+		if err := s.ensureNotLastAdmin(ctx, u); err != nil {
+			return err
+		}
+
+		if err := s.repo.Delete(ctx, id); err != nil {
+			return errors.NewInternal("Failed to delete user", err)
+		}
+
+		s.audit.Record(ctx, "user.deleted", "user", u.ID, summary(u), nil)
+		return nil
+	})
 }
 
 // UpdateLastLogin updates user's last login time
-func (s *Service) UpdateLastLogin(id int) error {
-	return s.repo.UpdateLastLogin(id)
+func (s *Service) UpdateLastLogin(ctx context.Context, id int) error {
+	return s.repo.UpdateLastLogin(ctx, id)
+}
+
+// InviteUserRequest represents an admin invite for a new user
+type InviteUserRequest struct {
+	Email    string `json:"email" validate:"required,email"`
+	Name     string `json:"name" validate:"required,min=2"`
+	Role     string `json:"role" validate:"required"`
+	Password string `json:"password,omitempty"` // Generated when empty, otherwise checked against the password policy
+}
+
+// InviteUser creates an active user with the given role.
+// When no password is supplied a temporary one is generated and returned.
+func (s *Service) InviteUser(ctx context.Context, req *InviteUserRequest) (*user.User, string, error) {
+	if !s.roleService.RoleExists(ctx, req.Role) {
+		return nil, "", errors.NewValidation("unknown role: " + req.Role)
+	}
+
+	if req.Password != "" {
+		if err := s.ValidateNewPassword(req.Password, req.Email, req.Name); err != nil {
+			return nil, "", err
+		}
+	}
+
+	password, temporary, err := s.passwordOrTemporary(req.Password)
+	if err != nil {
+		return nil, "", err
+	}
+
+	passwordHash, err := s.hasher.HashPassword(password)
+	if err != nil {
+		return nil, "", errors.NewInternal("Failed to hash password", err)
+	}
+
+	u := &user.User{
+		Email:        user.NormalizeEmail(req.Email),
+		PasswordHash: passwordHash,
+		Name:         strings.TrimSpace(req.Name),
+		Role:         user.Role(req.Role),
+	}
+
+	if err := s.CreateUser(ctx, u); err != nil {
+		if errors.IsAppError(err) {
+			return nil, "", err
+		}
+		return nil, "", errors.NewInternal("Failed to create user", err)
+	}
+
+	s.audit.Record(ctx, "user.invited", "user", u.ID, nil, summary(u))
+	return u, temporary, nil
+}
+
+// ChangeRole assigns a new role to a user
+func (s *Service) ChangeRole(ctx context.Context, id int, roleName string) (*user.User, error) {
+	var u *user.User
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if u, err = s.GetUserByID(ctx, id); err != nil {
+			return err
+		}
+
+		if !s.roleService.RoleExists(ctx, roleName) {
+			return errors.NewValidation("unknown role: " + roleName)
+		}
+
+		if u.Role == user.Role(roleName) {
+			return nil
+		}
+
+		if err := s.ensureNotLastAdmin(ctx, u); err != nil {
+			return err
+		}
+
+		previous := u.Role
+		u.Role = user.Role(roleName)
+		if err := s.UpdateUser(ctx, u); err != nil {
+			return errors.NewInternal("Failed to update user", err)
+		}
+
+		s.audit.Record(ctx, "user.role_changed", "user", u.ID,
+			map[string]interface{}{"email": u.Email, "role": previous},
+			map[string]interface{}{"email": u.Email, "role": u.Role},
+		)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// SetActive deactivates or reactivates a user
+func (s *Service) SetActive(ctx context.Context, id int, active bool) (*user.User, error) {
+	var u *user.User
+	err := s.uow.WithTx(ctx, func(ctx context.Context) error {
+		var err error
+		if u, err = s.GetUserByID(ctx, id); err != nil {
+			return err
+		}
+
+		if u.IsActive == active {
+			return nil
+		}
+
+		if !active {
+			if err := s.ensureNotLastAdmin(ctx, u); err != nil {
+				return err
+			}
+		}
+
+		u.IsActive = active
+		if err := s.UpdateUser(ctx, u); err != nil {
+			return errors.NewInternal("Failed to update user", err)
+		}
+
+		action := "user.deactivated"
+		if active {
+			action = "user.reactivated"
+		}
+		s.audit.Record(ctx, action, "user", u.ID,
+			map[string]interface{}{"email": u.Email, "is_active": !active},
+			map[string]interface{}{"email": u.Email, "is_active": active},
+		)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// ResetPassword sets a new password for a user.
+// When no password is supplied a temporary one is generated and returned.
+func (s *Service) ResetPassword(ctx context.Context, id int, password string) (string, error) {
+	u, err := s.GetUserByID(ctx, id)
+	if err != nil {
+		return "", err
+	}
+
+	if password != "" {
+		if err := s.setPassword(ctx, u, password); err != nil {
+			return "", err
+		}
+		s.audit.Record(ctx, "user.password_reset", "user", u.ID, nil,
+			map[string]interface{}{"email": u.Email, "temporary": false})
+		return "", nil
+	}
+
+	temporary, err := auth.GenerateTemporaryPassword()
+	if err != nil {
+		return "", errors.NewInternal("Failed to generate password", err)
+	}
+
+	// Generated passwords are random, so only the old hash is kept in the history
+	if err := s.replacePasswordHash(ctx, u, temporary); err != nil {
+		return "", err
+	}
+
+	s.audit.Record(ctx, "user.password_reset", "user", u.ID, nil,
+		map[string]interface{}{"email": u.Email, "temporary": true})
+	return temporary, nil
+}
+
+// ensureNotLastAdmin prevents removing, demoting or deactivating the last
+// active admin. Callers run it in the transaction of the write it guards, so
+// concurrent changes can't both pass the check.
+func (s *Service) ensureNotLastAdmin(ctx context.Context, u *user.User) error {
+	if u.Role != user.RoleAdmin || !u.IsActive {
+		return nil
+	}
+
+	count, err := s.repo.CountActiveByRole(ctx, user.RoleAdmin)
+	if err != nil {
+		return errors.NewInternal("Failed to count admins", err)
+	}
+	if count <= 1 {
+		return errors.NewConflict("Cannot remove the last active admin")
+	}
+
+	return nil
+}
+
+// passwordOrTemporary returns the given password, or a generated one which is
+// also returned as the temporary password to hand over to the user
+func (s *Service) passwordOrTemporary(password string) (string, string, error) {
+	if password != "" {
+		return password, "", nil
+	}
+
+	temporary, err := auth.GenerateTemporaryPassword()
+	if err != nil {
+		return "", "", errors.NewInternal("Failed to generate password", err)
+	}
+	return temporary, temporary, nil
+}
+
+// summary describes a user in audit entries (never includes credentials)
+func summary(u *user.User) map[string]interface{} {
+	return map[string]interface{}{
+		"email":     u.Email,
+		"name":      u.Name,
+		"role":      u.Role,
+		"is_active": u.IsActive,
+	}
 }
@@ -0,0 +1,41 @@
+package apitoken
+
+import "time"
+
+// Kind distinguishes token owners
+type Kind string
+
+const (
+	// KindPersonal tokens act on behalf of a user, limited to their scopes
+	KindPersonal Kind = "personal"
+	// KindService tokens belong to an integration (CI, headless frontend) and act with their scopes only
+	KindService Kind = "service"
+)
+
+// Token represents an API token. The plaintext is never stored; requests
+// are matched by the SHA-256 hash of the presented token.
+type Token struct {
+	ID         int        `json:"id"`
+	Name       string     `json:"name"`
+	Kind       Kind       `json:"kind"`
+	UserID     *int       `json:"user_id,omitempty"` // Owner of a personal token
+	CreatedBy  *int       `json:"created_by,omitempty"`
+	Prefix     string     `json:"prefix"` // First characters of the token, for identification
+	TokenHash  string     `json:"-"`
+	Scopes     []string   `json:"scopes"`
+	ExpiresAt  time.Time  `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	LastUsedIP string     `json:"last_used_ip,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// IsActive checks if the token can be used at the given time
+func (t *Token) IsActive(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// IsOwnedBy checks if the token is a personal token of the given user
+func (t *Token) IsOwnedBy(userID int) bool {
+	return t.UserID != nil && *t.UserID == userID
+}
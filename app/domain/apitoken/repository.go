@@ -0,0 +1,17 @@
+package apitoken
+
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for API token data access
+type Repository interface {
+	FindByID(ctx context.Context, id int) (*Token, error)
+	FindByHash(ctx context.Context, hash string) (*Token, error)
+	FindByUser(ctx context.Context, userID int) ([]*Token, error)
+	FindAll(ctx context.Context) ([]*Token, error)
+	Create(ctx context.Context, t *Token) error
+	TouchLastUsed(ctx context.Context, id int, at time.Time, ip string) error
+	Revoke(ctx context.Context, id int, at time.Time) error
+}
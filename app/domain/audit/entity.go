@@ -0,0 +1,70 @@
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+// Entry represents one audit log record. Entries are append-only: they are
+// never updated or deleted, and keep a copy of the actor's email so they
+// still read correctly after the user is gone.
+type Entry struct {
+	ID         int       `json:"id"`
+	ActorID    *int      `json:"actor_id,omitempty"` // Nil for system actions (CLI, scheduled jobs) and anonymous auth events
+	ActorEmail string    `json:"actor_email,omitempty"`
+	TokenID    *int      `json:"token_id,omitempty"` // Set when the action was made with an API token
+	Action     string    `json:"action"`             // e.g. "user.role_changed", "auth.login_failed"
+	TargetType string    `json:"target_type,omitempty"`
+	TargetID   string    `json:"target_id,omitempty"`
+	Before     string    `json:"before,omitempty"` // JSON summary of the target before the change
+	After      string    `json:"after,omitempty"`  // JSON summary of the target after the change
+	IPAddress  string    `json:"ip_address,omitempty"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Set when an admin performed the action while impersonating the actor
+	ImpersonatorID    *int   `json:"impersonator_id,omitempty"`
+	ImpersonatorEmail string `json:"impersonator_email,omitempty"`
+}
+
+// Filter narrows down audit log queries. Zero values match everything.
+type Filter struct {
+	ActorID    *int
+	ActorEmail string // Substring match
+	Action     string // Exact action, or a prefix when it ends with "." (e.g. "auth.")
+	TargetType string
+	TargetID   string
+	Since      *time.Time
+	Until      *time.Time
+	Limit      int
+	Offset     int
+}
+
+// Actor identifies who performs an action and from where
+type Actor struct {
+	UserID    *int
+	Email     string
+	TokenID   *int
+	IPAddress string
+	UserAgent string
+
+	ImpersonatorID    *int // Admin acting as the user
+	ImpersonatorEmail string
+}
+
+type actorKey struct{}
+
+// WithActor returns a context carrying the actor of the current request
+func WithActor(ctx context.Context, actor Actor) context.Context {
+	return context.WithValue(ctx, actorKey{}, actor)
+}
+
+// ActorFromContext returns the actor carried by the context. Actions
+// without one (CLI, scheduled jobs) are recorded as system actions.
+func ActorFromContext(ctx context.Context) Actor {
+	if ctx == nil {
+		return Actor{}
+	}
+	actor, _ := ctx.Value(actorKey{}).(Actor)
+	return actor
+}
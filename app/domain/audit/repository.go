@@ -0,0 +1,11 @@
+package audit
+
+import "context"
+
+// Repository defines the interface for audit log data access.
+// There is deliberately no update or delete.
+type Repository interface {
+	Append(ctx context.Context, e *Entry) error
+	Find(ctx context.Context, filter Filter) ([]*Entry, error)
+	Count(ctx context.Context, filter Filter) (int, error)
+}
@@ -1,5 +1,7 @@
 package component
 
+import "time"
+
 // Type represents component types
 type Type string
 
@@ -25,6 +27,7 @@ type Component struct {
 	LinkURL  string `json:"link_url"`
 	LinkText string `json:"link_text"`
 	DataJSON string `json:"data_json"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Set while the component is in the trash
 }
 
 // Defaults returns default values for a component type
@@ -76,3 +79,57 @@ func (c *Component) MergeWithDefaults() *Component {
 	
 	return c
 }
+
+// Overrides replace fields of a component where it is placed on one page.
+// Nil fields aren't overridden; an empty string blanks the field.
+type Overrides struct {
+	Title    *string `json:"title,omitempty"`
+	Subtitle *string `json:"subtitle,omitempty"`
+	Content  *string `json:"content,omitempty"`
+	ImageURL *string `json:"image_url,omitempty"`
+	LinkURL  *string `json:"link_url,omitempty"`
+	LinkText *string `json:"link_text,omitempty"`
+	DataJSON *string `json:"data_json,omitempty"`
+}
+
+// Fields lists the overridden fields by their JSON names
+func (o *Overrides) Fields() []string {
+	var fields []string
+	if o == nil {
+		return fields
+	}
+	for _, f := range []struct {
+		name  string
+		value *string
+	}{
+		{"title", o.Title}, {"subtitle", o.Subtitle}, {"content", o.Content},
+		{"image_url", o.ImageURL}, {"link_url", o.LinkURL}, {"link_text", o.LinkText},
+		{"data_json", o.DataJSON},
+	} {
+		if f.value != nil {
+			fields = append(fields, f.name)
+		}
+	}
+	return fields
+}
+
+// WithOverrides returns a copy of the component with the overrides applied
+func (c *Component) WithOverrides(o *Overrides) *Component {
+	out := *c
+	if o == nil {
+		return &out
+	}
+	for _, f := range []struct {
+		field *string
+		value *string
+	}{
+		{&out.Title, o.Title}, {&out.Subtitle, o.Subtitle}, {&out.Content, o.Content},
+		{&out.ImageURL, o.ImageURL}, {&out.LinkURL, o.LinkURL}, {&out.LinkText, o.LinkText},
+		{&out.DataJSON, o.DataJSON},
+	} {
+		if f.value != nil {
+			*f.field = *f.value
+		}
+	}
+	return &out
+}
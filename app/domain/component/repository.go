@@ -1,12 +1,25 @@
 package component
 
-// Repository defines the interface for component data persistence
+import (
+	"context"
+	"time"
+)
+
+// Repository defines the interface for component data persistence. Finders
+// skip components in the trash; Delete moves a component there and keeps
+// its placements on pages.
 type Repository interface {
-	FindByID(id int) (*Component, error)
-	FindByName(name string) (*Component, error)
-	FindByType(componentType Type) ([]*Component, error)
-	FindAll() ([]*Component, error)
-	Create(component *Component) error
-	Update(component *Component) error
-	Delete(id int) error
+	FindByID(ctx context.Context, id int) (*Component, error)
+	FindByName(ctx context.Context, name string) (*Component, error)
+	FindByType(ctx context.Context, componentType Type) ([]*Component, error)
+	FindAll(ctx context.Context) ([]*Component, error)
+	Create(ctx context.Context, component *Component) error
+	Update(ctx context.Context, component *Component) error
+	Delete(ctx context.Context, id int) error
+
+	// Trash
+	FindDeleted(ctx context.Context) ([]*Component, error)
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error)
 }
@@ -13,6 +13,7 @@ type Media struct {
 	Path        string    `json:"path"`
 	URL         string    `json:"url"`
 	CreatedAt   time.Time `json:"created_at"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"` // Set while the media is in the trash
 }
 
 // IsImage checks if media is an image
@@ -1,12 +1,20 @@
 package media
 
-// Repository defines the interface for media data persistence
+import "context"
+
+// Repository defines the interface for media data persistence. Finders skip
+// media in the trash; Delete moves a record there and leaves its file.
 type Repository interface {
-	FindByID(id int) (*Media, error)
-	FindAll(limit, offset int) ([]*Media, error)
-	FindByFilename(filename string) (*Media, error)
-	Create(media *Media) error
-	Update(media *Media) error
-	Delete(id int) error
-	Count() (int, error)
+	FindByID(ctx context.Context, id int) (*Media, error)
+	FindAll(ctx context.Context, limit, offset int) ([]*Media, error)
+	FindByFilename(ctx context.Context, filename string) (*Media, error)
+	Create(ctx context.Context, media *Media) error
+	Update(ctx context.Context, media *Media) error
+	Delete(ctx context.Context, id int) error
+	Count(ctx context.Context) (int, error)
+
+	// Trash
+	FindDeleted(ctx context.Context) ([]*Media, error)
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
 }
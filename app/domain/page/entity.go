@@ -1,6 +1,10 @@
 package page
 
-import "time"
+import (
+	"time"
+
+	"cacto-cms/app/domain/component"
+)
 
 // Status represents the publication status of a page
 type Status string
@@ -24,20 +28,23 @@ type Page struct {
 	Status          Status    `json:"status"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
-	Components      []Component `json:"components,omitempty"`
+	DeletedAt       *time.Time `json:"deleted_at,omitempty"` // Set while the page is in the trash
+	Components      []Placement `json:"components,omitempty"`
+}
+
+// Placement is a shared component placed on a page. Its overrides change
+// the component on this page only; the shared component is left as it is.
+type Placement struct {
+	component.Component
+	PlacementID int                  `json:"placement_id"`
+	Position    int                  `json:"position"`
+	Overrides   *component.Overrides `json:"overrides,omitempty"`
 }
 
-// Component represents a page component entity
-type Component struct {
-	ID       int    `json:"id"`
-	Type     string `json:"type"`
-	Name     string `json:"name"`
-	Title    string `json:"title"`
-	Subtitle string `json:"subtitle"`
-	Content  string `json:"content"`
-	ImageURL string `json:"image_url"`
-	LinkURL  string `json:"link_url"`
-	LinkText string `json:"link_text"`
-	DataJSON string `json:"data_json"`
-	Position int    `json:"position"`
+// Rendered returns the component as the page shows it: the defaults of its
+// type fill the fields the shared component leaves empty, then the
+// overrides apply, so an override can blank a field the defaults would fill
+func (p *Placement) Rendered() *component.Component {
+	shared := p.Component
+	return shared.MergeWithDefaults().WithOverrides(p.Overrides)
 }
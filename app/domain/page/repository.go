@@ -1,14 +1,30 @@
 package page
 
-// Repository defines the interface for page data persistence
+import (
+	"context"
+	"time"
+
+	"cacto-cms/app/domain/component"
+)
+
+// Repository defines the interface for page data persistence. Finders skip
+// pages in the trash; Delete moves a page there.
 // This interface belongs to the domain layer and should not depend on infrastructure
 type Repository interface {
-	FindByID(id int) (*Page, error)
-	FindBySlug(slug string) (*Page, error)
-	FindAll() ([]*Page, error)
-	FindPublished() ([]*Page, error)
-	Create(page *Page) error
-	Update(page *Page) error
-	Delete(id int) error
-	GetComponents(pageID int) ([]Component, error)
+	FindByID(ctx context.Context, id int) (*Page, error)
+	FindBySlug(ctx context.Context, slug string) (*Page, error)
+	FindAll(ctx context.Context) ([]*Page, error)
+	FindPublished(ctx context.Context) ([]*Page, error)
+	Create(ctx context.Context, page *Page) error
+	Update(ctx context.Context, page *Page) error
+	Delete(ctx context.Context, id int) error
+	GetComponents(ctx context.Context, pageID int) ([]Placement, error)
+	SetComponents(ctx context.Context, pageID int, layout []Placement) error
+	SetOverrides(ctx context.Context, pageID, placementID int, overrides *component.Overrides) error
+
+	// Trash
+	FindDeleted(ctx context.Context) ([]*Page, error)
+	Restore(ctx context.Context, id int) error
+	Purge(ctx context.Context, id int) error
+	PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error)
 }
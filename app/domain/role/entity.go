@@ -0,0 +1,48 @@
+package role
+
+import (
+	"strings"
+	"time"
+)
+
+// Wildcard grants every permission
+const Wildcard = "*"
+
+// Role represents a named set of permissions
+type Role struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	IsSystem    bool      `json:"is_system"` // Default roles cannot be deleted
+	Permissions []string  `json:"permissions"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// Permission represents a known permission
+type Permission struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+// HasPermission checks if the role grants a specific permission
+func (r *Role) HasPermission(permission string) bool {
+	return Grants(r.Permissions, permission)
+}
+
+// Grants checks if a set of granted permissions covers the requested one.
+// "*" matches everything and "pages:*" matches every pages permission.
+func Grants(granted []string, permission string) bool {
+	resource, _, _ := strings.Cut(permission, ":")
+
+	for _, g := range granted {
+		if g == Wildcard || g == permission {
+			return true
+		}
+		if strings.HasSuffix(g, ":*") && strings.TrimSuffix(g, ":*") == resource {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,16 @@
+package role
+
+import "context"
+
+// Repository defines the interface for role data persistence
+type Repository interface {
+	FindByID(ctx context.Context, id int) (*Role, error)
+	FindByName(ctx context.Context, name string) (*Role, error)
+	FindAll(ctx context.Context) ([]*Role, error)
+	Create(ctx context.Context, role *Role) error
+	Update(ctx context.Context, role *Role) error
+	Delete(ctx context.Context, id int) error
+	SetPermissions(ctx context.Context, roleID int, permissions []string) error
+	FindPermissions(ctx context.Context) ([]*Permission, error)
+	CountUsers(ctx context.Context, name string) (int, error)
+}
@@ -0,0 +1,19 @@
+package setting
+
+import "time"
+
+// Keys of the settings the application reads
+const (
+	KeySiteName             = "site_name"
+	KeySiteDescription      = "site_description"
+	KeySitemapEnabled       = "sitemap_enabled"
+	KeySitemapLastGenerated = "sitemap_last_generated" // RFC 3339, written by the sitemap generator
+)
+
+// Setting is a site-wide value stored in the database. Values are strings;
+// the settings service gives them their types.
+type Setting struct {
+	Key       string    `json:"key"`
+	Value     string    `json:"value"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,10 @@
+package setting
+
+import "context"
+
+// Repository defines the interface for settings persistence
+// This interface belongs to the domain layer and should not depend on infrastructure
+type Repository interface {
+	FindAll(ctx context.Context) ([]*Setting, error)
+	Save(ctx context.Context, values map[string]string) error
+}
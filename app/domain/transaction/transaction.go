@@ -0,0 +1,12 @@
+package transaction
+
+import "context"
+
+// UnitOfWork defines the interface for running repository calls in one transaction
+type UnitOfWork interface {
+	WithTx(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit defers fn until the transaction ctx carries commits, so
+	// others only hear of changes they can read. Outside of one, fn runs at once.
+	AfterCommit(ctx context.Context, fn func())
+}
@@ -1,8 +1,14 @@
 package user
 
-import "time"
+import (
+	"strings"
+	"time"
 
-// Role represents user roles
+	"cacto-cms/app/domain/role"
+)
+
+// Role represents user roles.
+// The constants below are the default roles; custom roles live in the roles table.
 type Role string
 
 const (
@@ -21,40 +27,19 @@ type User struct {
 	Role         Role      `json:"role"`
 	IsActive     bool      `json:"is_active"`
 	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	Permissions  []string  `json:"permissions,omitempty"` // Resolved from the role, not stored
 	CreatedAt    time.Time `json:"created_at"`
 	UpdatedAt    time.Time `json:"updated_at"`
 }
 
-// HasPermission checks if user has a specific permission
+// HasPermission checks if user has a specific permission.
+// Permissions are resolved from the user's role and must be loaded first.
 func (u *User) HasPermission(permission string) bool {
 	if !u.IsActive {
 		return false
 	}
 
-	// Admin has all permissions
-	if u.Role == RoleAdmin {
-		return true
-	}
-
-	// Define role-based permissions
-	permissions := map[Role][]string{
-		RoleEditor: {"pages:read", "pages:write", "pages:delete", "components:read", "components:write"},
-		RoleAuthor: {"pages:read", "pages:write", "components:read"},
-		RoleViewer: {"pages:read", "components:read"},
-	}
-
-	rolePerms, exists := permissions[u.Role]
-	if !exists {
-		return false
-	}
-
-	for _, perm := range rolePerms {
-		if perm == permission {
-			return true
-		}
-	}
-
-	return false
+	return role.Grants(u.Permissions, permission)
 }
 
 // CanEdit checks if user can edit content
@@ -66,3 +51,9 @@ func (u *User) CanEdit() bool {
 func (u *User) CanDelete() bool {
 	return u.HasPermission("pages:delete")
 }
+
+// NormalizeEmail returns the form emails are stored and looked up in:
+// addresses differing only in case belong to the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
@@ -0,0 +1,15 @@
+package user
+
+import "time"
+
+// Identity links a user to an account at an external identity provider.
+// Provider is the issuer URL and Subject the provider's stable user ID.
+type Identity struct {
+	ID          int        `json:"id"`
+	UserID      int        `json:"user_id"`
+	Provider    string     `json:"provider"`
+	Subject     string     `json:"subject"`
+	Email       string     `json:"email"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastLoginAt *time.Time `json:"last_login_at,omitempty"`
+}
@@ -0,0 +1,40 @@
+package user
+
+import "time"
+
+// LoginAttempt represents a single login attempt for an account.
+// Attempts are tracked by normalized email so unknown accounts are
+// throttled exactly like existing ones.
+type LoginAttempt struct {
+	ID        int       `json:"id"`
+	Email     string    `json:"email"`
+	IPAddress string    `json:"ip_address"`
+	UserAgent string    `json:"user_agent"`
+	Success   bool      `json:"success"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Lockout represents a temporary account lockout
+type Lockout struct {
+	ID             int        `json:"id"`
+	Email          string     `json:"email"`
+	FailedAttempts int        `json:"failed_attempts"`
+	IPAddress      string     `json:"ip_address"`
+	LockedUntil    time.Time  `json:"locked_until"`
+	UnlockedAt     *time.Time `json:"unlocked_at,omitempty"`
+	UnlockedBy     *int       `json:"unlocked_by,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// IsActive checks if the lockout is still in effect at the given time
+func (l *Lockout) IsActive(now time.Time) bool {
+	return l.UnlockedAt == nil && now.Before(l.LockedUntil)
+}
+
+// ResetAt returns the point after which failed attempts count again
+func (l *Lockout) ResetAt() time.Time {
+	if l.UnlockedAt != nil {
+		return *l.UnlockedAt
+	}
+	return l.CreatedAt
+}
@@ -0,0 +1,21 @@
+package user
+
+import "time"
+
+// Passkey is a WebAuthn credential a user can sign in with instead of a
+// password. CredentialID and PublicKey (a COSE_Key) are base64url encoded.
+type Passkey struct {
+	ID             int        `json:"id"`
+	UserID         int        `json:"user_id"`
+	Name           string     `json:"name"`
+	CredentialID   string     `json:"credential_id"`
+	PublicKey      string     `json:"-"`
+	Algorithm      int        `json:"algorithm"`
+	SignCount      uint32     `json:"-"`
+	AAGUID         string     `json:"aaguid,omitempty"`
+	Transports     []string   `json:"transports"`
+	BackupEligible bool       `json:"backup_eligible"` // Synced passkey (e.g. iCloud Keychain, Google Password Manager)
+	BackedUp       bool       `json:"backed_up"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastUsedAt     *time.Time `json:"last_used_at,omitempty"`
+}
@@ -1,12 +1,67 @@
 package user
 
+import (
+	"context"
+	"time"
+)
+
 // Repository defines the interface for user data persistence
 type Repository interface {
-	FindByID(id int) (*User, error)
-	FindByEmail(email string) (*User, error)
-	FindAll() ([]*User, error)
-	Create(user *User) error
-	Update(user *User) error
-	Delete(id int) error
-	UpdateLastLogin(id int) error
+	FindByID(ctx context.Context, id int) (*User, error)
+	FindByEmail(ctx context.Context, email string) (*User, error)
+	FindAll(ctx context.Context) ([]*User, error)
+	Create(ctx context.Context, user *User) error
+	Update(ctx context.Context, user *User) error
+	Delete(ctx context.Context, id int) error
+	UpdateLastLogin(ctx context.Context, id int) error
+	CountActiveByRole(ctx context.Context, role Role) (int, error)
+}
+
+// LockoutRepository defines the interface for login attempt and lockout persistence
+type LockoutRepository interface {
+	RecordAttempt(ctx context.Context, attempt *LoginAttempt) error
+	MarkAttemptSucceeded(ctx context.Context, id int) error
+	DeleteAttempt(ctx context.Context, id int) error
+	FindRecentAttempts(ctx context.Context, email string, since time.Time, beforeID int, limit int) ([]*LoginAttempt, error)
+	DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error)
+	CreateLockout(ctx context.Context, lockout *Lockout) error
+	FindLatestLockout(ctx context.Context, email string) (*Lockout, error)
+	FindLockouts(ctx context.Context, limit int) ([]*Lockout, error)
+	Unlock(ctx context.Context, email string, unlockedBy *int, at time.Time) error
+}
+
+// IdentityRepository defines the interface for external identity data access
+type IdentityRepository interface {
+	FindByProviderSubject(ctx context.Context, provider, subject string) (*Identity, error)
+	Create(ctx context.Context, i *Identity) error
+	TouchLogin(ctx context.Context, id int, email string, at time.Time) error
+}
+
+// PasswordHistoryRepository defines the interface for previous password hashes
+type PasswordHistoryRepository interface {
+	Add(ctx context.Context, userID int, passwordHash string, at time.Time) error
+	FindRecent(ctx context.Context, userID int, limit int) ([]string, error)
+	Prune(ctx context.Context, userID int, keep int) error
+}
+
+// SessionRepository defines the interface for signed-in session persistence
+type SessionRepository interface {
+	Create(ctx context.Context, s *Session) error
+	FindBySessionID(ctx context.Context, sessionID string) (*Session, error)
+	FindByID(ctx context.Context, id int) (*Session, error)
+	FindActiveByUser(ctx context.Context, userID int, now time.Time) ([]*Session, error)
+	Touch(ctx context.Context, id int, at time.Time, ip string) error
+	Revoke(ctx context.Context, id int, at time.Time) error
+	RevokeAllForUser(ctx context.Context, userID int, exceptID int, at time.Time) error
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// PasskeyRepository defines the interface for WebAuthn credential persistence
+type PasskeyRepository interface {
+	Create(ctx context.Context, p *Passkey) error
+	FindByID(ctx context.Context, id int) (*Passkey, error)
+	FindByCredentialID(ctx context.Context, credentialID string) (*Passkey, error)
+	FindByUser(ctx context.Context, userID int) ([]*Passkey, error)
+	RecordUse(ctx context.Context, id int, signCount uint32, backedUp bool, at time.Time) error
+	Delete(ctx context.Context, id int) error
 }
@@ -0,0 +1,71 @@
+package user
+
+import (
+	"strings"
+	"time"
+)
+
+// Session represents a signed-in session. SessionID is the random value
+// carried in the JWT; the numeric ID is used to refer to it in the API.
+type Session struct {
+	ID             int        `json:"id"`
+	SessionID      string     `json:"-"`
+	UserID         int        `json:"user_id"`
+	ImpersonatorID *int       `json:"impersonator_id,omitempty"` // Admin acting as the user
+	IPAddress      string     `json:"ip_address"`
+	UserAgent      string     `json:"user_agent"`
+	CreatedAt      time.Time  `json:"created_at"`
+	LastSeenAt     time.Time  `json:"last_seen_at"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	RevokedAt      *time.Time `json:"revoked_at,omitempty"`
+	Current        bool       `json:"current"` // Set when listing: the session making the request
+}
+
+// IsActive checks if the session can be used at the given time
+func (s *Session) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// IsImpersonation checks if the session belongs to an admin acting as the user
+func (s *Session) IsImpersonation() bool {
+	return s.ImpersonatorID != nil
+}
+
+// Device returns a short description of the browser and OS, e.g. "Firefox on Linux"
+func (s *Session) Device() string {
+	ua := s.UserAgent
+	if ua == "" {
+		return "Unknown device"
+	}
+
+	browser := "Unknown browser"
+	for _, b := range []struct{ token, name string }{
+		{"Edg/", "Edge"},
+		{"OPR/", "Opera"},
+		{"Firefox/", "Firefox"},
+		{"Chrome/", "Chrome"},
+		{"Safari/", "Safari"},
+		{"curl/", "curl"},
+	} {
+		if strings.Contains(ua, b.token) {
+			browser = b.name
+			break
+		}
+	}
+
+	for _, o := range []struct{ token, name string }{
+		{"iPhone", "iOS"},
+		{"iPad", "iOS"},
+		{"Android", "Android"},
+		{"Windows", "Windows"},
+		{"Mac OS X", "macOS"},
+		{"CrOS", "ChromeOS"},
+		{"Linux", "Linux"},
+	} {
+		if strings.Contains(ua, o.token) {
+			return browser + " on " + o.name
+		}
+	}
+
+	return browser
+}
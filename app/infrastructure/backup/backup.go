@@ -0,0 +1,533 @@
+// Package backup takes consistent snapshots of the SQLite database and the
+// uploads directory, verifies them, prunes them by retention rules and
+// restores them.
+//
+// A snapshot is a single backup-YYYYMMDD-HHMMSS.tar.gz archive holding a
+// manifest.json, the database copied with VACUUM INTO (safe while the server
+// is writing, WAL included) and the uploads under uploads/.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"cacto-cms/app/infrastructure/database"
+)
+
+const (
+	manifestFile = "manifest.json"
+	databaseFile = "database.sqlite"
+	uploadsDir   = "uploads"
+	timeLayout   = "20060102-150405"
+)
+
+// snapshotName matches backup-20261018-185100.tar.gz and backup-20261018-185100-2.tar.gz
+var snapshotName = regexp.MustCompile(`^backup-(\d{8}-\d{6})(-\d+)?\.tar\.gz$`)
+
+// Config describes what is backed up and where snapshots are kept
+type Config struct {
+	Dir       string // Directory holding the snapshots
+	DBPath    string
+	UploadDir string
+	Retention Retention
+}
+
+// Snapshot is a backup archive on disk
+type Snapshot struct {
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size"`
+}
+
+// Manifest describes the contents of a snapshot
+type Manifest struct {
+	Format         int       `json:"format"`
+	CreatedAt      time.Time `json:"created_at"`
+	DatabaseSHA256 string    `json:"database_sha256"`
+	DatabaseSize   int64     `json:"database_size"`
+	SchemaVersion  int       `json:"schema_version"` // Highest applied migration
+	Uploads        int       `json:"uploads"`        // Number of uploaded files
+	UploadsSize    int64     `json:"uploads_size"`
+}
+
+// Manager creates, verifies, prunes and restores snapshots
+type Manager struct {
+	db  *sql.DB
+	cfg Config
+}
+
+// NewManager creates a backup manager. db is the live connection snapshots
+// are taken from; it may be nil for listing, verifying and restoring.
+func NewManager(db *sql.DB, cfg Config) *Manager {
+	return &Manager{db: db, cfg: cfg}
+}
+
+// Create takes a snapshot and verifies it before it is kept
+func (m *Manager) Create() (*Snapshot, *Manifest, error) {
+	if m.db == nil {
+		return nil, nil, fmt.Errorf("no database connection to back up")
+	}
+	if err := os.MkdirAll(m.cfg.Dir, 0750); err != nil {
+		return nil, nil, fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	work, err := os.MkdirTemp(m.cfg.Dir, ".backup-*")
+	if err != nil {
+		return nil, nil, err
+	}
+	defer os.RemoveAll(work)
+
+	// VACUUM INTO writes a consistent, compacted copy without blocking writers
+	dbCopy := filepath.Join(work, databaseFile)
+	if _, err := m.db.Exec(`VACUUM INTO ?`, dbCopy); err != nil {
+		return nil, nil, fmt.Errorf("failed to copy database: %w", err)
+	}
+
+	schemaVersion, err := checkDatabase(dbCopy)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	now := time.Now().UTC()
+	manifest := &Manifest{
+		Format:        1,
+		CreatedAt:     now,
+		SchemaVersion: schemaVersion,
+	}
+	if manifest.DatabaseSHA256, manifest.DatabaseSize, err = fileDigest(dbCopy); err != nil {
+		return nil, nil, err
+	}
+
+	uploads, err := m.uploadFiles()
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, u := range uploads {
+		manifest.Uploads++
+		manifest.UploadsSize += u.size
+	}
+
+	name := m.availableName(now)
+	partial := filepath.Join(work, name)
+	if err := writeArchive(partial, manifest, dbCopy, uploads); err != nil {
+		return nil, nil, fmt.Errorf("failed to write archive: %w", err)
+	}
+
+	// Verify what was written, not what we meant to write
+	if _, err := Verify(partial); err != nil {
+		return nil, nil, fmt.Errorf("snapshot failed verification: %w", err)
+	}
+
+	path := filepath.Join(m.cfg.Dir, name)
+	if err := os.Rename(partial, path); err != nil {
+		return nil, nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &Snapshot{Name: name, Path: path, CreatedAt: now, Size: info.Size()}, manifest, nil
+}
+
+// List returns the snapshots in the backup directory, newest first
+func (m *Manager) List() ([]*Snapshot, error) {
+	entries, err := os.ReadDir(m.cfg.Dir)
+	if os.IsNotExist(err) {
+		return []*Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	snapshots := make([]*Snapshot, 0, len(entries))
+	for _, entry := range entries {
+		match := snapshotName.FindStringSubmatch(entry.Name())
+		if match == nil || entry.IsDir() {
+			continue
+		}
+		createdAt, err := time.Parse(timeLayout, match[1])
+		if err != nil {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		snapshots = append(snapshots, &Snapshot{
+			Name:      entry.Name(),
+			Path:      filepath.Join(m.cfg.Dir, entry.Name()),
+			CreatedAt: createdAt,
+			Size:      info.Size(),
+		})
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].CreatedAt.Equal(snapshots[j].CreatedAt) {
+			return snapshots[i].Name > snapshots[j].Name
+		}
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// Find resolves a snapshot by name or path
+func (m *Manager) Find(ref string) (string, error) {
+	for _, candidate := range []string{ref, filepath.Join(m.cfg.Dir, ref)} {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return candidate, nil
+		}
+	}
+	return "", fmt.Errorf("backup %q not found", ref)
+}
+
+// Prune deletes the snapshots the retention rules do not keep
+func (m *Manager) Prune() ([]*Snapshot, error) {
+	snapshots, err := m.List()
+	if err != nil {
+		return nil, err
+	}
+
+	keep := m.cfg.Retention.Keep(snapshots)
+	deleted := make([]*Snapshot, 0)
+	for _, s := range snapshots {
+		if keep[s] {
+			continue
+		}
+		if err := os.Remove(s.Path); err != nil {
+			return deleted, fmt.Errorf("failed to delete %s: %w", s.Name, err)
+		}
+		deleted = append(deleted, s)
+	}
+	return deleted, nil
+}
+
+// Verify checks a snapshot's manifest, database checksum and integrity
+func Verify(path string) (*Manifest, error) {
+	work, err := os.MkdirTemp(filepath.Dir(path), ".verify-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(work)
+
+	dbCopy := filepath.Join(work, databaseFile)
+	return extract(path, dbCopy, "")
+}
+
+// Restore replaces the database and uploads with a snapshot. The caller
+// holds the server lock of the database from before it looks at the current
+// state until Restore returns: a server starting meanwhile would keep
+// writing to the replaced file.
+func (m *Manager) Restore(path string, lock *database.ServerLock) (*Manifest, error) {
+	if !lock.Covers(m.cfg.DBPath) {
+		return nil, fmt.Errorf("restoring %s requires its server lock", m.cfg.DBPath)
+	}
+
+	// Extract next to the targets so the final renames are atomic
+	dbRestore := m.cfg.DBPath + ".restore"
+	uploadsRestore := strings.TrimRight(m.cfg.UploadDir, string(filepath.Separator)) + ".restore"
+	os.Remove(dbRestore)
+	os.RemoveAll(uploadsRestore)
+	defer os.Remove(dbRestore)
+	defer os.RemoveAll(uploadsRestore)
+
+	manifest, err := extract(path, dbRestore, uploadsRestore)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, suffix := range []string{"-wal", "-shm"} {
+		if err := os.Remove(m.cfg.DBPath + suffix); err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+	if err := os.Rename(dbRestore, m.cfg.DBPath); err != nil {
+		return nil, fmt.Errorf("failed to replace database: %w", err)
+	}
+
+	previous := uploadsRestore + ".old"
+	os.RemoveAll(previous)
+	if err := os.Rename(m.cfg.UploadDir, previous); err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to replace uploads: %w", err)
+	}
+	if err := os.Rename(uploadsRestore, m.cfg.UploadDir); err != nil {
+		os.Rename(previous, m.cfg.UploadDir)
+		return nil, fmt.Errorf("failed to replace uploads: %w", err)
+	}
+	os.RemoveAll(previous)
+
+	return manifest, nil
+}
+
+// availableName returns an unused snapshot file name for t
+func (m *Manager) availableName(t time.Time) string {
+	base := "backup-" + t.Format(timeLayout)
+	name := base + ".tar.gz"
+	for i := 2; ; i++ {
+		if _, err := os.Stat(filepath.Join(m.cfg.Dir, name)); os.IsNotExist(err) {
+			return name
+		}
+		name = fmt.Sprintf("%s-%d.tar.gz", base, i)
+	}
+}
+
+// uploadFile is a file under the uploads directory
+type uploadFile struct {
+	path string // On disk
+	name string // Slash-separated, relative to the uploads directory
+	size int64
+	mode fs.FileMode
+	mod  time.Time
+}
+
+// uploadFiles lists the regular files under the uploads directory
+func (m *Manager) uploadFiles() ([]uploadFile, error) {
+	files := make([]uploadFile, 0)
+	err := filepath.WalkDir(m.cfg.UploadDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && path == m.cfg.UploadDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(m.cfg.UploadDir, path)
+		if err != nil {
+			return err
+		}
+		files = append(files, uploadFile{path: path, name: filepath.ToSlash(rel), size: info.Size(), mode: info.Mode().Perm(), mod: info.ModTime()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read uploads: %w", err)
+	}
+	return files, nil
+}
+
+// writeArchive writes the manifest, database and uploads as a .tar.gz
+func writeArchive(path string, manifest *Manifest, dbCopy string, uploads []uploadFile) (err error) {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := out.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+
+	manifestJSON, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := tw.WriteHeader(&tar.Header{Name: manifestFile, Mode: 0644, Size: int64(len(manifestJSON)), ModTime: manifest.CreatedAt}); err != nil {
+		return err
+	}
+	if _, err := tw.Write(manifestJSON); err != nil {
+		return err
+	}
+
+	if err := addFile(tw, dbCopy, databaseFile, manifest.DatabaseSize, 0640, manifest.CreatedAt); err != nil {
+		return err
+	}
+	for _, u := range uploads {
+		if err := addFile(tw, u.path, uploadsDir+"/"+u.name, u.size, u.mode, u.mod); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	return out.Sync()
+}
+
+// addFile copies a file into the archive
+func addFile(tw *tar.Writer, path, name string, size int64, mode fs.FileMode, mod time.Time) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := tw.WriteHeader(&tar.Header{Name: name, Mode: int64(mode), Size: size, ModTime: mod}); err != nil {
+		return err
+	}
+	if _, err := io.CopyN(tw, in, size); err != nil {
+		return fmt.Errorf("failed to archive %s: %w", name, err)
+	}
+	return nil
+}
+
+// extract reads a snapshot, writing the database to dbPath and, when
+// uploadsPath is set, the uploads below it. The database is checked against
+// the manifest and with integrity_check.
+func extract(path, dbPath, uploadsPath string) (*Manifest, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return nil, fmt.Errorf("not a backup archive: %w", err)
+	}
+	tr := tar.NewReader(gz)
+
+	var manifest *Manifest
+	foundDatabase := false
+	if uploadsPath != "" {
+		if err := os.MkdirAll(uploadsPath, 0755); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("corrupt archive: %w", err)
+		}
+		if header.Typeflag != tar.TypeReg || !filepath.IsLocal(header.Name) {
+			return nil, fmt.Errorf("unexpected entry in archive: %s", header.Name)
+		}
+
+		switch {
+		case header.Name == manifestFile:
+			manifest = &Manifest{}
+			if err := json.NewDecoder(tr).Decode(manifest); err != nil {
+				return nil, fmt.Errorf("invalid manifest: %w", err)
+			}
+		case header.Name == databaseFile:
+			if err := writeFile(dbPath, tr, 0640); err != nil {
+				return nil, err
+			}
+			foundDatabase = true
+		case strings.HasPrefix(header.Name, uploadsDir+"/"):
+			if uploadsPath == "" {
+				continue
+			}
+			target := filepath.Join(uploadsPath, filepath.FromSlash(strings.TrimPrefix(header.Name, uploadsDir+"/")))
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return nil, err
+			}
+			if err := writeFile(target, tr, fs.FileMode(header.Mode).Perm()); err != nil {
+				return nil, err
+			}
+			os.Chtimes(target, header.ModTime, header.ModTime)
+		default:
+			return nil, fmt.Errorf("unexpected entry in archive: %s", header.Name)
+		}
+	}
+
+	if manifest == nil {
+		return nil, fmt.Errorf("archive has no manifest")
+	}
+	if !foundDatabase {
+		return nil, fmt.Errorf("archive has no database")
+	}
+
+	digest, size, err := fileDigest(dbPath)
+	if err != nil {
+		return nil, err
+	}
+	if digest != manifest.DatabaseSHA256 || size != manifest.DatabaseSize {
+		return nil, fmt.Errorf("database checksum does not match the manifest")
+	}
+	if _, err := checkDatabase(dbPath); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// writeFile writes r to a new file
+func writeFile(path string, r io.Reader, mode fs.FileMode) error {
+	out, err := os.OpenFile(path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// checkDatabase runs PRAGMA integrity_check on a database copy and returns
+// its highest applied migration
+func checkDatabase(path string) (int, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return 0, fmt.Errorf("integrity check failed: %w", err)
+	}
+	var problems []string
+	for rows.Next() {
+		var result string
+		if err := rows.Scan(&result); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		if result != "ok" {
+			problems = append(problems, result)
+		}
+	}
+	rows.Close()
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("integrity check failed: %s", strings.Join(problems, "; "))
+	}
+
+	var version sql.NullInt64
+	db.QueryRow(`SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	return int(version.Int64), nil
+}
+
+// fileDigest returns the SHA-256 and size of a file
+func fileDigest(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	size, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), size, nil
+}
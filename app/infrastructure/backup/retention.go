@@ -0,0 +1,101 @@
+package backup
+
+import (
+	"fmt"
+	"log"
+	"time"
+)
+
+// Retention says how many snapshots to keep per period. The newest snapshot
+// of each of the last Hourly hours, Daily days and Weekly ISO weeks is kept,
+// and so is the newest snapshot overall. All zero keeps everything.
+type Retention struct {
+	Hourly int
+	Daily  int
+	Weekly int
+}
+
+// Keep returns the snapshots to keep; snapshots must be newest first
+func (r Retention) Keep(snapshots []*Snapshot) map[*Snapshot]bool {
+	keep := make(map[*Snapshot]bool, len(snapshots))
+	if r.Hourly <= 0 && r.Daily <= 0 && r.Weekly <= 0 {
+		for _, s := range snapshots {
+			keep[s] = true
+		}
+		return keep
+	}
+	if len(snapshots) > 0 {
+		keep[snapshots[0]] = true
+	}
+
+	periods := []struct {
+		count  int
+		bucket func(t time.Time) string
+	}{
+		{r.Hourly, func(t time.Time) string { return t.Format("2006-01-02T15") }},
+		{r.Daily, func(t time.Time) string { return t.Format("2006-01-02") }},
+		{r.Weekly, func(t time.Time) string {
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week)
+		}},
+	}
+
+	for _, period := range periods {
+		seen := make(map[string]bool)
+		for _, s := range snapshots {
+			if len(seen) >= period.count {
+				break
+			}
+			bucket := period.bucket(s.CreatedAt.UTC())
+			if seen[bucket] {
+				continue
+			}
+			seen[bucket] = true
+			keep[s] = true
+		}
+	}
+	return keep
+}
+
+// Schedule takes a snapshot and prunes old ones every interval. When the
+// newest snapshot is already older than the interval, the first one is taken
+// right away so restarts do not postpone backups indefinitely.
+func (m *Manager) Schedule(interval time.Duration) {
+	go func() {
+		wait := interval
+		if snapshots, err := m.List(); err == nil {
+			if len(snapshots) == 0 {
+				wait = 0
+			} else if age := time.Since(snapshots[0].CreatedAt); age >= interval {
+				wait = 0
+			} else {
+				wait = interval - age
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		for range timer.C {
+			m.runScheduled()
+			timer.Reset(interval)
+		}
+	}()
+}
+
+// runScheduled takes one scheduled snapshot and applies retention
+func (m *Manager) runScheduled() {
+	snapshot, _, err := m.Create()
+	if err != nil {
+		log.Printf("❌ Scheduled backup failed: %v", err)
+		return
+	}
+	log.Printf("💾 Backup created: %s (%d KB)", snapshot.Name, snapshot.Size/1024)
+
+	deleted, err := m.Prune()
+	if err != nil {
+		log.Printf("❌ Backup pruning failed: %v", err)
+		return
+	}
+	if len(deleted) > 0 {
+		log.Printf("💾 Pruned %d old backup(s)", len(deleted))
+	}
+}
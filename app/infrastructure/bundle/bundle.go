@@ -0,0 +1,664 @@
+// Package bundle moves content between installations, e.g. from staging to
+// production. A bundle holds pages, components, their placements on pages,
+// media files and settings, keyed by the IDs they had on the exporting site.
+//
+// Bundles are written as a ZIP archive (bundle.json plus the media files
+// under media/) or as a single JSON document with the media inlined as
+// base64. Both carry a format version so older installations can refuse
+// bundles they do not understand.
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/shared/events"
+)
+
+// Version is the bundle format written by this build. Format 2 added
+// placement overrides; format 1 bundles are still read.
+const Version = 2
+
+const (
+	bundleFile = "bundle.json"
+	mediaDir   = "media"
+)
+
+// localSettings describe the installation itself and are never exported
+var localSettings = map[string]bool{
+	"sitemap_last_generated": true,
+}
+
+// Bundle is the content of an export
+type Bundle struct {
+	Format        int               `json:"format"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Source        string            `json:"source,omitempty"` // Base URL of the exporting site
+	SchemaVersion int               `json:"schema_version"`   // Highest applied migration
+	Pages         []Page            `json:"pages"`
+	Components    []Component       `json:"components"`
+	Placements    []Placement       `json:"placements"`
+	Media         []Media           `json:"media"`
+	Settings      map[string]string `json:"settings,omitempty"`
+}
+
+// Page is an exported page
+type Page struct {
+	ID              int    `json:"id"`
+	Slug            string `json:"slug"`
+	Title           string `json:"title"`
+	Content         string `json:"content"`
+	MetaTitle       string `json:"meta_title"`
+	MetaDescription string `json:"meta_description"`
+	MetaKeywords    string `json:"meta_keywords"`
+	OGImage         string `json:"og_image"`
+	Status          string `json:"status"`
+}
+
+// Component is an exported component
+type Component struct {
+	ID       int    `json:"id"`
+	Type     string `json:"type"`
+	Name     string `json:"name"`
+	Title    string `json:"title"`
+	Subtitle string `json:"subtitle"`
+	Content  string `json:"content"`
+	ImageURL string `json:"image_url"`
+	LinkURL  string `json:"link_url"`
+	LinkText string `json:"link_text"`
+	DataJSON string `json:"data_json"`
+}
+
+// Placement puts a component on a page
+type Placement struct {
+	PageID      int    `json:"page_id"`
+	ComponentID int    `json:"component_id"`
+	Position    int    `json:"position"`
+	Overrides   string `json:"overrides,omitempty"` // JSON object of the fields overridden on this page
+}
+
+// Media is an exported media file. Data is only set in JSON bundles and
+// after a bundle has been read.
+type Media struct {
+	ID           int       `json:"id"`
+	Filename     string    `json:"filename"`
+	OriginalName string    `json:"original_name"`
+	MimeType     string    `json:"mime_type"`
+	Size         int64     `json:"size"`
+	AltText      string    `json:"alt_text"`
+	SHA256       string    `json:"sha256"`
+	CreatedAt    time.Time `json:"created_at"`
+	Data         []byte    `json:"data,omitempty"`
+}
+
+// Summary counts the records in the bundle
+func (b *Bundle) Summary() map[string]int {
+	return map[string]int{
+		"pages":      len(b.Pages),
+		"components": len(b.Components),
+		"placements": len(b.Placements),
+		"media":      len(b.Media),
+		"settings":   len(b.Settings),
+	}
+}
+
+// Config describes where bundle content lives on this installation
+type Config struct {
+	UploadDir string
+	Source    string // Recorded in exported bundles
+}
+
+// ExportOptions select what goes into a bundle
+type ExportOptions struct {
+	Pages    []string // Slugs to export; all pages when empty
+	Media    bool     // Include media; only media the pages refer to when Pages is set
+	Settings bool
+}
+
+// Recorder writes audit entries. The audit service satisfies it; the entries
+// join the transaction of the import.
+type Recorder interface {
+	Record(ctx context.Context, action, targetType string, targetID interface{}, before, after interface{})
+}
+
+// Manager exports and imports bundles
+type Manager struct {
+	db     *sql.DB
+	cfg    Config
+	audit  Recorder
+	events *events.Bus
+}
+
+// NewManager creates a bundle manager
+func NewManager(db *sql.DB, cfg Config) *Manager {
+	return &Manager{db: db, cfg: cfg}
+}
+
+// SetAudit records every imported record in the audit log
+func (m *Manager) SetAudit(r Recorder) {
+	m.audit = r
+}
+
+// SetEvents publishes the imported pages, components and settings once the
+// import commits, e.g. so the page cache drops stale pages
+func (m *Manager) SetEvents(bus *events.Bus) {
+	m.events = bus
+}
+
+// Export reads the selected content from the database. Media files are
+// checksummed but not loaded; Write streams them from the uploads directory.
+func (m *Manager) Export(ctx context.Context, opts ExportOptions) (*Bundle, error) {
+	b := &Bundle{
+		Format:     Version,
+		CreatedAt:  time.Now().UTC(),
+		Source:     m.cfg.Source,
+		Pages:      []Page{},
+		Components: []Component{},
+		Placements: []Placement{},
+		Media:      []Media{},
+	}
+
+	// Only the migration table may be missing, on databases that predate it
+	m.db.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&b.SchemaVersion)
+
+	pages, err := m.exportPages(ctx, opts.Pages)
+	if err != nil {
+		return nil, err
+	}
+	b.Pages = pages
+
+	pageIDs := make(map[int]bool, len(pages))
+	for _, p := range pages {
+		pageIDs[p.ID] = true
+	}
+
+	placements, err := m.exportPlacements(ctx, pageIDs)
+	if err != nil {
+		return nil, err
+	}
+	b.Placements = placements
+
+	componentIDs := make(map[int]bool)
+	for _, pl := range placements {
+		componentIDs[pl.ComponentID] = true
+	}
+	components, err := m.exportComponents(ctx, componentIDs, len(opts.Pages) == 0)
+	if err != nil {
+		return nil, err
+	}
+	b.Components = components
+
+	if opts.Media {
+		var referenced func(filename string) bool
+		if len(opts.Pages) > 0 {
+			text := referencedText(b)
+			referenced = func(filename string) bool {
+				return strings.Contains(text, "/uploads/"+filename)
+			}
+		}
+		if b.Media, err = m.exportMedia(ctx, referenced); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Settings {
+		if b.Settings, err = m.exportSettings(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return b, nil
+}
+
+// exportPages reads all pages, or the pages with the given slugs
+func (m *Manager) exportPages(ctx context.Context, slugs []string) ([]Page, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, slug, title, COALESCE(content, ''), COALESCE(meta_title, ''),
+		       COALESCE(meta_description, ''), COALESCE(meta_keywords, ''),
+		       COALESCE(og_image, ''), status
+		FROM pages WHERE deleted_at IS NULL ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pages: %w", err)
+	}
+	defer rows.Close()
+
+	wanted := make(map[string]bool, len(slugs))
+	missing := make(map[string]bool, len(slugs))
+	for _, slug := range slugs {
+		wanted[slug], missing[slug] = true, true
+	}
+
+	pages := make([]Page, 0)
+	for rows.Next() {
+		var p Page
+		if err := rows.Scan(&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle,
+			&p.MetaDescription, &p.MetaKeywords, &p.OGImage, &p.Status); err != nil {
+			return nil, err
+		}
+		if len(wanted) > 0 && !wanted[p.Slug] {
+			continue
+		}
+		delete(missing, p.Slug)
+		pages = append(pages, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for slug := range missing {
+		return nil, fmt.Errorf("page %q not found", slug)
+	}
+	return pages, nil
+}
+
+// exportPlacements reads the component placements of the given pages,
+// leaving out those of components in the trash
+func (m *Manager) exportPlacements(ctx context.Context, pageIDs map[int]bool) ([]Placement, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT pc.page_id, pc.component_id, pc.position, COALESCE(pc.overrides_json, '')
+		FROM page_components pc
+		JOIN components c ON c.id = pc.component_id AND c.deleted_at IS NULL
+		ORDER BY pc.page_id, pc.position, pc.id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read page components: %w", err)
+	}
+	defer rows.Close()
+
+	placements := make([]Placement, 0)
+	for rows.Next() {
+		var pl Placement
+		if err := rows.Scan(&pl.PageID, &pl.ComponentID, &pl.Position, &pl.Overrides); err != nil {
+			return nil, err
+		}
+		if pageIDs[pl.PageID] {
+			placements = append(placements, pl)
+		}
+	}
+	return placements, rows.Err()
+}
+
+// exportComponents reads the given components, or all of them
+func (m *Manager) exportComponents(ctx context.Context, ids map[int]bool, all bool) ([]Component, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, type, name, COALESCE(title, ''), COALESCE(subtitle, ''), COALESCE(content, ''),
+		       COALESCE(image_url, ''), COALESCE(link_url, ''), COALESCE(link_text, ''), COALESCE(data_json, '')
+		FROM components WHERE deleted_at IS NULL ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read components: %w", err)
+	}
+	defer rows.Close()
+
+	components := make([]Component, 0)
+	for rows.Next() {
+		var c Component
+		if err := rows.Scan(&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
+			&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON); err != nil {
+			return nil, err
+		}
+		if all || ids[c.ID] {
+			components = append(components, c)
+		}
+	}
+	return components, rows.Err()
+}
+
+// exportMedia reads the media records whose files exist, optionally only
+// those referenced is true for
+func (m *Manager) exportMedia(ctx context.Context, referenced func(filename string) bool) ([]Media, error) {
+	rows, err := m.db.QueryContext(ctx, `
+		SELECT id, filename, original_name, mime_type, size, COALESCE(alt_text, ''), created_at
+		FROM media WHERE deleted_at IS NULL ORDER BY id
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read media: %w", err)
+	}
+	defer rows.Close()
+
+	media := make([]Media, 0)
+	for rows.Next() {
+		var md Media
+		if err := rows.Scan(&md.ID, &md.Filename, &md.OriginalName, &md.MimeType,
+			&md.Size, &md.AltText, &md.CreatedAt); err != nil {
+			return nil, err
+		}
+		if referenced != nil && !referenced(md.Filename) {
+			continue
+		}
+		if !safeFilename(md.Filename) {
+			log.Printf("⚠️  Media #%d has an unsafe file name %q, not exported", md.ID, md.Filename)
+			continue
+		}
+
+		sum, size, err := fileDigest(filepath.Join(m.cfg.UploadDir, md.Filename))
+		if os.IsNotExist(err) {
+			log.Printf("⚠️  Media #%d (%s) is missing from %s, not exported", md.ID, md.Filename, m.cfg.UploadDir)
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		md.SHA256, md.Size = sum, size
+		media = append(media, md)
+	}
+	return media, rows.Err()
+}
+
+// exportSettings reads the settings that describe the site, not the installation
+func (m *Manager) exportSettings(ctx context.Context) (map[string]string, error) {
+	rows, err := m.db.QueryContext(ctx, `SELECT key, value FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read settings: %w", err)
+	}
+	defer rows.Close()
+
+	settings := make(map[string]string)
+	for rows.Next() {
+		var key, value string
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, err
+		}
+		if !localSettings[key] {
+			settings[key] = value
+		}
+	}
+	return settings, rows.Err()
+}
+
+// Write writes a bundle as a ZIP archive, or as JSON when asJSON is set.
+// Media without Data are read from the uploads directory.
+func (m *Manager) Write(w io.Writer, b *Bundle, asJSON bool) error {
+	if asJSON {
+		inlined := *b
+		inlined.Media = make([]Media, len(b.Media))
+		for i, md := range b.Media {
+			data, err := m.mediaData(md)
+			if err != nil {
+				return err
+			}
+			md.Data = data
+			inlined.Media[i] = md
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(&inlined)
+	}
+
+	zw := zip.NewWriter(w)
+	manifest := *b
+	manifest.Media = make([]Media, len(b.Media))
+	for i, md := range b.Media {
+		md.Data = nil
+		manifest.Media[i] = md
+	}
+	manifestJSON, err := json.MarshalIndent(&manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	entry, err := zw.CreateHeader(&zip.FileHeader{Name: bundleFile, Method: zip.Deflate, Modified: b.CreatedAt})
+	if err != nil {
+		return err
+	}
+	if _, err := entry.Write(manifestJSON); err != nil {
+		return err
+	}
+
+	for _, md := range b.Media {
+		entry, err := zw.CreateHeader(&zip.FileHeader{Name: mediaDir + "/" + md.Filename, Method: zip.Deflate, Modified: md.CreatedAt})
+		if err != nil {
+			return err
+		}
+		if md.Data != nil {
+			_, err = entry.Write(md.Data)
+		} else {
+			err = copyFile(entry, filepath.Join(m.cfg.UploadDir, md.Filename))
+		}
+		if err != nil {
+			return fmt.Errorf("failed to add %s: %w", md.Filename, err)
+		}
+	}
+	return zw.Close()
+}
+
+// WriteFile exports to path, as JSON when the path ends in .json
+func (m *Manager) WriteFile(path string, b *Bundle) (err error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	partial := path + ".partial"
+	out, err := os.OpenFile(partial, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0640)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(partial)
+
+	if err := m.Write(out, b, strings.EqualFold(filepath.Ext(path), ".json")); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Rename(partial, path)
+}
+
+// ExportArchive writes everything as a ZIP archive, for downloads
+func (m *Manager) ExportArchive(ctx context.Context, w io.Writer) (map[string]int, error) {
+	b, err := m.Export(ctx, ExportOptions{Media: true, Settings: true})
+	if err != nil {
+		return nil, err
+	}
+	return b.Summary(), m.Write(w, b, false)
+}
+
+// mediaData returns a media file's content
+func (m *Manager) mediaData(md Media) ([]byte, error) {
+	if md.Data != nil {
+		return md.Data, nil
+	}
+	data, err := os.ReadFile(filepath.Join(m.cfg.UploadDir, md.Filename))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", md.Filename, err)
+	}
+	return data, nil
+}
+
+// Open reads a bundle written by Write, checking its format, references and
+// media checksums
+func Open(path string) (*Bundle, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var b *Bundle
+	if bytes.HasPrefix(data, []byte("PK")) {
+		b, err = readZip(data)
+	} else {
+		err = json.Unmarshal(data, &b)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a content bundle: %w", path, err)
+	}
+	if b == nil {
+		return nil, fmt.Errorf("%s is not a content bundle", path)
+	}
+	if err := b.validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return b, nil
+}
+
+// readZip reads bundle.json and the media files from a ZIP archive
+func readZip(data []byte) (*Bundle, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+
+	manifest, ok := files[bundleFile]
+	if !ok {
+		return nil, fmt.Errorf("%s is missing", bundleFile)
+	}
+	var b Bundle
+	if err := readJSON(manifest, &b); err != nil {
+		return nil, err
+	}
+
+	for i := range b.Media {
+		f, ok := files[mediaDir+"/"+b.Media[i].Filename]
+		if !ok {
+			return nil, fmt.Errorf("media file %s is missing", b.Media[i].Filename)
+		}
+		if b.Media[i].Data, err = readAll(f); err != nil {
+			return nil, err
+		}
+	}
+	return &b, nil
+}
+
+// validate checks the format version and that placements and media are complete
+func (b *Bundle) validate() error {
+	if b.Format < 1 || b.Format > Version {
+		return fmt.Errorf("unsupported bundle format %d (this build reads up to %d)", b.Format, Version)
+	}
+
+	pages := make(map[int]bool, len(b.Pages))
+	slugs := make(map[string]bool, len(b.Pages))
+	for _, p := range b.Pages {
+		if pages[p.ID] || slugs[p.Slug] {
+			return fmt.Errorf("page %q appears twice", p.Slug)
+		}
+		switch p.Status {
+		case "draft", "published", "archived":
+		default:
+			return fmt.Errorf("page %q has an unknown status %q", p.Slug, p.Status)
+		}
+		pages[p.ID], slugs[p.Slug] = true, true
+	}
+
+	components := make(map[int]bool, len(b.Components))
+	for _, c := range b.Components {
+		if components[c.ID] {
+			return fmt.Errorf("component #%d appears twice", c.ID)
+		}
+		components[c.ID] = true
+	}
+
+	for _, pl := range b.Placements {
+		if !pages[pl.PageID] || !components[pl.ComponentID] {
+			return fmt.Errorf("placement of component #%d on page #%d refers to content missing from the bundle", pl.ComponentID, pl.PageID)
+		}
+		if pl.Overrides != "" {
+			if err := json.Unmarshal([]byte(pl.Overrides), &component.Overrides{}); err != nil {
+				return fmt.Errorf("placement of component #%d on page #%d has invalid overrides: %w", pl.ComponentID, pl.PageID, err)
+			}
+		}
+	}
+
+	filenames := make(map[string]bool, len(b.Media))
+	for _, md := range b.Media {
+		if !safeFilename(md.Filename) || filenames[md.Filename] {
+			return fmt.Errorf("invalid or duplicate media file name %q", md.Filename)
+		}
+		filenames[md.Filename] = true
+
+		sum := sha256.Sum256(md.Data)
+		if hex.EncodeToString(sum[:]) != md.SHA256 {
+			return fmt.Errorf("media file %s does not match its checksum", md.Filename)
+		}
+	}
+	return nil
+}
+
+// referencedText joins every field of the bundle's pages and components
+// that may point at an upload
+func referencedText(b *Bundle) string {
+	var sb strings.Builder
+	for _, p := range b.Pages {
+		sb.WriteString(p.Content + "\n" + p.OGImage + "\n")
+	}
+	for _, c := range b.Components {
+		sb.WriteString(c.Content + "\n" + c.ImageURL + "\n" + c.LinkURL + "\n" + c.DataJSON + "\n")
+	}
+	for _, pl := range b.Placements {
+		sb.WriteString(pl.Overrides + "\n")
+	}
+	return sb.String()
+}
+
+// safeFilename checks that a media file name stays inside the uploads directory
+func safeFilename(name string) bool {
+	return name != "" && name == filepath.Base(name) && !strings.HasPrefix(name, ".") && filepath.IsLocal(name)
+}
+
+// fileDigest returns a file's SHA-256 and size
+func fileDigest(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	if err != nil {
+		return "", 0, err
+	}
+	return hex.EncodeToString(h.Sum(nil)), n, nil
+}
+
+// copyFile copies a file's content to w
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+// readJSON decodes a JSON file from an archive
+func readJSON(f *zip.File, v interface{}) error {
+	data, err := readAll(f)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// readAll reads a file from an archive
+func readAll(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
+	}
+	return data, nil
+}
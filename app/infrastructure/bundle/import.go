@@ -0,0 +1,864 @@
+package bundle
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"cacto-cms/app/infrastructure/database"
+	"cacto-cms/app/shared/events"
+)
+
+// Strategy decides what happens when imported content collides with
+// existing content: a page with the same slug, a component with the same
+// type and name, or a media file with the same name but different bytes
+type Strategy string
+
+const (
+	StrategyFail      Strategy = "fail"      // Abort the import
+	StrategySkip      Strategy = "skip"      // Keep the existing content
+	StrategyRename    Strategy = "rename"    // Import under a free slug, name or file name
+	StrategyOverwrite Strategy = "overwrite" // Replace the existing content
+)
+
+// ParseStrategy validates a strategy name
+func ParseStrategy(s string) (Strategy, error) {
+	switch strategy := Strategy(strings.ToLower(strings.TrimSpace(s))); strategy {
+	case StrategyFail, StrategySkip, StrategyRename, StrategyOverwrite:
+		return strategy, nil
+	default:
+		return "", fmt.Errorf("unknown conflict strategy %q (use fail, skip, rename or overwrite)", s)
+	}
+}
+
+// Action is what an import does with one record
+type Action string
+
+const (
+	ActionCreate    Action = "create"
+	ActionUpdate    Action = "update"
+	ActionRename    Action = "rename" // Created under another slug, name or file name
+	ActionSkip      Action = "skip"
+	ActionUnchanged Action = "unchanged"
+	ActionConflict  Action = "conflict" // Only with StrategyFail
+)
+
+// Change describes what an import did, or would do, with one record
+type Change struct {
+	Kind     string `json:"kind"` // page, component, media or setting
+	Key      string `json:"key"`  // Slug, type/name, file name or setting key
+	Action   Action `json:"action"`
+	SourceID int    `json:"source_id,omitempty"` // ID in the bundle
+	TargetID int    `json:"target_id,omitempty"` // ID on this installation
+	Detail   string `json:"detail,omitempty"`
+
+	before, after map[string]interface{} // Audited state of the record
+}
+
+// changed reports whether the change wrote to the database
+func (c Change) changed() bool {
+	return c.Action == ActionCreate || c.Action == ActionUpdate || c.Action == ActionRename
+}
+
+// Report lists the changes of an import. In a dry run the target IDs of
+// created records are provisional.
+type Report struct {
+	DryRun   bool     `json:"dry_run"`
+	Strategy Strategy `json:"strategy"`
+	Changes  []Change `json:"changes"`
+}
+
+// Count returns the number of changes with the given action
+func (r *Report) Count(action Action) int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Action == action {
+			n++
+		}
+	}
+	return n
+}
+
+// ImportOptions control an import
+type ImportOptions struct {
+	Strategy Strategy
+	DryRun   bool // Plan the import and report it without changing anything
+	Settings bool // Apply the bundle's settings
+}
+
+// errDryRun rolls a dry run back
+var errDryRun = errors.New("dry run")
+
+// importer holds the state of one import
+type importer struct {
+	db        *sql.DB
+	uploadDir string
+	opts      ImportOptions
+	report    *Report
+
+	componentIDs map[int]int       // Bundle ID → ID here
+	renamedMedia map[string]string // Bundle file name → file name here
+	claimedMedia map[string]bool   // File names taken by this import
+	staged       []stagedFile
+}
+
+// stagedFile is a media file written next to its destination, moved into
+// place once the import is committed
+type stagedFile struct {
+	temp, path string
+}
+
+// Import applies a bundle as one unit of work, joining the transaction ctx
+// carries if any. Every record written is audited like a change made
+// through the services, and once the transaction commits the media files
+// are moved into place and the changed pages, components and settings are
+// published on the event bus. A dry run writes nothing at all. With
+// StrategyFail, any conflict aborts the import and is listed in the report;
+// in a dry run the conflicts are reported without an error.
+func (m *Manager) Import(ctx context.Context, b *Bundle, opts ImportOptions) (*Report, error) {
+	if opts.Strategy == "" {
+		opts.Strategy = StrategyFail
+	}
+	if opts.DryRun && database.InTx(ctx) {
+		return nil, fmt.Errorf("a dry run can't be part of another transaction")
+	}
+
+	imp := &importer{
+		db:           m.db,
+		uploadDir:    m.cfg.UploadDir,
+		opts:         opts,
+		report:       &Report{DryRun: opts.DryRun, Strategy: opts.Strategy, Changes: []Change{}},
+		componentIDs: make(map[int]int, len(b.Components)),
+		renamedMedia: make(map[string]string),
+		claimedMedia: make(map[string]bool),
+	}
+	// Inside another transaction the staged files wait for it to commit
+	nested := database.InTx(ctx)
+	var err error
+	defer func() {
+		if !nested || err != nil {
+			imp.discardStaged()
+		}
+	}()
+
+	var conflicts int
+	var moveErr error
+	err = database.WithTx(ctx, m.db, func(ctx context.Context) error {
+		if err := imp.importMedia(ctx, b.Media); err != nil {
+			return err
+		}
+		if err := imp.importComponents(ctx, b.Components); err != nil {
+			return err
+		}
+		if err := imp.importPages(ctx, b.Pages, b.Placements); err != nil {
+			return err
+		}
+		if opts.Settings {
+			if err := imp.importSettings(ctx, b.Settings); err != nil {
+				return err
+			}
+		}
+
+		if opts.DryRun {
+			return errDryRun
+		}
+		if conflicts = imp.report.Count(ActionConflict); conflicts > 0 {
+			return fmt.Errorf("%d conflict(s) with existing content; nothing was imported (choose skip, rename or overwrite)", conflicts)
+		}
+
+		imp.audit(ctx, m.audit)
+		database.AfterCommit(ctx, func() {
+			if moveErr = imp.moveStaged(); moveErr != nil {
+				log.Printf("❌ %v", moveErr)
+			}
+			imp.publish(m.events)
+		})
+		return nil
+	})
+	switch {
+	case errors.Is(err, errDryRun):
+		return imp.report, nil
+	case err != nil && conflicts > 0:
+		return imp.report, err
+	case err != nil:
+		return nil, err
+	case moveErr != nil:
+		return imp.report, moveErr
+	}
+	return imp.report, nil
+}
+
+// audit records every change that wrote to the database, in the
+// transaction of the import
+func (imp *importer) audit(ctx context.Context, recorder Recorder) {
+	if recorder == nil {
+		return
+	}
+	var settingsBefore, settingsAfter map[string]interface{}
+	for _, c := range imp.report.Changes {
+		if !c.changed() {
+			continue
+		}
+		switch c.Kind {
+		case "setting":
+			if settingsAfter == nil {
+				settingsBefore, settingsAfter = make(map[string]interface{}), make(map[string]interface{})
+			}
+			if c.before != nil {
+				settingsBefore[c.Key] = c.before["value"]
+			}
+			settingsAfter[c.Key] = c.after["value"]
+		default:
+			action := c.Kind + ".created"
+			if c.Action == ActionUpdate {
+				action = c.Kind + ".updated"
+			}
+			recorder.Record(ctx, action, c.Kind, c.TargetID, c.before, c.after)
+		}
+	}
+	if settingsAfter != nil {
+		recorder.Record(ctx, "settings.updated", "settings", nil, settingsBefore, settingsAfter)
+	}
+}
+
+// publish announces the changed pages, components and settings, e.g. to the
+// page cache
+func (imp *importer) publish(bus *events.Bus) {
+	settings := false
+	for _, c := range imp.report.Changes {
+		if !c.changed() {
+			continue
+		}
+		switch c.Kind {
+		case "page":
+			bus.Publish(events.Event{Kind: events.PageChanged, ID: c.TargetID})
+		case "component":
+			bus.Publish(events.Event{Kind: events.ComponentChanged, ID: c.TargetID})
+		case "setting":
+			settings = true
+		}
+	}
+	if settings {
+		bus.Publish(events.Event{Kind: events.SettingsChanged})
+	}
+}
+
+// mediaSummary returns the audited fields of an imported media record
+func mediaSummary(md Media, filename string) map[string]interface{} {
+	return map[string]interface{}{
+		"filename":      filename,
+		"original_name": md.OriginalName,
+		"mime_type":     md.MimeType,
+		"size":          md.Size,
+		"alt_text":      md.AltText,
+	}
+}
+
+// componentSummary returns the audited fields of an imported component
+func componentSummary(c Component) map[string]interface{} {
+	return map[string]interface{}{"type": c.Type, "name": c.Name, "title": c.Title}
+}
+
+// pageSummary returns the audited fields of an imported page
+func pageSummary(p Page) map[string]interface{} {
+	return map[string]interface{}{
+		"slug":           p.Slug,
+		"title":          p.Title,
+		"status":         p.Status,
+		"content_length": len(p.Content),
+	}
+}
+
+// conn returns the transaction of the import
+func (imp *importer) conn(ctx context.Context) database.Executor {
+	return database.Conn(ctx, imp.db)
+}
+
+// record adds a change to the report
+func (imp *importer) record(c Change) {
+	imp.report.Changes = append(imp.report.Changes, c)
+}
+
+// importMedia creates the media records and stages their files
+func (imp *importer) importMedia(ctx context.Context, media []Media) error {
+	for _, md := range media {
+		change := Change{Kind: "media", Key: md.Filename, SourceID: md.ID}
+
+		existingID, trashed, err := imp.mediaID(ctx, md.Filename)
+		if err != nil {
+			return err
+		}
+		sum, _, err := fileDigest(filepath.Join(imp.uploadDir, md.Filename))
+		fileExists := err == nil
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		imp.claimedMedia[md.Filename] = true
+
+		switch {
+		case existingID == 0 && !fileExists:
+			change.Action = ActionCreate
+			if change.TargetID, err = imp.createMedia(ctx, md, md.Filename); err != nil {
+				return err
+			}
+		case fileExists && sum == md.SHA256:
+			change.Action, change.TargetID = ActionUnchanged, existingID
+			switch {
+			case existingID == 0:
+				change.Action, change.Detail = ActionCreate, "file already present"
+				if change.TargetID, err = imp.insertMedia(ctx, md, md.Filename); err != nil {
+					return err
+				}
+			case trashed:
+				change.Action, change.Detail = ActionUpdate, "restored from the trash"
+				if _, err := imp.conn(ctx).ExecContext(ctx, `UPDATE media SET deleted_at = NULL WHERE id = ?`, existingID); err != nil {
+					return fmt.Errorf("failed to restore media %s: %w", md.Filename, err)
+				}
+			}
+		default:
+			// The same bytes may already be here under another name, e.g.
+			// from an earlier import with StrategyRename
+			copyID, copyName, err := imp.findMediaCopy(ctx, md)
+			if err != nil {
+				return err
+			}
+			if copyID != 0 {
+				imp.renamedMedia[md.Filename] = copyName
+				change.Action, change.TargetID, change.Detail = ActionUnchanged, copyID, "same file as "+copyName
+				break
+			}
+
+			change.TargetID = existingID
+			switch imp.opts.Strategy {
+			case StrategySkip:
+				change.Action, change.Detail = ActionSkip, "a different file with this name exists"
+			case StrategyRename:
+				name, err := imp.freeMediaName(ctx, md.Filename)
+				if err != nil {
+					return err
+				}
+				imp.renamedMedia[md.Filename] = name
+				change.Action, change.Detail = ActionRename, "as "+name
+				if change.TargetID, err = imp.createMedia(ctx, md, name); err != nil {
+					return err
+				}
+			case StrategyOverwrite:
+				change.Action = ActionUpdate
+				if err := imp.stage(md, md.Filename); err != nil {
+					return err
+				}
+				if existingID == 0 {
+					change.TargetID, err = imp.insertMedia(ctx, md, md.Filename)
+				} else {
+					_, err = imp.conn(ctx).ExecContext(ctx, `UPDATE media SET original_name = ?, mime_type = ?, size = ?, alt_text = ?, deleted_at = NULL WHERE id = ?`,
+						md.OriginalName, md.MimeType, md.Size, md.AltText, existingID)
+				}
+				if err != nil {
+					return fmt.Errorf("failed to update media %s: %w", md.Filename, err)
+				}
+			default:
+				change.Action, change.Detail = ActionConflict, "a different file with this name exists"
+			}
+		}
+		if change.Action == ActionRename {
+			change.after = mediaSummary(md, imp.renamedMedia[md.Filename])
+		} else {
+			change.after = mediaSummary(md, md.Filename)
+		}
+		imp.record(change)
+	}
+	return nil
+}
+
+// mediaID returns the ID of the media record for a file name, or 0. A record
+// in the trash is returned too, since its file is still on disk; trashed
+// reports it.
+func (imp *importer) mediaID(ctx context.Context, filename string) (id int, trashed bool, err error) {
+	err = imp.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, deleted_at IS NOT NULL FROM media WHERE filename = ?
+		ORDER BY deleted_at IS NOT NULL, id LIMIT 1
+	`, filename).Scan(&id, &trashed)
+	if err == sql.ErrNoRows {
+		return 0, false, nil
+	}
+	return id, trashed, err
+}
+
+// findMediaCopy finds a media record whose file has the same content
+func (imp *importer) findMediaCopy(ctx context.Context, md Media) (int, string, error) {
+	rows, err := imp.conn(ctx).QueryContext(ctx, `SELECT id, filename FROM media WHERE size = ? AND filename != ? AND deleted_at IS NULL ORDER BY id`, md.Size, md.Filename)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id int
+		var filename string
+		if err := rows.Scan(&id, &filename); err != nil {
+			return 0, "", err
+		}
+		if !safeFilename(filename) {
+			continue
+		}
+		if sum, _, err := fileDigest(filepath.Join(imp.uploadDir, filename)); err == nil && sum == md.SHA256 {
+			return id, filename, nil
+		}
+	}
+	return 0, "", rows.Err()
+}
+
+// createMedia stages a media file under name and inserts its record
+func (imp *importer) createMedia(ctx context.Context, md Media, name string) (int, error) {
+	if err := imp.stage(md, name); err != nil {
+		return 0, err
+	}
+	return imp.insertMedia(ctx, md, name)
+}
+
+// insertMedia inserts a media record under name
+func (imp *importer) insertMedia(ctx context.Context, md Media, name string) (int, error) {
+	result, err := imp.conn(ctx).ExecContext(ctx, `
+		INSERT INTO media (filename, original_name, mime_type, size, alt_text, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, name, md.OriginalName, md.MimeType, md.Size, md.AltText, md.CreatedAt)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create media %s: %w", name, err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// freeMediaName returns a file name like photo-2.jpg that is neither on
+// disk, in the database nor taken by this import
+func (imp *importer) freeMediaName(ctx context.Context, filename string) (string, error) {
+	ext := filepath.Ext(filename)
+	base := strings.TrimSuffix(filename, ext)
+	for i := 2; ; i++ {
+		name := fmt.Sprintf("%s-%d%s", base, i, ext)
+		if imp.claimedMedia[name] {
+			continue
+		}
+		if _, err := os.Stat(filepath.Join(imp.uploadDir, name)); !os.IsNotExist(err) {
+			continue
+		}
+		id, _, err := imp.mediaID(ctx, name)
+		if err != nil {
+			return "", err
+		}
+		if id == 0 {
+			imp.claimedMedia[name] = true
+			return name, nil
+		}
+	}
+}
+
+// stage writes a media file next to its destination, unless this is a dry run
+func (imp *importer) stage(md Media, name string) error {
+	if imp.opts.DryRun {
+		return nil
+	}
+	if err := os.MkdirAll(imp.uploadDir, 0755); err != nil {
+		return err
+	}
+
+	path := filepath.Join(imp.uploadDir, name)
+	temp := filepath.Join(imp.uploadDir, ".import-"+name)
+	if err := os.WriteFile(temp, md.Data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	imp.staged = append(imp.staged, stagedFile{temp: temp, path: path})
+	return nil
+}
+
+// moveStaged moves the staged media files into place
+func (imp *importer) moveStaged() error {
+	for i, f := range imp.staged {
+		if err := os.Rename(f.temp, f.path); err != nil {
+			imp.staged = imp.staged[i:]
+			return fmt.Errorf("content was imported but media file %s could not be written: %w", filepath.Base(f.path), err)
+		}
+	}
+	imp.staged = nil
+	return nil
+}
+
+// discardStaged removes staged files that were never moved into place
+func (imp *importer) discardStaged() {
+	for _, f := range imp.staged {
+		os.Remove(f.temp)
+	}
+}
+
+// rewrite points upload URLs at the new names of renamed media
+func (imp *importer) rewrite(s string) string {
+	for from, to := range imp.renamedMedia {
+		s = strings.ReplaceAll(s, "/uploads/"+from, "/uploads/"+to)
+	}
+	return s
+}
+
+// importComponents creates or matches components by type and name
+func (imp *importer) importComponents(ctx context.Context, components []Component) error {
+	for _, c := range components {
+		c.Content = imp.rewrite(c.Content)
+		c.ImageURL = imp.rewrite(c.ImageURL)
+		c.LinkURL = imp.rewrite(c.LinkURL)
+		c.DataJSON = imp.rewrite(c.DataJSON)
+		change := Change{Kind: "component", Key: c.Type + "/" + c.Name, SourceID: c.ID}
+
+		existing, err := imp.findComponent(ctx, c.Type, c.Name)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case existing == nil:
+			change.Action = ActionCreate
+			if change.TargetID, err = imp.insertComponent(ctx, c); err != nil {
+				return err
+			}
+		case sameComponent(*existing, c):
+			change.Action, change.TargetID = ActionUnchanged, existing.ID
+		default:
+			change.TargetID = existing.ID
+			switch imp.opts.Strategy {
+			case StrategySkip:
+				change.Action, change.Detail = ActionSkip, "kept the existing component"
+			case StrategyRename:
+				if c.Name, err = imp.freeComponentName(ctx, c.Type, c.Name); err != nil {
+					return err
+				}
+				change.Action, change.Detail = ActionRename, "as "+c.Name
+				if change.TargetID, err = imp.insertComponent(ctx, c); err != nil {
+					return err
+				}
+			case StrategyOverwrite:
+				change.Action = ActionUpdate
+				_, err = imp.conn(ctx).ExecContext(ctx, `
+					UPDATE components
+					SET title = ?, subtitle = ?, content = ?, image_url = ?, link_url = ?,
+					    link_text = ?, data_json = ?, updated_at = CURRENT_TIMESTAMP
+					WHERE id = ?
+				`, c.Title, c.Subtitle, c.Content, c.ImageURL, c.LinkURL, c.LinkText, c.DataJSON, existing.ID)
+				if err != nil {
+					return fmt.Errorf("failed to update component %s: %w", change.Key, err)
+				}
+			default:
+				change.Action, change.Detail = ActionConflict, "a different component with this name exists"
+			}
+		}
+		if change.Action == ActionUpdate {
+			change.before = componentSummary(*existing)
+		}
+		change.after = componentSummary(c)
+
+		imp.componentIDs[c.ID] = change.TargetID
+		imp.record(change)
+	}
+	return nil
+}
+
+// findComponent returns the oldest component with a type and name that is
+// not in the trash, or nil
+func (imp *importer) findComponent(ctx context.Context, componentType, name string) (*Component, error) {
+	c := &Component{}
+	err := imp.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, type, name, COALESCE(title, ''), COALESCE(subtitle, ''), COALESCE(content, ''),
+		       COALESCE(image_url, ''), COALESCE(link_url, ''), COALESCE(link_text, ''), COALESCE(data_json, '')
+		FROM components WHERE type = ? AND name = ? AND deleted_at IS NULL ORDER BY id LIMIT 1
+	`, componentType, name).Scan(&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
+		&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// insertComponent creates a component
+func (imp *importer) insertComponent(ctx context.Context, c Component) (int, error) {
+	result, err := imp.conn(ctx).ExecContext(ctx, `
+		INSERT INTO components (type, name, title, subtitle, content,
+		                        image_url, link_url, link_text, data_json)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, c.Type, c.Name, c.Title, c.Subtitle, c.Content, c.ImageURL, c.LinkURL, c.LinkText, c.DataJSON)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create component %s/%s: %w", c.Type, c.Name, err)
+	}
+	id, err := result.LastInsertId()
+	return int(id), err
+}
+
+// freeComponentName returns a name like hero-2 not yet used for the type
+func (imp *importer) freeComponentName(ctx context.Context, componentType, name string) (string, error) {
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", name, i)
+		existing, err := imp.findComponent(ctx, componentType, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+	}
+}
+
+// sameComponent compares the content of two components, ignoring IDs
+func sameComponent(a, b Component) bool {
+	a.ID, b.ID = 0, 0
+	return a == b
+}
+
+// importPages creates or matches pages by slug, with their placements
+func (imp *importer) importPages(ctx context.Context, pages []Page, placements []Placement) error {
+	byPage := make(map[int][]Placement)
+	for _, pl := range placements {
+		byPage[pl.PageID] = append(byPage[pl.PageID], Placement{
+			ComponentID: imp.componentIDs[pl.ComponentID],
+			Position:    pl.Position,
+			Overrides:   imp.rewrite(pl.Overrides),
+		})
+	}
+
+	for _, p := range pages {
+		p.Content = imp.rewrite(p.Content)
+		p.OGImage = imp.rewrite(p.OGImage)
+		wanted := byPage[p.ID]
+		change := Change{Kind: "page", Key: "/" + p.Slug, SourceID: p.ID}
+
+		existing, trashed, err := imp.findPage(ctx, p.Slug)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case existing == nil:
+			change.Action = ActionCreate
+			if change.TargetID, err = imp.insertPage(ctx, p, wanted); err != nil {
+				return err
+			}
+		case trashed:
+			// The deleted page only holds the slug: it is replaced and restored
+			change.Action, change.Detail, change.TargetID = ActionUpdate, "replaced the page in the trash", existing.ID
+			if err := imp.updatePage(ctx, existing.ID, p, wanted); err != nil {
+				return err
+			}
+		default:
+			current, err := imp.placements(ctx, existing.ID)
+			if err != nil {
+				return err
+			}
+			change.TargetID = existing.ID
+			if samePage(*existing, p) && samePlacements(current, wanted) {
+				change.Action = ActionUnchanged
+				break
+			}
+
+			switch imp.opts.Strategy {
+			case StrategySkip:
+				change.Action, change.Detail = ActionSkip, "kept the existing page"
+			case StrategyRename:
+				if p.Slug, err = imp.freeSlug(ctx, p.Slug); err != nil {
+					return err
+				}
+				change.Action, change.Detail = ActionRename, "as /"+p.Slug
+				if change.TargetID, err = imp.insertPage(ctx, p, wanted); err != nil {
+					return err
+				}
+			case StrategyOverwrite:
+				change.Action = ActionUpdate
+				if err := imp.updatePage(ctx, existing.ID, p, wanted); err != nil {
+					return err
+				}
+			default:
+				change.Action, change.Detail = ActionConflict, "a different page with this slug exists"
+			}
+		}
+		if change.Action == ActionUpdate {
+			change.before = pageSummary(*existing)
+		}
+		change.after = pageSummary(p)
+		imp.record(change)
+	}
+	return nil
+}
+
+// findPage returns the page with a slug, or nil. A page in the trash is
+// returned too, since it still holds the slug; trashed reports it.
+func (imp *importer) findPage(ctx context.Context, slug string) (p *Page, trashed bool, err error) {
+	p = &Page{}
+	err = imp.conn(ctx).QueryRowContext(ctx, `
+		SELECT id, slug, title, COALESCE(content, ''), COALESCE(meta_title, ''),
+		       COALESCE(meta_description, ''), COALESCE(meta_keywords, ''),
+		       COALESCE(og_image, ''), status, deleted_at IS NOT NULL
+		FROM pages WHERE slug = ?
+	`, slug).Scan(&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle,
+		&p.MetaDescription, &p.MetaKeywords, &p.OGImage, &p.Status, &trashed)
+	if err == sql.ErrNoRows {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return p, trashed, nil
+}
+
+// placements returns a page's placements in order
+func (imp *importer) placements(ctx context.Context, pageID int) ([]Placement, error) {
+	rows, err := imp.conn(ctx).QueryContext(ctx, `SELECT component_id, position, COALESCE(overrides_json, '') FROM page_components WHERE page_id = ? ORDER BY position, id`, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	placements := make([]Placement, 0)
+	for rows.Next() {
+		var pl Placement
+		if err := rows.Scan(&pl.ComponentID, &pl.Position, &pl.Overrides); err != nil {
+			return nil, err
+		}
+		placements = append(placements, pl)
+	}
+	return placements, rows.Err()
+}
+
+// insertPage creates a page with its placements
+func (imp *importer) insertPage(ctx context.Context, p Page, placements []Placement) (int, error) {
+	now := time.Now()
+	result, err := imp.conn(ctx).ExecContext(ctx, `
+		INSERT INTO pages (slug, title, content, meta_title, meta_description,
+		                   meta_keywords, og_image, status, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, p.Slug, p.Title, p.Content, p.MetaTitle, p.MetaDescription, p.MetaKeywords, p.OGImage, p.Status, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create page %q: %w", p.Slug, err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	return int(id), imp.insertPlacements(ctx, int(id), placements)
+}
+
+// updatePage overwrites a page, taking it out of the trash, and replaces its
+// placements
+func (imp *importer) updatePage(ctx context.Context, id int, p Page, placements []Placement) error {
+	_, err := imp.conn(ctx).ExecContext(ctx, `
+		UPDATE pages
+		SET title = ?, content = ?, meta_title = ?, meta_description = ?,
+		    meta_keywords = ?, og_image = ?, status = ?, updated_at = ?, deleted_at = NULL
+		WHERE id = ?
+	`, p.Title, p.Content, p.MetaTitle, p.MetaDescription, p.MetaKeywords, p.OGImage, p.Status, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to update page %q: %w", p.Slug, err)
+	}
+	if _, err := imp.conn(ctx).ExecContext(ctx, `DELETE FROM page_components WHERE page_id = ?`, id); err != nil {
+		return err
+	}
+	return imp.insertPlacements(ctx, id, placements)
+}
+
+// insertPlacements adds components to a page
+func (imp *importer) insertPlacements(ctx context.Context, pageID int, placements []Placement) error {
+	for _, pl := range placements {
+		var overrides interface{}
+		if pl.Overrides != "" {
+			overrides = pl.Overrides
+		}
+		if _, err := imp.conn(ctx).ExecContext(ctx, `INSERT INTO page_components (page_id, component_id, position, overrides_json) VALUES (?, ?, ?, ?)`,
+			pageID, pl.ComponentID, pl.Position, overrides); err != nil {
+			return fmt.Errorf("failed to place component #%d: %w", pl.ComponentID, err)
+		}
+	}
+	return nil
+}
+
+// freeSlug returns a slug like about-2 that no page uses; the home page
+// becomes home-2
+func (imp *importer) freeSlug(ctx context.Context, slug string) (string, error) {
+	if slug == "" {
+		slug = "home"
+	}
+	for i := 2; ; i++ {
+		candidate := fmt.Sprintf("%s-%d", slug, i)
+		existing, _, err := imp.findPage(ctx, candidate)
+		if err != nil {
+			return "", err
+		}
+		if existing == nil {
+			return candidate, nil
+		}
+	}
+}
+
+// samePage compares the content of two pages, ignoring IDs
+func samePage(a, b Page) bool {
+	a.ID, b.ID = 0, 0
+	return a == b
+}
+
+// samePlacements compares two ordered lists of placements
+func samePlacements(a, b []Placement) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// importSettings adds missing settings. Differing values are only replaced
+// with StrategyOverwrite; they never count as conflicts.
+func (imp *importer) importSettings(ctx context.Context, settings map[string]string) error {
+	keys := make([]string, 0, len(settings))
+	for key := range settings {
+		if !localSettings[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := settings[key]
+		change := Change{Kind: "setting", Key: key}
+
+		var current string
+		err := imp.conn(ctx).QueryRowContext(ctx, `SELECT value FROM settings WHERE key = ?`, key).Scan(&current)
+		switch {
+		case err == sql.ErrNoRows:
+			change.Action = ActionCreate
+			_, err = imp.conn(ctx).ExecContext(ctx, `INSERT INTO settings (key, value, updated_at) VALUES (?, ?, CURRENT_TIMESTAMP)`, key, value)
+		case err != nil:
+			return err
+		case current == value:
+			change.Action = ActionUnchanged
+		case imp.opts.Strategy == StrategyOverwrite:
+			change.Action, change.Detail = ActionUpdate, fmt.Sprintf("%q → %q", current, value)
+			_, err = imp.conn(ctx).ExecContext(ctx, `UPDATE settings SET value = ?, updated_at = CURRENT_TIMESTAMP WHERE key = ?`, value, key)
+		default:
+			change.Action, change.Detail = ActionSkip, fmt.Sprintf("kept %q", current)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to import setting %s: %w", key, err)
+		}
+		if change.Action == ActionUpdate {
+			change.before = map[string]interface{}{"value": current}
+		}
+		change.after = map[string]interface{}{"value": value}
+		imp.record(change)
+	}
+	return nil
+}
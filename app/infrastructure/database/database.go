@@ -7,7 +7,6 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
 
 	_ "modernc.org/sqlite"
 )
@@ -20,7 +19,8 @@ type Database struct {
 	DB *sql.DB
 }
 
-// New creates a new database connection and runs migrations
+// New opens a database connection. Migrations are applied separately with
+// Migrate or through the migrator.
 func New(dbPath string) (*Database, error) {
 	// Ensure directory exists
 	dir := filepath.Dir(dbPath)
@@ -28,62 +28,47 @@ func New(dbPath string) (*Database, error) {
 		return nil, fmt.Errorf("failed to create db directory: %w", err)
 	}
 
-	db, err := sql.Open("sqlite", dbPath)
+	// Connection pragmas go in the DSN so that every pooled connection gets
+	// them: foreign keys are enforced, and writers wait for the lock instead
+	// of failing with SQLITE_BUSY, e.g. when concurrent logins record their
+	// attempts. Transactions take the write lock when they begin, so one
+	// that reads before it writes (a check guarding the write) waits for
+	// the others instead of failing on a stale read.
+	db, err := sql.Open("sqlite", dbPath+"?_pragma=busy_timeout(5000)&_pragma=foreign_keys(1)&_txlock=immediate")
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
 
-	// Enable foreign keys
-	if _, err := db.Exec("PRAGMA foreign_keys = ON"); err != nil {
-		return nil, fmt.Errorf("failed to enable foreign keys: %w", err)
-	}
-
 	// Set pragmas for better performance
 	if _, err := db.Exec("PRAGMA journal_mode = WAL"); err != nil {
 		return nil, fmt.Errorf("failed to set WAL mode: %w", err)
 	}
 
-	database := &Database{DB: db}
-
-	// Run migrations
-	if err := database.runMigrations(); err != nil {
-		return nil, fmt.Errorf("migration failed: %w", err)
-	}
-
-	return database, nil
+	return &Database{DB: db}, nil
 }
 
-func (d *Database) runMigrations() error {
-	log.Println("🔄 Running migrations...")
+// Migrator returns the migrator for the embedded migrations
+func (d *Database) Migrator() (*Migrator, error) {
+	return NewMigrator(d.DB)
+}
 
-	files, err := migrationFS.ReadDir("migrations")
+// Migrate applies all pending migrations
+func (d *Database) Migrate() error {
+	migrator, err := d.Migrator()
 	if err != nil {
 		return err
 	}
 
-	// Sort files to ensure order
-	sort.Slice(files, func(i, j int) bool {
-		return files[i].Name() < files[j].Name()
-	})
-
-	for _, file := range files {
-		if filepath.Ext(file.Name()) != ".sql" {
-			continue
-		}
-
-		content, err := migrationFS.ReadFile("migrations/" + file.Name())
-		if err != nil {
-			return fmt.Errorf("failed to read %s: %w", file.Name(), err)
-		}
-
-		if _, err := d.DB.Exec(string(content)); err != nil {
-			return fmt.Errorf("migration %s failed: %w", file.Name(), err)
-		}
-
-		log.Printf("  ✓ Applied: %s", file.Name())
+	log.Println("🔄 Running migrations...")
+	applied, err := migrator.Migrate()
+	if err != nil {
+		return fmt.Errorf("migration failed: %w", err)
+	}
+	if len(applied) == 0 {
+		log.Println("✅ Nothing to migrate")
+	} else {
+		log.Printf("✅ Applied %d migration(s)", len(applied))
 	}
-
-	log.Println("✅ All migrations completed")
 	return nil
 }
 
@@ -0,0 +1,6 @@
+DROP TABLE IF EXISTS settings;
+DROP TABLE IF EXISTS users;
+DROP TABLE IF EXISTS page_components;
+DROP TABLE IF EXISTS components;
+DROP TABLE IF EXISTS media;
+DROP TABLE IF EXISTS pages;
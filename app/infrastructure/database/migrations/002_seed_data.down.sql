@@ -0,0 +1 @@
+-- Nothing to undo: seed data is managed by the seeders
@@ -0,0 +1,26 @@
+-- Login attempts (per-account brute-force tracking)
+CREATE TABLE IF NOT EXISTS login_attempts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    ip_address TEXT DEFAULT '',
+    user_agent TEXT DEFAULT '',
+    success INTEGER NOT NULL DEFAULT 0 CHECK(success IN (0, 1)),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempts_email ON login_attempts(email, created_at);
+
+-- Account lockouts (kept after expiry/unlock as an audit trail)
+CREATE TABLE IF NOT EXISTS account_lockouts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT NOT NULL,
+    failed_attempts INTEGER NOT NULL DEFAULT 0,
+    ip_address TEXT DEFAULT '',
+    locked_until DATETIME NOT NULL,
+    unlocked_at DATETIME,
+    unlocked_by INTEGER,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (unlocked_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_account_lockouts_email ON account_lockouts(email, created_at);
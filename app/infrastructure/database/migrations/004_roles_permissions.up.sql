@@ -0,0 +1,69 @@
+-- Roles
+CREATE TABLE IF NOT EXISTS roles (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT UNIQUE NOT NULL,
+    description TEXT DEFAULT '',
+    is_system INTEGER DEFAULT 0 CHECK(is_system IN (0, 1)),
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Permission catalog (roles can only be granted known permissions)
+CREATE TABLE IF NOT EXISTS permissions (
+    name TEXT PRIMARY KEY,
+    description TEXT DEFAULT ''
+);
+
+-- Role-Permission mapping
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id INTEGER NOT NULL,
+    permission TEXT NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE
+);
+
+-- Default roles (NOT EXISTS instead of OR IGNORE so ids are not burned on every boot)
+INSERT INTO roles (name, description, is_system)
+SELECT d.name, d.description, 1 FROM (
+    SELECT 'admin' AS name, 'Full access to everything' AS description
+    UNION ALL SELECT 'editor', 'Manages all content'
+    UNION ALL SELECT 'author', 'Writes pages'
+    UNION ALL SELECT 'viewer', 'Read-only access'
+) d
+WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.name = d.name);
+
+-- Known permissions ("*" and "resource:*" are wildcards)
+INSERT OR IGNORE INTO permissions (name, description) VALUES
+    ('*', 'All permissions'),
+    ('dashboard:access', 'Access the admin dashboard'),
+    ('pages:read', 'View pages'),
+    ('pages:write', 'Create and edit pages'),
+    ('pages:delete', 'Delete pages'),
+    ('components:read', 'View components'),
+    ('components:write', 'Create and edit components'),
+    ('components:delete', 'Delete components'),
+    ('media:read', 'View media'),
+    ('media:write', 'Upload and edit media'),
+    ('media:delete', 'Delete media'),
+    ('users:read', 'View users'),
+    ('users:write', 'Manage users and account lockouts'),
+    ('roles:manage', 'Manage roles and permissions');
+
+-- Default grants, applied only on a fresh install so admin edits are kept
+INSERT INTO role_permissions (role_id, permission)
+SELECT r.id, p.permission FROM roles r
+JOIN (
+    SELECT 'admin' AS role, '*' AS permission
+    UNION ALL SELECT 'editor', 'dashboard:access'
+    UNION ALL SELECT 'editor', 'pages:read'
+    UNION ALL SELECT 'editor', 'pages:write'
+    UNION ALL SELECT 'editor', 'pages:delete'
+    UNION ALL SELECT 'editor', 'components:read'
+    UNION ALL SELECT 'editor', 'components:write'
+    UNION ALL SELECT 'author', 'pages:read'
+    UNION ALL SELECT 'author', 'pages:write'
+    UNION ALL SELECT 'author', 'components:read'
+    UNION ALL SELECT 'viewer', 'pages:read'
+    UNION ALL SELECT 'viewer', 'components:read'
+) p ON p.role = r.name
+WHERE NOT EXISTS (SELECT 1 FROM role_permissions);
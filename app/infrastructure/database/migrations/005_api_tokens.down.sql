@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS api_tokens;
+
+DELETE FROM role_permissions WHERE permission = 'tokens:manage';
+DELETE FROM permissions WHERE name = 'tokens:manage';
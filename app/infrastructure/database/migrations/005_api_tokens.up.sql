@@ -0,0 +1,24 @@
+-- API tokens (personal access tokens and service tokens).
+-- Only the SHA-256 hash of a token is stored; the plaintext is shown once on creation.
+CREATE TABLE IF NOT EXISTS api_tokens (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    kind TEXT NOT NULL CHECK(kind IN ('personal', 'service')),
+    user_id INTEGER,
+    created_by INTEGER,
+    token_prefix TEXT NOT NULL,
+    token_hash TEXT UNIQUE NOT NULL,
+    scopes TEXT NOT NULL DEFAULT '',
+    expires_at DATETIME NOT NULL,
+    last_used_at DATETIME,
+    last_used_ip TEXT DEFAULT '',
+    revoked_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE,
+    FOREIGN KEY (created_by) REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user ON api_tokens(user_id);
+
+INSERT OR IGNORE INTO permissions (name, description) VALUES
+    ('tokens:manage', 'Manage service tokens and revoke any API token');
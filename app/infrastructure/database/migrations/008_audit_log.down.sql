@@ -0,0 +1,6 @@
+DROP TRIGGER IF EXISTS audit_log_no_update;
+DROP TRIGGER IF EXISTS audit_log_no_delete;
+DROP TABLE IF EXISTS audit_log;
+
+DELETE FROM role_permissions WHERE permission = 'audit:read';
+DELETE FROM permissions WHERE name = 'audit:read';
@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS user_sessions;
+
+DELETE FROM role_permissions WHERE permission = 'users:impersonate';
+DELETE FROM permissions WHERE name = 'users:impersonate';
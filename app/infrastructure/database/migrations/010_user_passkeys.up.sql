@@ -0,0 +1,20 @@
+-- WebAuthn credentials (passkeys) for passwordless sign-in.
+-- credential_id and public_key (COSE_Key) are stored base64url encoded.
+CREATE TABLE IF NOT EXISTS user_passkeys (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL,
+    name TEXT NOT NULL,
+    credential_id TEXT UNIQUE NOT NULL,
+    public_key TEXT NOT NULL,
+    algorithm INTEGER NOT NULL,
+    sign_count INTEGER NOT NULL DEFAULT 0,
+    aaguid TEXT DEFAULT '',
+    transports TEXT DEFAULT '',
+    backup_eligible BOOLEAN DEFAULT 0,
+    backed_up BOOLEAN DEFAULT 0,
+    created_at DATETIME NOT NULL,
+    last_used_at DATETIME,
+    FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_passkeys_user ON user_passkeys(user_id);
@@ -0,0 +1,21 @@
+-- Restores the built-in role constraint; fails while users have custom roles
+CREATE TABLE users_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    name TEXT NOT NULL,
+    role TEXT DEFAULT 'viewer' CHECK(role IN ('admin', 'editor', 'author', 'viewer')),
+    is_active INTEGER DEFAULT 1 CHECK(is_active IN (0, 1)),
+    last_login_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users_old (id, email, password_hash, name, role, is_active, last_login_at, created_at, updated_at)
+SELECT id, email, password_hash, name, role, is_active, last_login_at, created_at, updated_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_old RENAME TO users;
+
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
@@ -0,0 +1,22 @@
+-- Drop the hardcoded CHECK(role IN (...)) so custom roles from the roles
+-- table can be assigned. SQLite cannot drop a constraint, so the table is rebuilt.
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    email TEXT UNIQUE NOT NULL,
+    password_hash TEXT NOT NULL,
+    name TEXT NOT NULL,
+    role TEXT NOT NULL DEFAULT 'viewer',
+    is_active INTEGER DEFAULT 1 CHECK(is_active IN (0, 1)),
+    last_login_at DATETIME,
+    created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+);
+
+INSERT INTO users_new (id, email, password_hash, name, role, is_active, last_login_at, created_at, updated_at)
+SELECT id, email, password_hash, name, role, is_active, last_login_at, created_at, updated_at FROM users;
+
+DROP TABLE users;
+ALTER TABLE users_new RENAME TO users;
+
+CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
+CREATE INDEX IF NOT EXISTS idx_users_role ON users(role);
@@ -0,0 +1,2 @@
+ALTER TABLE audit_log DROP COLUMN impersonator_email;
+ALTER TABLE audit_log DROP COLUMN impersonator_id;
@@ -0,0 +1,3 @@
+-- Attribute audit entries written during an impersonation to the admin
+ALTER TABLE audit_log ADD COLUMN impersonator_id INTEGER;
+ALTER TABLE audit_log ADD COLUMN impersonator_email TEXT DEFAULT '';
@@ -0,0 +1,2 @@
+DELETE FROM role_permissions WHERE permission = 'content:export';
+DELETE FROM permissions WHERE name = 'content:export';
@@ -0,0 +1,2 @@
+INSERT OR IGNORE INTO permissions (name, description) VALUES
+    ('content:export', 'Download content bundles');
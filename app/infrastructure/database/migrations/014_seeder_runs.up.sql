@@ -0,0 +1,9 @@
+-- Seeders that have run. A seeder whose fixture file is unchanged is not
+-- run again; an edited one runs again and adds only the new records.
+CREATE TABLE IF NOT EXISTS seeder_runs (
+    name TEXT PRIMARY KEY,
+    checksum TEXT NOT NULL,
+    environment TEXT NOT NULL,
+    records INTEGER NOT NULL DEFAULT 0,
+    ran_at DATETIME NOT NULL
+);
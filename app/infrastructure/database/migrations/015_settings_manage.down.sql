@@ -0,0 +1,6 @@
+DELETE FROM role_permissions WHERE permission = 'settings:manage';
+DELETE FROM permissions WHERE name = 'settings:manage';
+INSERT OR IGNORE INTO settings (key, value) VALUES
+    ('site_name', 'Cacto CMS'),
+    ('site_description', 'Performans odaklı kurumsal web sitesi'),
+    ('sitemap_last_generated', '');
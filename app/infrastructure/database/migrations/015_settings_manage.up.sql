@@ -0,0 +1,8 @@
+INSERT OR IGNORE INTO permissions (name, description) VALUES
+    ('settings:manage', 'Change the site settings');
+
+-- The values seeded with the schema were never read. Drop them so
+-- SITE_NAME and SITE_DESCRIPTION stay in effect until changed in the admin.
+DELETE FROM settings WHERE key = 'site_name' AND value = 'Cacto CMS';
+DELETE FROM settings WHERE key = 'site_description' AND value = 'Performans odaklı kurumsal web sitesi';
+DELETE FROM settings WHERE key = 'sitemap_last_generated' AND value = '';
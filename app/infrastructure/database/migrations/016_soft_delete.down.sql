@@ -0,0 +1,10 @@
+-- Empties the trash first, otherwise deleted pages and components would reappear
+DELETE FROM page_components WHERE page_id IN (SELECT id FROM pages WHERE deleted_at IS NOT NULL)
+    OR component_id IN (SELECT id FROM components WHERE deleted_at IS NOT NULL);
+DELETE FROM pages WHERE deleted_at IS NOT NULL;
+DELETE FROM components WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_pages_deleted_at;
+DROP INDEX IF EXISTS idx_components_deleted_at;
+ALTER TABLE pages DROP COLUMN deleted_at;
+ALTER TABLE components DROP COLUMN deleted_at;
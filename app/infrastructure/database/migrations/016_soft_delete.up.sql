@@ -0,0 +1,8 @@
+-- Deleted pages and components go to the trash: deleted_at is set and they
+-- are hidden until restored or purged. Placements of a deleted component
+-- are kept, so restoring it puts it back where it was.
+ALTER TABLE pages ADD COLUMN deleted_at DATETIME;
+ALTER TABLE components ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_pages_deleted_at ON pages(deleted_at);
+CREATE INDEX IF NOT EXISTS idx_components_deleted_at ON components(deleted_at);
@@ -0,0 +1 @@
+ALTER TABLE page_components DROP COLUMN overrides_json;
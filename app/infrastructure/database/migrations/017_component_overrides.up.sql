@@ -0,0 +1,3 @@
+-- A placement can override fields of the shared component on its page only,
+-- stored as a JSON object of the overridden fields
+ALTER TABLE page_components ADD COLUMN overrides_json TEXT;
@@ -0,0 +1,6 @@
+-- Empties the media trash first, otherwise deleted media would reappear.
+-- Their files are left in the uploads directory.
+DELETE FROM media WHERE deleted_at IS NOT NULL;
+
+DROP INDEX IF EXISTS idx_media_deleted_at;
+ALTER TABLE media DROP COLUMN deleted_at;
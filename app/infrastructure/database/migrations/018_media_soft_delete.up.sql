@@ -0,0 +1,6 @@
+-- Deleted media go to the trash like pages and components: deleted_at is
+-- set and the record is hidden until restored or purged. The file stays in
+-- the uploads directory until the record is purged.
+ALTER TABLE media ADD COLUMN deleted_at DATETIME;
+
+CREATE INDEX IF NOT EXISTS idx_media_deleted_at ON media(deleted_at);
@@ -0,0 +1,2 @@
+-- Emails stay lowercased.
+DROP INDEX IF EXISTS idx_users_email_nocase;
@@ -0,0 +1,6 @@
+-- Emails are stored lowercased and compared without case: addresses that
+-- differ only in case belong to the same account. Fails if two existing
+-- accounts differ only in case; merge or rename one of them first.
+UPDATE users SET email = lower(trim(email)) WHERE email != lower(trim(email));
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_email_nocase ON users(email COLLATE NOCASE);
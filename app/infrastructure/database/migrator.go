@@ -0,0 +1,464 @@
+package database
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// baselineVersion is the last migration of the released schema, which ran
+// its scripts on every boot. Databases created by it, before
+// schema_migrations existed, are recorded as being at this version.
+const baselineVersion = 2
+
+// staleLockAge is how long a migration lock is honored before it is assumed
+// to belong to a crashed process
+const staleLockAge = 15 * time.Minute
+
+// migrationFile matches "001_initial_schema.up.sql" and "001_initial_schema.down.sql"
+var migrationFile = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is a versioned pair of up and down SQL scripts
+type Migration struct {
+	Version  int
+	Name     string
+	Up       string
+	Down     string
+	Checksum string // SHA-256 of the up script
+}
+
+// ID returns the migration file prefix, e.g. "001_initial_schema"
+func (m Migration) ID() string {
+	return fmt.Sprintf("%03d_%s", m.Version, m.Name)
+}
+
+// MigrationStatus describes a migration and whether it has been applied
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt *time.Time
+	Batch     int
+	Modified  bool // Applied with a different checksum (the file was edited)
+	Missing   bool // Applied, but the file no longer exists
+}
+
+// Migrator applies and rolls back versioned migrations, recording them in
+// schema_migrations. Each migration runs in its own transaction with
+// foreign keys off (so tables can be rebuilt) and is checked with
+// foreign_key_check before it commits.
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// NewMigrator creates a migrator for the embedded migrations
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	sub, err := fs.Sub(migrationFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+	migrations, err := LoadMigrations(sub)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// LoadMigrations reads up/down pairs from a directory. Every version needs
+// both scripts, and versions must be unique.
+func LoadMigrations(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	byVersion := make(map[int]*Migration)
+	for _, entry := range entries {
+		match := migrationFile.FindStringSubmatch(entry.Name())
+		if match == nil {
+			if strings.HasSuffix(entry.Name(), ".sql") {
+				return nil, fmt.Errorf("migration %s: expected NNN_name.up.sql or NNN_name.down.sql", entry.Name())
+			}
+			continue
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", entry.Name(), err)
+		}
+
+		m, ok := byVersion[version]
+		if !ok {
+			m = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = m
+		} else if m.Name != match[2] {
+			return nil, fmt.Errorf("migration version %d is used by both %s and %s", version, m.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			m.Up = string(content)
+			sum := sha256.Sum256(content)
+			m.Checksum = hex.EncodeToString(sum[:])
+		} else {
+			m.Down = string(content)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, m := range byVersion {
+		if m.Checksum == "" {
+			return nil, fmt.Errorf("migration %s has no up script", m.ID())
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			return nil, fmt.Errorf("migration %s has no down script", m.ID())
+		}
+		migrations = append(migrations, *m)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// appliedMigration is a row of schema_migrations
+type appliedMigration struct {
+	version   int
+	name      string
+	checksum  string
+	batch     int
+	appliedAt time.Time
+}
+
+// Status lists all known and applied migrations in version order
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	if err := m.ensureTables(context.Background(), m.db); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(context.Background(), m.db)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		status := MigrationStatus{Migration: migration}
+		if a, ok := applied[migration.Version]; ok {
+			at := a.appliedAt
+			status.Applied = true
+			status.AppliedAt = &at
+			status.Batch = a.batch
+			status.Modified = a.checksum != migration.Checksum
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	for _, a := range applied {
+		at := a.appliedAt
+		statuses = append(statuses, MigrationStatus{
+			Migration: Migration{Version: a.version, Name: a.name, Checksum: a.checksum},
+			Applied:   true,
+			AppliedAt: &at,
+			Batch:     a.batch,
+			Missing:   true,
+		})
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Pending returns the migrations that have not been applied yet
+func (m *Migrator) Pending() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	pending := make([]Migration, 0)
+	for _, s := range statuses {
+		if !s.Applied {
+			pending = append(pending, s.Migration)
+		}
+	}
+	return pending, nil
+}
+
+// Migrate applies all pending migrations as one batch. It refuses to run
+// when an applied migration was edited, since the schema would no longer
+// match what the files describe.
+func (m *Migrator) Migrate() ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		if err := m.adoptLegacySchema(ctx, conn); err != nil {
+			return err
+		}
+
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.migrations {
+			if a, ok := applied[migration.Version]; ok && a.checksum != migration.Checksum {
+				return fmt.Errorf("migration %s was modified after it was applied (checksum mismatch)", migration.ID())
+			}
+		}
+
+		batch := 1
+		for _, a := range applied {
+			if a.batch >= batch {
+				batch = a.batch + 1
+			}
+		}
+
+		for _, migration := range m.migrations {
+			if _, ok := applied[migration.Version]; ok {
+				continue
+			}
+			if err := m.run(ctx, conn, migration, migration.Up, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx,
+					`INSERT INTO schema_migrations (version, name, checksum, batch, applied_at) VALUES (?, ?, ?, ?, ?)`,
+					migration.Version, migration.Name, migration.Checksum, batch, time.Now().UTC(),
+				)
+				return err
+			}); err != nil {
+				return err
+			}
+			log.Printf("  ✓ Migrated: %s", migration.ID())
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// Rollback reverts the last batch of migrations, or the last steps
+// migrations when steps is greater than zero
+func (m *Migrator) Rollback(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.withLock(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := m.applied(ctx, conn)
+		if err != nil {
+			return err
+		}
+
+		versions := make([]int, 0, len(applied))
+		lastBatch := 0
+		for version, a := range applied {
+			versions = append(versions, version)
+			if a.batch > lastBatch {
+				lastBatch = a.batch
+			}
+		}
+		sort.Sort(sort.Reverse(sort.IntSlice(versions)))
+
+		// Batch 0 is the baseline of an adopted database; it is only
+		// rolled back when steps are given explicitly
+		if steps <= 0 && lastBatch == 0 {
+			return nil
+		}
+
+		known := make(map[int]Migration, len(m.migrations))
+		for _, migration := range m.migrations {
+			known[migration.Version] = migration
+		}
+
+		for i, version := range versions {
+			if steps > 0 && i >= steps || steps <= 0 && applied[version].batch != lastBatch {
+				break
+			}
+
+			migration, ok := known[version]
+			if !ok {
+				return fmt.Errorf("migration %03d_%s cannot be rolled back: its file no longer exists", version, applied[version].name)
+			}
+			if err := m.run(ctx, conn, migration, migration.Down, func(tx *sql.Tx) error {
+				_, err := tx.ExecContext(ctx, `DELETE FROM schema_migrations WHERE version = ?`, version)
+				return err
+			}); err != nil {
+				return err
+			}
+			log.Printf("  ↩ Rolled back: %s", migration.ID())
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+// run executes one migration script and its bookkeeping in a transaction
+func (m *Migrator) run(ctx context.Context, conn *sql.Conn, migration Migration, script string, record func(tx *sql.Tx) error) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if strings.TrimSpace(stripComments(script)) != "" {
+		if _, err := tx.ExecContext(ctx, script); err != nil {
+			return fmt.Errorf("migration %s failed: %w", migration.ID(), err)
+		}
+	}
+
+	rows, err := tx.QueryContext(ctx, `PRAGMA foreign_key_check`)
+	if err != nil {
+		return err
+	}
+	violation := rows.Next()
+	rows.Close()
+	if violation {
+		return fmt.Errorf("migration %s failed: it leaves foreign key violations", migration.ID())
+	}
+
+	if err := record(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// withLock runs fn on a dedicated connection (foreign keys off) while
+// holding the migration lock, so two processes never migrate at once
+func (m *Migrator) withLock(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	if err := m.ensureTables(ctx, conn); err != nil {
+		return err
+	}
+
+	holder := lockHolder()
+	now := time.Now().UTC()
+	if _, err := conn.ExecContext(ctx, `DELETE FROM schema_migrations_lock WHERE locked_at < ?`, now.Add(-staleLockAge)); err != nil {
+		return err
+	}
+	if _, err := conn.ExecContext(ctx, `INSERT INTO schema_migrations_lock (id, holder, locked_at) VALUES (1, ?, ?)`, holder, now); err != nil {
+		var current string
+		conn.QueryRowContext(ctx, `SELECT holder FROM schema_migrations_lock WHERE id = 1`).Scan(&current)
+		return fmt.Errorf("migrations are locked by %s, try again later", current)
+	}
+	defer conn.ExecContext(ctx, `DELETE FROM schema_migrations_lock WHERE id = 1 AND holder = ?`, holder)
+
+	// Foreign keys must be off while tables are rebuilt, and the pragma
+	// only applies outside a transaction
+	if _, err := conn.ExecContext(ctx, "PRAGMA foreign_keys = OFF"); err != nil {
+		return err
+	}
+	defer conn.ExecContext(ctx, "PRAGMA foreign_keys = ON")
+
+	return fn(ctx, conn)
+}
+
+// execer is satisfied by *sql.DB, *sql.Conn and *sql.Tx
+type execer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// ensureTables creates the bookkeeping tables
+func (m *Migrator) ensureTables(ctx context.Context, db execer) error {
+	_, err := db.ExecContext(ctx, `
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			name TEXT NOT NULL,
+			checksum TEXT NOT NULL,
+			batch INTEGER NOT NULL,
+			applied_at DATETIME NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS schema_migrations_lock (
+			id INTEGER PRIMARY KEY CHECK (id = 1),
+			holder TEXT NOT NULL,
+			locked_at DATETIME NOT NULL
+		);
+	`)
+	return err
+}
+
+// applied loads schema_migrations keyed by version
+func (m *Migrator) applied(ctx context.Context, db execer) (map[int]appliedMigration, error) {
+	rows, err := db.QueryContext(ctx, `SELECT version, name, checksum, batch, applied_at FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int]appliedMigration)
+	for rows.Next() {
+		var a appliedMigration
+		if err := rows.Scan(&a.version, &a.name, &a.checksum, &a.batch, &a.appliedAt); err != nil {
+			return nil, err
+		}
+		applied[a.version] = a
+	}
+	return applied, rows.Err()
+}
+
+// adoptLegacySchema records databases created by the released schema,
+// which ran its scripts on every boot. They are run once more (they are
+// idempotent), then migrations up to baselineVersion are marked as applied
+// in batch 0; the later ones apply as usual.
+func (m *Migrator) adoptLegacySchema(ctx context.Context, conn *sql.Conn) error {
+	var tracked, legacy int
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM schema_migrations`).Scan(&tracked); err != nil {
+		return err
+	}
+	if err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = 'users'`).Scan(&legacy); err != nil {
+		return err
+	}
+	if tracked > 0 || legacy == 0 {
+		return nil
+	}
+
+	log.Println("  ↻ Existing database without migration history, recording it as the baseline")
+	for _, migration := range m.migrations {
+		if migration.Version > baselineVersion {
+			break
+		}
+		if _, err := conn.ExecContext(ctx, migration.Up); err != nil {
+			return fmt.Errorf("baseline %s failed: %w", migration.ID(), err)
+		}
+		if _, err := conn.ExecContext(ctx,
+			`INSERT INTO schema_migrations (version, name, checksum, batch, applied_at) VALUES (?, ?, ?, 0, ?)`,
+			migration.Version, migration.Name, migration.Checksum, time.Now().UTC(),
+		); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stripComments removes "--" line comments, to detect scripts with no statements
+func stripComments(script string) string {
+	var b strings.Builder
+	for _, line := range strings.Split(script, "\n") {
+		if !strings.HasPrefix(strings.TrimSpace(line), "--") {
+			b.WriteString(line)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// lockHolder identifies this process in the migration lock
+func lockHolder() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d", host, os.Getpid())
+}
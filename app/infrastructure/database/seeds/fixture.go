@@ -0,0 +1,224 @@
+package seeds
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+//go:embed data/*.yaml
+var defaultFixtures embed.FS
+
+// Fixture is one seeder: a JSON or YAML file of records. Its name is the
+// file name without the order prefix and extension, so 02_pages.yaml is
+// "pages". A file may hold components, pages and users; they are seeded in
+// that order.
+type Fixture struct {
+	Name         string   `json:"name" yaml:"-"`
+	Path         string   `json:"path" yaml:"-"`
+	Checksum     string   `json:"checksum" yaml:"-"`
+	Environments []string `json:"environments" yaml:"environments"` // Empty means every environment
+
+	Components []ComponentRecord `json:"components,omitempty" yaml:"components"`
+	Pages      []PageRecord      `json:"pages,omitempty" yaml:"pages"`
+	Users      []UserRecord      `json:"users,omitempty" yaml:"users"`
+}
+
+// ComponentRecord is a component to seed, matched by name
+type ComponentRecord struct {
+	Type     string `json:"type" yaml:"type"`
+	Name     string `json:"name" yaml:"name"`
+	Title    string `json:"title" yaml:"title"`
+	Subtitle string `json:"subtitle" yaml:"subtitle"`
+	Content  string `json:"content" yaml:"content"`
+	ImageURL string `json:"image_url" yaml:"image_url"`
+	LinkURL  string `json:"link_url" yaml:"link_url"`
+	LinkText string `json:"link_text" yaml:"link_text"`
+	DataJSON string `json:"data_json" yaml:"data_json"`
+}
+
+// PageRecord is a page to seed, matched by slug. An empty slug is the home page.
+type PageRecord struct {
+	Slug            string   `json:"slug" yaml:"slug"`
+	Title           string   `json:"title" yaml:"title"`
+	Content         string   `json:"content" yaml:"content"`
+	MetaTitle       string   `json:"meta_title" yaml:"meta_title"`
+	MetaDescription string   `json:"meta_description" yaml:"meta_description"`
+	MetaKeywords    string   `json:"meta_keywords" yaml:"meta_keywords"`
+	OGImage         string   `json:"og_image" yaml:"og_image"`
+	Status          string   `json:"status" yaml:"status"`
+	Components      []string `json:"components" yaml:"components"` // Component names, in layout order
+}
+
+// UserRecord is a user to seed, matched by email. The password is hashed
+// when it is seeded.
+type UserRecord struct {
+	Email    string `json:"email" yaml:"email"`
+	Name     string `json:"name" yaml:"name"`
+	Role     string `json:"role" yaml:"role"`
+	Password string `json:"password" yaml:"password"`
+	Inactive bool   `json:"inactive" yaml:"inactive"`
+}
+
+// Records counts the records of a fixture
+func (f *Fixture) Records() int {
+	return len(f.Components) + len(f.Pages) + len(f.Users)
+}
+
+// RunsIn reports whether the fixture is tagged for an environment
+func (f *Fixture) RunsIn(env string) bool {
+	if len(f.Environments) == 0 {
+		return true
+	}
+	for _, e := range f.Environments {
+		if e == env {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvironmentTag maps an ENV value to the tag fixtures use, e.g.
+// development to dev
+func EnvironmentTag(env string) string {
+	switch env {
+	case "", "development":
+		return "dev"
+	case "production":
+		return "prod"
+	default:
+		return env
+	}
+}
+
+// LoadFixtures reads the built-in fixtures and then those in dir, ordered by
+// file name. A file in dir replaces the built-in fixture of the same name.
+// A missing dir is not an error.
+func LoadFixtures(dir string) ([]*Fixture, error) {
+	byName := make(map[string]*Fixture)
+	order := make(map[string]string)
+
+	add := func(fsys fs.FS, root, path string) error {
+		f, err := readFixture(fsys, path)
+		if err != nil {
+			return err
+		}
+		f.Path = filepath.Join(root, path)
+		byName[f.Name] = f
+		order[f.Name] = filepath.Base(path)
+		return nil
+	}
+
+	builtIn, err := fs.Glob(defaultFixtures, "data/*.yaml")
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range builtIn {
+		if err := add(defaultFixtures, "(built-in)", path); err != nil {
+			return nil, err
+		}
+	}
+
+	if dir != "" {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read seed directory %s: %w", dir, err)
+		}
+		for _, entry := range entries {
+			if entry.IsDir() || fixtureFormat(entry.Name()) == "" {
+				continue
+			}
+			if err := add(os.DirFS(dir), dir, entry.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	fixtures := make([]*Fixture, 0, len(byName))
+	for _, f := range byName {
+		fixtures = append(fixtures, f)
+	}
+	sort.Slice(fixtures, func(i, j int) bool {
+		return order[fixtures[i].Name] < order[fixtures[j].Name]
+	})
+	return fixtures, nil
+}
+
+// readFixture parses one fixture file
+func readFixture(fsys fs.FS, path string) (*Fixture, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	f := &Fixture{}
+	switch fixtureFormat(path) {
+	case "json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		err = decoder.Decode(f)
+	default:
+		decoder := yaml.NewDecoder(bytes.NewReader(data))
+		decoder.KnownFields(true)
+		err = decoder.Decode(f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid seed file %s: %w", path, err)
+	}
+
+	f.Name = fixtureName(path)
+	sum := sha256.Sum256(data)
+	f.Checksum = hex.EncodeToString(sum[:])
+	return f, f.validate()
+}
+
+// validate checks the records a seeder can't insert
+func (f *Fixture) validate() error {
+	for i, c := range f.Components {
+		if c.Name == "" || c.Type == "" {
+			return fmt.Errorf("seed %s: component %d needs a type and a name", f.Name, i+1)
+		}
+	}
+	for i, p := range f.Pages {
+		if p.Title == "" {
+			return fmt.Errorf("seed %s: page %d needs a title", f.Name, i+1)
+		}
+	}
+	for i, u := range f.Users {
+		if u.Email == "" || u.Password == "" || u.Role == "" {
+			return fmt.Errorf("seed %s: user %d needs an email, a password and a role", f.Name, i+1)
+		}
+	}
+	return nil
+}
+
+// fixtureFormat returns json or yaml for a seed file name, "" for other files
+func fixtureFormat(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return "json"
+	case ".yaml", ".yml":
+		return "yaml"
+	default:
+		return ""
+	}
+}
+
+// fixtureName strips the order prefix and extension: 02_pages.yaml is "pages"
+func fixtureName(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	if i := strings.IndexByte(name, '_'); i > 0 && strings.Trim(name[:i], "0123456789") == "" {
+		name = name[i+1:]
+	}
+	return name
+}
@@ -1,209 +1,309 @@
 package seeds
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"cacto-cms/app/infrastructure/database"
+	"cacto-cms/app/shared/auth"
 )
 
-// Seeder handles database seeding
+// Seeder fills the database from fixture files
 type Seeder struct {
-	db *sql.DB
+	db  *sql.DB
+	dir string
 }
 
-// NewSeeder creates a new seeder
-func NewSeeder(db *sql.DB) *Seeder {
-	return &Seeder{db: db}
+// NewSeeder creates a seeder for the built-in fixtures and those in dir
+func NewSeeder(db *sql.DB, dir string) *Seeder {
+	return &Seeder{db: db, dir: dir}
 }
 
-// SeedAll seeds all data
-func (s *Seeder) SeedAll() error {
-	log.Println("🌱 Starting database seeding...")
+// Options select the seeders a run applies
+type Options struct {
+	Environment string   // Tag the fixtures must carry, e.g. dev
+	Only        []string // Seeder names; empty runs them all
+	Rerun       bool     // Run seeders again even if their file is unchanged
+}
 
-	if err := s.SeedComponents(); err != nil {
-		return fmt.Errorf("failed to seed components: %w", err)
+// Result reports what one seeder did
+type Result struct {
+	Name    string `json:"name"`
+	Status  string `json:"status"` // ran, unchanged or skipped
+	Detail  string `json:"detail,omitempty"`
+	Created int    `json:"created"`
+	Existed int    `json:"existed"`
+}
+
+// Run records what a seeder last did
+type Run struct {
+	Name        string    `json:"name"`
+	Checksum    string    `json:"checksum"`
+	Environment string    `json:"environment"`
+	Records     int       `json:"records"`
+	RanAt       time.Time `json:"ran_at"`
+}
+
+// Fixtures returns the seeders in the order they run
+func (s *Seeder) Fixtures() ([]*Fixture, error) {
+	return LoadFixtures(s.dir)
+}
+
+// Runs returns the recorded run of each seeder by name
+func (s *Seeder) Runs(ctx context.Context) (map[string]*Run, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT name, checksum, environment, records, ran_at FROM seeder_runs`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read seeder runs (run ./artisan migrate first): %w", err)
 	}
+	defer rows.Close()
 
-	if err := s.SeedPages(); err != nil {
-		return fmt.Errorf("failed to seed pages: %w", err)
+	runs := make(map[string]*Run)
+	for rows.Next() {
+		r := &Run{}
+		if err := rows.Scan(&r.Name, &r.Checksum, &r.Environment, &r.Records, &r.RanAt); err != nil {
+			return nil, err
+		}
+		runs[r.Name] = r
 	}
+	return runs, rows.Err()
+}
 
-	if err := s.SeedUsers(); err != nil {
-		return fmt.Errorf("failed to seed users: %w", err)
+// Run applies the selected seeders. Each one runs in its own transaction
+// together with the record of its run. Records that already exist are left
+// alone, and a seeder whose file hasn't changed since it last ran is
+// skipped unless opts.Rerun is set.
+func (s *Seeder) Run(ctx context.Context, opts Options) ([]Result, error) {
+	fixtures, err := s.Fixtures()
+	if err != nil {
+		return nil, err
+	}
+	if err := checkNames(fixtures, opts.Only); err != nil {
+		return nil, err
+	}
+	runs, err := s.Runs(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	log.Println("✅ Database seeding completed")
-	return nil
+	log.Printf("🌱 Seeding for environment %q...", opts.Environment)
+	results := make([]Result, 0, len(fixtures))
+	for _, f := range fixtures {
+		if len(opts.Only) > 0 && !contains(opts.Only, f.Name) {
+			continue
+		}
+
+		result := Result{Name: f.Name}
+		switch previous := runs[f.Name]; {
+		case !f.RunsIn(opts.Environment):
+			result.Status = "skipped"
+			result.Detail = "tagged " + strings.Join(f.Environments, ", ")
+		case previous != nil && previous.Checksum == f.Checksum && !opts.Rerun:
+			result.Status = "unchanged"
+			result.Detail = "ran " + previous.RanAt.Local().Format("2006-01-02 15:04")
+		default:
+			err := database.WithTx(ctx, s.db, func(ctx context.Context) error {
+				return s.apply(ctx, f, opts.Environment, &result)
+			})
+			if err != nil {
+				return results, fmt.Errorf("seeder %s failed: %w", f.Name, err)
+			}
+			result.Status = "ran"
+		}
+
+		if result.Status != "ran" {
+			log.Printf("  ⏭️  %s: %s (%s)", f.Name, result.Status, result.Detail)
+		}
+		results = append(results, result)
+	}
+	return results, nil
 }
 
-// SeedComponents seeds component data
-func (s *Seeder) SeedComponents() error {
-	log.Println("  📦 Seeding components...")
+// apply seeds the records of a fixture and records the run
+func (s *Seeder) apply(ctx context.Context, f *Fixture, env string, result *Result) error {
+	log.Printf("  📦 Seeding %s (%s)...", f.Name, f.Path)
+
+	steps := []func(context.Context, *Fixture, *Result) error{s.seedComponents, s.seedPages, s.seedUsers}
+	for _, step := range steps {
+		if err := step(ctx, f, result); err != nil {
+			return err
+		}
+	}
 
-	components := Components()
-	
-	for _, comp := range components {
-		// Check if component already exists
+	_, err := database.Conn(ctx, s.db).ExecContext(ctx, `
+		INSERT INTO seeder_runs (name, checksum, environment, records, ran_at)
+		VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT(name) DO UPDATE SET checksum = excluded.checksum,
+			environment = excluded.environment, records = excluded.records, ran_at = excluded.ran_at
+	`, f.Name, f.Checksum, env, result.Created, time.Now().UTC())
+	return err
+}
+
+// seedComponents inserts the components that don't exist yet
+func (s *Seeder) seedComponents(ctx context.Context, f *Fixture, result *Result) error {
+	conn := database.Conn(ctx, s.db)
+
+	for _, c := range f.Components {
 		var exists bool
-		err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM components WHERE name = ?)", comp.Name).Scan(&exists)
+		err := conn.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM components WHERE name = ?)", c.Name).Scan(&exists)
 		if err != nil {
 			return err
 		}
-
 		if exists {
-			log.Printf("    ⏭️  Component '%s' already exists, skipping", comp.Name)
+			log.Printf("    ⏭️  Component '%s' already exists, skipping", c.Name)
+			result.Existed++
 			continue
 		}
 
 		query := `
 			INSERT INTO components (type, name, title, subtitle, content,
-								image_url, link_url, link_text, data_json)
+			                        image_url, link_url, link_text, data_json)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 		`
-
-		_, err = s.db.Exec(query,
-			comp.Type, comp.Name, comp.Title, comp.Subtitle, comp.Content,
-			comp.ImageURL, comp.LinkURL, comp.LinkText, comp.DataJSON,
-		)
-
-		if err != nil {
-			return fmt.Errorf("failed to insert component %s: %w", comp.Name, err)
+		if _, err := conn.ExecContext(ctx, query,
+			c.Type, c.Name, c.Title, c.Subtitle, c.Content,
+			c.ImageURL, c.LinkURL, c.LinkText, c.DataJSON,
+		); err != nil {
+			return fmt.Errorf("failed to insert component %s: %w", c.Name, err)
 		}
 
-		log.Printf("    ✓ Seeded component: %s", comp.Name)
+		log.Printf("    ✓ Seeded component: %s", c.Name)
+		result.Created++
 	}
 
 	return nil
 }
 
-// SeedPages seeds page data
-func (s *Seeder) SeedPages() error {
-	log.Println("  📄 Seeding pages...")
+// seedPages inserts the pages that don't exist yet, with their components
+func (s *Seeder) seedPages(ctx context.Context, f *Fixture, result *Result) error {
+	conn := database.Conn(ctx, s.db)
 
-	pages := Pages()
+	for _, p := range f.Pages {
+		pageName := p.Slug
+		if pageName == "" {
+			pageName = "home"
+		}
 
-	for _, pageSeed := range pages {
-		// Check if page already exists
 		var exists bool
-		var query string
-		if pageSeed.Page.Slug == "" {
-			// Home page check (slug is empty)
-			query = "SELECT EXISTS(SELECT 1 FROM pages WHERE slug = '' OR slug IS NULL)"
-		} else {
-			query = "SELECT EXISTS(SELECT 1 FROM pages WHERE slug = ?)"
-		}
-		
-		var err error
-		if pageSeed.Page.Slug == "" {
-			err = s.db.QueryRow(query).Scan(&exists)
-		} else {
-			err = s.db.QueryRow(query, pageSeed.Page.Slug).Scan(&exists)
-		}
-		
+		err := conn.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM pages WHERE slug = ? OR (? = '' AND slug IS NULL))", p.Slug, p.Slug).Scan(&exists)
 		if err != nil {
 			return err
 		}
-
-		pageName := pageSeed.Page.Slug
-		if pageName == "" {
-			pageName = "home"
-		}
-		
 		if exists {
 			log.Printf("    ⏭️  Page '%s' already exists, skipping", pageName)
+			result.Existed++
 			continue
 		}
 
-		// Insert page
+		status := p.Status
+		if status == "" {
+			status = "draft"
+		}
+
 		pageQuery := `
 			INSERT INTO pages (slug, title, content, meta_title, meta_description,
-							meta_keywords, og_image, status, created_at, updated_at)
+			                   meta_keywords, og_image, status, created_at, updated_at)
 			VALUES (?, ?, ?, ?, ?, ?, ?, ?, datetime('now'), datetime('now'))
 		`
-
-		result, err := s.db.Exec(pageQuery,
-			pageSeed.Page.Slug, pageSeed.Page.Title, pageSeed.Page.Content,
-			pageSeed.Page.MetaTitle, pageSeed.Page.MetaDescription,
-			pageSeed.Page.MetaKeywords, pageSeed.Page.OGImage, pageSeed.Page.Status,
+		res, err := conn.ExecContext(ctx, pageQuery,
+			p.Slug, p.Title, p.Content, p.MetaTitle, p.MetaDescription,
+			p.MetaKeywords, p.OGImage, status,
 		)
-
 		if err != nil {
-			pageName := pageSeed.Page.Slug
-			if pageName == "" {
-				pageName = "home"
-			}
 			return fmt.Errorf("failed to insert page %s: %w", pageName, err)
 		}
-
-		pageID, err := result.LastInsertId()
+		pageID, err := res.LastInsertId()
 		if err != nil {
 			return err
 		}
 
 		// Associate components with page
-		for position, componentName := range pageSeed.ComponentNames {
-			// Get component ID
+		for position, componentName := range p.Components {
 			var componentID int
-			err = s.db.QueryRow("SELECT id FROM components WHERE name = ?", componentName).Scan(&componentID)
+			err = conn.QueryRowContext(ctx, "SELECT id FROM components WHERE name = ?", componentName).Scan(&componentID)
 			if err != nil {
 				log.Printf("    ⚠️  Component '%s' not found, skipping association", componentName)
 				continue
 			}
 
-			// Insert page_component association
 			associationQuery := `
 				INSERT INTO page_components (page_id, component_id, position)
 				VALUES (?, ?, ?)
 			`
-
-			_, err = s.db.Exec(associationQuery, pageID, componentID, position)
-			if err != nil {
-				return fmt.Errorf("failed to associate component %s with page %s: %w", componentName, pageSeed.Page.Slug, err)
+			if _, err := conn.ExecContext(ctx, associationQuery, pageID, componentID, position); err != nil {
+				return fmt.Errorf("failed to associate component %s with page %s: %w", componentName, pageName, err)
 			}
 		}
 
 		log.Printf("    ✓ Seeded page: %s", pageName)
+		result.Created++
 	}
 
 	return nil
 }
 
-// SeedUsers seeds user data
-func (s *Seeder) SeedUsers() error {
-	log.Println("  👤 Seeding users...")
+// seedUsers inserts the users that don't exist yet
+func (s *Seeder) seedUsers(ctx context.Context, f *Fixture, result *Result) error {
+	conn := database.Conn(ctx, s.db)
+	hasher := auth.NewPasswordHasher()
 
-	users := SeedUsers()
-
-	for _, userSeed := range users {
-		// Check if user already exists
+	for _, u := range f.Users {
 		var exists bool
-		err := s.db.QueryRow("SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", userSeed.User.Email).Scan(&exists)
+		err := conn.QueryRowContext(ctx, "SELECT EXISTS(SELECT 1 FROM users WHERE email = ?)", u.Email).Scan(&exists)
 		if err != nil {
 			return err
 		}
-
 		if exists {
-			log.Printf("    ⏭️  User '%s' already exists, skipping", userSeed.User.Email)
+			log.Printf("    ⏭️  User '%s' already exists, skipping", u.Email)
+			result.Existed++
 			continue
 		}
 
-		// Insert user
+		hash, err := hasher.HashPassword(u.Password)
+		if err != nil {
+			return fmt.Errorf("failed to hash password of %s: %w", u.Email, err)
+		}
+
 		query := `
 			INSERT INTO users (email, password_hash, name, role, is_active, created_at, updated_at)
 			VALUES (?, ?, ?, ?, ?, datetime('now'), datetime('now'))
 		`
-
-		_, err = s.db.Exec(query,
-			userSeed.User.Email, userSeed.User.PasswordHash, userSeed.User.Name,
-			userSeed.User.Role, userSeed.User.IsActive,
-		)
-
-		if err != nil {
-			return fmt.Errorf("failed to insert user %s: %w", userSeed.User.Email, err)
+		if _, err := conn.ExecContext(ctx, query, u.Email, hash, u.Name, u.Role, !u.Inactive); err != nil {
+			return fmt.Errorf("failed to insert user %s: %w", u.Email, err)
 		}
 
-		log.Printf("    ✓ Seeded user: %s (%s)", userSeed.User.Email, userSeed.User.Role)
+		log.Printf("    ✓ Seeded user: %s (%s)", u.Email, u.Role)
+		result.Created++
 	}
 
 	return nil
 }
+
+// checkNames rejects --only names no fixture has
+func checkNames(fixtures []*Fixture, names []string) error {
+	known := make([]string, 0, len(fixtures))
+	for _, f := range fixtures {
+		known = append(known, f.Name)
+	}
+	for _, name := range names {
+		if !contains(known, name) {
+			sort.Strings(known)
+			return fmt.Errorf("unknown seeder %q (available: %s)", name, strings.Join(known, ", "))
+		}
+	}
+	return nil
+}
+
+// contains reports whether list holds s
+func contains(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
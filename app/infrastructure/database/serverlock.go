@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"os"
+)
+
+// ServerLock is held by the server for as long as it has the database open,
+// and by offline maintenance (like restoring a backup) while it runs, so
+// neither starts while the other holds the database
+type ServerLock struct {
+	file *os.File
+}
+
+// serverLockPath returns the lock file next to the database
+func serverLockPath(dbPath string) string {
+	return dbPath + ".lock"
+}
+
+// AcquireServerLock takes the server lock for a database. It fails when
+// another process already holds it.
+func AcquireServerLock(dbPath string) (*ServerLock, error) {
+	file, err := os.OpenFile(serverLockPath(dbPath), os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open lock file: %w", err)
+	}
+	if err := lockFile(file); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("database %s is in use by another process (a server or backup:restore)", dbPath)
+	}
+
+	file.Truncate(0)
+	fmt.Fprintf(file, "%d\n", os.Getpid())
+	return &ServerLock{file: file}, nil
+}
+
+// Release gives up the lock
+func (l *ServerLock) Release() error {
+	unlockFile(l.file)
+	return l.file.Close()
+}
+
+// Covers reports whether the lock is the one for a database
+func (l *ServerLock) Covers(dbPath string) bool {
+	return l != nil && l.file.Name() == serverLockPath(dbPath)
+}
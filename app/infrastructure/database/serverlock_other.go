@@ -0,0 +1,16 @@
+//go:build !unix
+
+package database
+
+import "os"
+
+// lockFile is a no-op where advisory locks are unavailable; a running
+// server is then not detected
+func lockFile(file *os.File) error {
+	return nil
+}
+
+// unlockFile is a no-op where advisory locks are unavailable
+func unlockFile(file *os.File) error {
+	return nil
+}
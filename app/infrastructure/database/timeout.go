@@ -0,0 +1,38 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultQueryTimeout bounds a repository query unless configured otherwise
+const DefaultQueryTimeout = 5 * time.Second
+
+var queryTimeout atomic.Int64
+
+func init() {
+	queryTimeout.Store(int64(DefaultQueryTimeout))
+}
+
+// SetQueryTimeout sets the deadline each repository query gets. Zero or
+// less leaves queries bounded only by their caller's context.
+func SetQueryTimeout(d time.Duration) {
+	queryTimeout.Store(int64(d))
+}
+
+// QueryTimeout returns the configured per-query deadline
+func QueryTimeout() time.Duration {
+	return time.Duration(queryTimeout.Load())
+}
+
+// WithQueryTimeout derives the context for one repository query. The
+// caller's deadline or cancellation (e.g. a disconnected client) still
+// applies when it comes first. Call cancel once the rows are read.
+func WithQueryTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	d := QueryTimeout()
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
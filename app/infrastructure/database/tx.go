@@ -0,0 +1,102 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// Executor runs queries. Both *sql.DB and *sql.Tx satisfy it, so a
+// repository doesn't need to know whether it is part of a transaction.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// commitHooksKey carries the functions to run once the transaction commits
+type commitHooksKey struct{}
+
+// Conn returns the transaction the context carries, or db outside of one.
+// Repositories run every query on it so they join a unit of work in progress.
+func Conn(ctx context.Context, db *sql.DB) Executor {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+	return db
+}
+
+// InTx reports whether the context carries a transaction
+func InTx(ctx context.Context) bool {
+	_, ok := ctx.Value(txKey{}).(*sql.Tx)
+	return ok
+}
+
+// WithTx runs fn in a transaction carried by the context fn receives. If ctx
+// already carries one, fn joins it and the outermost WithTx decides: it
+// commits when fn returns nil and rolls back on an error or a panic.
+func WithTx(ctx context.Context, db *sql.DB, fn func(ctx context.Context) error) (err error) {
+	if InTx(ctx) {
+		return fn(ctx)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	hooks := &[]func(){}
+	ctx = context.WithValue(context.WithValue(ctx, txKey{}, tx), commitHooksKey{}, hooks)
+	if err = fn(ctx); err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	for _, hook := range *hooks {
+		hook()
+	}
+	return nil
+}
+
+// AfterCommit runs fn once the transaction ctx carries commits, or at once
+// outside of one. It is not run if the transaction rolls back.
+func AfterCommit(ctx context.Context, fn func()) {
+	if hooks, ok := ctx.Value(commitHooksKey{}).(*[]func()); ok {
+		*hooks = append(*hooks, fn)
+		return
+	}
+	fn()
+}
+
+// UnitOfWork runs application operations in a single transaction. It
+// implements transaction.UnitOfWork for the services.
+type UnitOfWork struct {
+	db *sql.DB
+}
+
+// NewUnitOfWork creates a unit of work on the database
+func NewUnitOfWork(db *sql.DB) *UnitOfWork {
+	return &UnitOfWork{db: db}
+}
+
+// WithTx runs fn in a transaction that repositories called with fn's context join
+func (u *UnitOfWork) WithTx(ctx context.Context, fn func(ctx context.Context) error) error {
+	return WithTx(ctx, u.db, fn)
+}
+
+// AfterCommit runs fn once the transaction in progress commits, or at once
+func (u *UnitOfWork) AfterCommit(ctx context.Context, fn func()) {
+	AfterCommit(ctx, fn)
+}
@@ -0,0 +1,178 @@
+package apitoken
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"cacto-cms/app/domain/apitoken"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// Repository implements apitoken.Repository interface
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new API token repository
+func NewRepository(db *sql.DB) apitoken.Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `
+	SELECT id, name, kind, user_id, created_by, token_prefix, token_hash, scopes,
+	       expires_at, last_used_at, last_used_ip, revoked_at, created_at
+	FROM api_tokens
+`
+
+// FindByID retrieves a token by ID
+func (r *Repository) FindByID(ctx context.Context, id int) (*apitoken.Token, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	t, err := scanToken(database.Conn(ctx, r.db).QueryRowContext(ctx, selectColumns+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("token not found")
+	}
+	return t, err
+}
+
+// FindByHash retrieves a token by the hash of its plaintext
+func (r *Repository) FindByHash(ctx context.Context, hash string) (*apitoken.Token, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	t, err := scanToken(database.Conn(ctx, r.db).QueryRowContext(ctx, selectColumns+` WHERE token_hash = ?`, hash))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("token not found")
+	}
+	return t, err
+}
+
+// FindByUser retrieves the personal tokens of a user, newest first
+func (r *Repository) FindByUser(ctx context.Context, userID int) ([]*apitoken.Token, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.query(ctx, selectColumns+` WHERE user_id = ? ORDER BY created_at DESC, id DESC`, userID)
+}
+
+// FindAll retrieves all tokens, newest first
+func (r *Repository) FindAll(ctx context.Context) ([]*apitoken.Token, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return r.query(ctx, selectColumns+` ORDER BY created_at DESC, id DESC`)
+}
+
+// Create stores a new token
+func (r *Repository) Create(ctx context.Context, t *apitoken.Token) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO api_tokens (name, kind, user_id, created_by, token_prefix, token_hash, scopes, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		t.Name, t.Kind, t.UserID, t.CreatedBy, t.Prefix, t.TokenHash,
+		strings.Join(t.Scopes, " "), t.ExpiresAt.UTC(), t.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	t.ID = int(id)
+	return nil
+}
+
+// TouchLastUsed records when and from where a token was last used
+func (r *Repository) TouchLastUsed(ctx context.Context, id int, at time.Time, ip string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE api_tokens SET last_used_at = ?, last_used_ip = ? WHERE id = ?`,
+		at.UTC(), ip, id,
+	)
+	return err
+}
+
+// Revoke revokes a token (revoked tokens are kept for reference)
+func (r *Repository) Revoke(ctx context.Context, id int, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE api_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		at.UTC(), id,
+	)
+	return err
+}
+
+// query runs a token query and scans all rows
+func (r *Repository) query(ctx context.Context, query string, args ...interface{}) ([]*apitoken.Token, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tokens := make([]*apitoken.Token, 0)
+	for rows.Next() {
+		t, err := scanToken(rows)
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+	}
+
+	return tokens, rows.Err()
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanToken scans a single token row
+func scanToken(row rowScanner) (*apitoken.Token, error) {
+	t := &apitoken.Token{}
+	var userID, createdBy sql.NullInt64
+	var lastUsedAt, revokedAt sql.NullTime
+	var scopes string
+
+	err := row.Scan(
+		&t.ID, &t.Name, &t.Kind, &userID, &createdBy, &t.Prefix, &t.TokenHash, &scopes,
+		&t.ExpiresAt, &lastUsedAt, &t.LastUsedIP, &revokedAt, &t.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	t.Scopes = strings.Fields(scopes)
+	if userID.Valid {
+		id := int(userID.Int64)
+		t.UserID = &id
+	}
+	if createdBy.Valid {
+		id := int(createdBy.Int64)
+		t.CreatedBy = &id
+	}
+	if lastUsedAt.Valid {
+		t.LastUsedAt = &lastUsedAt.Time
+	}
+	if revokedAt.Valid {
+		t.RevokedAt = &revokedAt.Time
+	}
+
+	return t, nil
+}
@@ -0,0 +1,184 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// Repository implements audit.Repository interface
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new audit log repository
+func NewRepository(db *sql.DB) audit.Repository {
+	return &Repository{db: db}
+}
+
+const selectColumns = `
+	SELECT id, actor_id, actor_email, token_id, action, target_type, target_id,
+	       before_state, after_state, ip_address, user_agent, created_at,
+	       impersonator_id, impersonator_email
+	FROM audit_log
+`
+
+// Append stores a new entry
+func (r *Repository) Append(ctx context.Context, e *audit.Entry) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO audit_log (actor_id, actor_email, token_id, action, target_type, target_id,
+		                       before_state, after_state, ip_address, user_agent, created_at,
+		                       impersonator_id, impersonator_email)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		e.ActorID, e.ActorEmail, e.TokenID, e.Action, e.TargetType, e.TargetID,
+		e.Before, e.After, e.IPAddress, e.UserAgent, e.CreatedAt.UTC(),
+		e.ImpersonatorID, e.ImpersonatorEmail,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	e.ID = int(id)
+	return nil
+}
+
+// Find retrieves entries matching the filter, newest first
+func (r *Repository) Find(ctx context.Context, filter audit.Filter) ([]*audit.Entry, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	where, args := whereClause(filter)
+	query := selectColumns + where + ` ORDER BY created_at DESC, id DESC`
+	if filter.Limit > 0 {
+		query += ` LIMIT ? OFFSET ?`
+		args = append(args, filter.Limit, filter.Offset)
+	}
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := make([]*audit.Entry, 0)
+	for rows.Next() {
+		e, err := scanEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+// Count counts entries matching the filter (limit and offset are ignored)
+func (r *Repository) Count(ctx context.Context, filter audit.Filter) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	where, args := whereClause(filter)
+
+	var count int
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_log`+where, args...).Scan(&count)
+	return count, err
+}
+
+// whereClause builds the WHERE clause for a filter
+func whereClause(filter audit.Filter) (string, []interface{}) {
+	var conditions []string
+	var args []interface{}
+
+	if filter.ActorID != nil {
+		conditions = append(conditions, `actor_id = ?`)
+		args = append(args, *filter.ActorID)
+	}
+	if filter.ActorEmail != "" {
+		conditions = append(conditions, `actor_email LIKE ? ESCAPE '\'`)
+		args = append(args, "%"+escapeLike(filter.ActorEmail)+"%")
+	}
+	if filter.Action != "" {
+		if strings.HasSuffix(filter.Action, ".") {
+			conditions = append(conditions, `action LIKE ? ESCAPE '\'`)
+			args = append(args, escapeLike(filter.Action)+"%")
+		} else {
+			conditions = append(conditions, `action = ?`)
+			args = append(args, filter.Action)
+		}
+	}
+	if filter.TargetType != "" {
+		conditions = append(conditions, `target_type = ?`)
+		args = append(args, filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		conditions = append(conditions, `target_id = ?`)
+		args = append(args, filter.TargetID)
+	}
+	if filter.Since != nil {
+		conditions = append(conditions, `created_at >= ?`)
+		args = append(args, filter.Since.UTC())
+	}
+	if filter.Until != nil {
+		conditions = append(conditions, `created_at < ?`)
+		args = append(args, filter.Until.UTC())
+	}
+
+	if len(conditions) == 0 {
+		return "", args
+	}
+	return ` WHERE ` + strings.Join(conditions, ` AND `), args
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanEntry scans a single audit log row
+func scanEntry(row rowScanner) (*audit.Entry, error) {
+	e := &audit.Entry{}
+	var actorID, tokenID, impersonatorID sql.NullInt64
+
+	err := row.Scan(
+		&e.ID, &actorID, &e.ActorEmail, &tokenID, &e.Action, &e.TargetType, &e.TargetID,
+		&e.Before, &e.After, &e.IPAddress, &e.UserAgent, &e.CreatedAt,
+		&impersonatorID, &e.ImpersonatorEmail,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if actorID.Valid {
+		id := int(actorID.Int64)
+		e.ActorID = &id
+	}
+	if tokenID.Valid {
+		id := int(tokenID.Int64)
+		e.TokenID = &id
+	}
+	if impersonatorID.Valid {
+		id := int(impersonatorID.Int64)
+		e.ImpersonatorID = &id
+	}
+
+	return e, nil
+}
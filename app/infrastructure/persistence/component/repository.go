@@ -1,10 +1,13 @@
 package component
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"cacto-cms/app/domain/component"
+	"cacto-cms/app/infrastructure/database"
 )
 
 // Repository implements the component.Repository interface using SQLite
@@ -18,15 +21,18 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 // FindByID retrieves a component by its ID
-func (r *Repository) FindByID(id int) (*component.Component, error) {
+func (r *Repository) FindByID(ctx context.Context, id int) (*component.Component, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, type, name, title, subtitle, content,
 		       image_url, link_url, link_text, data_json
-		FROM components WHERE id = ?
+		FROM components WHERE id = ? AND deleted_at IS NULL
 	`
 
 	c := &component.Component{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
 		&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON,
 	)
@@ -42,15 +48,18 @@ func (r *Repository) FindByID(id int) (*component.Component, error) {
 }
 
 // FindByName retrieves a component by its name
-func (r *Repository) FindByName(name string) (*component.Component, error) {
+func (r *Repository) FindByName(ctx context.Context, name string) (*component.Component, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, type, name, title, subtitle, content,
 		       image_url, link_url, link_text, data_json
-		FROM components WHERE name = ?
+		FROM components WHERE name = ? AND deleted_at IS NULL
 	`
 
 	c := &component.Component{}
-	err := r.db.QueryRow(query, name).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(
 		&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
 		&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON,
 	)
@@ -66,15 +75,18 @@ func (r *Repository) FindByName(name string) (*component.Component, error) {
 }
 
 // FindByType retrieves components by type
-func (r *Repository) FindByType(componentType component.Type) ([]*component.Component, error) {
+func (r *Repository) FindByType(ctx context.Context, componentType component.Type) ([]*component.Component, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, type, name, title, subtitle, content,
 		       image_url, link_url, link_text, data_json
-		FROM components WHERE type = ?
+		FROM components WHERE type = ? AND deleted_at IS NULL
 		ORDER BY id ASC
 	`
 
-	rows, err := r.db.Query(query, componentType)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, componentType)
 	if err != nil {
 		return nil, err
 	}
@@ -84,15 +96,18 @@ func (r *Repository) FindByType(componentType component.Type) ([]*component.Comp
 }
 
 // FindAll retrieves all components
-func (r *Repository) FindAll() ([]*component.Component, error) {
+func (r *Repository) FindAll(ctx context.Context) ([]*component.Component, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, type, name, title, subtitle, content,
 		       image_url, link_url, link_text, data_json
-		FROM components
+		FROM components WHERE deleted_at IS NULL
 		ORDER BY id ASC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -102,14 +117,17 @@ func (r *Repository) FindAll() ([]*component.Component, error) {
 }
 
 // Create creates a new component
-func (r *Repository) Create(c *component.Component) error {
+func (r *Repository) Create(ctx context.Context, c *component.Component) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO components (type, name, title, subtitle, content,
 		                       image_url, link_url, link_text, data_json)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		c.Type, c.Name, c.Title, c.Subtitle, c.Content,
 		c.ImageURL, c.LinkURL, c.LinkText, c.DataJSON,
 	)
@@ -128,15 +146,18 @@ func (r *Repository) Create(c *component.Component) error {
 }
 
 // Update updates an existing component
-func (r *Repository) Update(c *component.Component) error {
+func (r *Repository) Update(ctx context.Context, c *component.Component) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE components 
 		SET type = ?, name = ?, title = ?, subtitle = ?, content = ?,
 		    image_url = ?, link_url = ?, link_text = ?, data_json = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		c.Type, c.Name, c.Title, c.Subtitle, c.Content,
 		c.ImageURL, c.LinkURL, c.LinkText, c.DataJSON, c.ID,
 	)
@@ -144,11 +165,100 @@ func (r *Repository) Update(c *component.Component) error {
 	return err
 }
 
-// Delete deletes a component by ID
-func (r *Repository) Delete(id int) error {
-	query := "DELETE FROM components WHERE id = ?"
-	_, err := r.db.Exec(query, id)
-	return err
+// Delete moves a component to the trash. Its placements on pages are kept,
+// so restoring it puts it back in the same positions.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE components SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL"
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, time.Now().UTC(), id))
+}
+
+// FindDeleted retrieves the components in the trash, most recently deleted first
+func (r *Repository) FindDeleted(ctx context.Context) ([]*component.Component, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, type, name, title, subtitle, content,
+		       image_url, link_url, link_text, data_json, deleted_at
+		FROM components WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	components := make([]*component.Component, 0)
+	for rows.Next() {
+		c := &component.Component{}
+		var deletedAt time.Time
+		if err := rows.Scan(
+			&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
+			&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON, &deletedAt,
+		); err != nil {
+			return nil, err
+		}
+		c.DeletedAt = &deletedAt
+		components = append(components, c)
+	}
+
+	return components, rows.Err()
+}
+
+// Restore takes a component out of the trash
+func (r *Repository) Restore(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE components SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL"
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, id))
+}
+
+// Purge permanently deletes a component in the trash and its placements
+func (r *Repository) Purge(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM page_components
+			WHERE component_id IN (SELECT id FROM components WHERE id = ? AND deleted_at IS NOT NULL)
+		`, id); err != nil {
+			return err
+		}
+		return expectOne(tx.ExecContext(ctx, `DELETE FROM components WHERE id = ? AND deleted_at IS NOT NULL`, id))
+	})
+}
+
+// PurgeDeletedBefore permanently deletes the components moved to the trash
+// before a time and returns how many there were
+func (r *Repository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var purged int64
+	err := database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM page_components
+			WHERE component_id IN (SELECT id FROM components WHERE deleted_at IS NOT NULL AND deleted_at < ?)
+		`, before.UTC()); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `DELETE FROM components WHERE deleted_at IS NOT NULL AND deleted_at < ?`, before.UTC())
+		if err != nil {
+			return err
+		}
+		purged, err = result.RowsAffected()
+		return err
+	})
+	return int(purged), err
 }
 
 // scanComponents is a helper method to scan multiple components from rows
@@ -169,3 +279,19 @@ func (r *Repository) scanComponents(rows *sql.Rows) ([]*component.Component, err
 
 	return components, nil
 }
+
+// expectOne checks that a statement changed exactly one row, reporting
+// "not found" otherwise
+func expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("component not found")
+	}
+	return nil
+}
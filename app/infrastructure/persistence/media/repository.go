@@ -1,10 +1,13 @@
 package media
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
+	"time"
 
 	"cacto-cms/app/domain/media"
+	"cacto-cms/app/infrastructure/database"
 )
 
 // Repository implements media.Repository interface
@@ -18,14 +21,17 @@ func NewRepository(db *sql.DB) media.Repository {
 }
 
 // FindByID retrieves a media by ID
-func (r *Repository) FindByID(id int) (*media.Media, error) {
+func (r *Repository) FindByID(ctx context.Context, id int) (*media.Media, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, filename, original_name, mime_type, size, alt_text, created_at
-		FROM media WHERE id = ?
+		FROM media WHERE id = ? AND deleted_at IS NULL
 	`
 
 	m := &media.Media{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&m.ID, &m.Filename, &m.OriginalName, &m.MimeType,
 		&m.Size, &m.AltText, &m.CreatedAt,
 	)
@@ -45,13 +51,17 @@ func (r *Repository) FindByID(id int) (*media.Media, error) {
 }
 
 // FindAll retrieves all media with pagination
-func (r *Repository) FindAll(limit, offset int) ([]*media.Media, error) {
+func (r *Repository) FindAll(ctx context.Context, limit, offset int) ([]*media.Media, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, filename, original_name, mime_type, size, alt_text, created_at
-		FROM media ORDER BY created_at DESC LIMIT ? OFFSET ?
+		FROM media WHERE deleted_at IS NULL
+		ORDER BY created_at DESC LIMIT ? OFFSET ?
 	`
 
-	rows, err := r.db.Query(query, limit, offset)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -78,14 +88,17 @@ func (r *Repository) FindAll(limit, offset int) ([]*media.Media, error) {
 }
 
 // FindByFilename retrieves a media by filename
-func (r *Repository) FindByFilename(filename string) (*media.Media, error) {
+func (r *Repository) FindByFilename(ctx context.Context, filename string) (*media.Media, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, filename, original_name, mime_type, size, alt_text, created_at
-		FROM media WHERE filename = ?
+		FROM media WHERE filename = ? AND deleted_at IS NULL
 	`
 
 	m := &media.Media{}
-	err := r.db.QueryRow(query, filename).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, filename).Scan(
 		&m.ID, &m.Filename, &m.OriginalName, &m.MimeType,
 		&m.Size, &m.AltText, &m.CreatedAt,
 	)
@@ -104,13 +117,16 @@ func (r *Repository) FindByFilename(filename string) (*media.Media, error) {
 }
 
 // Create creates a new media record
-func (r *Repository) Create(m *media.Media) error {
+func (r *Repository) Create(ctx context.Context, m *media.Media) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO media (filename, original_name, mime_type, size, alt_text, created_at)
 		VALUES (?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		m.Filename, m.OriginalName, m.MimeType, m.Size, m.AltText, m.CreatedAt,
 	)
 	if err != nil {
@@ -127,29 +143,111 @@ func (r *Repository) Create(m *media.Media) error {
 }
 
 // Update updates media metadata
-func (r *Repository) Update(m *media.Media) error {
+func (r *Repository) Update(ctx context.Context, m *media.Media) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE media 
 		SET filename = ?, original_name = ?, mime_type = ?, size = ?, alt_text = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		m.Filename, m.OriginalName, m.MimeType, m.Size, m.AltText, m.ID,
 	)
 	return err
 }
 
-// Delete deletes a media by ID
-func (r *Repository) Delete(id int) error {
-	query := `DELETE FROM media WHERE id = ?`
-	_, err := r.db.Exec(query, id)
-	return err
+// Delete moves a media record to the trash. Its file is kept until the
+// record is purged.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE media SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL`
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, time.Now().UTC(), id))
+}
+
+// FindDeleted retrieves the media in the trash, most recently deleted first
+func (r *Repository) FindDeleted(ctx context.Context) ([]*media.Media, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, filename, original_name, mime_type, size, alt_text, created_at, deleted_at
+		FROM media WHERE deleted_at IS NOT NULL
+		ORDER BY deleted_at DESC
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	mediaList := make([]*media.Media, 0)
+	for rows.Next() {
+		m := &media.Media{}
+		var deletedAt time.Time
+		if err := rows.Scan(
+			&m.ID, &m.Filename, &m.OriginalName, &m.MimeType,
+			&m.Size, &m.AltText, &m.CreatedAt, &deletedAt,
+		); err != nil {
+			return nil, err
+		}
+		m.DeletedAt = &deletedAt
+
+		m.Path = fmt.Sprintf("/uploads/%s", m.Filename)
+		m.URL = fmt.Sprintf("/uploads/%s", m.Filename)
+
+		mediaList = append(mediaList, m)
+	}
+
+	return mediaList, rows.Err()
+}
+
+// Restore takes a media record out of the trash
+func (r *Repository) Restore(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE media SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL`
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, id))
+}
+
+// Purge permanently deletes a media record in the trash. Removing the file
+// is left to the caller.
+func (r *Repository) Purge(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `DELETE FROM media WHERE id = ? AND deleted_at IS NOT NULL`
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, id))
 }
 
 // Count returns total number of media files
-func (r *Repository) Count() (int, error) {
+func (r *Repository) Count(ctx context.Context) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var count int
-	err := r.db.QueryRow("SELECT COUNT(*) FROM media").Scan(&count)
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, "SELECT COUNT(*) FROM media WHERE deleted_at IS NULL").Scan(&count)
 	return count, err
 }
+
+// expectOne checks that a statement changed exactly one row, reporting
+// "not found" otherwise
+func expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("media not found")
+	}
+	return nil
+}
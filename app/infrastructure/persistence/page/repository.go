@@ -1,11 +1,15 @@
 package page
 
 import (
+	"context"
 	"database/sql"
+	"encoding/json"
 	"fmt"
 	"time"
 
+	"cacto-cms/app/domain/component"
 	"cacto-cms/app/domain/page"
+	"cacto-cms/app/infrastructure/database"
 )
 
 // Repository implements the page.Repository interface using SQLite
@@ -19,15 +23,18 @@ func NewRepository(db *sql.DB) *Repository {
 }
 
 // FindByID retrieves a page by its ID
-func (r *Repository) FindByID(id int) (*page.Page, error) {
+func (r *Repository) FindByID(ctx context.Context, id int) (*page.Page, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, slug, title, content, meta_title, meta_description, 
 		       meta_keywords, og_image, status, created_at, updated_at
-		FROM pages WHERE id = ?
+		FROM pages WHERE id = ? AND deleted_at IS NULL
 	`
 
 	p := &page.Page{}
-	err := r.db.QueryRow(query, id).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle, &p.MetaDescription,
 		&p.MetaKeywords, &p.OGImage, &p.Status, &p.CreatedAt, &p.UpdatedAt,
 	)
@@ -44,32 +51,35 @@ func (r *Repository) FindByID(id int) (*page.Page, error) {
 
 // FindBySlug retrieves a page by its slug
 // Empty slug means home page
-func (r *Repository) FindBySlug(slug string) (*page.Page, error) {
+func (r *Repository) FindBySlug(ctx context.Context, slug string) (*page.Page, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	var query string
 	if slug == "" {
 		// Home page has empty slug
 		query = `
 			SELECT id, slug, title, content, meta_title, meta_description, 
 			       meta_keywords, og_image, status, created_at, updated_at
-			FROM pages WHERE slug = '' OR slug IS NULL
+			FROM pages WHERE (slug = '' OR slug IS NULL) AND deleted_at IS NULL
 		`
 	} else {
 		query = `
 			SELECT id, slug, title, content, meta_title, meta_description, 
 			       meta_keywords, og_image, status, created_at, updated_at
-			FROM pages WHERE slug = ?
+			FROM pages WHERE slug = ? AND deleted_at IS NULL
 		`
 	}
 
 	p := &page.Page{}
 	var err error
 	if slug == "" {
-		err = r.db.QueryRow(query).Scan(
+		err = database.Conn(ctx, r.db).QueryRowContext(ctx, query).Scan(
 			&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle, &p.MetaDescription,
 			&p.MetaKeywords, &p.OGImage, &p.Status, &p.CreatedAt, &p.UpdatedAt,
 		)
 	} else {
-		err = r.db.QueryRow(query, slug).Scan(
+		err = database.Conn(ctx, r.db).QueryRowContext(ctx, query, slug).Scan(
 			&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle, &p.MetaDescription,
 			&p.MetaKeywords, &p.OGImage, &p.Status, &p.CreatedAt, &p.UpdatedAt,
 		)
@@ -86,14 +96,17 @@ func (r *Repository) FindBySlug(slug string) (*page.Page, error) {
 }
 
 // FindAll retrieves all pages
-func (r *Repository) FindAll() ([]*page.Page, error) {
+func (r *Repository) FindAll(ctx context.Context) ([]*page.Page, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, slug, title, content, meta_title, meta_description, 
 		       meta_keywords, og_image, status, created_at, updated_at
-		FROM pages ORDER BY created_at DESC
+		FROM pages WHERE deleted_at IS NULL ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -103,14 +116,17 @@ func (r *Repository) FindAll() ([]*page.Page, error) {
 }
 
 // FindPublished retrieves only published pages
-func (r *Repository) FindPublished() ([]*page.Page, error) {
+func (r *Repository) FindPublished(ctx context.Context) ([]*page.Page, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, slug, title, content, meta_title, meta_description, 
 		       meta_keywords, og_image, status, created_at, updated_at
-		FROM pages WHERE status = 'published' ORDER BY created_at DESC
+		FROM pages WHERE status = 'published' AND deleted_at IS NULL ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -120,14 +136,17 @@ func (r *Repository) FindPublished() ([]*page.Page, error) {
 }
 
 // Create creates a new page
-func (r *Repository) Create(p *page.Page) error {
+func (r *Repository) Create(ctx context.Context, p *page.Page) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO pages (slug, title, content, meta_title, meta_description, 
 		                   meta_keywords, og_image, status, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		p.Slug, p.Title, p.Content, p.MetaTitle, p.MetaDescription,
 		p.MetaKeywords, p.OGImage, p.Status, p.CreatedAt, p.UpdatedAt,
 	)
@@ -146,15 +165,18 @@ func (r *Repository) Create(p *page.Page) error {
 }
 
 // Update updates an existing page
-func (r *Repository) Update(p *page.Page) error {
+func (r *Repository) Update(ctx context.Context, p *page.Page) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE pages 
 		SET slug = ?, title = ?, content = ?, meta_title = ?, meta_description = ?,
 		    meta_keywords = ?, og_image = ?, status = ?, updated_at = ?
-		WHERE id = ?
+		WHERE id = ? AND deleted_at IS NULL
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		p.Slug, p.Title, p.Content, p.MetaTitle, p.MetaDescription,
 		p.MetaKeywords, p.OGImage, p.Status, time.Now(), p.ID,
 	)
@@ -162,46 +184,255 @@ func (r *Repository) Update(p *page.Page) error {
 	return err
 }
 
-// Delete deletes a page by ID
-func (r *Repository) Delete(id int) error {
-	query := "DELETE FROM pages WHERE id = ?"
-	_, err := r.db.Exec(query, id)
-	return err
+// Delete moves a page to the trash. Its component layout is kept.
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE pages SET deleted_at = ? WHERE id = ? AND deleted_at IS NULL"
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, time.Now().UTC(), id))
 }
 
-// GetComponents retrieves all components for a page
-func (r *Repository) GetComponents(pageID int) ([]page.Component, error) {
+// FindDeleted retrieves the pages in the trash, most recently deleted first
+func (r *Repository) FindDeleted(ctx context.Context) ([]*page.Page, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, slug, title, content, meta_title, meta_description,
+		       meta_keywords, og_image, status, created_at, updated_at, deleted_at
+		FROM pages WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	pages := make([]*page.Page, 0)
+	for rows.Next() {
+		p := &page.Page{}
+		var deletedAt time.Time
+		if err := rows.Scan(
+			&p.ID, &p.Slug, &p.Title, &p.Content, &p.MetaTitle, &p.MetaDescription,
+			&p.MetaKeywords, &p.OGImage, &p.Status, &p.CreatedAt, &p.UpdatedAt, &deletedAt,
+		); err != nil {
+			return nil, err
+		}
+		p.DeletedAt = &deletedAt
+		pages = append(pages, p)
+	}
+
+	return pages, rows.Err()
+}
+
+// Restore takes a page out of the trash
+func (r *Repository) Restore(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := "UPDATE pages SET deleted_at = NULL WHERE id = ? AND deleted_at IS NOT NULL"
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, id))
+}
+
+// Purge permanently deletes a page in the trash and its component layout
+func (r *Repository) Purge(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM page_components
+			WHERE page_id IN (SELECT id FROM pages WHERE id = ? AND deleted_at IS NOT NULL)
+		`, id); err != nil {
+			return err
+		}
+		return expectOne(tx.ExecContext(ctx, `DELETE FROM pages WHERE id = ? AND deleted_at IS NOT NULL`, id))
+	})
+}
+
+// PurgeDeletedBefore permanently deletes the pages moved to the trash
+// before a time and returns how many there were
+func (r *Repository) PurgeDeletedBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var purged int64
+	err := database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM page_components
+			WHERE page_id IN (SELECT id FROM pages WHERE deleted_at IS NOT NULL AND deleted_at < ?)
+		`, before.UTC()); err != nil {
+			return err
+		}
+		result, err := tx.ExecContext(ctx, `DELETE FROM pages WHERE deleted_at IS NOT NULL AND deleted_at < ?`, before.UTC())
+		if err != nil {
+			return err
+		}
+		purged, err = result.RowsAffected()
+		return err
+	})
+	return int(purged), err
+}
+
+// GetComponents retrieves the placements of a page in order, each with
+// its shared component
+func (r *Repository) GetComponents(ctx context.Context, pageID int) ([]page.Placement, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT c.id, c.type, c.name, c.title, c.subtitle, c.content,
-		       c.image_url, c.link_url, c.link_text, c.data_json, pc.position
+		       c.image_url, c.link_url, c.link_text, c.data_json,
+		       pc.id, pc.position, COALESCE(pc.overrides_json, '')
 		FROM components c
 		JOIN page_components pc ON c.id = pc.component_id
-		WHERE pc.page_id = ?
-		ORDER BY pc.position ASC
+		WHERE pc.page_id = ? AND c.deleted_at IS NULL
+		ORDER BY pc.position ASC, pc.id ASC
 	`
 
-	rows, err := r.db.Query(query, pageID)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, pageID)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var components []page.Component
+	var components []page.Placement
 	for rows.Next() {
-		var c page.Component
+		var c page.Placement
+		var overrides string
 		err := rows.Scan(
 			&c.ID, &c.Type, &c.Name, &c.Title, &c.Subtitle, &c.Content,
-			&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON, &c.Position,
+			&c.ImageURL, &c.LinkURL, &c.LinkText, &c.DataJSON,
+			&c.PlacementID, &c.Position, &overrides,
 		)
 		if err != nil {
 			return nil, err
 		}
+		if overrides != "" {
+			c.Overrides = &component.Overrides{}
+			if err := json.Unmarshal([]byte(overrides), c.Overrides); err != nil {
+				return nil, fmt.Errorf("invalid overrides of placement %d: %w", c.PlacementID, err)
+			}
+		}
 		components = append(components, c)
 	}
 
 	return components, nil
 }
 
+// SetComponents replaces the layout of a page with the placed components in
+// order, keeping their overrides. Placements of components in the trash are
+// kept for when they are restored: each keeps its rank in the order, and the
+// new layout fills the positions around them.
+func (r *Repository) SetComponents(ctx context.Context, pageID int, layout []page.Placement) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		kept, err := trashedPlacements(ctx, tx, pageID)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `
+			DELETE FROM page_components
+			WHERE page_id = ? AND component_id NOT IN (SELECT id FROM components WHERE deleted_at IS NOT NULL)
+		`, pageID); err != nil {
+			return err
+		}
+
+		next, total := 0, len(layout)+len(kept)
+		for position := 0; position < total; position++ {
+			if len(kept) > 0 && (kept[0].rank <= position || next == len(layout)) {
+				if _, err := tx.ExecContext(ctx, `UPDATE page_components SET position = ? WHERE id = ?`, position, kept[0].id); err != nil {
+					return err
+				}
+				kept = kept[1:]
+				continue
+			}
+
+			placement := layout[next]
+			next++
+			overrides, err := overridesJSON(placement.Overrides)
+			if err != nil {
+				return err
+			}
+			if _, err := tx.ExecContext(ctx,
+				`INSERT INTO page_components (page_id, component_id, position, overrides_json) VALUES (?, ?, ?, ?)`,
+				pageID, placement.ID, position, overrides,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// keptPlacement is the placement of a component in the trash and its rank
+// among all placements of the page
+type keptPlacement struct {
+	id   int
+	rank int
+}
+
+// trashedPlacements returns the placements of components in the trash on a
+// page, in order
+func trashedPlacements(ctx context.Context, tx database.Executor, pageID int) ([]keptPlacement, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT pc.id, c.deleted_at IS NOT NULL
+		FROM page_components pc
+		JOIN components c ON c.id = pc.component_id
+		WHERE pc.page_id = ?
+		ORDER BY pc.position ASC, pc.id ASC
+	`, pageID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var kept []keptPlacement
+	for rank := 0; rows.Next(); rank++ {
+		var id int
+		var trashed bool
+		if err := rows.Scan(&id, &trashed); err != nil {
+			return nil, err
+		}
+		if trashed {
+			kept = append(kept, keptPlacement{id: id, rank: rank})
+		}
+	}
+	return kept, rows.Err()
+}
+
+// SetOverrides replaces the overrides of one placement; nil removes them
+func (r *Repository) SetOverrides(ctx context.Context, pageID, placementID int, overrides *component.Overrides) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	value, err := overridesJSON(overrides)
+	if err != nil {
+		return err
+	}
+	query := "UPDATE page_components SET overrides_json = ? WHERE id = ? AND page_id = ?"
+	return expectOne(database.Conn(ctx, r.db).ExecContext(ctx, query, value, placementID, pageID))
+}
+
+// overridesJSON encodes overrides for storage, as NULL when there are none
+func overridesJSON(overrides *component.Overrides) (interface{}, error) {
+	if len(overrides.Fields()) == 0 {
+		return nil, nil
+	}
+	data, err := json.Marshal(overrides)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
 // scanPages is a helper method to scan multiple pages from rows
 func (r *Repository) scanPages(rows *sql.Rows) ([]*page.Page, error) {
 	var pages []*page.Page
@@ -220,3 +451,19 @@ func (r *Repository) scanPages(rows *sql.Rows) ([]*page.Page, error) {
 
 	return pages, nil
 }
+
+// expectOne checks that a statement changed exactly one row, reporting
+// "not found" otherwise
+func expectOne(result sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n != 1 {
+		return fmt.Errorf("page not found")
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package page
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/infrastructure/database"
+	componentpersistence "cacto-cms/app/infrastructure/persistence/component"
+)
+
+// layoutEnv is a page and its component repositories on a migrated database
+type layoutEnv struct {
+	pages      *Repository
+	components *componentpersistence.Repository
+	pageID     int
+	ids        map[string]int // Component IDs by name
+}
+
+func newLayoutEnv(t *testing.T, names ...string) *layoutEnv {
+	t.Helper()
+
+	db, err := database.New(filepath.Join(t.TempDir(), "cacto.db"))
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := db.Migrate(); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+
+	ctx := context.Background()
+	env := &layoutEnv{
+		pages:      NewRepository(db.DB),
+		components: componentpersistence.NewRepository(db.DB),
+		ids:        make(map[string]int),
+	}
+	now := time.Now()
+	p := &page.Page{Slug: "about", Title: "About", Status: page.StatusPublished, CreatedAt: now, UpdatedAt: now}
+	if err := env.pages.Create(ctx, p); err != nil {
+		t.Fatalf("create page: %v", err)
+	}
+	env.pageID = p.ID
+	for _, name := range names {
+		c := &component.Component{Type: component.TypeText, Name: name}
+		if err := env.components.Create(ctx, c); err != nil {
+			t.Fatalf("create component %s: %v", name, err)
+		}
+		env.ids[name] = c.ID
+	}
+	return env
+}
+
+// setLayout places the named components on the page, in order
+func (e *layoutEnv) setLayout(t *testing.T, names ...string) {
+	t.Helper()
+
+	layout := make([]page.Placement, 0, len(names))
+	for _, name := range names {
+		layout = append(layout, page.Placement{Component: component.Component{ID: e.ids[name]}})
+	}
+	if err := e.pages.SetComponents(context.Background(), e.pageID, layout); err != nil {
+		t.Fatalf("SetComponents(%v): %v", names, err)
+	}
+}
+
+// layout returns the names and positions of the components the page shows
+func (e *layoutEnv) layout(t *testing.T) ([]string, []int) {
+	t.Helper()
+
+	placements, err := e.pages.GetComponents(context.Background(), e.pageID)
+	if err != nil {
+		t.Fatalf("GetComponents: %v", err)
+	}
+	var names []string
+	var positions []int
+	for _, p := range placements {
+		names = append(names, p.Name)
+		positions = append(positions, p.Position)
+	}
+	return names, positions
+}
+
+func TestSetComponentsKeepsTrashedPlacementsInOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		layout   []string // Saved before the component is trashed
+		trashed  string
+		relayout []string // Saved while it is in the trash
+		want     []string // Shown once it is restored
+	}{
+		{
+			name:     "reordered around the trashed component",
+			layout:   []string{"hero", "text", "gallery"},
+			trashed:  "text",
+			relayout: []string{"gallery", "hero", "cta"},
+			want:     []string{"gallery", "text", "hero", "cta"},
+		},
+		{
+			name:     "trashed first",
+			layout:   []string{"hero", "text", "gallery"},
+			trashed:  "hero",
+			relayout: []string{"gallery", "text"},
+			want:     []string{"hero", "gallery", "text"},
+		},
+		{
+			name:     "layout shorter than the trashed component's rank",
+			layout:   []string{"hero", "text", "gallery"},
+			trashed:  "gallery",
+			relayout: []string{"text"},
+			want:     []string{"text", "gallery"},
+		},
+		{
+			name:     "everything else removed",
+			layout:   []string{"hero", "text"},
+			trashed:  "text",
+			relayout: nil,
+			want:     []string{"text"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := newLayoutEnv(t, "hero", "text", "gallery", "cta")
+			ctx := context.Background()
+
+			env.setLayout(t, tt.layout...)
+			if err := env.components.Delete(ctx, env.ids[tt.trashed]); err != nil {
+				t.Fatal(err)
+			}
+			env.setLayout(t, tt.relayout...)
+			if err := env.components.Restore(ctx, env.ids[tt.trashed]); err != nil {
+				t.Fatal(err)
+			}
+
+			names, positions := env.layout(t)
+			if !reflect.DeepEqual(names, tt.want) {
+				t.Errorf("layout after the restore = %v, want %v", names, tt.want)
+			}
+			for i, position := range positions {
+				if position != i {
+					t.Errorf("positions = %v, want 0..%d without gaps or duplicates", positions, len(positions)-1)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package role
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cacto-cms/app/domain/role"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// Repository implements role.Repository interface
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new role repository
+func NewRepository(db *sql.DB) role.Repository {
+	return &Repository{db: db}
+}
+
+// FindByID retrieves a role by ID, including its permissions
+func (r *Repository) FindByID(ctx context.Context, id int) (*role.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, name, description, is_system, created_at, updated_at
+		FROM roles WHERE id = ?
+	`
+
+	ro := &role.Role{}
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
+		&ro.ID, &ro.Name, &ro.Description, &ro.IsSystem, &ro.CreatedAt, &ro.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ro.Permissions, err = r.findRolePermissions(ctx, ro.ID); err != nil {
+		return nil, err
+	}
+
+	return ro, nil
+}
+
+// FindByName retrieves a role by name, including its permissions
+func (r *Repository) FindByName(ctx context.Context, name string) (*role.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, name, description, is_system, created_at, updated_at
+		FROM roles WHERE name = ?
+	`
+
+	ro := &role.Role{}
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, name).Scan(
+		&ro.ID, &ro.Name, &ro.Description, &ro.IsSystem, &ro.CreatedAt, &ro.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("role not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if ro.Permissions, err = r.findRolePermissions(ctx, ro.ID); err != nil {
+		return nil, err
+	}
+
+	return ro, nil
+}
+
+// FindAll retrieves all roles, including their permissions
+func (r *Repository) FindAll(ctx context.Context) ([]*role.Role, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, name, description, is_system, created_at, updated_at
+		FROM roles ORDER BY is_system DESC, id ASC
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := make([]*role.Role, 0)
+	byID := make(map[int]*role.Role)
+	for rows.Next() {
+		ro := &role.Role{Permissions: make([]string, 0)}
+		if err := rows.Scan(
+			&ro.ID, &ro.Name, &ro.Description, &ro.IsSystem, &ro.CreatedAt, &ro.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		roles = append(roles, ro)
+		byID[ro.ID] = ro
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	permRows, err := database.Conn(ctx, r.db).QueryContext(ctx, `SELECT role_id, permission FROM role_permissions ORDER BY permission ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer permRows.Close()
+
+	for permRows.Next() {
+		var roleID int
+		var permission string
+		if err := permRows.Scan(&roleID, &permission); err != nil {
+			return nil, err
+		}
+		if ro, ok := byID[roleID]; ok {
+			ro.Permissions = append(ro.Permissions, permission)
+		}
+	}
+
+	return roles, permRows.Err()
+}
+
+// Create creates a new role (permissions are stored with SetPermissions)
+func (r *Repository) Create(ctx context.Context, ro *role.Role) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO roles (name, description, is_system, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		ro.Name, ro.Description, ro.IsSystem, ro.CreatedAt, ro.UpdatedAt,
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	ro.ID = int(id)
+	return nil
+}
+
+// Update updates a role's description
+func (r *Repository) Update(ctx context.Context, ro *role.Role) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `UPDATE roles SET description = ?, updated_at = ? WHERE id = ?`
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query, ro.Description, time.Now(), ro.ID)
+	return err
+}
+
+// Delete deletes a role by ID (permissions cascade)
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = ?`, id); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, `DELETE FROM roles WHERE id = ?`, id)
+		return err
+	})
+}
+
+// SetPermissions replaces all permissions of a role
+func (r *Repository) SetPermissions(ctx context.Context, roleID int, permissions []string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		tx := database.Conn(ctx, r.db)
+		if _, err := tx.ExecContext(ctx, `DELETE FROM role_permissions WHERE role_id = ?`, roleID); err != nil {
+			return err
+		}
+
+		for _, permission := range permissions {
+			if _, err := tx.ExecContext(ctx,
+				`INSERT OR IGNORE INTO role_permissions (role_id, permission) VALUES (?, ?)`,
+				roleID, permission,
+			); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// FindPermissions retrieves the permission catalog
+func (r *Repository) FindPermissions(ctx context.Context) ([]*role.Permission, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, `SELECT name, description FROM permissions ORDER BY name ASC`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := make([]*role.Permission, 0)
+	for rows.Next() {
+		p := &role.Permission{}
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+
+	return permissions, rows.Err()
+}
+
+// CountUsers returns the number of users assigned to a role
+func (r *Repository) CountUsers(ctx context.Context, name string) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE role = ?`, name).Scan(&count)
+	return count, err
+}
+
+// findRolePermissions loads the permissions granted to a role
+func (r *Repository) findRolePermissions(ctx context.Context, roleID int) ([]string, error) {
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		`SELECT permission FROM role_permissions WHERE role_id = ? ORDER BY permission ASC`, roleID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := make([]string, 0)
+	for rows.Next() {
+		var permission string
+		if err := rows.Scan(&permission); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, permission)
+	}
+
+	return permissions, rows.Err()
+}
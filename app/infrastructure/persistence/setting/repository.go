@@ -0,0 +1,65 @@
+package setting
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"cacto-cms/app/domain/setting"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// Repository implements setting.Repository using SQLite
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new settings repository
+func NewRepository(db *sql.DB) setting.Repository {
+	return &Repository{db: db}
+}
+
+// FindAll retrieves every stored setting
+func (r *Repository) FindAll(ctx context.Context) ([]*setting.Setting, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, `SELECT key, value, updated_at FROM settings ORDER BY key`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	settings := make([]*setting.Setting, 0)
+	for rows.Next() {
+		s := &setting.Setting{}
+		var updatedAt sql.NullTime
+		if err := rows.Scan(&s.Key, &s.Value, &updatedAt); err != nil {
+			return nil, err
+		}
+		s.UpdatedAt = updatedAt.Time
+		settings = append(settings, s)
+	}
+
+	return settings, rows.Err()
+}
+
+// Save stores the given values, creating missing keys, in one transaction
+func (r *Repository) Save(ctx context.Context, values map[string]string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	return database.WithTx(ctx, r.db, func(ctx context.Context) error {
+		conn := database.Conn(ctx, r.db)
+		now := time.Now().UTC()
+		for key, value := range values {
+			if _, err := conn.ExecContext(ctx, `
+				INSERT INTO settings (key, value, updated_at) VALUES (?, ?, ?)
+				ON CONFLICT(key) DO UPDATE SET value = excluded.value, updated_at = excluded.updated_at
+			`, key, value, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,88 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// IdentityRepository implements user.IdentityRepository interface
+type IdentityRepository struct {
+	db *sql.DB
+}
+
+// NewIdentityRepository creates a new identity repository
+func NewIdentityRepository(db *sql.DB) user.IdentityRepository {
+	return &IdentityRepository{db: db}
+}
+
+// FindByProviderSubject retrieves the identity for a provider account
+func (r *IdentityRepository) FindByProviderSubject(ctx context.Context, provider, subject string) (*user.Identity, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, user_id, provider, subject, email, created_at, last_login_at
+		FROM user_identities
+		WHERE provider = ? AND subject = ?
+	`
+
+	i := &user.Identity{}
+	var lastLoginAt sql.NullTime
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, provider, subject).Scan(
+		&i.ID, &i.UserID, &i.Provider, &i.Subject, &i.Email, &i.CreatedAt, &lastLoginAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("identity not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if lastLoginAt.Valid {
+		i.LastLoginAt = &lastLoginAt.Time
+	}
+
+	return i, nil
+}
+
+// Create links a provider account to a user
+func (r *IdentityRepository) Create(ctx context.Context, i *user.Identity) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_identities (user_id, provider, subject, email, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query, i.UserID, i.Provider, i.Subject, i.Email, i.CreatedAt.UTC())
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	i.ID = int(id)
+	return nil
+}
+
+// TouchLogin records a login through the identity and the email it reported
+func (r *IdentityRepository) TouchLogin(ctx context.Context, id int, email string, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE user_identities SET email = ?, last_login_at = ? WHERE id = ?`,
+		email, at.UTC(), id,
+	)
+	return err
+}
@@ -0,0 +1,240 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// LockoutRepository implements user.LockoutRepository interface
+type LockoutRepository struct {
+	db *sql.DB
+}
+
+// NewLockoutRepository creates a new lockout repository
+func NewLockoutRepository(db *sql.DB) user.LockoutRepository {
+	return &LockoutRepository{db: db}
+}
+
+// RecordAttempt stores a login attempt
+func (r *LockoutRepository) RecordAttempt(ctx context.Context, a *user.LoginAttempt) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO login_attempts (email, ip_address, user_agent, success, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		a.Email, a.IPAddress, a.UserAgent, a.Success, a.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	a.ID = int(id)
+	return nil
+}
+
+// MarkAttemptSucceeded turns a recorded attempt into a successful one
+func (r *LockoutRepository) MarkAttemptSucceeded(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, `UPDATE login_attempts SET success = 1 WHERE id = ?`, id)
+	return err
+}
+
+// DeleteAttempt removes a recorded attempt
+func (r *LockoutRepository) DeleteAttempt(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM login_attempts WHERE id = ?`, id)
+	return err
+}
+
+// FindRecentAttempts retrieves the newest attempts for an email since the
+// given time that were recorded before the attempt with beforeID
+func (r *LockoutRepository) FindRecentAttempts(ctx context.Context, email string, since time.Time, beforeID int, limit int) ([]*user.LoginAttempt, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, email, ip_address, user_agent, success, created_at
+		FROM login_attempts
+		WHERE email = ? AND created_at > ? AND id < ?
+		ORDER BY id DESC
+		LIMIT ?
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, email, since.UTC(), beforeID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	attempts := make([]*user.LoginAttempt, 0)
+	for rows.Next() {
+		a := &user.LoginAttempt{}
+		if err := rows.Scan(
+			&a.ID, &a.Email, &a.IPAddress, &a.UserAgent, &a.Success, &a.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		attempts = append(attempts, a)
+	}
+
+	return attempts, rows.Err()
+}
+
+// DeleteAttemptsBefore removes the attempts recorded before the given time
+func (r *LockoutRepository) DeleteAttemptsBefore(ctx context.Context, before time.Time) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM login_attempts WHERE created_at < ?`, before.UTC())
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// CreateLockout stores a new lockout
+func (r *LockoutRepository) CreateLockout(ctx context.Context, l *user.Lockout) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO account_lockouts (email, failed_attempts, ip_address, locked_until, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		l.Email, l.FailedAttempts, l.IPAddress, l.LockedUntil.UTC(), l.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	l.ID = int(id)
+	return nil
+}
+
+// FindLatestLockout retrieves the most recent lockout for an email.
+// Returns nil without error when the account has never been locked.
+func (r *LockoutRepository) FindLatestLockout(ctx context.Context, email string) (*user.Lockout, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, email, failed_attempts, ip_address, locked_until,
+		       unlocked_at, unlocked_by, created_at
+		FROM account_lockouts
+		WHERE email = ?
+		ORDER BY created_at DESC, id DESC
+		LIMIT 1
+	`
+
+	l, err := scanLockout(database.Conn(ctx, r.db).QueryRowContext(ctx, query, email))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return l, nil
+}
+
+// FindLockouts retrieves the newest lockouts across all accounts
+func (r *LockoutRepository) FindLockouts(ctx context.Context, limit int) ([]*user.Lockout, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		SELECT id, email, failed_attempts, ip_address, locked_until,
+		       unlocked_at, unlocked_by, created_at
+		FROM account_lockouts
+		ORDER BY created_at DESC, id DESC
+		LIMIT ?
+	`
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	lockouts := make([]*user.Lockout, 0)
+	for rows.Next() {
+		l, err := scanLockout(rows)
+		if err != nil {
+			return nil, err
+		}
+		lockouts = append(lockouts, l)
+	}
+
+	return lockouts, rows.Err()
+}
+
+// Unlock lifts every lockout for an email that has not been lifted yet.
+// unlockedBy is nil when the unlock was not made by a user (e.g. a service token).
+func (r *LockoutRepository) Unlock(ctx context.Context, email string, unlockedBy *int, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		UPDATE account_lockouts
+		SET unlocked_at = ?, unlocked_by = ?
+		WHERE email = ? AND unlocked_at IS NULL
+	`
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query, at.UTC(), unlockedBy, email)
+	return err
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanLockout scans a single lockout row
+func scanLockout(row rowScanner) (*user.Lockout, error) {
+	l := &user.Lockout{}
+	var unlockedAt sql.NullTime
+	var unlockedBy sql.NullInt64
+
+	err := row.Scan(
+		&l.ID, &l.Email, &l.FailedAttempts, &l.IPAddress, &l.LockedUntil,
+		&unlockedAt, &unlockedBy, &l.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if unlockedAt.Valid {
+		l.UnlockedAt = &unlockedAt.Time
+	}
+	if unlockedBy.Valid {
+		id := int(unlockedBy.Int64)
+		l.UnlockedBy = &id
+	}
+
+	return l, nil
+}
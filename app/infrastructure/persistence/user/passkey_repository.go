@@ -0,0 +1,146 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// PasskeyRepository implements user.PasskeyRepository interface
+type PasskeyRepository struct {
+	db *sql.DB
+}
+
+// NewPasskeyRepository creates a new passkey repository
+func NewPasskeyRepository(db *sql.DB) user.PasskeyRepository {
+	return &PasskeyRepository{db: db}
+}
+
+const passkeyColumns = `
+	SELECT id, user_id, name, credential_id, public_key, algorithm, sign_count,
+	       aaguid, transports, backup_eligible, backed_up, created_at, last_used_at
+	FROM user_passkeys
+`
+
+// Create stores a new passkey
+func (r *PasskeyRepository) Create(ctx context.Context, p *user.Passkey) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_passkeys (user_id, name, credential_id, public_key, algorithm, sign_count,
+			aaguid, transports, backup_eligible, backed_up, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		p.UserID, p.Name, p.CredentialID, p.PublicKey, p.Algorithm, p.SignCount,
+		p.AAGUID, strings.Join(p.Transports, " "), p.BackupEligible, p.BackedUp, p.CreatedAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	p.ID = int(id)
+	return nil
+}
+
+// FindByID retrieves a passkey by ID
+func (r *PasskeyRepository) FindByID(ctx context.Context, id int) (*user.Passkey, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	p, err := scanPasskey(database.Conn(ctx, r.db).QueryRowContext(ctx, passkeyColumns+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("passkey not found")
+	}
+	return p, err
+}
+
+// FindByCredentialID retrieves a passkey by its WebAuthn credential ID
+func (r *PasskeyRepository) FindByCredentialID(ctx context.Context, credentialID string) (*user.Passkey, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	p, err := scanPasskey(database.Conn(ctx, r.db).QueryRowContext(ctx, passkeyColumns+` WHERE credential_id = ?`, credentialID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("passkey not found")
+	}
+	return p, err
+}
+
+// FindByUser retrieves the passkeys of a user, oldest first
+func (r *PasskeyRepository) FindByUser(ctx context.Context, userID int) ([]*user.Passkey, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, passkeyColumns+` WHERE user_id = ? ORDER BY id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	passkeys := make([]*user.Passkey, 0)
+	for rows.Next() {
+		p, err := scanPasskey(rows)
+		if err != nil {
+			return nil, err
+		}
+		passkeys = append(passkeys, p)
+	}
+
+	return passkeys, rows.Err()
+}
+
+// RecordUse stores the signature counter and backup state after a sign-in
+func (r *PasskeyRepository) RecordUse(ctx context.Context, id int, signCount uint32, backedUp bool, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE user_passkeys SET sign_count = ?, backed_up = ?, last_used_at = ? WHERE id = ?`,
+		signCount, backedUp, at.UTC(), id,
+	)
+	return err
+}
+
+// Delete removes a passkey
+func (r *PasskeyRepository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM user_passkeys WHERE id = ?`, id)
+	return err
+}
+
+// scanPasskey scans a single passkey row
+func scanPasskey(row rowScanner) (*user.Passkey, error) {
+	p := &user.Passkey{}
+	var transports string
+	var lastUsedAt sql.NullTime
+
+	err := row.Scan(
+		&p.ID, &p.UserID, &p.Name, &p.CredentialID, &p.PublicKey, &p.Algorithm, &p.SignCount,
+		&p.AAGUID, &transports, &p.BackupEligible, &p.BackedUp, &p.CreatedAt, &lastUsedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Transports = strings.Fields(transports)
+	if lastUsedAt.Valid {
+		p.LastUsedAt = &lastUsedAt.Time
+	}
+
+	return p, nil
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// PasswordHistoryRepository implements user.PasswordHistoryRepository interface
+type PasswordHistoryRepository struct {
+	db *sql.DB
+}
+
+// NewPasswordHistoryRepository creates a new password history repository
+func NewPasswordHistoryRepository(db *sql.DB) user.PasswordHistoryRepository {
+	return &PasswordHistoryRepository{db: db}
+}
+
+// Add stores a previous password hash
+func (r *PasswordHistoryRepository) Add(ctx context.Context, userID int, passwordHash string, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`INSERT INTO password_history (user_id, password_hash, created_at) VALUES (?, ?, ?)`,
+		userID, passwordHash, at.UTC(),
+	)
+	return err
+}
+
+// FindRecent retrieves the newest previous password hashes of a user
+func (r *PasswordHistoryRepository) FindRecent(ctx context.Context, userID int, limit int) ([]string, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		`SELECT password_hash FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?`,
+		userID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make([]string, 0)
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+
+	return hashes, rows.Err()
+}
+
+// Prune deletes all but the newest entries of a user
+func (r *PasswordHistoryRepository) Prune(ctx context.Context, userID int, keep int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, `
+		DELETE FROM password_history
+		WHERE user_id = ? AND id NOT IN (
+			SELECT id FROM password_history WHERE user_id = ? ORDER BY id DESC LIMIT ?
+		)
+	`, userID, userID, keep)
+	return err
+}
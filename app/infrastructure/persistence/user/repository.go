@@ -1,11 +1,13 @@
 package user
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
 	"time"
 
 	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
 )
 
 // Repository implements user.Repository interface
@@ -19,7 +21,10 @@ func NewRepository(db *sql.DB) user.Repository {
 }
 
 // FindByID retrieves a user by ID
-func (r *Repository) FindByID(id int) (*user.User, error) {
+func (r *Repository) FindByID(ctx context.Context, id int) (*user.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, email, password_hash, name, role, is_active, 
 		       last_login_at, created_at, updated_at
@@ -29,7 +34,7 @@ func (r *Repository) FindByID(id int) (*user.User, error) {
 	u := &user.User{}
 	var lastLoginAt sql.NullTime
 
-	err := r.db.QueryRow(query, id).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, id).Scan(
 		&u.ID, &u.Email, &u.PasswordHash, &u.Name, &u.Role,
 		&u.IsActive, &lastLoginAt, &u.CreatedAt, &u.UpdatedAt,
 	)
@@ -49,17 +54,20 @@ func (r *Repository) FindByID(id int) (*user.User, error) {
 }
 
 // FindByEmail retrieves a user by email
-func (r *Repository) FindByEmail(email string) (*user.User, error) {
+func (r *Repository) FindByEmail(ctx context.Context, email string) (*user.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, email, password_hash, name, role, is_active,
 		       last_login_at, created_at, updated_at
-		FROM users WHERE email = ?
+		FROM users WHERE email = ? COLLATE NOCASE
 	`
 
 	u := &user.User{}
 	var lastLoginAt sql.NullTime
 
-	err := r.db.QueryRow(query, email).Scan(
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, email).Scan(
 		&u.ID, &u.Email, &u.PasswordHash, &u.Name, &u.Role,
 		&u.IsActive, &lastLoginAt, &u.CreatedAt, &u.UpdatedAt,
 	)
@@ -79,14 +87,17 @@ func (r *Repository) FindByEmail(email string) (*user.User, error) {
 }
 
 // FindAll retrieves all users
-func (r *Repository) FindAll() ([]*user.User, error) {
+func (r *Repository) FindAll(ctx context.Context) ([]*user.User, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		SELECT id, email, password_hash, name, role, is_active,
 		       last_login_at, created_at, updated_at
 		FROM users ORDER BY created_at DESC
 	`
 
-	rows, err := r.db.Query(query)
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -116,13 +127,16 @@ func (r *Repository) FindAll() ([]*user.User, error) {
 }
 
 // Create creates a new user
-func (r *Repository) Create(u *user.User) error {
+func (r *Repository) Create(ctx context.Context, u *user.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		INSERT INTO users (email, password_hash, name, role, is_active, created_at, updated_at)
 		VALUES (?, ?, ?, ?, ?, ?, ?)
 	`
 
-	result, err := r.db.Exec(query,
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		u.Email, u.PasswordHash, u.Name, u.Role, u.IsActive,
 		u.CreatedAt, u.UpdatedAt,
 	)
@@ -140,7 +154,10 @@ func (r *Repository) Create(u *user.User) error {
 }
 
 // Update updates an existing user
-func (r *Repository) Update(u *user.User) error {
+func (r *Repository) Update(ctx context.Context, u *user.User) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `
 		UPDATE users 
 		SET email = ?, password_hash = ?, name = ?, role = ?, 
@@ -148,7 +165,7 @@ func (r *Repository) Update(u *user.User) error {
 		WHERE id = ?
 	`
 
-	_, err := r.db.Exec(query,
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
 		u.Email, u.PasswordHash, u.Name, u.Role, u.IsActive,
 		u.UpdatedAt, u.ID,
 	)
@@ -156,15 +173,33 @@ func (r *Repository) Update(u *user.User) error {
 }
 
 // Delete deletes a user by ID
-func (r *Repository) Delete(id int) error {
+func (r *Repository) Delete(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `DELETE FROM users WHERE id = ?`
-	_, err := r.db.Exec(query, id)
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query, id)
 	return err
 }
 
 // UpdateLastLogin updates user's last login time
-func (r *Repository) UpdateLastLogin(id int) error {
+func (r *Repository) UpdateLastLogin(ctx context.Context, id int) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
 	query := `UPDATE users SET last_login_at = ? WHERE id = ?`
-	_, err := r.db.Exec(query, time.Now(), id)
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, query, time.Now(), id)
 	return err
 }
+
+// CountActiveByRole returns the number of active users with a role
+func (r *Repository) CountActiveByRole(ctx context.Context, role user.Role) (int, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	var count int
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM users WHERE role = ? AND is_active = 1`, role,
+	).Scan(&count)
+	return count, err
+}
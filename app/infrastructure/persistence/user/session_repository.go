@@ -0,0 +1,174 @@
+package user
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/infrastructure/database"
+)
+
+// SessionRepository implements user.SessionRepository interface
+type SessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a new session repository
+func NewSessionRepository(db *sql.DB) user.SessionRepository {
+	return &SessionRepository{db: db}
+}
+
+const sessionColumns = `
+	SELECT id, session_id, user_id, impersonator_id, ip_address, user_agent,
+	       created_at, last_seen_at, expires_at, revoked_at
+	FROM user_sessions
+`
+
+// Create stores a new session
+func (r *SessionRepository) Create(ctx context.Context, s *user.Session) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	query := `
+		INSERT INTO user_sessions (session_id, user_id, impersonator_id, ip_address, user_agent, created_at, last_seen_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	result, err := database.Conn(ctx, r.db).ExecContext(ctx, query,
+		s.SessionID, s.UserID, s.ImpersonatorID, s.IPAddress, s.UserAgent,
+		s.CreatedAt.UTC(), s.LastSeenAt.UTC(), s.ExpiresAt.UTC(),
+	)
+	if err != nil {
+		return err
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return err
+	}
+
+	s.ID = int(id)
+	return nil
+}
+
+// FindBySessionID retrieves a session by the ID carried in its JWT
+func (r *SessionRepository) FindBySessionID(ctx context.Context, sessionID string) (*user.Session, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanSession(database.Conn(ctx, r.db).QueryRowContext(ctx, sessionColumns+` WHERE session_id = ?`, sessionID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found")
+	}
+	return s, err
+}
+
+// FindByID retrieves a session by ID
+func (r *SessionRepository) FindByID(ctx context.Context, id int) (*user.Session, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	s, err := scanSession(database.Conn(ctx, r.db).QueryRowContext(ctx, sessionColumns+` WHERE id = ?`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("session not found")
+	}
+	return s, err
+}
+
+// FindActiveByUser retrieves the unexpired, unrevoked sessions of a user, most recently seen first
+func (r *SessionRepository) FindActiveByUser(ctx context.Context, userID int, now time.Time) ([]*user.Session, error) {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx,
+		sessionColumns+` WHERE user_id = ? AND revoked_at IS NULL AND expires_at > ? ORDER BY last_seen_at DESC, id DESC`,
+		userID, now.UTC(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := make([]*user.Session, 0)
+	for rows.Next() {
+		s, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+
+	return sessions, rows.Err()
+}
+
+// Touch records when and from where a session was last used
+func (r *SessionRepository) Touch(ctx context.Context, id int, at time.Time, ip string) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE user_sessions SET last_seen_at = ?, ip_address = ? WHERE id = ?`,
+		at.UTC(), ip, id,
+	)
+	return err
+}
+
+// Revoke revokes a session
+func (r *SessionRepository) Revoke(ctx context.Context, id int, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE user_sessions SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+		at.UTC(), id,
+	)
+	return err
+}
+
+// RevokeAllForUser revokes every session of a user except one (0 keeps none)
+func (r *SessionRepository) RevokeAllForUser(ctx context.Context, userID int, exceptID int, at time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx,
+		`UPDATE user_sessions SET revoked_at = ? WHERE user_id = ? AND id != ? AND revoked_at IS NULL`,
+		at.UTC(), userID, exceptID,
+	)
+	return err
+}
+
+// DeleteExpired removes sessions that expired before the given time
+func (r *SessionRepository) DeleteExpired(ctx context.Context, before time.Time) error {
+	ctx, cancel := database.WithQueryTimeout(ctx)
+	defer cancel()
+
+	_, err := database.Conn(ctx, r.db).ExecContext(ctx, `DELETE FROM user_sessions WHERE expires_at < ?`, before.UTC())
+	return err
+}
+
+// scanSession scans a single session row
+func scanSession(row rowScanner) (*user.Session, error) {
+	s := &user.Session{}
+	var impersonatorID sql.NullInt64
+	var revokedAt sql.NullTime
+
+	err := row.Scan(
+		&s.ID, &s.SessionID, &s.UserID, &impersonatorID, &s.IPAddress, &s.UserAgent,
+		&s.CreatedAt, &s.LastSeenAt, &s.ExpiresAt, &revokedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if impersonatorID.Valid {
+		id := int(impersonatorID.Int64)
+		s.ImpersonatorID = &id
+	}
+	if revokedAt.Valid {
+		s.RevokedAt = &revokedAt.Time
+	}
+
+	return s, nil
+}
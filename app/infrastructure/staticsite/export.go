@@ -0,0 +1,343 @@
+// Package staticsite exports the published pages as plain HTML, for sites
+// that use the CMS only to author content. Pages are rendered by the same
+// pipeline that serves them and written as <slug>/index.html, next to copies
+// of the static assets and the media.
+//
+// Every export leaves a manifest in the output directory with a fingerprint
+// of each page: its fields, its components, the site settings it shows and
+// the fingerprinted assets it links.
+// The next export renders only the pages whose fingerprint changed.
+package staticsite
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/shared/assets"
+)
+
+// manifestFile records the previous export in the output directory
+const manifestFile = ".cacto-export.json"
+
+// manifestVersion is bumped when the output layout changes, forcing a full export
+const manifestVersion = 1
+
+// Renderer renders a page as the server would serve it
+type Renderer interface {
+	RenderPage(ctx context.Context, slug string) ([]byte, error)
+}
+
+// Config configures an export
+type Config struct {
+	OutputDir string
+	BaseURL   string // Where the exported site is served from, possibly under a path
+	SourceURL string // BASE_URL of the CMS; links to it are pointed at BaseURL
+	StaticDir string // Copied to static/, except the CMS's own sitemap
+	UploadDir string // Copied to uploads/
+	Full      bool   // Render every page, ignoring the previous export
+}
+
+// Result reports what an export changed
+type Result struct {
+	Rendered     []string `json:"rendered"` // Paths of the pages written
+	Unchanged    int      `json:"unchanged"`
+	Removed      []string `json:"removed"` // Paths of pages no longer published
+	FilesCopied  int      `json:"files_copied"`
+	FilesRemoved int      `json:"files_removed"`
+	Sitemap      bool     `json:"sitemap"`
+}
+
+// manifest is the record of an export
+type manifest struct {
+	Version int               `json:"version"`
+	BaseURL string            `json:"base_url"`
+	Pages   map[string]string `json:"pages"` // Fingerprints by slug
+}
+
+// Exporter writes a static copy of the site
+type Exporter struct {
+	renderer Renderer
+	cfg      Config
+	basePath string // Path of BaseURL without the trailing slash, e.g. /docs
+}
+
+// NewExporter creates an exporter rendering pages with renderer
+func NewExporter(renderer Renderer, cfg Config) (*Exporter, error) {
+	target, err := url.Parse(cfg.BaseURL)
+	if err != nil || target.Scheme == "" || target.Host == "" {
+		return nil, fmt.Errorf("base URL %q must be absolute", cfg.BaseURL)
+	}
+	cfg.BaseURL = strings.TrimSuffix(cfg.BaseURL, "/")
+	cfg.SourceURL = strings.TrimSuffix(cfg.SourceURL, "/")
+
+	return &Exporter{
+		renderer: renderer,
+		cfg:      cfg,
+		basePath: strings.TrimSuffix(target.Path, "/"),
+	}, nil
+}
+
+// Export writes the given pages, which carry their components, and copies
+// the assets and media. settings are the site settings pages show: a change
+// re-renders every page. A nil sitemap removes sitemap.xml.
+func (e *Exporter) Export(ctx context.Context, pages []*page.Page, settings map[string]string, sitemap []byte) (*Result, error) {
+	if err := e.prepare(); err != nil {
+		return nil, err
+	}
+
+	previous := e.readManifest()
+	// Pages link assets by their fingerprinted URLs: a new build re-renders them
+	linked, _ := assets.ReadManifest(e.cfg.StaticDir)
+	next := manifest{Version: manifestVersion, BaseURL: e.cfg.BaseURL, Pages: make(map[string]string)}
+	result := &Result{Rendered: []string{}, Removed: []string{}}
+
+	for _, p := range pages {
+		fingerprint, err := pageFingerprint(p, settings, linked)
+		if err != nil {
+			return nil, err
+		}
+		next.Pages[p.Slug] = fingerprint
+
+		file := filepath.Join(e.cfg.OutputDir, pageFile(p.Slug))
+		if _, err := os.Stat(file); err == nil && previous.Pages[p.Slug] == fingerprint {
+			result.Unchanged++
+			continue
+		}
+
+		html, err := e.renderer.RenderPage(ctx, p.Slug)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render /%s: %w", p.Slug, err)
+		}
+		if err := writeFile(file, e.rewriteLinks(html)); err != nil {
+			return nil, err
+		}
+		result.Rendered = append(result.Rendered, "/"+p.Slug)
+	}
+
+	for slug := range previous.Pages {
+		if _, ok := next.Pages[slug]; ok {
+			continue
+		}
+		if err := e.removePage(slug); err != nil {
+			return nil, err
+		}
+		result.Removed = append(result.Removed, "/"+slug)
+	}
+
+	// The CMS writes its own sitemap into the static directory; the export
+	// gets one with its own URLs instead
+	skip := map[string]bool{"sitemap.xml": true}
+	for _, dir := range []struct{ src, dst string }{
+		{e.cfg.StaticDir, "static"},
+		{e.cfg.UploadDir, "uploads"},
+	} {
+		copied, removed, err := syncDir(dir.src, filepath.Join(e.cfg.OutputDir, dir.dst), skip)
+		if err != nil {
+			return nil, err
+		}
+		result.FilesCopied += copied
+		result.FilesRemoved += removed
+		skip = nil
+	}
+
+	sitemapPath := filepath.Join(e.cfg.OutputDir, "sitemap.xml")
+	if sitemap != nil {
+		if err := writeFile(sitemapPath, replaceLinks(sitemapLoc, sitemap, e.siteLink)); err != nil {
+			return nil, err
+		}
+		result.Sitemap = true
+	} else if err := os.Remove(sitemapPath); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	// Written last: an interrupted export renders its pages again next time
+	if err := e.writeManifest(next); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// prepare creates the output directory. It refuses a directory that overlaps
+// the ones copied from, and a non-empty one that holds no previous export.
+func (e *Exporter) prepare() error {
+	if e.cfg.OutputDir == "" {
+		return fmt.Errorf("an output directory is required")
+	}
+	out, err := filepath.Abs(e.cfg.OutputDir)
+	if err != nil {
+		return err
+	}
+	for _, dir := range []string{e.cfg.StaticDir, e.cfg.UploadDir} {
+		src, err := filepath.Abs(dir)
+		if err != nil {
+			return err
+		}
+		if within(out, src) || within(src, out) {
+			return fmt.Errorf("the output directory %s overlaps %s", e.cfg.OutputDir, dir)
+		}
+	}
+	// Exported pages link the vendored scripts like served ones
+	if err := assets.CheckVendored(e.cfg.StaticDir); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(out)
+	if os.IsNotExist(err) {
+		return os.MkdirAll(out, 0755)
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(out, manifestFile)); err != nil {
+			return fmt.Errorf("%s is not empty and holds no previous export", e.cfg.OutputDir)
+		}
+	}
+	return nil
+}
+
+// readManifest returns the previous export's record, or an empty one when
+// there is none or everything has to be rendered again
+func (e *Exporter) readManifest() manifest {
+	empty := manifest{Pages: map[string]string{}}
+	data, err := os.ReadFile(filepath.Join(e.cfg.OutputDir, manifestFile))
+	if err != nil {
+		return empty
+	}
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil || m.Pages == nil {
+		return empty
+	}
+	if e.cfg.Full || m.Version != manifestVersion || m.BaseURL != e.cfg.BaseURL {
+		// Pages of the previous export are still removed when unpublished
+		for slug := range m.Pages {
+			m.Pages[slug] = ""
+		}
+	}
+	return m
+}
+
+// writeManifest records an export
+func (e *Exporter) writeManifest(m manifest) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFile(filepath.Join(e.cfg.OutputDir, manifestFile), data)
+}
+
+// removePage deletes the file of a page and its directory once empty
+func (e *Exporter) removePage(slug string) error {
+	file := filepath.Join(e.cfg.OutputDir, pageFile(slug))
+	if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if slug != "" {
+		os.Remove(filepath.Dir(file)) // Fails, harmlessly, if something else is in it
+	}
+	return nil
+}
+
+// linkAttr matches the attributes holding links in rendered pages
+var linkAttr = regexp.MustCompile(`(\s(?:href|src|action)=")([^"]*)(")`)
+
+// sitemapLoc matches the page URLs of a sitemap
+var sitemapLoc = regexp.MustCompile(`(<loc>)([^<]*)(</loc>)`)
+
+// rewriteLinks points links at the exported site: absolute links to the CMS
+// use the export's base URL, and links are passed through siteLink
+func (e *Exporter) rewriteLinks(html []byte) []byte {
+	if e.cfg.SourceURL != "" && e.cfg.SourceURL != e.cfg.BaseURL {
+		html = bytes.ReplaceAll(html, []byte(e.cfg.SourceURL), []byte(e.cfg.BaseURL))
+	}
+	return replaceLinks(linkAttr, html, e.siteLink)
+}
+
+// siteLink rewrites a link into the site: root-relative links get the path
+// of the base URL, and links to pages end in a slash so any static host
+// finds their index.html. Other links are returned as they are.
+func (e *Exporter) siteLink(link string) string {
+	prefix := e.basePath
+	switch {
+	case link == e.cfg.BaseURL || strings.HasPrefix(link, e.cfg.BaseURL+"/"):
+		prefix, link = e.cfg.BaseURL, "/"+strings.TrimPrefix(strings.TrimPrefix(link, e.cfg.BaseURL), "/")
+	case !strings.HasPrefix(link, "/") || strings.HasPrefix(link, "//"):
+		return link
+	}
+
+	rest := ""
+	if i := strings.IndexAny(link, "?#"); i >= 0 {
+		link, rest = link[:i], link[i:]
+	}
+	if isPageLink(link) && !strings.HasSuffix(link, "/") {
+		link += "/"
+	}
+	return prefix + link + rest
+}
+
+// replaceLinks rewrites the second group of every match of re
+func replaceLinks(re *regexp.Regexp, data []byte, rewrite func(string) string) []byte {
+	return re.ReplaceAllFunc(data, func(match []byte) []byte {
+		parts := re.FindSubmatch(match)
+		return []byte(string(parts[1]) + rewrite(string(parts[2])) + string(parts[3]))
+	})
+}
+
+// isPageLink reports whether a root-relative path names a page rather than
+// a file
+func isPageLink(p string) bool {
+	if strings.HasPrefix(p, "/static/") || strings.HasPrefix(p, "/uploads/") {
+		return false
+	}
+	return !strings.Contains(path.Base(p), ".")
+}
+
+// pageFile returns where a page is written, relative to the output directory
+func pageFile(slug string) string {
+	if slug == "" {
+		return "index.html"
+	}
+	return filepath.Join(filepath.FromSlash(slug), "index.html")
+}
+
+// pageFingerprint hashes everything a rendered page is made of
+func pageFingerprint(p *page.Page, settings map[string]string, linked *assets.Manifest) (string, error) {
+	data, err := json.Marshal(struct {
+		Page     *page.Page        `json:"page"`
+		Settings map[string]string `json:"settings"`
+		Assets   *assets.Manifest  `json:"assets,omitempty"`
+	}{p, settings, linked})
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// within reports whether path is dir or inside it
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// writeFile writes a file, creating its directory
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package staticsite
+
+import (
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// syncDir makes dst a copy of src. Files whose size and modification time
+// match are left alone; files src no longer has are removed. A missing src
+// counts as empty. Top-level names in skip aren't copied.
+func syncDir(src, dst string, skip map[string]bool) (copied, removed int, err error) {
+	seen := make(map[string]bool)
+
+	err = filepath.WalkDir(src, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && name == src {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		rel, err := filepath.Rel(src, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		if skip[rel] {
+			return nil
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(dst, rel), 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		seen[rel] = true
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if existing, err := os.Stat(target); err == nil &&
+			existing.Size() == info.Size() && existing.ModTime().Equal(info.ModTime()) {
+			return nil
+		}
+		if err := copyFile(name, target, info); err != nil {
+			return err
+		}
+		copied++
+		return nil
+	})
+	if err != nil {
+		return copied, removed, err
+	}
+
+	err = filepath.WalkDir(dst, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && name == dst {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(dst, name)
+		if err != nil {
+			return err
+		}
+		if !seen[rel] {
+			if err := os.Remove(name); err != nil {
+				return err
+			}
+			removed++
+		}
+		return nil
+	})
+	return copied, removed, err
+}
+
+// copyFile copies a file and its modification time, so the next sync can
+// tell it is up to date
+func copyFile(src, dst string, info fs.FileInfo) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(dst, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
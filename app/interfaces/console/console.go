@@ -0,0 +1,304 @@
+// Package console is the command registry behind the artisan CLI. Commands
+// are self-contained units with a namespaced name ("migrate:status"), their
+// own flags and help text:
+//
+//	app := console.New("artisan", cfg)
+//	app.Register(&console.Command{
+//		Name:        "cache:clear",
+//		Description: "Clear the page cache",
+//		Run:         func(ctx *console.Context) error { ... },
+//	})
+//	os.Exit(app.Run(os.Args[1:]))
+package console
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"cacto-cms/config"
+)
+
+// Command is a single CLI command
+type Command struct {
+	Name        string // Namespaced name, e.g. "migrate:rollback"
+	Description string // One line shown in the command list
+	Help        string // Longer help text shown by "help <command>" (optional)
+	Arguments   string // Positional arguments for the usage line, e.g. "<name>"
+
+	// Destructive commands ask for confirmation when ENV is production,
+	// unless --force is given
+	Destructive bool
+
+	// Output commands get --format=table|json; print results with ctx.Render
+	Output bool
+
+	// Flags defines the command's flags; read them with ctx.Bool, ctx.Int
+	// and ctx.String
+	Flags func(fs *flag.FlagSet)
+
+	Run func(ctx *Context) error
+}
+
+// Namespace returns the part of the name before the colon
+func (c *Command) Namespace() string {
+	if i := strings.Index(c.Name, ":"); i >= 0 {
+		return c.Name[:i]
+	}
+	return ""
+}
+
+// Application holds the registered commands
+type Application struct {
+	name     string
+	config   *config.Config
+	commands map[string]*Command
+
+	Stdin  io.Reader
+	Stdout io.Writer
+	Stderr io.Writer
+}
+
+// New creates a command application
+func New(name string, cfg *config.Config) *Application {
+	app := &Application{
+		name:     name,
+		config:   cfg,
+		commands: make(map[string]*Command),
+		Stdin:    os.Stdin,
+		Stdout:   os.Stdout,
+		Stderr:   os.Stderr,
+	}
+
+	app.Register(&Command{
+		Name:        "list",
+		Description: "List all commands",
+		Run: func(ctx *Context) error {
+			app.printList()
+			return nil
+		},
+	}, &Command{
+		Name:        "help",
+		Description: "Show help for a command",
+		Arguments:   "<command>",
+		Run: func(ctx *Context) error {
+			if len(ctx.Args) == 0 {
+				app.printList()
+				return nil
+			}
+			cmd, err := app.find(ctx.Args[0])
+			if err != nil {
+				return err
+			}
+			app.printHelp(cmd, app.flagSet(cmd))
+			return nil
+		},
+	})
+
+	return app
+}
+
+// Register adds commands. Registering a name twice is a programming error.
+func (a *Application) Register(commands ...*Command) {
+	for _, cmd := range commands {
+		if _, exists := a.commands[cmd.Name]; exists {
+			panic(fmt.Sprintf("console: command %q registered twice", cmd.Name))
+		}
+		a.commands[cmd.Name] = cmd
+	}
+}
+
+// Run runs the command named by the first argument and returns the exit code
+func (a *Application) Run(args []string) int {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "--help" {
+		a.printList()
+		return 0
+	}
+
+	cmd, err := a.find(args[0])
+	if err != nil {
+		fmt.Fprintf(a.Stderr, "%v\n", err)
+		return 1
+	}
+
+	fs := a.flagSet(cmd)
+	positional, err := parseInterspersed(fs, args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		a.printHelp(cmd, fs)
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(a.Stderr, "%v\n\n", err)
+		a.printHelp(cmd, fs)
+		return 1
+	}
+
+	runCtx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	ctx := &Context{
+		Args:   positional,
+		Config: a.config,
+		Stdout: a.Stdout,
+		Stderr: a.Stderr,
+		ctx:    runCtx,
+		flags:  fs,
+		input:  bufio.NewReader(a.Stdin),
+	}
+
+	if cmd.Output {
+		if format := ctx.String("format"); format != FormatTable && format != FormatJSON {
+			fmt.Fprintf(a.Stderr, "Unknown format %q, expected %s or %s\n", format, FormatTable, FormatJSON)
+			return 1
+		}
+	}
+
+	if cmd.Destructive && a.config.IsProduction() && !ctx.Bool("force") {
+		if !ctx.Confirm(fmt.Sprintf("%s is destructive and the application is in production. Do you really wish to run it?", cmd.Name)) {
+			fmt.Fprintln(a.Stderr, "Command cancelled.")
+			return 1
+		}
+	}
+
+	if err := cmd.Run(ctx); err != nil {
+		fmt.Fprintf(a.Stderr, "❌ %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// find looks up a command, suggesting others in the same namespace
+func (a *Application) find(name string) (*Command, error) {
+	if cmd, ok := a.commands[name]; ok {
+		return cmd, nil
+	}
+
+	namespace := strings.SplitN(name, ":", 2)[0]
+	var similar []string
+	for _, cmd := range a.sorted() {
+		if cmd.Namespace() == namespace || strings.HasPrefix(cmd.Name, name) {
+			similar = append(similar, cmd.Name)
+		}
+	}
+	if len(similar) > 0 {
+		return nil, fmt.Errorf("Command %q is not defined. Did you mean one of these?\n  %s", name, strings.Join(similar, "\n  "))
+	}
+	return nil, fmt.Errorf("Command %q is not defined. Run \"%s list\" to see all commands.", name, a.name)
+}
+
+// flagSet builds the flags of a command, including --force for
+// destructive commands
+func (a *Application) flagSet(cmd *Command) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if cmd.Flags != nil {
+		cmd.Flags(fs)
+	}
+	if cmd.Output {
+		fs.String("format", FormatTable, "Output format: table or json")
+	}
+	if cmd.Destructive {
+		fs.Bool("force", false, "Run without confirmation in production")
+	}
+	return fs
+}
+
+// sorted returns the commands without a namespace first, then the
+// namespaced ones, each ordered by name
+func (a *Application) sorted() []*Command {
+	commands := make([]*Command, 0, len(a.commands))
+	for _, cmd := range a.commands {
+		commands = append(commands, cmd)
+	}
+	sort.Slice(commands, func(i, j int) bool {
+		ri, rj := commands[i].Namespace() == "", commands[j].Namespace() == ""
+		if ri != rj {
+			return ri
+		}
+		return commands[i].Name < commands[j].Name
+	})
+	return commands
+}
+
+// printList prints all commands grouped by namespace
+func (a *Application) printList() {
+	fmt.Fprintf(a.Stdout, "Usage:\n  %s <command> [options] [arguments]\n\n", a.name)
+	fmt.Fprintf(a.Stdout, "Run \"%s help <command>\" for the options of a command.\n\n", a.name)
+	fmt.Fprintln(a.Stdout, "Available commands:")
+
+	commands := a.sorted()
+	width := 0
+	for _, cmd := range commands {
+		width = max(width, len(cmd.Name))
+	}
+
+	namespace := ""
+	for _, cmd := range commands {
+		if ns := cmd.Namespace(); ns != namespace {
+			namespace = ns
+			fmt.Fprintf(a.Stdout, " %s\n", ns)
+		}
+		fmt.Fprintf(a.Stdout, "  %-*s  %s\n", width, cmd.Name, cmd.Description)
+	}
+}
+
+// printHelp prints the usage, help text and flags of a command
+func (a *Application) printHelp(cmd *Command, fs *flag.FlagSet) {
+	usage := a.name + " " + cmd.Name
+	hasFlags := false
+	fs.VisitAll(func(*flag.Flag) { hasFlags = true })
+	if hasFlags {
+		usage += " [options]"
+	}
+	if cmd.Arguments != "" {
+		usage += " " + cmd.Arguments
+	}
+
+	fmt.Fprintf(a.Stdout, "Description:\n  %s\n\nUsage:\n  %s\n", cmd.Description, usage)
+	if cmd.Help != "" {
+		fmt.Fprintf(a.Stdout, "\nHelp:\n  %s\n", strings.ReplaceAll(strings.TrimSpace(cmd.Help), "\n", "\n  "))
+	}
+	if !hasFlags {
+		return
+	}
+
+	fmt.Fprintln(a.Stdout, "\nOptions:")
+	w := tabwriter.NewWriter(a.Stdout, 0, 0, 2, ' ', 0)
+	fs.VisitAll(func(f *flag.Flag) {
+		name := "--" + f.Name
+		if _, isBool := f.Value.(interface{ IsBoolFlag() bool }); !isBool {
+			name += "=" + strings.ToUpper(f.Name)
+		}
+		usage := f.Usage
+		if f.DefValue != "" && f.DefValue != "false" && f.DefValue != "0" {
+			usage += fmt.Sprintf(" (default: %s)", f.DefValue)
+		}
+		fmt.Fprintf(w, "  %s\t%s\n", name, usage)
+	})
+	w.Flush()
+}
+
+// parseInterspersed parses flags that may appear before or after
+// positional arguments
+func parseInterspersed(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
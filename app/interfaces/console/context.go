@@ -0,0 +1,93 @@
+package console
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"cacto-cms/config"
+)
+
+// Context is passed to a running command
+type Context struct {
+	Args   []string // Positional arguments
+	Config *config.Config
+	Stdout io.Writer
+	Stderr io.Writer
+
+	ctx   context.Context
+	flags *flag.FlagSet
+	input *bufio.Reader
+}
+
+// Context returns the command's context, cancelled on Ctrl+C so running
+// queries stop
+func (c *Context) Context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+	return c.ctx
+}
+
+// Arg returns the positional argument at i, or "" when it is missing
+func (c *Context) Arg(i int) string {
+	if i < len(c.Args) {
+		return c.Args[i]
+	}
+	return ""
+}
+
+// Bool returns the value of a boolean flag
+func (c *Context) Bool(name string) bool {
+	v, _ := c.value(name).(bool)
+	return v
+}
+
+// Int returns the value of an integer flag
+func (c *Context) Int(name string) int {
+	v, _ := c.value(name).(int)
+	return v
+}
+
+// String returns the value of a string flag
+func (c *Context) String(name string) string {
+	v, _ := c.value(name).(string)
+	return v
+}
+
+// IsSet reports whether a flag was given on the command line
+func (c *Context) IsSet(name string) bool {
+	set := false
+	c.flags.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+// Confirm asks a yes/no question. Anything but "y" or "yes", including
+// closed input, is a no.
+func (c *Context) Confirm(question string) bool {
+	fmt.Fprintf(c.Stdout, "%s [y/N] ", question)
+	answer, _ := c.input.ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// Printf writes to standard output
+func (c *Context) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(c.Stdout, format, args...)
+}
+
+// value looks up a defined flag's value
+func (c *Context) value(name string) interface{} {
+	f := c.flags.Lookup(name)
+	if f == nil {
+		panic(fmt.Sprintf("console: flag --%s is not defined for %s", name, c.flags.Name()))
+	}
+	return f.Value.(flag.Getter).Get()
+}
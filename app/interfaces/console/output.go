@@ -0,0 +1,54 @@
+package console
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+)
+
+// Output formats for commands with Output set
+const (
+	FormatTable = "table"
+	FormatJSON  = "json"
+)
+
+// Table is the human readable form of a command's result
+type Table struct {
+	Headers []string
+	Rows    [][]string
+}
+
+// AddRow appends a row of cells
+func (t *Table) AddRow(cells ...string) {
+	t.Rows = append(t.Rows, cells)
+}
+
+// JSON reports whether the command was asked for JSON output
+func (c *Context) JSON() bool {
+	return c.flags.Lookup("format") != nil && c.String("format") == FormatJSON
+}
+
+// Render prints a result: data as indented JSON with --format=json,
+// otherwise the table
+func (c *Context) Render(data interface{}, table *Table) error {
+	if c.JSON() {
+		enc := json.NewEncoder(c.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(data)
+	}
+
+	if len(table.Rows) == 0 {
+		fmt.Fprintln(c.Stdout, "No results.")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(c.Stdout, 0, 0, 2, ' ', 0)
+	if len(table.Headers) > 0 {
+		fmt.Fprintln(w, strings.ToUpper(strings.Join(table.Headers, "\t")))
+	}
+	for _, row := range table.Rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
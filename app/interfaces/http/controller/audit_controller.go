@@ -0,0 +1,171 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/config"
+)
+
+// AuditController serves the audit log (admin screen, JSON API and CSV export)
+type AuditController struct {
+	auditService *auditservice.Service
+	permissions  PermissionResolver
+	config       *config.Config
+}
+
+// NewAuditController creates a new audit controller
+func NewAuditController(auditService *auditservice.Service, permissions PermissionResolver, cfg *config.Config) *AuditController {
+	return &AuditController{
+		auditService: auditService,
+		permissions:  permissions,
+		config:       cfg,
+	}
+}
+
+// ListEntries returns a page of audit entries (JSON).
+// Accepts the same filters as the admin screen plus page and per_page.
+func (c *AuditController) ListEntries(w http.ResponseWriter, r *http.Request) {
+	query, filter, err := parseAuditQuery(r)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	perPage, _ := strconv.Atoi(r.URL.Query().Get("per_page"))
+	if perPage <= 0 || perPage > auditservice.MaxPageSize {
+		perPage = auditservice.DefaultPageSize
+	}
+	filter.Limit = perPage
+	filter.Offset = (query.Page - 1) * perPage
+
+	entries, total, err := c.auditService.Search(r.Context(), filter)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":  entries,
+		"total":    total,
+		"page":     query.Page,
+		"per_page": perPage,
+	})
+}
+
+// ShowAuditLog renders the filterable audit log screen
+func (c *AuditController) ShowAuditLog(w http.ResponseWriter, r *http.Request) {
+	query, filter, err := parseAuditQuery(r)
+	if err != nil {
+		http.Error(w, errors.AsAppError(err).Message, http.StatusBadRequest)
+		return
+	}
+
+	filter.Limit = auditservice.DefaultPageSize
+	filter.Offset = (query.Page - 1) * auditservice.DefaultPageSize
+
+	entries, total, err := c.auditService.Search(r.Context(), filter)
+	if err != nil {
+		http.Error(w, "Failed to load audit log", http.StatusInternalServerError)
+		return
+	}
+
+	query.Total = total
+	query.Pages = (total + auditservice.DefaultPageSize - 1) / auditservice.DefaultPageSize
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.AuditLog(adminViewer(r, c.permissions), entries, query).Render(r.Context(), w)
+}
+
+// ExportCSV downloads the entries matching the filters as CSV
+func (c *AuditController) ExportCSV(w http.ResponseWriter, r *http.Request) {
+	query, filter, err := parseAuditQuery(r)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	entries, err := c.auditService.Export(r.Context(), filter)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	// Exports are themselves audited
+	c.auditService.Record(r.Context(), "audit.exported", "", nil, nil, map[string]interface{}{
+		"filters": query.Values().Encode(),
+		"rows":    len(entries),
+	})
+
+	filename := fmt.Sprintf("audit-log-%s.csv", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Cache-Control", "no-store")
+
+	if err := auditservice.WriteCSV(w, entries); err != nil {
+		log.Printf("Audit export failed: %v", err)
+	}
+}
+
+// parseAuditQuery reads the audit filters from the query string
+func parseAuditQuery(r *http.Request) (admin.AuditQuery, audit.Filter, error) {
+	q := r.URL.Query()
+	query := admin.AuditQuery{
+		Actor:      strings.TrimSpace(q.Get("actor")),
+		Action:     strings.TrimSpace(q.Get("action")),
+		TargetType: strings.TrimSpace(q.Get("target_type")),
+		TargetID:   strings.TrimSpace(q.Get("target_id")),
+		From:       q.Get("from"),
+		To:         q.Get("to"),
+	}
+
+	query.Page, _ = strconv.Atoi(q.Get("page"))
+	if query.Page < 1 {
+		query.Page = 1
+	}
+
+	filter := audit.Filter{
+		Action:     query.Action,
+		TargetType: query.TargetType,
+		TargetID:   query.TargetID,
+	}
+
+	if query.Actor != "" {
+		if id, err := strconv.Atoi(query.Actor); err == nil {
+			filter.ActorID = &id
+		} else {
+			filter.ActorEmail = query.Actor
+		}
+	}
+
+	if query.From != "" {
+		from, err := time.ParseInLocation("2006-01-02", query.From, time.Local)
+		if err != nil {
+			return query, filter, errors.NewBadRequest("Invalid 'from' date, expected YYYY-MM-DD")
+		}
+		filter.Since = &from
+	}
+
+	if query.To != "" {
+		to, err := time.ParseInLocation("2006-01-02", query.To, time.Local)
+		if err != nil {
+			return query, filter, errors.NewBadRequest("Invalid 'to' date, expected YYYY-MM-DD")
+		}
+		// The end date is inclusive
+		until := to.AddDate(0, 0, 1)
+		filter.Until = &until
+	}
+
+	return query, filter, nil
+}
@@ -45,7 +45,7 @@ func (c *AuthController) Login(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Login
-	response, err := c.authService.Login(&req)
+	response, err := c.authService.Login(r.Context(), &req, loginMeta(r))
 	if err != nil {
 		middleware.ErrorResponse(w, err, c.config)
 		return
@@ -86,7 +86,7 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Register
-	user, err := c.authService.Register(&req)
+	user, err := c.authService.Register(r.Context(), &req)
 	if err != nil {
 		middleware.ErrorResponse(w, err, c.config)
 		return
@@ -100,16 +100,36 @@ func (c *AuthController) Register(w http.ResponseWriter, r *http.Request) {
 
 // Logout handles user logout
 func (c *AuthController) Logout(w http.ResponseWriter, r *http.Request) {
-	// Clear cookie
-	http.SetCookie(w, &http.Cookie{
-		Name:     "auth_token",
-		Value:    "",
-		Path:     "/",
-		HttpOnly: true,
-		Secure:   c.config.GetCookieSecure(),
-		MaxAge:   -1,
-	})
+	sessionID, _ := middleware.GetSessionID(r.Context())
+	c.authService.Logout(r.Context(), sessionID)
+
+	// Clear cookies
+	clearAuthCookies(w, c.config)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{"message": "Logged out successfully"})
 }
+
+// ChangePassword changes the signed-in user's password
+func (c *AuthController) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	var req auth.ChangePasswordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	sessionID, _ := middleware.GetSessionID(r.Context())
+	if err := c.authService.ChangePassword(r.Context(), userID, sessionID, &req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Password changed"})
+}
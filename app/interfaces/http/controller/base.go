@@ -1,10 +1,16 @@
 package controller
 
 import (
+	"context"
 	"net/http"
 
+	"cacto-cms/app/application/auth"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/assets"
 	"cacto-cms/app/shared/seo"
 	"cacto-cms/app/interfaces/templates/layouts"
+	"cacto-cms/config"
 
 	"github.com/a-h/templ"
 )
@@ -38,7 +44,7 @@ func (c *BaseController) RenderHTML(w http.ResponseWriter, r *http.Request, meta
 	<meta name="viewport" content="width=device-width, initial-scale=1.0">
 	<title>` + meta.Title + `</title>
 	<meta name="description" content="` + meta.Description + `">
-	<link rel="stylesheet" href="/static/css/output.css">
+	<link rel="stylesheet" href="` + assets.URL("css/output.css") + `">
 </head>
 <body>
 	<header class="header">
@@ -59,3 +65,52 @@ func (c *BaseController) RenderHTML(w http.ResponseWriter, r *http.Request, meta
 func (c *BaseController) BaseURL() string {
 	return c.baseURL
 }
+
+// loginMeta collects the request details recorded with a login attempt
+func loginMeta(r *http.Request) auth.LoginMeta {
+	return auth.LoginMeta{
+		IPAddress: middleware.ClientIP(r),
+		UserAgent: r.UserAgent(),
+	}
+}
+
+// PermissionResolver resolves the permissions granted to a role
+type PermissionResolver interface {
+	PermissionsFor(ctx context.Context, role string) []string
+}
+
+// adminViewer describes the signed-in user for admin templates
+func adminViewer(r *http.Request, permissions PermissionResolver) admin.Viewer {
+	userID, _ := middleware.GetUserID(r.Context())
+	email, _ := middleware.GetUserEmail(r.Context())
+	role, _ := middleware.GetUserRole(r.Context())
+
+	viewer := admin.Viewer{
+		UserID:      userID,
+		Email:       email,
+		Role:        role,
+		Permissions: permissions.PermissionsFor(r.Context(), role),
+	}
+	if impersonation, ok := middleware.GetImpersonation(r.Context()); ok {
+		viewer.ImpersonatorEmail = impersonation.ImpersonatorEmail
+		viewer.ImpersonationEnds = impersonation.ExpiresAt
+	}
+	return viewer
+}
+
+// impersonatorCookie keeps the admin's own token while they impersonate a user
+const impersonatorCookie = "auth_token_admin"
+
+// clearAuthCookies removes the session cookies
+func clearAuthCookies(w http.ResponseWriter, cfg *config.Config) {
+	for _, name := range []string{"auth_token", impersonatorCookie} {
+		http.SetCookie(w, &http.Cookie{
+			Name:     name,
+			Value:    "",
+			Path:     "/",
+			HttpOnly: true,
+			Secure:   cfg.GetCookieSecure(),
+			MaxAge:   -1,
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	auditservice "cacto-cms/app/application/audit"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/config"
+)
+
+// ContentExporter writes all content as a bundle archive and returns how
+// many records of each kind it holds
+type ContentExporter interface {
+	ExportArchive(ctx context.Context, w io.Writer) (map[string]int, error)
+}
+
+// ContentController serves content bundle downloads
+type ContentController struct {
+	exporter     ContentExporter
+	auditService *auditservice.Service
+	config       *config.Config
+}
+
+// NewContentController creates a new content controller
+func NewContentController(exporter ContentExporter, auditService *auditservice.Service, cfg *config.Config) *ContentController {
+	return &ContentController{
+		exporter:     exporter,
+		auditService: auditService,
+		config:       cfg,
+	}
+}
+
+// ExportBundle downloads pages, components, media and settings as a ZIP
+// bundle for content:import on another installation
+func (c *ContentController) ExportBundle(w http.ResponseWriter, r *http.Request) {
+	// Built in memory so a failed export is an error page, not a truncated file
+	var buf bytes.Buffer
+	counts, err := c.exporter.ExportArchive(r.Context(), &buf)
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewInternal("Failed to export content", err), c.config)
+		return
+	}
+
+	c.auditService.Record(r.Context(), "content.exported", "", nil, nil, counts)
+
+	filename := fmt.Sprintf("content-%s.zip", time.Now().Format("20060102-150405"))
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+filename+`"`)
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.Header().Set("Cache-Control", "no-store")
+
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Printf("Content export failed: %v", err)
+	}
+}
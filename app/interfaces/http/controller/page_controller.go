@@ -1,13 +1,20 @@
 package controller
 
 import (
+	"bytes"
+	"context"
+	stderrors "errors"
+	"fmt"
+	"log"
 	"net/http"
 
 	componentservice "cacto-cms/app/application/component"
 	"cacto-cms/app/application/page"
 	"cacto-cms/app/domain/component"
+	"cacto-cms/app/interfaces/templates/layouts"
 	"cacto-cms/app/interfaces/templates/pages"
 	componentrenderer "cacto-cms/app/shared/component"
+	"cacto-cms/app/shared/pagecache"
 	"cacto-cms/app/shared/sanitize"
 	"cacto-cms/app/shared/seo"
 
@@ -22,6 +29,7 @@ type PageController struct {
 	componentService *componentservice.Service
 	componentRenderer *componentrenderer.Renderer
 	seoManager      *seo.Manager
+	cache           *pagecache.Cache // Nil when the page cache is disabled
 }
 
 // NewPageController creates a new page controller
@@ -30,6 +38,7 @@ func NewPageController(
 	pageService *page.Service,
 	componentService *componentservice.Service,
 	seoManager *seo.Manager,
+	cache *pagecache.Cache,
 ) *PageController {
 	return &PageController{
 		BaseController:   NewBaseController(baseURL),
@@ -37,105 +46,106 @@ func NewPageController(
 		componentService: componentService,
 		componentRenderer: componentrenderer.NewRenderer(),
 		seoManager:       seoManager,
+		cache:            cache,
 	}
 }
 
+// errPageNotFound is returned by renderPage for a slug without a page
+var errPageNotFound = stderrors.New("page not found")
+
 // ShowHome renders the home page
 func (c *PageController) ShowHome(w http.ResponseWriter, r *http.Request) {
-	// Get home page from database (slug is empty string)
-	p, err := c.pageService.GetPageBySlug("")
-	if err != nil {
-		http.NotFound(w, r)
-		return
-	}
+	// The home page has an empty slug
+	c.servePage(w, r, "")
+}
 
-	// Get page components
-	var renderedComponents []templ.Component
-	if len(p.Components) > 0 {
-		// Convert page.Component to domain.Component
-		domainComponents := make([]*component.Component, len(p.Components))
-		for i, comp := range p.Components {
-			domainComponents[i] = &component.Component{
-				ID:       comp.ID,
-				Type:     component.Type(comp.Type),
-				Name:     comp.Name,
-				Title:    comp.Title,
-				Subtitle: comp.Subtitle,
-				Content:  comp.Content,
-				ImageURL: comp.ImageURL,
-				LinkURL:  comp.LinkURL,
-				LinkText: comp.LinkText,
-				DataJSON: comp.DataJSON,
-			}
-			// Merge with defaults
-			domainComponents[i].MergeWithDefaults()
-		}
+// ShowPage renders a page by slug
+func (c *PageController) ShowPage(w http.ResponseWriter, r *http.Request) {
+	c.servePage(w, r, chi.URLParam(r, "slug"))
+}
 
-		renderedComponents, err = c.componentRenderer.RenderMultiple(domainComponents)
-		if err != nil {
-			http.Error(w, "Failed to render components", http.StatusInternalServerError)
+// servePage answers with a page from the cache, rendering it on a miss. A
+// stale page is served while it is rendered again in the background. The
+// ETag and Last-Modified headers let browsers revalidate with a conditional
+// GET, answered with 304 Not Modified.
+func (c *PageController) servePage(w http.ResponseWriter, r *http.Request, slug string) {
+	key := cacheKey(r, slug)
+	entry, state := c.cache.Get(key)
+
+	switch state {
+	case pagecache.Stale:
+		if c.cache.Claim(key) {
+			go c.refresh(context.WithoutCancel(r.Context()), key, slug)
+		}
+	case pagecache.Miss:
+		generation := c.cache.Generation()
+		var err error
+		entry, err = c.renderPage(r.Context(), slug)
+		if stderrors.Is(err, errPageNotFound) {
+			http.NotFound(w, r)
 			return
 		}
-	} else {
-		// Fallback to simple content (sanitize content)
-		sanitizedContent := sanitize.HTML(p.Content)
-		renderedComponents = []templ.Component{
-			pages.SimpleContent(p.Title, sanitizedContent),
+		if err != nil {
+			log.Printf("❌ Failed to render page /%s: %v", slug, err)
+			http.Error(w, "Failed to render page", http.StatusInternalServerError)
+			return
 		}
+		c.cache.Store(key, entry, generation)
 	}
 
-	// Create page wrapper component
-	pageComponent := pages.PageWithComponents(renderedComponents)
-
-	// SEO - use page meta if available, otherwise use defaults
-	meta := c.seoManager.ForPageWithDefaults(
-		p.MetaTitle,
-		p.MetaDescription,
-		p.MetaKeywords,
-		p.OGImage,
-		"",
-	)
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Header().Set("ETag", entry.ETag)
+	w.Header().Set("Cache-Control", c.cache.CacheControl())
+	w.Header().Set("Vary", "HX-Request")
+	if c.cache != nil {
+		w.Header().Set("X-Cache", string(state))
+	}
+	http.ServeContent(w, r, "", entry.LastModified, bytes.NewReader(entry.Body))
+}
 
-	// Render
-	c.Render(w, r, meta, pageComponent)
+// refresh renders a stale page again and caches it
+func (c *PageController) refresh(ctx context.Context, key, slug string) {
+	generation := c.cache.Generation()
+	entry, err := c.renderPage(ctx, slug)
+	switch {
+	case stderrors.Is(err, errPageNotFound):
+		c.cache.Remove(key)
+	case err != nil:
+		log.Printf("⚠️  Failed to refresh cached page /%s: %v", slug, err)
+		c.cache.Release(key)
+	default:
+		c.cache.Store(key, entry, generation)
+	}
 }
 
-// ShowPage renders a page by slug
-func (c *PageController) ShowPage(w http.ResponseWriter, r *http.Request) {
-	slug := chi.URLParam(r, "slug")
+// RenderPage renders a page as ShowPage serves it, bypassing the cache, e.g.
+// for a static export
+func (c *PageController) RenderPage(ctx context.Context, slug string) ([]byte, error) {
+	entry, err := c.renderPage(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	return entry.Body, nil
+}
 
-	p, err := c.pageService.GetPageBySlug(slug)
+// renderPage renders a page with its components into a cache entry
+func (c *PageController) renderPage(ctx context.Context, slug string) (*pagecache.Entry, error) {
+	p, err := c.pageService.GetPageBySlug(ctx, slug)
 	if err != nil {
-		http.NotFound(w, r)
-		return
+		return nil, errPageNotFound
 	}
 
 	// Get page components
 	var renderedComponents []templ.Component
 	if len(p.Components) > 0 {
-		// Convert page.Component to domain.Component
-		domainComponents := make([]*component.Component, len(p.Components))
-		for i, comp := range p.Components {
-			domainComponents[i] = &component.Component{
-				ID:       comp.ID,
-				Type:     component.Type(comp.Type),
-				Name:     comp.Name,
-				Title:    comp.Title,
-				Subtitle: comp.Subtitle,
-				Content:  comp.Content,
-				ImageURL: comp.ImageURL,
-				LinkURL:  comp.LinkURL,
-				LinkText: comp.LinkText,
-				DataJSON: comp.DataJSON,
-			}
-			// Merge with defaults
-			domainComponents[i].MergeWithDefaults()
+		components := make([]*component.Component, len(p.Components))
+		for i := range p.Components {
+			components[i] = p.Components[i].Rendered()
 		}
 
-		renderedComponents, err = c.componentRenderer.RenderMultiple(domainComponents)
+		renderedComponents, err = c.componentRenderer.RenderMultiple(components)
 		if err != nil {
-			http.Error(w, "Failed to render components", http.StatusInternalServerError)
-			return
+			return nil, fmt.Errorf("failed to render components: %w", err)
 		}
 	} else {
 		// Fallback to simple content (sanitize content)
@@ -148,8 +158,9 @@ func (c *PageController) ShowPage(w http.ResponseWriter, r *http.Request) {
 	// Create page wrapper component
 	pageComponent := pages.PageWithComponents(renderedComponents)
 
-	// SEO
+	// SEO - use page meta if available, otherwise use defaults
 	meta := c.seoManager.ForPageWithDefaults(
+		ctx,
 		p.MetaTitle,
 		p.MetaDescription,
 		p.MetaKeywords,
@@ -158,6 +169,19 @@ func (c *PageController) ShowPage(w http.ResponseWriter, r *http.Request) {
 	)
 
 	// Render
-	c.Render(w, r, meta, pageComponent)
+	var buf bytes.Buffer
+	if err := layouts.Base(*meta, pageComponent).Render(ctx, &buf); err != nil {
+		return nil, err
+	}
+	return pagecache.NewEntry(p.ID, buf.Bytes()), nil
 }
 
+// cacheKey names the cached variant of a page. Requests from htmx (boosted
+// links) are kept apart from full page loads.
+func cacheKey(r *http.Request, slug string) string {
+	variant := "full"
+	if r.Header.Get("HX-Request") == "true" {
+		variant = "htmx"
+	}
+	return "/" + slug + " " + variant
+}
@@ -0,0 +1,209 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"cacto-cms/app/application/auth"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/validation"
+	"cacto-cms/app/shared/webauthn"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// PasskeyController handles passkey (WebAuthn) registration and sign-in
+type PasskeyController struct {
+	authService *auth.Service
+	permissions PermissionResolver
+	config      *config.Config
+}
+
+// NewPasskeyController creates a new passkey controller
+func NewPasskeyController(authService *auth.Service, permissions PermissionResolver, cfg *config.Config) *PasskeyController {
+	return &PasskeyController{
+		authService: authService,
+		permissions: permissions,
+		config:      cfg,
+	}
+}
+
+// BeginLogin returns the options for navigator.credentials.get (JSON)
+func (c *PasskeyController) BeginLogin(w http.ResponseWriter, r *http.Request) {
+	opts, err := c.authService.BeginPasskeyLogin()
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opts)
+}
+
+// FinishLogin verifies the signed challenge and signs the user in (JSON)
+func (c *PasskeyController) FinishLogin(w http.ResponseWriter, r *http.Request) {
+	var assertion webauthn.AssertionResponse
+	if err := json.NewDecoder(r.Body).Decode(&assertion); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	response, err := c.authService.FinishPasskeyLogin(r.Context(), &assertion, loginMeta(r))
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    response.Token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.config.GetCookieSecure(),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListPasskeys returns the signed-in user's passkeys (JSON)
+func (c *PasskeyController) ListPasskeys(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r.Context())
+
+	passkeys, err := c.authService.GetPasskeys(r.Context(), userID)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"passkeys": passkeys,
+	})
+}
+
+// BeginRegistration returns the options for navigator.credentials.create (JSON)
+func (c *PasskeyController) BeginRegistration(w http.ResponseWriter, r *http.Request) {
+	if err := rejectImpersonation(r); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	opts, err := c.authService.BeginPasskeyRegistration(r.Context(), userID)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(opts)
+}
+
+// FinishRegistration verifies and stores a new passkey (JSON)
+func (c *PasskeyController) FinishRegistration(w http.ResponseWriter, r *http.Request) {
+	if err := rejectImpersonation(r); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	var req auth.RegisterPasskeyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	passkey, err := c.authService.FinishPasskeyRegistration(r.Context(), userID, &req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(passkey)
+}
+
+// DeletePasskey removes one of the signed-in user's passkeys (JSON)
+func (c *PasskeyController) DeletePasskey(w http.ResponseWriter, r *http.Request) {
+	if err := rejectImpersonation(r); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid passkey ID"), c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if err := c.authService.DeletePasskey(r.Context(), userID, id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Passkey deleted"})
+}
+
+// ShowPasskeys renders the signed-in user's passkeys
+func (c *PasskeyController) ShowPasskeys(w http.ResponseWriter, r *http.Request) {
+	c.renderPasskeys(w, r, nil)
+}
+
+// HandleDeletePasskey handles the delete passkey form
+func (c *PasskeyController) HandleDeletePasskey(w http.ResponseWriter, r *http.Request) {
+	if err := rejectImpersonation(r); err != nil {
+		c.renderPasskeys(w, r, errorFlash(err))
+		return
+	}
+
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderPasskeys(w, r, errorFlash(errors.NewBadRequest("Invalid passkey ID")))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if err := c.authService.DeletePasskey(r.Context(), userID, id); err != nil {
+		c.renderPasskeys(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderPasskeys(w, r, &admin.Flash{Message: "Passkey deleted"})
+}
+
+// renderPasskeys renders the passkeys screen with an optional flash message
+func (c *PasskeyController) renderPasskeys(w http.ResponseWriter, r *http.Request, flash *admin.Flash) {
+	userID, _ := middleware.GetUserID(r.Context())
+
+	passkeys, err := c.authService.GetPasskeys(r.Context(), userID)
+	if err != nil {
+		http.Error(w, "Failed to load passkeys", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Passkeys(adminViewer(r, c.permissions), passkeys, c.authService.PasskeysEnabled(), flash).Render(r.Context(), w)
+}
+
+// rejectImpersonation keeps admins from changing the credentials of the user
+// they are impersonating
+func rejectImpersonation(r *http.Request) error {
+	if _, ok := middleware.GetImpersonation(r.Context()); ok {
+		return errors.NewForbidden("Passkeys cannot be changed while impersonating a user")
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	roleservice "cacto-cms/app/application/role"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/validation"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// RoleController handles role and permission management (JSON API)
+type RoleController struct {
+	roleService *roleservice.Service
+	config      *config.Config
+}
+
+// NewRoleController creates a new role controller
+func NewRoleController(roleService *roleservice.Service, cfg *config.Config) *RoleController {
+	return &RoleController{
+		roleService: roleService,
+		config:      cfg,
+	}
+}
+
+// ListRoles returns all roles with their permissions
+func (c *RoleController) ListRoles(w http.ResponseWriter, r *http.Request) {
+	roles, err := c.roleService.GetRoles(r.Context())
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"roles": roles,
+	})
+}
+
+// ListPermissions returns the permission catalog
+func (c *RoleController) ListPermissions(w http.ResponseWriter, r *http.Request) {
+	permissions, err := c.roleService.GetPermissions(r.Context())
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"permissions": permissions,
+	})
+}
+
+// CreateRole creates a custom role
+func (c *RoleController) CreateRole(w http.ResponseWriter, r *http.Request) {
+	var req roleservice.CreateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	role, err := c.roleService.CreateRole(r.Context(), &req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(role)
+}
+
+// UpdateRole updates a role's description and permissions
+func (c *RoleController) UpdateRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid role ID"), c.config)
+		return
+	}
+
+	var req roleservice.UpdateRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	role, err := c.roleService.UpdateRole(r.Context(), id, &req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(role)
+}
+
+// DeleteRole deletes a custom role
+func (c *RoleController) DeleteRole(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid role ID"), c.config)
+		return
+	}
+
+	if err := c.roleService.DeleteRole(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Role deleted"})
+}
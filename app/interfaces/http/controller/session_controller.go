@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cacto-cms/app/application/auth"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SessionController handles active sessions and admin impersonation
+type SessionController struct {
+	authService *auth.Service
+	permissions PermissionResolver
+	config      *config.Config
+}
+
+// NewSessionController creates a new session controller
+func NewSessionController(authService *auth.Service, permissions PermissionResolver, cfg *config.Config) *SessionController {
+	return &SessionController{
+		authService: authService,
+		permissions: permissions,
+		config:      cfg,
+	}
+}
+
+// ListSessions returns the signed-in user's active sessions (JSON)
+func (c *SessionController) ListSessions(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r.Context())
+	sessionID, _ := middleware.GetSessionID(r.Context())
+
+	sessions, err := c.authService.GetSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	type sessionView struct {
+		*user.Session
+		Device string `json:"device"`
+	}
+	views := make([]sessionView, 0, len(sessions))
+	for _, s := range sessions {
+		views = append(views, sessionView{Session: s, Device: s.Device()})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": views,
+	})
+}
+
+// RevokeSession revokes one of the signed-in user's sessions (JSON)
+func (c *SessionController) RevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid session ID"), c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if err := c.authService.RevokeSession(r.Context(), userID, id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Session revoked"})
+}
+
+// ShowSessions renders the signed-in user's active sessions
+func (c *SessionController) ShowSessions(w http.ResponseWriter, r *http.Request) {
+	c.renderSessions(w, r, nil)
+}
+
+// HandleRevokeSession handles the revoke session form
+func (c *SessionController) HandleRevokeSession(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderSessions(w, r, errorFlash(errors.NewBadRequest("Invalid session ID")))
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if err := c.authService.RevokeSession(r.Context(), userID, id); err != nil {
+		c.renderSessions(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderSessions(w, r, &admin.Flash{Message: "Session revoked"})
+}
+
+// Impersonate starts acting as another user and returns the impersonation token (JSON)
+func (c *SessionController) Impersonate(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid user ID"), c.config)
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(r.Context())
+	sessionID, _ := middleware.GetSessionID(r.Context())
+	response, err := c.authService.Impersonate(r.Context(), adminID, sessionID, targetID, loginMeta(r))
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// HandleImpersonate starts impersonation from the users screen. The admin's
+// own token is kept aside so stopping returns them to their session.
+func (c *SessionController) HandleImpersonate(w http.ResponseWriter, r *http.Request) {
+	targetID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	adminID, _ := middleware.GetUserID(r.Context())
+	sessionID, _ := middleware.GetSessionID(r.Context())
+	response, err := c.authService.Impersonate(r.Context(), adminID, sessionID, targetID, loginMeta(r))
+	if err != nil {
+		appErr := errors.AsAppError(err)
+		http.Error(w, appErr.Message, appErr.HTTPStatus)
+		return
+	}
+
+	maxAge := int(time.Until(response.ExpiresAt).Seconds())
+	if own, err := r.Cookie("auth_token"); err == nil {
+		http.SetCookie(w, &http.Cookie{
+			Name:     impersonatorCookie,
+			Value:    own.Value,
+			Path:     "/",
+			MaxAge:   maxAge,
+			HttpOnly: true,
+			Secure:   c.config.GetCookieSecure(),
+			SameSite: http.SameSiteStrictMode,
+		})
+	}
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    response.Token,
+		Path:     "/",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   c.config.GetCookieSecure(),
+		SameSite: http.SameSiteStrictMode,
+	})
+
+	http.Redirect(w, r, "/admin/impersonation", http.StatusSeeOther)
+}
+
+// ShowImpersonation shows who is being impersonated and their effective permissions
+func (c *SessionController) ShowImpersonation(w http.ResponseWriter, r *http.Request) {
+	viewer := adminViewer(r, c.permissions)
+	if !viewer.IsImpersonated() {
+		http.Redirect(w, r, "/admin/dashboard", http.StatusSeeOther)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Impersonation(viewer).Render(r.Context(), w)
+}
+
+// StopImpersonation ends the current impersonation. Browsers are returned to
+// the admin's own session when it was kept aside.
+func (c *SessionController) StopImpersonation(w http.ResponseWriter, r *http.Request) {
+	sessionID, _ := middleware.GetSessionID(r.Context())
+	if err := c.authService.StopImpersonation(r.Context(), sessionID); err != nil {
+		if isAPIRequest(r) {
+			middleware.ErrorResponse(w, err, c.config)
+		} else {
+			appErr := errors.AsAppError(err)
+			http.Error(w, appErr.Message, appErr.HTTPStatus)
+		}
+		return
+	}
+
+	if isAPIRequest(r) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]string{"message": "Impersonation ended"})
+		return
+	}
+
+	own, err := r.Cookie(impersonatorCookie)
+	if err != nil {
+		clearAuthCookies(w, c.config)
+		http.Redirect(w, r, "/admin/login", http.StatusSeeOther)
+		return
+	}
+
+	http.SetCookie(w, &http.Cookie{
+		Name:     "auth_token",
+		Value:    own.Value,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   c.config.GetCookieSecure(),
+		SameSite: http.SameSiteStrictMode,
+	})
+	http.SetCookie(w, &http.Cookie{
+		Name:     impersonatorCookie,
+		Value:    "",
+		Path:     "/",
+		MaxAge:   -1,
+		HttpOnly: true,
+		Secure:   c.config.GetCookieSecure(),
+	})
+	http.Redirect(w, r, "/admin/users", http.StatusSeeOther)
+}
+
+// renderSessions renders the sessions screen with an optional flash message
+func (c *SessionController) renderSessions(w http.ResponseWriter, r *http.Request, flash *admin.Flash) {
+	userID, _ := middleware.GetUserID(r.Context())
+	sessionID, _ := middleware.GetSessionID(r.Context())
+
+	sessions, err := c.authService.GetSessions(r.Context(), userID, sessionID)
+	if err != nil {
+		http.Error(w, "Failed to load sessions", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Sessions(adminViewer(r, c.permissions), sessions, flash).Render(r.Context(), w)
+}
@@ -0,0 +1,144 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	stderrors "errors"
+	"log"
+	"net/http"
+
+	settingservice "cacto-cms/app/application/setting"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/pagecache"
+	"cacto-cms/app/shared/sitemap"
+	"cacto-cms/app/shared/validation"
+	"cacto-cms/config"
+)
+
+// SitemapGenerator rewrites sitemap.xml, or removes it when it is disabled
+type SitemapGenerator interface {
+	Generate(ctx context.Context) error
+}
+
+// PageCache reports on and clears the cache of rendered pages
+type PageCache interface {
+	Stats() pagecache.Stats
+	Purge()
+}
+
+// SettingsController handles the site settings and the page cache (JSON API
+// and admin screen)
+type SettingsController struct {
+	settingService *settingservice.Service
+	sitemap        SitemapGenerator
+	cache          PageCache
+	permissions    PermissionResolver
+	config         *config.Config
+}
+
+// NewSettingsController creates a new settings controller
+func NewSettingsController(settingService *settingservice.Service, sitemapGen SitemapGenerator, cache PageCache, permissions PermissionResolver, cfg *config.Config) *SettingsController {
+	return &SettingsController{
+		settingService: settingService,
+		sitemap:        sitemapGen,
+		cache:          cache,
+		permissions:    permissions,
+		config:         cfg,
+	}
+}
+
+// GetSettings returns the effective site settings (JSON)
+func (c *SettingsController) GetSettings(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"settings": c.settingService.All(r.Context()),
+	})
+}
+
+// UpdateSettings changes the site settings (JSON)
+func (c *SettingsController) UpdateSettings(w http.ResponseWriter, r *http.Request) {
+	var req settingservice.UpdateSettingsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := c.update(r, &req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	c.GetSettings(w, r)
+}
+
+// GetCacheStats returns the page cache counters (JSON)
+func (c *SettingsController) GetCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(c.cache.Stats())
+}
+
+// PurgeCache drops every cached page (JSON)
+func (c *SettingsController) PurgeCache(w http.ResponseWriter, r *http.Request) {
+	c.cache.Purge()
+	c.GetCacheStats(w, r)
+}
+
+// ShowSettings renders the site settings form
+func (c *SettingsController) ShowSettings(w http.ResponseWriter, r *http.Request) {
+	c.renderSettings(w, r, nil)
+}
+
+// HandleUpdate handles the site settings form
+func (c *SettingsController) HandleUpdate(w http.ResponseWriter, r *http.Request) {
+	req := settingservice.UpdateSettingsRequest{
+		SiteName:        r.FormValue("site_name"),
+		SiteDescription: r.FormValue("site_description"),
+		SitemapEnabled:  r.FormValue("sitemap_enabled") != "",
+	}
+
+	if err := c.update(r, &req); err != nil {
+		c.renderSettings(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderSettings(w, r, &admin.Flash{Message: "Settings saved"})
+}
+
+// HandlePurgeCache handles the clear page cache form
+func (c *SettingsController) HandlePurgeCache(w http.ResponseWriter, r *http.Request) {
+	c.cache.Purge()
+	c.renderSettings(w, r, &admin.Flash{Message: "Page cache cleared"})
+}
+
+// update validates and stores the settings, then brings the sitemap in line
+// with them. A failed sitemap is logged; the settings are saved either way.
+func (c *SettingsController) update(r *http.Request, req *settingservice.UpdateSettingsRequest) error {
+	if err := validation.ValidateStruct(req); err != nil {
+		return err
+	}
+	if err := c.settingService.UpdateSettings(r.Context(), req); err != nil {
+		return err
+	}
+
+	if err := c.sitemap.Generate(r.Context()); err != nil && !stderrors.Is(err, sitemap.ErrDisabled) {
+		log.Printf("⚠️  Failed to regenerate sitemap after a settings change: %v", err)
+	}
+	return nil
+}
+
+// renderSettings renders the settings screen with an optional flash message
+func (c *SettingsController) renderSettings(w http.ResponseWriter, r *http.Request, flash *admin.Flash) {
+	ctx := r.Context()
+	form := admin.SettingsForm{
+		SiteName:        c.settingService.SiteName(ctx),
+		SiteDescription: c.settingService.SiteDescription(ctx),
+		SitemapEnabled:  c.settingService.SitemapEnabled(ctx),
+
+		SitemapLastGenerated: c.settingService.SitemapLastGenerated(ctx),
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Settings(adminViewer(r, c.permissions), form, c.cache.Stats(), flash).Render(ctx, w)
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	tokenservice "cacto-cms/app/application/apitoken"
+	"cacto-cms/app/domain/apitoken"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/validation"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TokenController handles API token management (JSON API)
+type TokenController struct {
+	tokenService *tokenservice.Service
+	config       *config.Config
+}
+
+// NewTokenController creates a new token controller
+func NewTokenController(tokenService *tokenservice.Service, cfg *config.Config) *TokenController {
+	return &TokenController{
+		tokenService: tokenService,
+		config:       cfg,
+	}
+}
+
+// ListMyTokens returns the signed-in user's personal tokens
+func (c *TokenController) ListMyTokens(w http.ResponseWriter, r *http.Request) {
+	userID, _ := middleware.GetUserID(r.Context())
+
+	tokens, err := c.tokenService.GetUserTokens(r.Context(), userID)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tokens": tokens,
+	})
+}
+
+// CreateMyToken creates a personal access token for the signed-in user
+func (c *TokenController) CreateMyToken(w http.ResponseWriter, r *http.Request) {
+	req, ok := c.decodeCreateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	t, plaintext, err := c.tokenService.CreatePersonalToken(r.Context(), userID, req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	c.writeCreated(w, t, plaintext)
+}
+
+// RevokeMyToken revokes one of the signed-in user's personal tokens
+func (c *TokenController) RevokeMyToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid token ID"), c.config)
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	if err := c.tokenService.RevokeUserToken(r.Context(), userID, id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Token revoked"})
+}
+
+// ListTokens returns every personal and service token
+func (c *TokenController) ListTokens(w http.ResponseWriter, r *http.Request) {
+	tokens, err := c.tokenService.GetTokens(r.Context())
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"tokens": tokens,
+	})
+}
+
+// CreateServiceToken creates a service token
+func (c *TokenController) CreateServiceToken(w http.ResponseWriter, r *http.Request) {
+	req, ok := c.decodeCreateRequest(w, r)
+	if !ok {
+		return
+	}
+
+	userID, _ := middleware.GetUserID(r.Context())
+	userRole, _ := middleware.GetUserRole(r.Context())
+	t, plaintext, err := c.tokenService.CreateServiceToken(r.Context(), userID, userRole, req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	c.writeCreated(w, t, plaintext)
+}
+
+// RevokeToken revokes any token
+func (c *TokenController) RevokeToken(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid token ID"), c.config)
+		return
+	}
+
+	if err := c.tokenService.RevokeToken(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "Token revoked"})
+}
+
+// decodeCreateRequest decodes and validates a token creation request
+func (c *TokenController) decodeCreateRequest(w http.ResponseWriter, r *http.Request) (*tokenservice.CreateTokenRequest, bool) {
+	var req tokenservice.CreateTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return nil, false
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return nil, false
+	}
+
+	return &req, true
+}
+
+// writeCreated writes a newly created token together with its plaintext value
+func (c *TokenController) writeCreated(w http.ResponseWriter, t *apitoken.Token, plaintext string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":     t,
+		"plaintext": plaintext,
+		"message":   "Store this token now, it will not be shown again",
+	})
+}
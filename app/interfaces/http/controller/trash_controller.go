@@ -0,0 +1,318 @@
+package controller
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+
+	trashservice "cacto-cms/app/application/trash"
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/media"
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/sitemap"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// TrashController handles deleted pages, components and media (JSON API and admin screen)
+type TrashController struct {
+	trashService *trashservice.Service
+	sitemap      SitemapGenerator
+	permissions  PermissionResolver
+	config       *config.Config
+}
+
+// NewTrashController creates a new trash controller
+func NewTrashController(trashService *trashservice.Service, sitemapGen SitemapGenerator, permissions PermissionResolver, cfg *config.Config) *TrashController {
+	return &TrashController{
+		trashService: trashService,
+		sitemap:      sitemapGen,
+		permissions:  permissions,
+		config:       cfg,
+	}
+}
+
+// ListTrash returns the deleted pages, components and media the user may restore (JSON)
+func (c *TrashController) ListTrash(w http.ResponseWriter, r *http.Request) {
+	view, err := c.trashView(r, adminViewer(r, c.permissions))
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pages":      view.Pages,
+		"components": view.Components,
+		"media":      view.Media,
+		"retention":  view.Retention.String(),
+	})
+}
+
+// RestorePage takes a page out of the trash (JSON)
+func (c *TrashController) RestorePage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid page ID"), c.config)
+		return
+	}
+
+	p, err := c.restorePage(r, id)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(p)
+}
+
+// PurgePage permanently deletes a page in the trash (JSON)
+func (c *TrashController) PurgePage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid page ID"), c.config)
+		return
+	}
+
+	if err := c.trashService.PurgePage(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreComponent takes a component out of the trash (JSON)
+func (c *TrashController) RestoreComponent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid component ID"), c.config)
+		return
+	}
+
+	comp, err := c.trashService.RestoreComponent(r.Context(), id)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(comp)
+}
+
+// PurgeComponent permanently deletes a component in the trash (JSON)
+func (c *TrashController) PurgeComponent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid component ID"), c.config)
+		return
+	}
+
+	if err := c.trashService.PurgeComponent(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RestoreMedia takes a media file out of the trash (JSON)
+func (c *TrashController) RestoreMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid media ID"), c.config)
+		return
+	}
+
+	m, err := c.trashService.RestoreMedia(r.Context(), id)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(m)
+}
+
+// PurgeMedia permanently deletes a media file in the trash (JSON)
+func (c *TrashController) PurgeMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid media ID"), c.config)
+		return
+	}
+
+	if err := c.trashService.PurgeMedia(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ShowTrash renders the trash screen
+func (c *TrashController) ShowTrash(w http.ResponseWriter, r *http.Request) {
+	c.renderTrash(w, r, nil)
+}
+
+// HandleRestorePage handles the restore page form
+func (c *TrashController) HandleRestorePage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid page ID")))
+		return
+	}
+
+	p, err := c.restorePage(r, id)
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: fmt.Sprintf("Page %q restored", p.Title)})
+}
+
+// HandlePurgePage handles the delete page forever form
+func (c *TrashController) HandlePurgePage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid page ID")))
+		return
+	}
+
+	if err := c.trashService.PurgePage(r.Context(), id); err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: "Page deleted permanently"})
+}
+
+// HandleRestoreComponent handles the restore component form
+func (c *TrashController) HandleRestoreComponent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid component ID")))
+		return
+	}
+
+	comp, err := c.trashService.RestoreComponent(r.Context(), id)
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: fmt.Sprintf("Component %q restored", comp.Name)})
+}
+
+// HandlePurgeComponent handles the delete component forever form
+func (c *TrashController) HandlePurgeComponent(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid component ID")))
+		return
+	}
+
+	if err := c.trashService.PurgeComponent(r.Context(), id); err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: "Component deleted permanently"})
+}
+
+// HandleRestoreMedia handles the restore media form
+func (c *TrashController) HandleRestoreMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid media ID")))
+		return
+	}
+
+	m, err := c.trashService.RestoreMedia(r.Context(), id)
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: fmt.Sprintf("Media %q restored", m.OriginalName)})
+}
+
+// HandlePurgeMedia handles the delete media forever form
+func (c *TrashController) HandlePurgeMedia(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderTrash(w, r, errorFlash(errors.NewBadRequest("Invalid media ID")))
+		return
+	}
+
+	if err := c.trashService.PurgeMedia(r.Context(), id); err != nil {
+		c.renderTrash(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderTrash(w, r, &admin.Flash{Message: "Media deleted permanently"})
+}
+
+// restorePage restores a page and puts it back in the sitemap. A failed
+// sitemap is logged; the page is restored either way.
+func (c *TrashController) restorePage(r *http.Request, id int) (*page.Page, error) {
+	p, err := c.trashService.RestorePage(r.Context(), id)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.sitemap.Generate(r.Context()); err != nil && !stderrors.Is(err, sitemap.ErrDisabled) {
+		log.Printf("⚠️  Failed to regenerate sitemap after restoring a page: %v", err)
+	}
+	return p, nil
+}
+
+// renderTrash renders the trash screen with an optional flash message
+func (c *TrashController) renderTrash(w http.ResponseWriter, r *http.Request, flash *admin.Flash) {
+	viewer := adminViewer(r, c.permissions)
+	view, err := c.trashView(r, viewer)
+	if err != nil {
+		flash = errorFlash(err)
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Trash(viewer, view, flash).Render(r.Context(), w)
+}
+
+// trashView lists the trash, keeping each kind of item to viewers who may
+// delete it
+func (c *TrashController) trashView(r *http.Request, viewer admin.Viewer) (admin.TrashView, error) {
+	view := admin.TrashView{
+		Pages:          []*page.Page{},
+		Components:     []*component.Component{},
+		Media:          []*media.Media{},
+		Retention:      c.trashService.Retention(),
+		ShowPages:      viewer.Can("pages:delete"),
+		ShowComponents: viewer.Can("components:delete"),
+		ShowMedia:      viewer.Can("media:delete"),
+	}
+
+	contents, err := c.trashService.Contents(r.Context())
+	if err != nil {
+		return view, err
+	}
+	if view.ShowPages {
+		view.Pages = contents.Pages
+	}
+	if view.ShowComponents {
+		view.Components = contents.Components
+	}
+	if view.ShowMedia {
+		view.Media = contents.Media
+	}
+	return view, nil
+}
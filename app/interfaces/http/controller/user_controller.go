@@ -0,0 +1,342 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	roleservice "cacto-cms/app/application/role"
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/interfaces/templates/admin"
+	"cacto-cms/app/shared/errors"
+	"cacto-cms/app/shared/validation"
+	"cacto-cms/config"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// UserController handles user management (JSON API and admin screens)
+type UserController struct {
+	userService *userservice.Service
+	roleService *roleservice.Service
+	config      *config.Config
+}
+
+// NewUserController creates a new user controller
+func NewUserController(userService *userservice.Service, roleService *roleservice.Service, cfg *config.Config) *UserController {
+	return &UserController{
+		userService: userService,
+		roleService: roleService,
+		config:      cfg,
+	}
+}
+
+// ChangeRoleRequest represents a role change request
+type ChangeRoleRequest struct {
+	Role string `json:"role" validate:"required"`
+}
+
+// ResetPasswordRequest represents a password reset request (password is generated when empty)
+type ResetPasswordRequest struct {
+	Password string `json:"password,omitempty"`
+}
+
+// ListUsers returns all users (JSON)
+func (c *UserController) ListUsers(w http.ResponseWriter, r *http.Request) {
+	users, err := c.userService.GetAllUsers(r.Context())
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewInternal("Failed to load users", err), c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"users": users,
+	})
+}
+
+// GetUser returns a single user (JSON)
+func (c *UserController) GetUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.userID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := c.userService.GetUserByID(r.Context(), id)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// InviteUser creates a user with a role (JSON)
+func (c *UserController) InviteUser(w http.ResponseWriter, r *http.Request) {
+	var req userservice.InviteUserRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	u, temporary, err := c.userService.InviteUser(r.Context(), &req)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	response := map[string]interface{}{"user": u}
+	if temporary != "" {
+		response["temporary_password"] = temporary
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ChangeRole assigns a new role to a user (JSON)
+func (c *UserController) ChangeRole(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ChangeRoleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+		return
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	u, err := c.userService.ChangeRole(r.Context(), id, req.Role)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// DeactivateUser deactivates a user (JSON)
+func (c *UserController) DeactivateUser(w http.ResponseWriter, r *http.Request) {
+	c.setActive(w, r, false)
+}
+
+// ReactivateUser reactivates a user (JSON)
+func (c *UserController) ReactivateUser(w http.ResponseWriter, r *http.Request) {
+	c.setActive(w, r, true)
+}
+
+func (c *UserController) setActive(w http.ResponseWriter, r *http.Request, active bool) {
+	id, ok := c.userID(w, r)
+	if !ok {
+		return
+	}
+
+	u, err := c.userService.SetActive(r.Context(), id, active)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(u)
+}
+
+// ResetPassword sets a new password for a user (JSON)
+func (c *UserController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.userID(w, r)
+	if !ok {
+		return
+	}
+
+	var req ResetPasswordRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			middleware.ErrorResponse(w, errors.NewBadRequest("Invalid request body"), c.config)
+			return
+		}
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	temporary, err := c.userService.ResetPassword(r.Context(), id, req.Password)
+	if err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	response := map[string]interface{}{"message": "Password reset"}
+	if temporary != "" {
+		response["temporary_password"] = temporary
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteUser deletes a user (JSON)
+func (c *UserController) DeleteUser(w http.ResponseWriter, r *http.Request) {
+	id, ok := c.userID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := c.userService.DeleteUser(r.Context(), id); err != nil {
+		middleware.ErrorResponse(w, err, c.config)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]string{"message": "User deleted"})
+}
+
+// ShowUsers renders the user management screen
+func (c *UserController) ShowUsers(w http.ResponseWriter, r *http.Request) {
+	c.renderUsers(w, r, nil)
+}
+
+// HandleInvite handles the invite form
+func (c *UserController) HandleInvite(w http.ResponseWriter, r *http.Request) {
+	req := userservice.InviteUserRequest{
+		Email: r.FormValue("email"),
+		Name:  r.FormValue("name"),
+		Role:  r.FormValue("role"),
+	}
+
+	if err := validation.ValidateStruct(&req); err != nil {
+		c.renderUsers(w, r, errorFlash(err))
+		return
+	}
+
+	u, temporary, err := c.userService.InviteUser(r.Context(), &req)
+	if err != nil {
+		c.renderUsers(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderUsers(w, r, &admin.Flash{
+		Message: fmt.Sprintf("Invited %s. Temporary password: %s", u.Email, temporary),
+	})
+}
+
+// HandleChangeRole handles the role change form
+func (c *UserController) HandleChangeRole(w http.ResponseWriter, r *http.Request) {
+	c.handleForm(w, r, func(id int) (string, error) {
+		u, err := c.userService.ChangeRole(r.Context(), id, r.FormValue("role"))
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s is now %s", u.Email, u.Role), nil
+	})
+}
+
+// HandleDeactivate handles the deactivate form
+func (c *UserController) HandleDeactivate(w http.ResponseWriter, r *http.Request) {
+	c.handleForm(w, r, func(id int) (string, error) {
+		u, err := c.userService.SetActive(r.Context(), id, false)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s has been deactivated", u.Email), nil
+	})
+}
+
+// HandleReactivate handles the reactivate form
+func (c *UserController) HandleReactivate(w http.ResponseWriter, r *http.Request) {
+	c.handleForm(w, r, func(id int) (string, error) {
+		u, err := c.userService.SetActive(r.Context(), id, true)
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("%s has been reactivated", u.Email), nil
+	})
+}
+
+// HandleResetPassword handles the reset password form
+func (c *UserController) HandleResetPassword(w http.ResponseWriter, r *http.Request) {
+	c.handleForm(w, r, func(id int) (string, error) {
+		temporary, err := c.userService.ResetPassword(r.Context(), id, "")
+		if err != nil {
+			return "", err
+		}
+		return "Password reset. Temporary password: " + temporary, nil
+	})
+}
+
+// HandleDelete handles the delete form
+func (c *UserController) HandleDelete(w http.ResponseWriter, r *http.Request) {
+	c.handleForm(w, r, func(id int) (string, error) {
+		if err := c.userService.DeleteUser(r.Context(), id); err != nil {
+			return "", err
+		}
+		return "User deleted", nil
+	})
+}
+
+// handleForm runs a user action from an admin form and re-renders the screen with the outcome
+func (c *UserController) handleForm(w http.ResponseWriter, r *http.Request, action func(id int) (string, error)) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		c.renderUsers(w, r, errorFlash(errors.NewBadRequest("Invalid user ID")))
+		return
+	}
+
+	message, err := action(id)
+	if err != nil {
+		c.renderUsers(w, r, errorFlash(err))
+		return
+	}
+
+	c.renderUsers(w, r, &admin.Flash{Message: message})
+}
+
+// renderUsers renders the user management screen with an optional flash message
+func (c *UserController) renderUsers(w http.ResponseWriter, r *http.Request, flash *admin.Flash) {
+	users, err := c.userService.GetAllUsers(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load users", http.StatusInternalServerError)
+		return
+	}
+
+	roles, err := c.roleService.GetRoles(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to load roles", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	admin.Users(adminViewer(r, c.roleService), users, roles, flash).Render(r.Context(), w)
+}
+
+// userID parses the {id} URL parameter, writing an error response if invalid
+func (c *UserController) userID(w http.ResponseWriter, r *http.Request) (int, bool) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil {
+		middleware.ErrorResponse(w, errors.NewBadRequest("Invalid user ID"), c.config)
+		return 0, false
+	}
+	return id, true
+}
+
+// errorFlash converts an error into a flash message
+func errorFlash(err error) *admin.Flash {
+	return &admin.Flash{Message: errors.AsAppError(err).Message, IsError: true}
+}
@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"net/http"
+
+	"cacto-cms/app/domain/audit"
+)
+
+// AuditContext attaches the audit actor (user, API token, impersonating
+// admin, IP and user agent) to the request context so services can attribute what they record.
+// It must run after AuthMiddleware.
+func AuditContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+
+		actor := audit.Actor{
+			IPAddress: ClientIP(r),
+			UserAgent: r.UserAgent(),
+		}
+		if userID, ok := GetUserID(ctx); ok {
+			actor.UserID = &userID
+			actor.Email, _ = GetUserEmail(ctx)
+		}
+		if tokenID, ok := GetTokenID(ctx); ok {
+			actor.TokenID = &tokenID
+		}
+		if impersonation, ok := GetImpersonation(ctx); ok {
+			actor.ImpersonatorID = &impersonation.ImpersonatorID
+			actor.ImpersonatorEmail = impersonation.ImpersonatorEmail
+		}
+
+		next.ServeHTTP(w, r.WithContext(audit.WithActor(ctx, actor)))
+	})
+}
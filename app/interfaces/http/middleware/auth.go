@@ -3,9 +3,12 @@ package middleware
 import (
 	"context"
 	"encoding/json"
+	"net"
 	"net/http"
 	"strings"
+	"time"
 
+	"cacto-cms/app/domain/role"
 	"cacto-cms/app/shared/auth"
 )
 
@@ -15,10 +18,34 @@ const (
 	UserIDKey contextKey = "user_id"
 	UserEmailKey contextKey = "user_email"
 	UserRoleKey contextKey = "user_role"
+	TokenIDKey contextKey = "token_id"
+	TokenScopesKey contextKey = "token_scopes"
+	SessionIDKey contextKey = "session_id"
+	ImpersonationKey contextKey = "impersonation"
 )
 
-// AuthMiddleware validates JWT tokens and sets user context
-func AuthMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler {
+// Impersonation describes an admin acting as the signed-in user
+type Impersonation struct {
+	ImpersonatorID    int
+	ImpersonatorEmail string
+	ExpiresAt         time.Time
+}
+
+// SessionValidator checks the server-side session behind a JWT and returns
+// the claims refreshed with the user's current email and role
+type SessionValidator interface {
+	ValidateSession(ctx context.Context, claims *auth.Claims, ipAddress, userAgent string) (*auth.Claims, error)
+}
+
+// TokenAuthenticator validates API tokens
+type TokenAuthenticator interface {
+	AuthenticateToken(ctx context.Context, token, ipAddress string) (*auth.TokenPrincipal, error)
+}
+
+// AuthMiddleware validates JWTs and API tokens and sets user context.
+// JWTs must belong to an active session. API tokens are only accepted
+// from the Authorization header.
+func AuthMiddleware(jwtManager *auth.JWTManager, sessions SessionValidator, tokens TokenAuthenticator) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			// Get token from Authorization header
@@ -42,6 +69,31 @@ func AuthMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 
 			token := parts[1]
 
+			// API tokens (personal and service) carry scopes instead of a session
+			if auth.IsAPIToken(token) {
+				if r.Header.Get("Authorization") == "" {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				principal, err := tokens.AuthenticateToken(r.Context(), token, ClientIP(r))
+				if err != nil {
+					next.ServeHTTP(w, r)
+					return
+				}
+
+				ctx := context.WithValue(r.Context(), TokenIDKey, principal.TokenID)
+				ctx = context.WithValue(ctx, TokenScopesKey, principal.Scopes)
+				if principal.UserID > 0 {
+					ctx = context.WithValue(ctx, UserIDKey, principal.UserID)
+					ctx = context.WithValue(ctx, UserEmailKey, principal.Email)
+					ctx = context.WithValue(ctx, UserRoleKey, principal.Role)
+				}
+
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
 			// Validate token
 			claims, err := jwtManager.ValidateToken(token)
 			if err != nil {
@@ -49,10 +101,28 @@ func AuthMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 				return
 			}
 
+			// Revoked or expired sessions and deactivated users are rejected
+			claims, err = sessions.ValidateSession(r.Context(), claims, ClientIP(r), r.UserAgent())
+			if err != nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+
 			// Set user context
 			ctx := context.WithValue(r.Context(), UserIDKey, claims.UserID)
 			ctx = context.WithValue(ctx, UserEmailKey, claims.Email)
 			ctx = context.WithValue(ctx, UserRoleKey, claims.Role)
+			ctx = context.WithValue(ctx, SessionIDKey, claims.SessionID)
+			if claims.IsImpersonation() {
+				impersonation := Impersonation{
+					ImpersonatorID:    claims.ImpersonatorID,
+					ImpersonatorEmail: claims.ImpersonatorEmail,
+				}
+				if claims.ExpiresAt != nil {
+					impersonation.ExpiresAt = claims.ExpiresAt.Time
+				}
+				ctx = context.WithValue(ctx, ImpersonationKey, impersonation)
+			}
 
 			next.ServeHTTP(w, r.WithContext(ctx))
 		})
@@ -62,8 +132,9 @@ func AuthMiddleware(jwtManager *auth.JWTManager) func(http.Handler) http.Handler
 // RequireAuth middleware requires authentication
 func RequireAuth(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		userID := r.Context().Value(UserIDKey)
-		if userID == nil {
+		_, hasUser := GetUserID(r.Context())
+		_, hasToken := GetTokenID(r.Context())
+		if !hasUser && !hasToken {
 			// Check if API request
 			accept := r.Header.Get("Accept")
 			if accept == "application/json" {
@@ -87,25 +158,25 @@ func RequireAuth(next http.Handler) http.Handler {
 	})
 }
 
-// RequireRole middleware requires specific role
-func RequireRole(roles ...string) func(http.Handler) http.Handler {
+// PermissionChecker resolves whether a role grants a permission
+type PermissionChecker interface {
+	HasPermission(ctx context.Context, role, permission string) bool
+}
+
+// RequirePermission middleware requires the user's role to grant a permission.
+// API tokens must also carry the permission as a scope; service tokens have
+// no role and are limited by their scopes alone.
+func RequirePermission(checker PermissionChecker, permission string) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			userRole, ok := r.Context().Value(UserRoleKey).(string)
-			if !ok {
+			scopes, scoped := GetTokenScopes(r.Context())
+			if scoped && !role.Grants(scopes, permission) {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
 
-			hasRole := false
-			for _, role := range roles {
-				if userRole == role {
-					hasRole = true
-					break
-				}
-			}
-
-			if !hasRole {
+			userRole, ok := r.Context().Value(UserRoleKey).(string)
+			if ok && !checker.HasPermission(r.Context(), userRole, permission) || !ok && !scoped {
 				http.Error(w, "Forbidden", http.StatusForbidden)
 				return
 			}
@@ -132,3 +203,50 @@ func GetUserEmail(ctx context.Context) (string, bool) {
 	email, ok := ctx.Value(UserEmailKey).(string)
 	return email, ok
 }
+
+// GetTokenID extracts the API token ID from context (only set for token requests)
+func GetTokenID(ctx context.Context) (int, bool) {
+	tokenID, ok := ctx.Value(TokenIDKey).(int)
+	return tokenID, ok
+}
+
+// GetTokenScopes extracts the API token scopes from context (only set for token requests)
+func GetTokenScopes(ctx context.Context) ([]string, bool) {
+	scopes, ok := ctx.Value(TokenScopesKey).([]string)
+	return scopes, ok
+}
+
+// GetSessionID extracts the session ID from context (only set for JWT requests)
+func GetSessionID(ctx context.Context) (string, bool) {
+	sessionID, ok := ctx.Value(SessionIDKey).(string)
+	return sessionID, ok
+}
+
+// GetImpersonation extracts the impersonation details from context (only set
+// when an admin is acting as the user)
+func GetImpersonation(ctx context.Context) (Impersonation, bool) {
+	impersonation, ok := ctx.Value(ImpersonationKey).(Impersonation)
+	return impersonation, ok
+}
+
+// RequireSession middleware rejects API tokens, for routes that must only be
+// reached by a signed-in user (e.g. managing the tokens themselves)
+func RequireSession(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := GetTokenID(r.Context()); ok {
+			http.Error(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// ClientIP returns the client IP address of a request
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,208 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"cacto-cms/app/shared/compress"
+)
+
+// Compress sends dynamic responses gzip or Brotli compressed when the
+// client accepts it. Only compressible content types of at least minSize
+// bytes are compressed; responses that already have a Content-Encoding,
+// such as precompressed static files, pass through.
+//
+// A compressed response is a different representation, so its ETag gets
+// the encoding as a suffix ("abc" becomes "abc-br"). The suffix is removed
+// from If-None-Match before the handler sees it, so conditional requests
+// still match and get 304 Not Modified.
+func Compress(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Responses the client gets uncompressed still say they vary
+			encoding := compress.Negotiate(r.Header.Get("Accept-Encoding"))
+			if r.Method == http.MethodHead {
+				encoding = ""
+			}
+
+			cw := &compressWriter{ResponseWriter: w, encoding: encoding, minSize: minSize}
+			if match := r.Header.Get("If-None-Match"); match != "" && encoding != "" {
+				if stripped, ok := stripETagSuffix(match, encoding); ok {
+					r = r.Clone(r.Context())
+					r.Header.Set("If-None-Match", stripped)
+					cw.matchedEncoded = true
+				}
+			}
+			defer cw.finish()
+
+			next.ServeHTTP(cw, r)
+		})
+	}
+}
+
+// compressWriter holds the start of a response back until it knows whether
+// to compress it: once minSize bytes were written, or the handler is done
+type compressWriter struct {
+	http.ResponseWriter
+	encoding       string // "" when the response is sent uncompressed
+	minSize        int
+	matchedEncoded bool // If-None-Match named the compressed representation
+
+	status  int
+	decided bool
+	buf     []byte
+	encoder compress.Encoder // Nil while the response isn't compressed
+}
+
+// WriteHeader records the status; it is sent once the encoding is decided
+func (cw *compressWriter) WriteHeader(code int) {
+	if cw.decided || cw.status != 0 {
+		return
+	}
+	cw.status = code
+}
+
+// Write buffers the response until it is long enough to compress
+func (cw *compressWriter) Write(p []byte) (int, error) {
+	if cw.status == 0 {
+		cw.status = http.StatusOK
+	}
+	if !cw.decided {
+		if cw.Header().Get("Content-Type") == "" {
+			cw.Header().Set("Content-Type", http.DetectContentType(p))
+		}
+		if !cw.eligible() {
+			cw.start(false)
+			cw.writeBuffered()
+		} else {
+			cw.buf = append(cw.buf, p...)
+			if len(cw.buf) < cw.minSize {
+				return len(p), nil
+			}
+			cw.start(true)
+			if _, err := cw.encoder.Write(cw.buf); err != nil {
+				return 0, err
+			}
+			cw.buf = nil
+			return len(p), nil
+		}
+	}
+
+	if cw.encoder != nil {
+		return cw.encoder.Write(p)
+	}
+	return cw.ResponseWriter.Write(p)
+}
+
+// Flush sends what was written so far, compressing it if it is eligible
+func (cw *compressWriter) Flush() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		compressed := cw.eligible() && len(cw.buf) > 0
+		cw.start(compressed)
+		cw.writeBuffered()
+	}
+	if cw.encoder != nil {
+		cw.encoder.Flush()
+	}
+	if f, ok := cw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (cw *compressWriter) Unwrap() http.ResponseWriter {
+	return cw.ResponseWriter
+}
+
+// finish sends a response that was too short to compress and ends the
+// compressed stream of one that wasn't
+func (cw *compressWriter) finish() {
+	if !cw.decided {
+		if cw.status == 0 {
+			cw.status = http.StatusOK
+		}
+		if cw.status == http.StatusNotModified && cw.matchedEncoded {
+			// The client holds the compressed representation: confirm its ETag
+			tagETag(cw.Header(), cw.encoding)
+			compress.Vary(cw.Header())
+		}
+		cw.start(false)
+		cw.writeBuffered()
+	}
+	if cw.encoder != nil {
+		cw.encoder.Close()
+	}
+}
+
+// eligible reports whether the response may be compressed
+func (cw *compressWriter) eligible() bool {
+	h := cw.Header()
+	switch {
+	case cw.encoding == "":
+		return false
+	case cw.status < http.StatusOK, cw.status == http.StatusNoContent,
+		cw.status == http.StatusPartialContent, cw.status == http.StatusNotModified:
+		return false
+	case h.Get("Content-Encoding") != "", h.Get("Content-Range") != "":
+		return false
+	case strings.Contains(h.Get("Cache-Control"), "no-transform"):
+		return false
+	}
+	return compress.Compressible(h.Get("Content-Type"))
+}
+
+// start sends the headers, compressed or not
+func (cw *compressWriter) start(compressed bool) {
+	cw.decided = true
+	h := cw.Header()
+	if compress.Compressible(h.Get("Content-Type")) {
+		compress.Vary(h)
+	}
+	if compressed {
+		h.Set("Content-Encoding", cw.encoding)
+		h.Del("Content-Length")
+		tagETag(h, cw.encoding)
+		cw.encoder = compress.NewEncoder(cw.encoding, cw.ResponseWriter)
+	}
+	cw.ResponseWriter.WriteHeader(cw.status)
+}
+
+// writeBuffered writes out what was held back
+func (cw *compressWriter) writeBuffered() {
+	if len(cw.buf) == 0 {
+		return
+	}
+	if cw.encoder != nil {
+		cw.encoder.Write(cw.buf)
+	} else {
+		cw.ResponseWriter.Write(cw.buf)
+	}
+	cw.buf = nil
+}
+
+// tagETag marks the ETag of a response as that of its compressed form
+func tagETag(h http.Header, encoding string) {
+	etag := h.Get("ETag")
+	if strings.HasSuffix(etag, `"`) && !strings.HasSuffix(etag, "-"+encoding+`"`) {
+		h.Set("ETag", strings.TrimSuffix(etag, `"`)+"-"+encoding+`"`)
+	}
+}
+
+// stripETagSuffix removes the encoding suffix tagETag adds from the entity
+// tags of an If-None-Match header and reports whether there was one
+func stripETagSuffix(header, encoding string) (string, bool) {
+	suffix := "-" + encoding + `"`
+	tags := strings.Split(header, ",")
+	stripped := false
+	for i, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if strings.HasSuffix(tag, suffix) {
+			tag, stripped = strings.TrimSuffix(tag, suffix)+`"`, true
+		}
+		tags[i] = tag
+	}
+	return strings.Join(tags, ", "), stripped
+}
@@ -1,7 +1,9 @@
 package middleware
 
 import (
+	"context"
 	"encoding/json"
+	stderrors "errors"
 	"log"
 	"net/http"
 
@@ -21,8 +23,8 @@ func ErrorHandler(cfg *config.Config) func(http.Handler) http.Handler {
 
 			next.ServeHTTP(rr, r)
 
-			// If status code indicates an error, handle it
-			if rr.statusCode >= 400 {
+			// If status code indicates an error and the handler wrote no body, handle it
+			if rr.statusCode >= 400 && !rr.wroteBody {
 				handleError(w, rr.statusCode, nil, cfg)
 			}
 		})
@@ -33,6 +35,12 @@ func ErrorHandler(cfg *config.Config) func(http.Handler) http.Handler {
 type responseRecorder struct {
 	http.ResponseWriter
 	statusCode int
+	wroteBody  bool
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteBody = true
+	return rr.ResponseWriter.Write(b)
 }
 
 func (rr *responseRecorder) WriteHeader(code int) {
@@ -55,6 +63,8 @@ func handleError(w http.ResponseWriter, statusCode int, err error, cfg *config.C
 			appErr = errors.ErrUnauthorized
 		case http.StatusForbidden:
 			appErr = errors.ErrForbidden
+		case http.StatusTooManyRequests:
+			appErr = errors.NewTooManyRequests("")
 		case http.StatusInternalServerError:
 			appErr = errors.ErrInternal
 		default:
@@ -91,6 +101,12 @@ func handleError(w http.ResponseWriter, statusCode int, err error, cfg *config.C
 
 // ErrorResponse writes an error response with config
 func ErrorResponse(w http.ResponseWriter, err error, cfg *config.Config) {
+	// The client went away; nobody is left to read a response
+	if stderrors.Is(err, context.Canceled) {
+		log.Printf("Request cancelled: %v", err)
+		return
+	}
+
 	appErr := errors.AsAppError(err)
 	handleError(w, appErr.HTTPStatus, err, cfg)
 }
@@ -23,10 +23,12 @@ func RateLimit(requestsPerMinute int) func(http.Handler) http.Handler {
 	)
 }
 
-// RateLimitAuth creates stricter rate limiting for auth endpoints
+// RateLimitAuth creates stricter rate limiting for auth endpoints.
+// Brute-force protection is enforced per account by the auth service, so this
+// limit only needs to stop floods and must leave room for users sharing a NAT.
 func RateLimitAuth() func(http.Handler) http.Handler {
-	// Stricter limit for auth: 5 requests per minute
-	return RateLimit(5)
+	// Stricter limit for auth: 20 requests per minute
+	return RateLimit(20)
 }
 
 // RateLimitAPI creates rate limiting for API endpoints
@@ -21,8 +21,9 @@ func SecurityHeaders(next http.Handler) http.Handler {
 		w.Header().Set("Referrer-Policy", "strict-origin-when-cross-origin")
 		
 		// Content Security Policy (can be customized per route if needed)
-		// For now, allow same-origin and inline scripts/styles (for HTMX/Alpine)
-		csp := "default-src 'self'; script-src 'self' 'unsafe-inline' https://unpkg.com; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:; font-src 'self' data:;"
+		// Scripts are self-hosted (HTMX/Alpine under /static/vendor); inline
+		// scripts/styles are still allowed
+		csp := "default-src 'self'; script-src 'self' 'unsafe-inline'; style-src 'self' 'unsafe-inline'; img-src 'self' data: https:; font-src 'self' data:;"
 		w.Header().Set("Content-Security-Policy", csp)
 		
 		// HSTS (only if HTTPS)
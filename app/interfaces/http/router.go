@@ -5,7 +5,9 @@ import (
 
 	"cacto-cms/app/interfaces/http/controller"
 	"cacto-cms/app/interfaces/http/middleware"
+	"cacto-cms/app/shared/assets"
 	"cacto-cms/app/shared/auth"
+	"cacto-cms/app/shared/compress"
 	"cacto-cms/config"
 	chimw "github.com/go-chi/chi/v5/middleware"
 
@@ -22,7 +24,20 @@ func NewRouter(
 	pageController *controller.PageController,
 	authController *controller.AuthController,
 	adminController *controller.AdminController,
+	roleController *controller.RoleController,
+	userController *controller.UserController,
+	tokenController *controller.TokenController,
+	auditController *controller.AuditController,
+	sessionController *controller.SessionController,
+	passkeyController *controller.PasskeyController,
+	contentController *controller.ContentController,
+	settingsController *controller.SettingsController,
+	trashController *controller.TrashController,
+	sitemap http.Handler,
+	permissions middleware.PermissionChecker,
 	jwtManager *auth.JWTManager,
+	sessions middleware.SessionValidator,
+	tokens middleware.TokenAuthenticator,
 	cfg *config.Config,
 ) *Router {
 	r := chi.NewRouter()
@@ -35,38 +50,52 @@ func NewRouter(
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recovery)
 	r.Use(middleware.ErrorHandler(cfg))
+	if cfg.CompressionEnabled {
+		r.Use(middleware.Compress(cfg.CompressionMinSize))
+	}
 
-	// Static files
-	r.Handle("/static/*", http.StripPrefix("/static/", http.FileServer(http.Dir("./web/static"))))
+	// Static files (with their precompressed .br/.gz siblings); fingerprinted
+	// copies under /static/assets/ are cached forever
+	r.Handle("/static/*", http.StripPrefix("/static/", assets.Immutable(compress.FileServer("./web/static"))))
 	r.Handle("/uploads/*", http.StripPrefix("/uploads/", http.FileServer(http.Dir("./web/uploads"))))
 
 	// Public routes
 	r.Get("/", pageController.ShowHome)
 	r.Get("/{slug}", pageController.ShowPage)
 
-	// Auth routes (API) - with rate limiting
+	// Auth routes (API) - with rate limiting.
+	// Sessions are read (not required) so logouts can be attributed.
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RateLimitAuth())
+		r.Use(middleware.AuthMiddleware(jwtManager, sessions, tokens))
+		r.Use(middleware.AuditContext)
 		r.Post("/api/auth/login", authController.Login)
 		r.Post("/api/auth/register", authController.Register)
 		r.Post("/api/auth/logout", authController.Logout)
+		r.Post("/api/auth/passkeys/login/begin", passkeyController.BeginLogin)
+		r.Post("/api/auth/passkeys/login/finish", passkeyController.FinishLogin)
 	})
 
 	// Admin login (public) - with rate limiting
 	r.Group(func(r chi.Router) {
 		r.Use(middleware.RateLimitAuth())
+		r.Use(middleware.AuthMiddleware(jwtManager, sessions, tokens))
+		r.Use(middleware.AuditContext)
 		r.Get("/admin/login", adminController.ShowLogin)
 		r.Post("/admin/login", adminController.HandleLogin)
+		r.Get("/admin/sso/login", adminController.HandleSSOLogin)
+		r.Get("/admin/sso/callback", adminController.HandleSSOCallback)
 	})
 
 	// Protected routes (require authentication)
 	r.Group(func(r chi.Router) {
-		r.Use(middleware.AuthMiddleware(jwtManager))
+		r.Use(middleware.AuthMiddleware(jwtManager, sessions, tokens))
 		r.Use(middleware.RequireAuth)
+		r.Use(middleware.AuditContext)
 
-		// Admin routes (require admin/editor role)
+		// Admin routes (require dashboard access)
 		r.Group(func(r chi.Router) {
-			r.Use(middleware.RequireRole("admin", "editor"))
+			r.Use(middleware.RequirePermission(permissions, "dashboard:access"))
 
 			r.Get("/admin", func(w http.ResponseWriter, r *http.Request) {
 				http.Redirect(w, r, "/admin/dashboard", http.StatusFound)
@@ -75,13 +104,178 @@ func NewRouter(
 			r.Get("/admin/dashboard", adminController.ShowDashboard)
 			r.Get("/admin/logout", adminController.HandleLogout)
 			r.Post("/admin/logout", adminController.HandleLogout)
+
+			r.Get("/admin/sessions", sessionController.ShowSessions)
+			r.Post("/admin/sessions/{id}/revoke", sessionController.HandleRevokeSession)
+
+			r.Get("/admin/passkeys", passkeyController.ShowPasskeys)
+			r.Post("/admin/passkeys/{id}/delete", passkeyController.HandleDeletePasskey)
+		})
+
+		// Ending an impersonation (the impersonated user may lack dashboard access)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+
+			r.Get("/admin/impersonation", sessionController.ShowImpersonation)
+			r.Post("/admin/impersonation/stop", sessionController.StopImpersonation)
+			r.Post("/api/auth/impersonation/stop", sessionController.StopImpersonation)
+		})
+
+		// Impersonation (signed-in admins only, tokens cannot impersonate)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+			r.Use(middleware.RequirePermission(permissions, "users:impersonate"))
+
+			r.Post("/admin/users/{id}/impersonate", sessionController.HandleImpersonate)
+			r.Post("/api/admin/users/{id}/impersonate", sessionController.Impersonate)
+		})
+
+		// Account security
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "users:write"))
+
+			r.Get("/api/admin/lockouts", adminController.ListLockouts)
+			r.Post("/api/admin/lockouts/unlock", adminController.UnlockAccount)
+		})
+
+		// User management (read)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "users:read"))
+
+			r.Get("/admin/users", userController.ShowUsers)
+			r.Get("/api/admin/users", userController.ListUsers)
+			r.Get("/api/admin/users/{id}", userController.GetUser)
+		})
+
+		// User management (write)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "users:write"))
+
+			r.Post("/admin/users", userController.HandleInvite)
+			r.Post("/admin/users/{id}/role", userController.HandleChangeRole)
+			r.Post("/admin/users/{id}/deactivate", userController.HandleDeactivate)
+			r.Post("/admin/users/{id}/reactivate", userController.HandleReactivate)
+			r.Post("/admin/users/{id}/reset-password", userController.HandleResetPassword)
+			r.Post("/admin/users/{id}/delete", userController.HandleDelete)
+
+			r.Post("/api/admin/users", userController.InviteUser)
+			r.Put("/api/admin/users/{id}/role", userController.ChangeRole)
+			r.Post("/api/admin/users/{id}/deactivate", userController.DeactivateUser)
+			r.Post("/api/admin/users/{id}/reactivate", userController.ReactivateUser)
+			r.Post("/api/admin/users/{id}/reset-password", userController.ResetPassword)
+			r.Delete("/api/admin/users/{id}", userController.DeleteUser)
+		})
+
+		// Own account and personal access tokens (signed-in users only, tokens cannot mint tokens)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+
+			r.Post("/api/auth/password", authController.ChangePassword)
+
+			r.Get("/api/auth/sessions", sessionController.ListSessions)
+			r.Delete("/api/auth/sessions/{id}", sessionController.RevokeSession)
+
+			r.Get("/api/auth/passkeys", passkeyController.ListPasskeys)
+			r.Post("/api/auth/passkeys/register/begin", passkeyController.BeginRegistration)
+			r.Post("/api/auth/passkeys/register/finish", passkeyController.FinishRegistration)
+			r.Delete("/api/auth/passkeys/{id}", passkeyController.DeletePasskey)
+
+			r.Get("/api/tokens", tokenController.ListMyTokens)
+			r.Post("/api/tokens", tokenController.CreateMyToken)
+			r.Delete("/api/tokens/{id}", tokenController.RevokeMyToken)
+		})
+
+		// Service tokens and token oversight
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequireSession)
+			r.Use(middleware.RequirePermission(permissions, "tokens:manage"))
+
+			r.Get("/api/admin/tokens", tokenController.ListTokens)
+			r.Post("/api/admin/tokens", tokenController.CreateServiceToken)
+			r.Delete("/api/admin/tokens/{id}", tokenController.RevokeToken)
+		})
+
+		// Audit log
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "audit:read"))
+
+			r.Get("/admin/audit", auditController.ShowAuditLog)
+			r.Get("/admin/audit/export", auditController.ExportCSV)
+			r.Get("/api/admin/audit", auditController.ListEntries)
+		})
+
+		// Content bundles
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "content:export"))
+
+			r.Get("/admin/content/export", contentController.ExportBundle)
+		})
+
+		// Site settings
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "settings:manage"))
+
+			r.Get("/admin/settings", settingsController.ShowSettings)
+			r.Post("/admin/settings", settingsController.HandleUpdate)
+			r.Get("/api/admin/settings", settingsController.GetSettings)
+			r.Put("/api/admin/settings", settingsController.UpdateSettings)
+			r.Post("/admin/settings/cache/purge", settingsController.HandlePurgeCache)
+			r.Get("/api/admin/cache", settingsController.GetCacheStats)
+			r.Delete("/api/admin/cache", settingsController.PurgeCache)
+		})
+
+		// Trash (each section is shown to users who may delete its items)
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "dashboard:access"))
+
+			r.Get("/admin/trash", trashController.ShowTrash)
+			r.Get("/api/admin/trash", trashController.ListTrash)
+		})
+
+		// Deleted pages
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "pages:delete"))
+
+			r.Post("/admin/trash/pages/{id}/restore", trashController.HandleRestorePage)
+			r.Post("/admin/trash/pages/{id}/purge", trashController.HandlePurgePage)
+			r.Post("/api/admin/trash/pages/{id}/restore", trashController.RestorePage)
+			r.Delete("/api/admin/trash/pages/{id}", trashController.PurgePage)
+		})
+
+		// Deleted components
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "components:delete"))
+
+			r.Post("/admin/trash/components/{id}/restore", trashController.HandleRestoreComponent)
+			r.Post("/admin/trash/components/{id}/purge", trashController.HandlePurgeComponent)
+			r.Post("/api/admin/trash/components/{id}/restore", trashController.RestoreComponent)
+			r.Delete("/api/admin/trash/components/{id}", trashController.PurgeComponent)
+		})
+
+		// Deleted media
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "media:delete"))
+
+			r.Post("/admin/trash/media/{id}/restore", trashController.HandleRestoreMedia)
+			r.Post("/admin/trash/media/{id}/purge", trashController.HandlePurgeMedia)
+			r.Post("/api/admin/trash/media/{id}/restore", trashController.RestoreMedia)
+			r.Delete("/api/admin/trash/media/{id}", trashController.PurgeMedia)
+		})
+
+		// Roles and permissions
+		r.Group(func(r chi.Router) {
+			r.Use(middleware.RequirePermission(permissions, "roles:manage"))
+
+			r.Get("/api/admin/roles", roleController.ListRoles)
+			r.Post("/api/admin/roles", roleController.CreateRole)
+			r.Put("/api/admin/roles/{id}", roleController.UpdateRole)
+			r.Delete("/api/admin/roles/{id}", roleController.DeleteRole)
+			r.Get("/api/admin/permissions", roleController.ListPermissions)
 		})
 	})
 
 	// Sitemap
-	r.Get("/sitemap.xml", func(w http.ResponseWriter, r *http.Request) {
-		http.ServeFile(w, r, "./web/static/sitemap.xml")
-	})
+	r.Get("/sitemap.xml", sitemap.ServeHTTP)
 
 	return &Router{r}
 }
@@ -8,7 +8,7 @@ package admin
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func Dashboard(userEmail string, userRole string) templ.Component {
+func Dashboard(viewer Viewer) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -29,33 +29,25 @@ func Dashboard(userEmail string, userRole string) templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Dashboard - Cacto CMS</title><link rel=\"stylesheet\" href=\"/static/css/output.css\"></head><body class=\"min-h-screen bg-gray-50\"><header class=\"bg-white border-b border-gray-200 shadow-sm\"><div class=\"container\"><div class=\"flex items-center justify-between h-16\"><h1 class=\"text-2xl font-bold text-gray-900\">Admin Dashboard</h1><div class=\"flex items-center space-x-4\"><span class=\"text-sm text-gray-600\">")
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		var templ_7745c5c3_Var2 string
-		templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(userEmail)
-		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/dashboard.templ`, Line: 18, Col: 54}
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</span> <span class=\"text-sm text-gray-500\">(")
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		var templ_7745c5c3_Var3 string
-		templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(userRole)
-		if templ_7745c5c3_Err != nil {
-			return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/dashboard.templ`, Line: 19, Col: 54}
-		}
-		_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
-		if templ_7745c5c3_Err != nil {
-			return templ_7745c5c3_Err
-		}
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, ")</span> <a href=\"/admin/logout\" class=\"px-4 py-2 bg-red-600 text-white rounded-lg hover:bg-red-700 transition-colors text-sm font-medium\">Logout</a></div></div></div></header><main class=\"container py-8\"><div class=\"card p-8 mb-8\"><h2 class=\"text-2xl font-bold text-gray-900 mb-4\">Welcome!</h2><p class=\"text-gray-700\">Welcome to Cacto CMS Admin Panel. You can manage content from here.</p></div><div class=\"grid grid-cols-1 md:grid-cols-3 gap-6\"><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Pages</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Components</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Media</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div></div></main></body></html>")
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div class=\"card p-8 mb-8\"><h2 class=\"text-2xl font-bold text-gray-900 mb-4\">Welcome!</h2><p class=\"text-gray-700\">Welcome to Cacto CMS Admin Panel. You can manage content from here.</p></div><div class=\"grid grid-cols-1 md:grid-cols-3 gap-6\"><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Pages</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Components</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div><div class=\"card p-6\"><h3 class=\"text-sm font-medium text-gray-600 mb-2\">Total Media</h3><div class=\"text-3xl font-bold text-blue-600\">-</div></div></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout("Admin Dashboard", viewer, nil).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -8,7 +8,9 @@ package admin
 import "github.com/a-h/templ"
 import templruntime "github.com/a-h/templ/runtime"
 
-func Login() templ.Component {
+import "cacto-cms/app/interfaces/templates/layouts"
+
+func Login(opts LoginOptions) templ.Component {
 	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
 		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
 		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
@@ -29,7 +31,100 @@ func Login() templ.Component {
 			templ_7745c5c3_Var1 = templ.NopComponent
 		}
 		ctx = templ.ClearChildren(ctx)
-		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Cacto CMS</title><link rel=\"stylesheet\" href=\"/static/css/output.css\"></head><body class=\"min-h-screen flex items-center justify-center bg-gradient-to-br from-blue-600 to-blue-800\"><div class=\"w-full max-w-md\"><div class=\"card p-8\"><h1 class=\"text-3xl font-bold text-gray-900 text-center mb-6\">Admin Login</h1><div id=\"error\" class=\"hidden bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-4\"></div><form id=\"loginForm\" method=\"POST\" action=\"/admin/login\" class=\"space-y-6\"><div><label for=\"email\" class=\"label\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" class=\"input\" required autofocus></div><div><label for=\"password\" class=\"label\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" class=\"input\" required></div><button type=\"submit\" class=\"btn-primary w-full\">Sign In</button></form></div></div><script>\n\t\t\t\tdocument.getElementById('loginForm').addEventListener('submit', async function(e) {\n\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\n\t\t\t\t\tconst email = document.getElementById('email').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\t\t\t\t\tconst errorDiv = document.getElementById('error');\n\t\t\t\t\t\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin/login', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ email, password })\n\t\t\t\t\t\t});\n\t\t\t\t\t\t\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\twindow.location.href = '/admin/dashboard';\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst data = await response.json();\n\t\t\t\t\t\t\terrorDiv.textContent = data.error?.message || 'Login failed';\n\t\t\t\t\t\t\terrorDiv.classList.remove('hidden');\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\terrorDiv.textContent = 'An error occurred';\n\t\t\t\t\t\terrorDiv.classList.remove('hidden');\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t</script></body></html>")
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<!doctype html><html lang=\"en\"><head><meta charset=\"UTF-8\"><meta name=\"viewport\" content=\"width=device-width, initial-scale=1.0\"><title>Admin Login - Cacto CMS</title>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = layouts.Stylesheet("css/output.css").Render(ctx, templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "</head><body class=\"min-h-screen flex items-center justify-center bg-gradient-to-br from-blue-600 to-blue-800\"><div class=\"w-full max-w-md\"><div class=\"card p-8\"><h1 class=\"text-3xl font-bold text-gray-900 text-center mb-6\">Admin Login</h1>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if opts.Error != "" {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div id=\"error\" class=\"bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-4\">")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var2 string
+			templ_7745c5c3_Var2, templ_7745c5c3_Err = templ.JoinStringErrs(opts.Error)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/login.templ`, Line: 21, Col: 113}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var2))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		} else {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, "<div id=\"error\" class=\"hidden bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-4\"></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if opts.SSOEnabled {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<a href=\"/admin/sso/login\" class=\"btn-secondary w-full block text-center\">Sign in with ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			var templ_7745c5c3_Var3 string
+			templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(opts.SSOName)
+			if templ_7745c5c3_Err != nil {
+				return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/login.templ`, Line: 27, Col: 34}
+			}
+			_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "</a> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if opts.PasskeysEnabled {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "<button type=\"button\" id=\"passkeyLogin\" class=\"btn-secondary w-full block text-center mt-3\">Sign in with a passkey</button> ")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if (opts.SSOEnabled || opts.PasskeysEnabled) && opts.PasswordEnabled {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "<div class=\"text-center text-sm text-gray-500 my-6\">or</div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if opts.PasswordEnabled {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "<form id=\"loginForm\" method=\"POST\" action=\"/admin/login\" class=\"space-y-6\"><div><label for=\"email\" class=\"label\">Email</label> <input type=\"email\" id=\"email\" name=\"email\" class=\"input\" required autofocus></div><div><label for=\"password\" class=\"label\">Password</label> <input type=\"password\" id=\"password\" name=\"password\" class=\"input\" required></div><button type=\"submit\" class=\"btn-primary w-full\">Sign In</button></form>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</div></div>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		if opts.PasskeysEnabled {
+			templ_7745c5c3_Err = passkeyScript().Render(ctx, templ_7745c5c3_Buffer)
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, " <script>\n\t\t\t\tdocument.getElementById('passkeyLogin').addEventListener('click', async function() {\n\t\t\t\t\ttry {\n\t\t\t\t\t\tawait signInWithPasskey();\n\t\t\t\t\t\twindow.location.href = '/admin/dashboard';\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\tshowPasskeyError(error);\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t</script>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		if opts.PasswordEnabled {
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "<script>\n\t\t\t\tdocument.getElementById('loginForm').addEventListener('submit', async function(e) {\n\t\t\t\t\te.preventDefault();\n\t\t\t\t\t\n\t\t\t\t\tconst email = document.getElementById('email').value;\n\t\t\t\t\tconst password = document.getElementById('password').value;\n\t\t\t\t\tconst errorDiv = document.getElementById('error');\n\t\t\t\t\t\n\t\t\t\t\ttry {\n\t\t\t\t\t\tconst response = await fetch('/admin/login', {\n\t\t\t\t\t\t\tmethod: 'POST',\n\t\t\t\t\t\t\theaders: {\n\t\t\t\t\t\t\t\t'Content-Type': 'application/json',\n\t\t\t\t\t\t\t},\n\t\t\t\t\t\t\tbody: JSON.stringify({ email, password })\n\t\t\t\t\t\t});\n\t\t\t\t\t\t\n\t\t\t\t\t\tif (response.ok) {\n\t\t\t\t\t\t\twindow.location.href = '/admin/dashboard';\n\t\t\t\t\t\t} else {\n\t\t\t\t\t\t\tconst data = await response.json();\n\t\t\t\t\t\t\terrorDiv.textContent = data.error?.message || 'Login failed';\n\t\t\t\t\t\t\terrorDiv.classList.remove('hidden');\n\t\t\t\t\t\t}\n\t\t\t\t\t} catch (error) {\n\t\t\t\t\t\terrorDiv.textContent = 'An error occurred';\n\t\t\t\t\t\terrorDiv.classList.remove('hidden');\n\t\t\t\t\t}\n\t\t\t\t});\n\t\t\t</script>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+		}
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "</body></html>")
 		if templ_7745c5c3_Err != nil {
 			return templ_7745c5c3_Err
 		}
@@ -0,0 +1,218 @@
+// Code generated by templ - DO NOT EDIT.
+
+// templ: version: v0.3.977
+package admin
+
+//lint:file-ignore SA4006 This context is only used if a nested component is present.
+
+import "github.com/a-h/templ"
+import templruntime "github.com/a-h/templ/runtime"
+
+import (
+	"fmt"
+
+	"cacto-cms/app/domain/user"
+)
+
+func Passkeys(viewer Viewer, passkeys []*user.Passkey, enabled bool, flash *Flash) templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var1 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var1 == nil {
+			templ_7745c5c3_Var1 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Var2 := templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+			templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+			templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+			if !templ_7745c5c3_IsBuffer {
+				defer func() {
+					templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+					if templ_7745c5c3_Err == nil {
+						templ_7745c5c3_Err = templ_7745c5c3_BufErr
+					}
+				}()
+			}
+			ctx = templ.InitializeContext(ctx)
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 1, "<div id=\"passkey-error\" class=\"hidden bg-red-50 border border-red-200 text-red-700 px-4 py-3 rounded-lg mb-6\"></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if !enabled {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 2, "<div class=\"card p-6 mb-8 text-gray-700\">Passkeys are not enabled on this site.</div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else if viewer.IsImpersonated() {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 3, "<div class=\"card p-6 mb-8 text-gray-700\">Passkeys cannot be changed while impersonating a user.</div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			} else {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 4, "<div class=\"card p-6 mb-8\"><h2 class=\"text-xl font-bold text-gray-900 mb-4\">Add a passkey</h2><form id=\"passkeyForm\" class=\"flex items-end space-x-4\"><div class=\"flex-1\"><label for=\"passkey-name\" class=\"label\">Name</label> <input type=\"text\" id=\"passkey-name\" name=\"name\" class=\"input\" maxlength=\"100\" placeholder=\"e.g. MacBook Touch ID\"></div><button type=\"submit\" class=\"btn-primary\">Add passkey</button></form><p class=\"text-sm text-gray-500 mt-3\">Sign in with your fingerprint, face or security key instead of your password. Your password keeps working.</p></div>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 5, " <div class=\"card overflow-x-auto\"><table class=\"min-w-full text-sm\"><thead class=\"bg-gray-100 text-left text-gray-600\"><tr><th class=\"px-4 py-3\">Name</th><th class=\"px-4 py-3\">Type</th><th class=\"px-4 py-3\">Added</th><th class=\"px-4 py-3\">Last used</th><th class=\"px-4 py-3\"></th></tr></thead> <tbody>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if len(passkeys) == 0 {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 6, "<tr class=\"border-t border-gray-200\"><td colspan=\"5\" class=\"px-4 py-3 text-gray-500\">No passkeys yet.</td></tr>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			for _, p := range passkeys {
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 7, "<tr class=\"border-t border-gray-200\"><td class=\"px-4 py-3 font-medium text-gray-900\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var3 string
+				templ_7745c5c3_Var3, templ_7745c5c3_Err = templ.JoinStringErrs(p.Name)
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/passkeys.templ`, Line: 48, Col: 63}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var3))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 8, "</td><td class=\"px-4 py-3 text-gray-700\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if p.BackupEligible {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 9, "Synced passkey")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				} else {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 10, "Device-bound")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 11, "</td><td class=\"px-4 py-3 text-gray-700\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var4 string
+				templ_7745c5c3_Var4, templ_7745c5c3_Err = templ.JoinStringErrs(formatTime(&p.CreatedAt))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/passkeys.templ`, Line: 56, Col: 69}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var4))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 12, "</td><td class=\"px-4 py-3 text-gray-700\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				var templ_7745c5c3_Var5 string
+				templ_7745c5c3_Var5, templ_7745c5c3_Err = templ.JoinStringErrs(formatTime(p.LastUsedAt))
+				if templ_7745c5c3_Err != nil {
+					return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/passkeys.templ`, Line: 57, Col: 69}
+				}
+				_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var5))
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 13, "</td><td class=\"px-4 py-3\">")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				if !viewer.IsImpersonated() {
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 14, "<form method=\"POST\" action=\"")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+					var templ_7745c5c3_Var6 templ.SafeURL
+					templ_7745c5c3_Var6, templ_7745c5c3_Err = templ.JoinURLErrs(templ.URL(fmt.Sprintf("/admin/passkeys/%d/delete", p.ID)))
+					if templ_7745c5c3_Err != nil {
+						return templ.Error{Err: templ_7745c5c3_Err, FileName: `app/interfaces/templates/admin/passkeys.templ`, Line: 60, Col: 95}
+					}
+					_, templ_7745c5c3_Err = templ_7745c5c3_Buffer.WriteString(templ.EscapeString(templ_7745c5c3_Var6))
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+					templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 15, "\" onsubmit=\"return confirm('Delete this passkey?')\"><button type=\"submit\" class=\"text-red-600 hover:underline\">Delete</button></form>")
+					if templ_7745c5c3_Err != nil {
+						return templ_7745c5c3_Err
+					}
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 16, "</td></tr>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 17, "</tbody></table></div>")
+			if templ_7745c5c3_Err != nil {
+				return templ_7745c5c3_Err
+			}
+			if enabled && !viewer.IsImpersonated() {
+				templ_7745c5c3_Err = passkeyScript().Render(ctx, templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+				templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 18, " <script>\n\t\t\tdocument.getElementById('passkeyForm').addEventListener('submit', async function(e) {\n\t\t\t\te.preventDefault();\n\t\t\t\ttry {\n\t\t\t\t\tawait registerPasskey(document.getElementById('passkey-name').value);\n\t\t\t\t\twindow.location.reload();\n\t\t\t\t} catch (error) {\n\t\t\t\t\tshowPasskeyError(error);\n\t\t\t\t}\n\t\t\t});\n\t\t</script>")
+				if templ_7745c5c3_Err != nil {
+					return templ_7745c5c3_Err
+				}
+			}
+			return nil
+		})
+		templ_7745c5c3_Err = Layout("Passkeys", viewer, flash).Render(templ.WithChildren(ctx, templ_7745c5c3_Var2), templ_7745c5c3_Buffer)
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+// passkeyScript defines the browser side of the WebAuthn ceremonies.
+// Binary values travel as base64url strings in JSON.
+func passkeyScript() templ.Component {
+	return templruntime.GeneratedTemplate(func(templ_7745c5c3_Input templruntime.GeneratedComponentInput) (templ_7745c5c3_Err error) {
+		templ_7745c5c3_W, ctx := templ_7745c5c3_Input.Writer, templ_7745c5c3_Input.Context
+		if templ_7745c5c3_CtxErr := ctx.Err(); templ_7745c5c3_CtxErr != nil {
+			return templ_7745c5c3_CtxErr
+		}
+		templ_7745c5c3_Buffer, templ_7745c5c3_IsBuffer := templruntime.GetBuffer(templ_7745c5c3_W)
+		if !templ_7745c5c3_IsBuffer {
+			defer func() {
+				templ_7745c5c3_BufErr := templruntime.ReleaseBuffer(templ_7745c5c3_Buffer)
+				if templ_7745c5c3_Err == nil {
+					templ_7745c5c3_Err = templ_7745c5c3_BufErr
+				}
+			}()
+		}
+		ctx = templ.InitializeContext(ctx)
+		templ_7745c5c3_Var7 := templ.GetChildren(ctx)
+		if templ_7745c5c3_Var7 == nil {
+			templ_7745c5c3_Var7 = templ.NopComponent
+		}
+		ctx = templ.ClearChildren(ctx)
+		templ_7745c5c3_Err = templruntime.WriteString(templ_7745c5c3_Buffer, 19, "<script>\n\tconst b64u = {\n\t\tdecode: s => Uint8Array.from(atob(s.replace(/-/g, '+').replace(/_/g, '/') + '==='.slice((s.length + 3) % 4)), c => c.charCodeAt(0)),\n\t\tencode: b => btoa(String.fromCharCode(...new Uint8Array(b))).replace(/\\+/g, '-').replace(/\\//g, '_').replace(/=+$/, ''),\n\t};\n\n\tasync function passkeyRequest(url, body) {\n\t\tconst response = await fetch(url, {\n\t\t\tmethod: 'POST',\n\t\t\theaders: { 'Accept': 'application/json', 'Content-Type': 'application/json' },\n\t\t\tbody: JSON.stringify(body || {})\n\t\t});\n\t\tconst data = await response.json();\n\t\tif (!response.ok) {\n\t\t\tthrow new Error(data.error?.message || 'Request failed');\n\t\t}\n\t\treturn data;\n\t}\n\n\tasync function registerPasskey(name) {\n\t\tconst options = await passkeyRequest('/api/auth/passkeys/register/begin');\n\t\toptions.challenge = b64u.decode(options.challenge);\n\t\toptions.user.id = b64u.decode(options.user.id);\n\t\toptions.excludeCredentials = options.excludeCredentials.map(c => ({ ...c, id: b64u.decode(c.id) }));\n\n\t\tconst credential = await navigator.credentials.create({ publicKey: options });\n\t\treturn passkeyRequest('/api/auth/passkeys/register/finish', {\n\t\t\tname: name,\n\t\t\tcredential: {\n\t\t\t\tid: credential.id,\n\t\t\t\trawId: b64u.encode(credential.rawId),\n\t\t\t\ttype: credential.type,\n\t\t\t\tresponse: {\n\t\t\t\t\tclientDataJSON: b64u.encode(credential.response.clientDataJSON),\n\t\t\t\t\tattestationObject: b64u.encode(credential.response.attestationObject),\n\t\t\t\t\ttransports: credential.response.getTransports ? credential.response.getTransports() : []\n\t\t\t\t}\n\t\t\t}\n\t\t});\n\t}\n\n\tasync function signInWithPasskey() {\n\t\tconst options = await passkeyRequest('/api/auth/passkeys/login/begin');\n\t\toptions.challenge = b64u.decode(options.challenge);\n\t\toptions.allowCredentials = options.allowCredentials.map(c => ({ ...c, id: b64u.decode(c.id) }));\n\n\t\tconst credential = await navigator.credentials.get({ publicKey: options });\n\t\treturn passkeyRequest('/api/auth/passkeys/login/finish', {\n\t\t\tid: credential.id,\n\t\t\trawId: b64u.encode(credential.rawId),\n\t\t\ttype: credential.type,\n\t\t\tresponse: {\n\t\t\t\tclientDataJSON: b64u.encode(credential.response.clientDataJSON),\n\t\t\t\tauthenticatorData: b64u.encode(credential.response.authenticatorData),\n\t\t\t\tsignature: b64u.encode(credential.response.signature),\n\t\t\t\tuserHandle: credential.response.userHandle ? b64u.encode(credential.response.userHandle) : ''\n\t\t\t}\n\t\t});\n\t}\n\n\tfunction showPasskeyError(error) {\n\t\tconst errorDiv = document.getElementById('passkey-error') || document.getElementById('error');\n\t\terrorDiv.textContent = error.name === 'NotAllowedError' ? 'Passkey request was cancelled' : error.message;\n\t\terrorDiv.classList.remove('hidden');\n\t}\n</script>")
+		if templ_7745c5c3_Err != nil {
+			return templ_7745c5c3_Err
+		}
+		return nil
+	})
+}
+
+var _ = templruntime.GeneratedTemplate
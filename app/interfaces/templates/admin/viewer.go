@@ -0,0 +1,148 @@
+package admin
+
+import (
+	"net/url"
+	"strconv"
+	"time"
+
+	"cacto-cms/app/domain/audit"
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/media"
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/domain/role"
+)
+
+// Viewer describes the signed-in user rendering an admin screen
+type Viewer struct {
+	UserID      int
+	Email       string
+	Role        string
+	Permissions []string
+
+	// Set while an admin is impersonating the viewer
+	ImpersonatorEmail string
+	ImpersonationEnds time.Time
+}
+
+// IsImpersonated checks if an admin is acting as the viewer
+func (v Viewer) IsImpersonated() bool {
+	return v.ImpersonatorEmail != ""
+}
+
+// Can checks if the viewer's role grants a permission
+func (v Viewer) Can(permission string) bool {
+	return role.Grants(v.Permissions, permission)
+}
+
+// Flash is a one-off message shown at the top of an admin screen
+type Flash struct {
+	Message string
+	IsError bool
+}
+
+// formatTime formats an optional timestamp for admin tables
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "Never"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// LoginOptions configures the login page
+type LoginOptions struct {
+	PasswordEnabled bool
+	SSOEnabled      bool
+	SSOName         string
+	PasskeysEnabled bool
+	Error           string
+}
+
+// SettingsForm holds the site settings as shown in the settings form
+type SettingsForm struct {
+	SiteName        string
+	SiteDescription string
+	SitemapEnabled  bool
+
+	SitemapLastGenerated time.Time // Zero if never generated
+}
+
+// TrashView holds the deleted pages, components and media the viewer may
+// restore
+type TrashView struct {
+	Pages      []*page.Page
+	Components []*component.Component
+	Media      []*media.Media
+	Retention  time.Duration
+
+	ShowPages      bool // The viewer can delete pages
+	ShowComponents bool // The viewer can delete components
+	ShowMedia      bool // The viewer can delete media
+}
+
+// PurgeAt returns when an item deleted at a time is purged for good
+func (t TrashView) PurgeAt(deletedAt *time.Time) *time.Time {
+	if deletedAt == nil {
+		return nil
+	}
+	at := deletedAt.Add(t.Retention)
+	return &at
+}
+
+// AuditQuery holds the audit log filters as entered in the filter form
+type AuditQuery struct {
+	Actor      string
+	Action     string
+	TargetType string
+	TargetID   string
+	From       string // YYYY-MM-DD
+	To         string // YYYY-MM-DD, inclusive
+	Page       int
+	Pages      int
+	Total      int
+}
+
+// Values encodes the filters (without the page) as URL query values
+func (q AuditQuery) Values() url.Values {
+	v := url.Values{}
+	for key, value := range map[string]string{
+		"actor":       q.Actor,
+		"action":      q.Action,
+		"target_type": q.TargetType,
+		"target_id":   q.TargetID,
+		"from":        q.From,
+		"to":          q.To,
+	} {
+		if value != "" {
+			v.Set(key, value)
+		}
+	}
+	return v
+}
+
+// PageURL returns the audit screen URL for another page with the same filters
+func (q AuditQuery) PageURL(page int) string {
+	v := q.Values()
+	v.Set("page", strconv.Itoa(page))
+	return "/admin/audit?" + v.Encode()
+}
+
+// ExportURL returns the CSV export URL for the current filters
+func (q AuditQuery) ExportURL() string {
+	return "/admin/audit/export?" + q.Values().Encode()
+}
+
+// actorLabel describes who performed an audited action
+func actorLabel(e *audit.Entry) string {
+	switch {
+	case e.ImpersonatorEmail != "":
+		return e.ImpersonatorEmail + " as " + e.ActorEmail
+	case e.ActorEmail != "":
+		return e.ActorEmail
+	case e.ActorID != nil:
+		return "user #" + strconv.Itoa(*e.ActorID)
+	case e.TokenID != nil:
+		return "service token"
+	default:
+		return "system"
+	}
+}
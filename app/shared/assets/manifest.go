@@ -0,0 +1,228 @@
+// Package assets fingerprints the files under web/static. Build copies each
+// file to web/static/assets under a name carrying a hash of its content and
+// writes a manifest mapping logical names ("css/output.css") to the copies
+// and their Subresource Integrity hashes. Templates resolve logical names
+// through the manifest, so browsers can cache the copies forever: a changed
+// file gets a new URL.
+package assets
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Dir holds the fingerprinted copies, inside the static directory
+const Dir = "assets"
+
+// ManifestFile is the name of the manifest inside Dir
+const ManifestFile = "manifest.json"
+
+// Asset is the fingerprinted copy of a file
+type Asset struct {
+	Path      string `json:"path"`      // Relative to the static directory, e.g. assets/css/output.3f2a9c1b.css
+	Integrity string `json:"integrity"` // Subresource Integrity hash of the content
+}
+
+// Manifest maps logical names, relative to the static directory, to assets
+type Manifest struct {
+	Assets map[string]Asset `json:"assets"`
+}
+
+// BuildResult reports what Build changed
+type BuildResult struct {
+	Assets  int `json:"assets"`  // Files in the manifest
+	Written int `json:"written"` // Copies written; unchanged files keep theirs
+	Removed int `json:"removed"` // Copies no longer in this or the previous manifest
+}
+
+// Vendored are the third-party scripts the layouts load, copied from
+// node_modules by `make vendor` at the versions pinned in package.json
+var Vendored = []string{"vendor/htmx.min.js", "vendor/alpine.min.js"}
+
+// CheckVendored returns an error naming the vendored scripts missing from
+// staticDir: pages linking them would load without HTMX and Alpine.js
+func CheckVendored(staticDir string) error {
+	var missing []string
+	for _, name := range Vendored {
+		if info, err := os.Stat(filepath.Join(staticDir, filepath.FromSlash(name))); err != nil || !info.Mode().IsRegular() {
+			missing = append(missing, name)
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing vendored scripts in %s: %s (run `make vendor`)", staticDir, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// skipped are top-level files of the static directory that aren't
+// fingerprinted: the sitemap is regenerated while the server runs
+var skipped = map[string]bool{"sitemap.xml": true}
+
+// Build fingerprints the files under staticDir and writes the manifest.
+// Copies of the previous build are kept, so pages rendered before a deploy
+// still find theirs; older ones are removed. The vendored scripts must be
+// present, so the manifest always covers them.
+func Build(staticDir string) (*BuildResult, error) {
+	if err := CheckVendored(staticDir); err != nil {
+		return nil, err
+	}
+	result := &BuildResult{}
+	outDir := filepath.Join(staticDir, Dir)
+	previous, _ := ReadManifest(staticDir)
+	next := &Manifest{Assets: make(map[string]Asset)}
+
+	err := filepath.WalkDir(staticDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(staticDir, name)
+		if err != nil || rel == "." {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if d.IsDir() {
+			if rel == Dir || strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || skipped[rel] || strings.HasPrefix(d.Name(), ".") || isSibling(rel) {
+			return nil
+		}
+
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		asset := Asset{Path: path.Join(Dir, fingerprint(rel, data)), Integrity: integrity(data)}
+		next.Assets[rel] = asset
+
+		target := filepath.Join(staticDir, filepath.FromSlash(asset.Path))
+		if _, err := os.Stat(target); err == nil {
+			return nil // The name carries the hash: an existing copy is identical
+		}
+		if err := writeFile(target, data); err != nil {
+			return err
+		}
+		result.Written++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	result.Assets = len(next.Assets)
+
+	keep := make(map[string]bool)
+	for _, m := range []*Manifest{previous, next} {
+		if m == nil {
+			continue
+		}
+		for _, asset := range m.Assets {
+			keep[asset.Path] = true
+		}
+	}
+	if result.Removed, err = removeStale(staticDir, outDir, keep); err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := writeFile(filepath.Join(outDir, ManifestFile), data); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// ReadManifest reads the manifest of a static directory
+func ReadManifest(staticDir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(staticDir, Dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// removeStale deletes the copies in outDir that aren't kept, with their
+// precompressed siblings
+func removeStale(staticDir, outDir string, keep map[string]bool) (int, error) {
+	removed := 0
+	err := filepath.WalkDir(outDir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) && name == outDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(staticDir, name)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == path.Join(Dir, ManifestFile) || keep[strings.TrimSuffix(strings.TrimSuffix(rel, ".br"), ".gz")] {
+			return nil
+		}
+		if err := os.Remove(name); err != nil {
+			return err
+		}
+		if !isSibling(rel) {
+			removed++
+		}
+		return nil
+	})
+	return removed, err
+}
+
+// fingerprint inserts a hash of the content before the extension:
+// css/output.css becomes css/output.3f2a9c1b.css
+func fingerprint(name string, data []byte) string {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:4])
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// integrity returns the Subresource Integrity hash of content
+func integrity(data []byte) string {
+	sum := sha512.Sum384(data)
+	return "sha384-" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// isSibling reports whether a file is a precompressed copy of another
+func isSibling(name string) bool {
+	return strings.HasSuffix(name, ".br") || strings.HasSuffix(name, ".gz")
+}
+
+// writeFile writes a file under a temporary name and renames it, so the
+// server never serves it half-written
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0755); err != nil {
+		return err
+	}
+	tmp := name + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, name); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
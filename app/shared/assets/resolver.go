@@ -0,0 +1,181 @@
+package assets
+
+import (
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// URLPrefix is where the static directory is served
+const URLPrefix = "/static/"
+
+// checkInterval is how often a resolver looks for a new manifest
+const checkInterval = time.Second
+
+// Resolver resolves logical asset names through the manifest of a static
+// directory. The manifest is read again when a build replaces it, so a
+// running server picks up new assets. Without a manifest, names resolve to
+// the files themselves, e.g. in development, and their integrity is hashed
+// from the file.
+type Resolver struct {
+	staticDir string
+
+	mu       sync.Mutex
+	manifest *Manifest
+	modTime  time.Time
+	checked  time.Time
+	files    map[string]hashedFile // Integrity of files outside the manifest
+}
+
+// hashedFile is the integrity of a file, valid while it is unchanged
+type hashedFile struct {
+	modTime   time.Time
+	size      int64
+	integrity string
+}
+
+// NewResolver creates a resolver for a static directory
+func NewResolver(staticDir string) *Resolver {
+	return &Resolver{staticDir: staticDir}
+}
+
+// Default resolves the assets of ./web/static, for templates
+var Default = NewResolver("./web/static")
+
+// URL returns the URL of an asset through the default resolver
+func URL(name string) string {
+	return Default.URL(name)
+}
+
+// Integrity returns the Subresource Integrity hash of an asset through the
+// default resolver
+func Integrity(name string) string {
+	return Default.Integrity(name)
+}
+
+// URL returns the fingerprinted URL of an asset, or the URL of the file
+// itself when the manifest doesn't list it
+func (r *Resolver) URL(name string) string {
+	if asset, ok := r.lookup(name); ok {
+		return URLPrefix + asset.Path
+	}
+	return URLPrefix + name
+}
+
+// Integrity returns the Subresource Integrity hash of an asset. Files the
+// manifest doesn't list are hashed as they are; "" means no such file.
+func (r *Resolver) Integrity(name string) string {
+	if asset, ok := r.lookup(name); ok {
+		return asset.Integrity
+	}
+	return r.hashFile(name)
+}
+
+// lookup finds an asset, reading the manifest again if it changed
+func (r *Resolver) lookup(name string) (Asset, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if now := time.Now(); now.Sub(r.checked) >= checkInterval {
+		r.checked = now
+		r.reload()
+	}
+	if r.manifest == nil {
+		return Asset{}, false
+	}
+	asset, ok := r.manifest.Assets[name]
+	return asset, ok
+}
+
+// hashFile returns the integrity of a file of the static directory, hashing
+// it again only when it changed
+func (r *Resolver) hashFile(name string) string {
+	file := filepath.Join(r.staticDir, filepath.FromSlash(path.Clean("/"+name)))
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if cached, ok := r.files[name]; ok && cached.modTime.Equal(info.ModTime()) && cached.size == info.Size() {
+		return cached.integrity
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	if r.files == nil {
+		r.files = make(map[string]hashedFile)
+	}
+	hashed := hashedFile{modTime: info.ModTime(), size: info.Size(), integrity: integrity(data)}
+	r.files[name] = hashed
+	return hashed.integrity
+}
+
+// reload reads the manifest if its modification time changed. A manifest
+// that can't be read leaves the previous one in use.
+func (r *Resolver) reload() {
+	info, err := os.Stat(filepath.Join(r.staticDir, Dir, ManifestFile))
+	if err != nil {
+		if os.IsNotExist(err) {
+			r.manifest, r.modTime = nil, time.Time{}
+		}
+		return
+	}
+	if info.ModTime().Equal(r.modTime) {
+		return
+	}
+	if m, err := ReadManifest(r.staticDir); err == nil {
+		r.manifest, r.modTime = m, info.ModTime()
+	}
+}
+
+// Immutable marks the fingerprinted copies served by next as cacheable
+// forever: their content never changes under the same URL. next serves the
+// static directory with URLPrefix stripped.
+func Immutable(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		if !strings.HasPrefix(name, "/"+Dir+"/") || path.Base(name) == ManifestFile {
+			next.ServeHTTP(w, r)
+			return
+		}
+		next.ServeHTTP(&immutableWriter{ResponseWriter: w}, r)
+	})
+}
+
+// immutableWriter adds the Cache-Control header to successful responses only,
+// so a missing copy isn't cached
+type immutableWriter struct {
+	http.ResponseWriter
+	wroteHeader bool
+}
+
+// WriteHeader sets Cache-Control for 2xx and 304 responses
+func (w *immutableWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if (code >= 200 && code < 300) || code == http.StatusNotModified {
+			w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write sends the header first if the handler didn't
+func (w *immutableWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Unwrap gives http.ResponseController the underlying writer
+func (w *immutableWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
@@ -0,0 +1,48 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"strings"
+)
+
+// APITokenPrefix marks bearer tokens that are API tokens rather than JWTs
+const APITokenPrefix = "cacto_"
+
+// apiTokenDisplayLength is the number of leading characters kept for identification
+const apiTokenDisplayLength = 14
+
+// TokenPrincipal describes who is acting through a validated API token
+type TokenPrincipal struct {
+	TokenID int
+	UserID  int    // Zero for service tokens
+	Email   string // Empty for service tokens
+	Role    string // Empty for service tokens
+	Scopes  []string
+}
+
+// GenerateAPIToken generates a new API token for the given kind ("pat" or "svc").
+// It returns the plaintext (shown once), a display prefix and the hash to store.
+func GenerateAPIToken(kind string) (plaintext, prefix, hash string, err error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", "", "", err
+	}
+
+	plaintext = APITokenPrefix + kind + "_" + base64.RawURLEncoding.EncodeToString(bytes)
+	return plaintext, plaintext[:apiTokenDisplayLength], HashAPIToken(plaintext), nil
+}
+
+// HashAPIToken hashes an API token for storage and lookup. Tokens are
+// high-entropy random values, so a fast hash is sufficient.
+func HashAPIToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// IsAPIToken checks if a bearer token looks like an API token
+func IsAPIToken(token string) bool {
+	return strings.HasPrefix(token, APITokenPrefix)
+}
@@ -20,14 +20,24 @@ type JWTManager struct {
 	tokenDuration time.Duration
 }
 
-// Claims represents JWT claims
+// Claims represents JWT claims. Every token belongs to a server-side
+// session (SessionID) so it can be listed and revoked. Impersonation
+// tokens also name the admin acting as the user.
 type Claims struct {
-	UserID int    `json:"user_id"`
-	Email  string `json:"email"`
-	Role   string `json:"role"`
+	UserID            int    `json:"user_id"`
+	Email             string `json:"email"`
+	Role              string `json:"role"`
+	SessionID         string `json:"sid,omitempty"`
+	ImpersonatorID    int    `json:"imp_id,omitempty"`
+	ImpersonatorEmail string `json:"imp_email,omitempty"`
 	jwt.RegisteredClaims
 }
 
+// IsImpersonation checks if the token was issued to an admin acting as another user
+func (c *Claims) IsImpersonation() bool {
+	return c.ImpersonatorID > 0
+}
+
 // NewJWTManager creates a new JWT manager
 func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 	if secretKey == "" {
@@ -40,20 +50,27 @@ func NewJWTManager(secretKey string, tokenDuration time.Duration) *JWTManager {
 	}
 }
 
-// GenerateToken generates a JWT token for a user
-func (m *JWTManager) GenerateToken(userID int, email, role string) (string, error) {
-	claims := &Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
-		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(m.tokenDuration)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
-			NotBefore: jwt.NewNumericDate(time.Now()),
-		},
+// TokenDuration returns how long generated tokens are valid by default
+func (m *JWTManager) TokenDuration() time.Duration {
+	return m.tokenDuration
+}
+
+// GenerateToken generates a JWT token for the given claims, valid until expiresAt
+// (the default token duration when zero)
+func (m *JWTManager) GenerateToken(claims Claims, expiresAt time.Time) (string, error) {
+	now := time.Now()
+	if expiresAt.IsZero() {
+		expiresAt = now.Add(m.tokenDuration)
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        claims.SessionID,
+		ExpiresAt: jwt.NewNumericDate(expiresAt),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, &claims)
 	return token.SignedString(m.secretKey)
 }
 
@@ -83,6 +100,15 @@ func (m *JWTManager) ValidateToken(tokenString string) (*Claims, error) {
 	return claims, nil
 }
 
+// GenerateSessionID returns a random, URL-safe session identifier
+func GenerateSessionID() (string, error) {
+	bytes := make([]byte, 24)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
+}
+
 // generateSecretKey generates a random secret key
 func generateSecretKey() string {
 	bytes := make([]byte, 32)
@@ -25,14 +25,40 @@ type PasswordHasher struct {
 	keyLength   uint32
 }
 
+// Argon2Params holds Argon2id cost parameters
+type Argon2Params struct {
+	Memory      uint32 // in KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultArgon2Params returns the current default parameters. Raising them
+// upgrades existing hashes the next time their users sign in.
+func DefaultArgon2Params() Argon2Params {
+	return Argon2Params{
+		Memory:      64 * 1024, // 64 MB
+		Iterations:  3,
+		Parallelism: 2,
+		SaltLength:  16,
+		KeyLength:   32,
+	}
+}
+
 // NewPasswordHasher creates a new password hasher with default settings
 func NewPasswordHasher() *PasswordHasher {
+	return NewPasswordHasherWithParams(DefaultArgon2Params())
+}
+
+// NewPasswordHasherWithParams creates a new password hasher with custom settings
+func NewPasswordHasherWithParams(p Argon2Params) *PasswordHasher {
 	return &PasswordHasher{
-		memory:      64 * 1024, // 64 MB
-		iterations:  3,
-		parallelism: 2,
-		saltLength:  16,
-		keyLength:   32,
+		memory:      p.Memory,
+		iterations:  p.Iterations,
+		parallelism: p.Parallelism,
+		saltLength:  p.SaltLength,
+		keyLength:   p.KeyLength,
 	}
 }
 
@@ -58,49 +84,85 @@ func (h *PasswordHasher) HashPassword(password string) (string, error) {
 
 // VerifyPassword verifies a password against a hash
 func (h *PasswordHasher) VerifyPassword(password, encodedHash string) (bool, error) {
+	params, salt, hash, err := decodeHash(encodedHash)
+	if err != nil {
+		return false, err
+	}
+
+	// Compute hash of provided password
+	otherHash := argon2.IDKey([]byte(password), salt, params.Iterations, params.Memory, params.Parallelism, params.KeyLength)
+
+	// Compare hashes
+	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// NeedsRehash checks if a hash was created with weaker parameters than the
+// current ones (or cannot be parsed) and should be replaced
+func (h *PasswordHasher) NeedsRehash(encodedHash string) bool {
+	params, _, _, err := decodeHash(encodedHash)
+	if err != nil {
+		return true
+	}
+
+	return params.Memory < h.memory ||
+		params.Iterations < h.iterations ||
+		params.Parallelism < h.parallelism ||
+		params.SaltLength < h.saltLength ||
+		params.KeyLength < h.keyLength
+}
+
+// decodeHash extracts the parameters, salt and key from an encoded hash
+func decodeHash(encodedHash string) (Argon2Params, []byte, []byte, error) {
+	var params Argon2Params
+
 	// Extract parts from encoded hash
 	parts := strings.Split(encodedHash, "$")
 	if len(parts) != 6 {
-		return false, ErrInvalidHash
+		return params, nil, nil, ErrInvalidHash
 	}
 
 	if parts[1] != "argon2id" {
-		return false, ErrInvalidHash
+		return params, nil, nil, ErrInvalidHash
 	}
 
 	var version int
 	_, err := fmt.Sscanf(parts[2], "v=%d", &version)
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 	if version != argon2.Version {
-		return false, ErrIncompatibleVersion
+		return params, nil, nil, ErrIncompatibleVersion
 	}
 
-	var memory, iterations uint32
-	var parallelism uint8
-	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &memory, &iterations, &parallelism)
+	_, err = fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.Memory, &params.Iterations, &params.Parallelism)
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
 	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
 	hash, err := base64.RawStdEncoding.DecodeString(parts[5])
 	if err != nil {
-		return false, err
+		return params, nil, nil, err
 	}
 
-	// Compute hash of provided password
-	otherHash := argon2.IDKey([]byte(password), salt, iterations, memory, parallelism, uint32(len(hash)))
+	params.SaltLength = uint32(len(salt))
+	params.KeyLength = uint32(len(hash))
+	return params, salt, hash, nil
+}
 
-	// Compare hashes
-	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
-		return true, nil
+// GenerateTemporaryPassword generates a random password for invites and resets
+func GenerateTemporaryPassword() (string, error) {
+	bytes := make([]byte, 12)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
 	}
-
-	return false, nil
+	return base64.RawURLEncoding.EncodeToString(bytes), nil
 }
@@ -0,0 +1,98 @@
+package auth
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+//go:embed common_passwords.txt
+var commonPasswords string
+
+// PasswordPolicy defines the rules new passwords must satisfy
+type PasswordPolicy struct {
+	MinLength   int  // Minimum number of characters
+	MaxLength   int  // Upper bound, keeps hashing cost predictable
+	CheckCommon bool // Reject passwords on the common/breached list
+	HistorySize int  // Number of previous passwords that cannot be reused (0 disables)
+
+	blocklist map[string]struct{}
+}
+
+// DefaultPasswordPolicy returns the default policy with the bundled common password list
+func DefaultPasswordPolicy() *PasswordPolicy {
+	p := &PasswordPolicy{
+		MinLength:   10,
+		MaxLength:   128,
+		CheckCommon: true,
+		HistorySize: 5,
+		blocklist:   make(map[string]struct{}),
+	}
+	p.addBlocklist(strings.NewReader(commonPasswords))
+	return p
+}
+
+// LoadBlocklist adds passwords from a file (one per line, '#' starts a comment),
+// e.g. a larger breached password list
+func (p *PasswordPolicy) LoadBlocklist(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return p.addBlocklist(f)
+}
+
+// addBlocklist adds passwords from a reader
+func (p *PasswordPolicy) addBlocklist(r io.Reader) error {
+	if p.blocklist == nil {
+		p.blocklist = make(map[string]struct{})
+	}
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		p.blocklist[strings.ToLower(line)] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+// Validate checks a new password against the policy. Personal values such
+// as the email address and name cannot be used as the password.
+func (p *PasswordPolicy) Validate(password string, personal ...string) error {
+	length := utf8.RuneCountInString(password)
+	if length < p.MinLength {
+		return fmt.Errorf("password must be at least %d characters", p.MinLength)
+	}
+	if p.MaxLength > 0 && length > p.MaxLength {
+		return fmt.Errorf("password must be at most %d characters", p.MaxLength)
+	}
+
+	lower := strings.ToLower(password)
+	if p.CheckCommon {
+		if _, found := p.blocklist[lower]; found {
+			return fmt.Errorf("password is too common, choose a less predictable one")
+		}
+	}
+
+	for _, value := range personal {
+		value = strings.ToLower(strings.TrimSpace(value))
+		if value == "" {
+			continue
+		}
+		local, _, _ := strings.Cut(value, "@")
+		if lower == value || lower == local {
+			return fmt.Errorf("password must not be your email address or name")
+		}
+	}
+
+	return nil
+}
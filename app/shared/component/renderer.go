@@ -48,14 +48,13 @@ func (r *Renderer) Render(c *Component) (templ.Component, error) {
 	return renderFunc(c)
 }
 
-// RenderMultiple renders multiple components in order
+// RenderMultiple renders multiple components in order, as given: callers
+// merge the defaults first (see page.Placement.Rendered), so fields a page
+// blanked stay blank
 func (r *Renderer) RenderMultiple(components []*Component) ([]templ.Component, error) {
 	rendered := make([]templ.Component, 0, len(components))
 	
 	for _, c := range components {
-		// Merge with defaults before rendering
-		c.MergeWithDefaults()
-		
 		comp, err := r.Render(c)
 		if err != nil {
 			return nil, fmt.Errorf("failed to render component %s: %w", c.Name, err)
@@ -0,0 +1,162 @@
+// Package compress negotiates response compression. Dynamic responses are
+// compressed on the fly by the Compress middleware; static files are served
+// from .br and .gz siblings written ahead of time by Precompress.
+package compress
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Supported encodings, in order of preference
+const (
+	Brotli = "br"
+	Gzip   = "gzip"
+)
+
+// extensions are the file name suffixes of precompressed siblings
+var extensions = map[string]string{
+	Brotli: ".br",
+	Gzip:   ".gz",
+}
+
+// Accepted lists the supported encodings an Accept-Encoding header allows,
+// best first: by quality, then Brotli before gzip
+func Accepted(header string) []string {
+	quality := make(map[string]float64)
+	for _, part := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		q := 1.0
+		if key, value, ok := strings.Cut(strings.TrimSpace(params), "="); ok && strings.TrimSpace(key) == "q" {
+			if parsed, err := strconv.ParseFloat(strings.TrimSpace(value), 64); err == nil {
+				q = parsed
+			}
+		}
+		quality[name] = q
+	}
+
+	var accepted []string
+	for _, encoding := range []string{Brotli, Gzip} {
+		q, ok := quality[encoding]
+		if !ok {
+			q, ok = quality["*"]
+		}
+		if ok && q > 0 {
+			accepted = append(accepted, encoding)
+		}
+	}
+	sort.SliceStable(accepted, func(i, j int) bool {
+		return qualityOf(quality, accepted[i]) > qualityOf(quality, accepted[j])
+	})
+	return accepted
+}
+
+// Negotiate returns the best encoding an Accept-Encoding header allows, or
+// "" to send the response as it is
+func Negotiate(header string) string {
+	if accepted := Accepted(header); len(accepted) > 0 {
+		return accepted[0]
+	}
+	return ""
+}
+
+// Vary records that a response depends on Accept-Encoding, once
+func Vary(h http.Header) {
+	for _, value := range h.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(name), "Accept-Encoding") {
+				return
+			}
+		}
+	}
+	h.Add("Vary", "Accept-Encoding")
+}
+
+// qualityOf returns the quality the client gave an encoding, directly or
+// through the wildcard
+func qualityOf(quality map[string]float64, encoding string) float64 {
+	if q, ok := quality[encoding]; ok {
+		return q
+	}
+	return quality["*"]
+}
+
+// Compressible reports whether a content type is worth compressing: text,
+// and the JSON, XML, JavaScript and SVG formats. Images, video, fonts and
+// archives are already compressed.
+func Compressible(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case strings.HasPrefix(mediaType, "text/"):
+		return true
+	case strings.HasSuffix(mediaType, "+json"), strings.HasSuffix(mediaType, "+xml"):
+		return true
+	}
+	switch mediaType {
+	case "application/json", "application/javascript", "application/xml",
+		"application/wasm", "image/x-icon":
+		return true
+	}
+	return false
+}
+
+// Encoder levels for responses compressed on the fly: fast, with most of the gain
+const (
+	gzipLevel   = gzip.DefaultCompression
+	brotliLevel = 5
+)
+
+var (
+	gzipWriters   = sync.Pool{New: func() interface{} { w, _ := gzip.NewWriterLevel(nil, gzipLevel); return w }}
+	brotliWriters = sync.Pool{New: func() interface{} { return brotli.NewWriterLevel(nil, brotliLevel) }}
+)
+
+// Encoder compresses into a writer
+type Encoder interface {
+	io.WriteCloser
+	Flush() error
+}
+
+// pooledEncoder returns its writer to the pool once closed
+type pooledEncoder struct {
+	Encoder
+	pool *sync.Pool
+}
+
+// Close flushes the compressed stream and releases the writer
+func (e *pooledEncoder) Close() error {
+	err := e.Encoder.Close()
+	e.pool.Put(e.Encoder)
+	return err
+}
+
+// NewEncoder returns an encoder writing to w, or nil for an unsupported
+// encoding. Closing it finishes the stream but leaves w open.
+func NewEncoder(encoding string, w io.Writer) Encoder {
+	switch encoding {
+	case Brotli:
+		bw := brotliWriters.Get().(*brotli.Writer)
+		bw.Reset(w)
+		return &pooledEncoder{Encoder: bw, pool: &brotliWriters}
+	case Gzip:
+		gw := gzipWriters.Get().(*gzip.Writer)
+		gw.Reset(w)
+		return &pooledEncoder{Encoder: gw, pool: &gzipWriters}
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package compress
+
+import (
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+)
+
+// FileServer serves the files under root like http.FileServer, but sends
+// the .br or .gz sibling of a file when the client accepts that encoding.
+// Siblings older than their file are ignored, so an asset edited after
+// Precompress ran is never served out of date.
+func FileServer(root string) http.Handler {
+	files := http.FileServer(http.Dir(root))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := path.Clean("/" + r.URL.Path)
+		contentType := mime.TypeByExtension(path.Ext(name))
+		if !Compressible(contentType) {
+			files.ServeHTTP(w, r)
+			return
+		}
+		file := filepath.Join(root, filepath.FromSlash(name))
+		info, err := os.Stat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			files.ServeHTTP(w, r)
+			return
+		}
+
+		// The response depends on Accept-Encoding whether or not a sibling
+		// is sent
+		Vary(w.Header())
+
+		for _, encoding := range Accepted(r.Header.Get("Accept-Encoding")) {
+			sibling, err := os.Open(file + extensions[encoding])
+			if err != nil {
+				continue
+			}
+			defer sibling.Close()
+			siblingInfo, err := sibling.Stat()
+			if err != nil || !siblingInfo.Mode().IsRegular() || siblingInfo.ModTime().Before(info.ModTime()) {
+				continue
+			}
+
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Encoding", encoding)
+			http.ServeContent(w, r, name, info.ModTime(), sibling)
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,153 @@
+package compress
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// Result reports what Precompress changed
+type Result struct {
+	Written   int   `json:"written"`   // Siblings compressed
+	Unchanged int   `json:"unchanged"` // Siblings already up to date
+	Removed   int   `json:"removed"`   // Siblings of files that changed or are gone
+	Original  int64 `json:"original_bytes"`
+	Brotli    int64 `json:"brotli_bytes"` // Size of the files as served to Brotli clients
+	Gzip      int64 `json:"gzip_bytes"`   // Size of the files as served to gzip clients
+}
+
+// Precompress writes .br and .gz siblings of the compressible files under
+// dir that are at least minSize bytes, at the best compression levels.
+// Siblings carry their file's modification time, so up-to-date ones are
+// skipped on the next run. A sibling that would not be smaller than its
+// file is not kept, and siblings without a file are removed.
+func Precompress(dir string, minSize int64) (*Result, error) {
+	result := &Result{}
+
+	err := filepath.WalkDir(dir, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		if source, encoding := siblingSource(name); encoding != "" {
+			// Orphaned siblings go; the others are handled with their file
+			if _, err := os.Stat(source); os.IsNotExist(err) {
+				result.Removed++
+				return os.Remove(name)
+			}
+			return nil
+		}
+		if !Compressible(mime.TypeByExtension(filepath.Ext(name))) {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		result.Original += info.Size()
+		sizes := map[string]*int64{Brotli: &result.Brotli, Gzip: &result.Gzip}
+		for encoding, size := range sizes {
+			*size += info.Size()
+			sibling := name + extensions[encoding]
+			if info.Size() < minSize {
+				if removeFile(sibling) {
+					result.Removed++
+				}
+				continue
+			}
+
+			if existing, err := os.Stat(sibling); err == nil && existing.ModTime().Equal(info.ModTime()) {
+				result.Unchanged++
+				*size += existing.Size() - info.Size()
+				continue
+			}
+			written, err := compressFile(name, sibling, encoding, info)
+			if err != nil {
+				return err
+			}
+			if written < 0 {
+				if removeFile(sibling) {
+					result.Removed++
+				}
+				continue
+			}
+			result.Written++
+			*size += written - info.Size()
+		}
+		return nil
+	})
+	return result, err
+}
+
+// compressFile writes the sibling of a file and returns its size, or -1
+// when compressing doesn't make the file smaller. The sibling is written
+// under a temporary name and renamed, so it is never served half-written.
+func compressFile(name, sibling, encoding string, info fs.FileInfo) (int64, error) {
+	data, err := os.ReadFile(name)
+	if err != nil {
+		return 0, err
+	}
+
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case Brotli:
+		w = brotli.NewWriterLevel(&buf, brotli.BestCompression)
+	default:
+		if w, err = gzip.NewWriterLevel(&buf, gzip.BestCompression); err != nil {
+			return 0, err
+		}
+	}
+	if _, err := w.Write(data); err != nil {
+		return 0, err
+	}
+	if err := w.Close(); err != nil {
+		return 0, err
+	}
+	if int64(buf.Len()) >= info.Size() {
+		return -1, nil
+	}
+
+	tmp := sibling + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0644); err != nil {
+		return 0, err
+	}
+	if err := os.Chtimes(tmp, info.ModTime(), info.ModTime()); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	if err := os.Rename(tmp, sibling); err != nil {
+		os.Remove(tmp)
+		return 0, err
+	}
+	return int64(buf.Len()), nil
+}
+
+// siblingSource returns the file a precompressed sibling belongs to and its
+// encoding, or "" for a file that is not a sibling
+func siblingSource(name string) (string, string) {
+	for encoding, ext := range extensions {
+		if strings.HasSuffix(name, ext) {
+			source := strings.TrimSuffix(name, ext)
+			if Compressible(mime.TypeByExtension(filepath.Ext(source))) {
+				return source, encoding
+			}
+		}
+	}
+	return "", ""
+}
+
+// removeFile deletes a file if it exists and reports whether it did
+func removeFile(name string) bool {
+	return os.Remove(name) == nil
+}
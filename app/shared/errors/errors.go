@@ -1,6 +1,7 @@
 package errors
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"net/http"
@@ -17,6 +18,8 @@ const (
 	ErrCodeInternal     ErrorCode = "INTERNAL_ERROR"
 	ErrCodeConflict     ErrorCode = "CONFLICT"
 	ErrCodeBadRequest   ErrorCode = "BAD_REQUEST"
+	ErrCodeTooManyRequests ErrorCode = "TOO_MANY_REQUESTS"
+	ErrCodeTimeout         ErrorCode = "TIMEOUT"
 )
 
 // AppError represents an application error
@@ -110,6 +113,22 @@ func NewBadRequest(message string) *AppError {
 	return New(ErrCodeBadRequest, message, http.StatusBadRequest)
 }
 
+// NewTooManyRequests creates a too many requests error
+func NewTooManyRequests(message string) *AppError {
+	if message == "" {
+		message = "Too many requests"
+	}
+	return New(ErrCodeTooManyRequests, message, http.StatusTooManyRequests)
+}
+
+// NewTimeout creates an error for work that ran past its deadline
+func NewTimeout(message string, err error) *AppError {
+	if message == "" {
+		message = "The request took too long"
+	}
+	return Wrap(err, ErrCodeTimeout, message, http.StatusGatewayTimeout)
+}
+
 // IsAppError checks if error is AppError
 func IsAppError(err error) bool {
 	var appErr *AppError
@@ -118,6 +137,11 @@ func IsAppError(err error) bool {
 
 // AsAppError converts error to AppError
 func AsAppError(err error) *AppError {
+	// A query deadline surfaces wrapped in whatever the service made of it
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewTimeout("", err)
+	}
+
 	var appErr *AppError
 	if errors.As(err, &appErr) {
 		return appErr
@@ -0,0 +1,53 @@
+package events
+
+import "sync"
+
+// Kind names what kind of content changed
+type Kind string
+
+const (
+	PageChanged      Kind = "page"
+	ComponentChanged Kind = "component"
+	SettingsChanged  Kind = "settings"
+)
+
+// Event announces a content change
+type Event struct {
+	Kind Kind
+	ID   int // Page or component ID; zero for settings
+}
+
+// Bus delivers content changes to the parts of the server that keep state
+// derived from the content, such as the page cache. A nil Bus drops events,
+// so services work without one (e.g. in artisan).
+type Bus struct {
+	mu       sync.RWMutex
+	handlers []func(Event)
+}
+
+// NewBus creates an event bus without subscribers
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe calls handler for every event published from now on
+func (b *Bus) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	b.handlers = append(b.handlers, handler)
+	b.mu.Unlock()
+}
+
+// Publish hands an event to the subscribers. They run synchronously, after
+// the change was stored, so they must be quick.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	b.mu.RLock()
+	handlers := b.handlers
+	b.mu.RUnlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
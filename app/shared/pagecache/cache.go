@@ -0,0 +1,285 @@
+package pagecache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	"cacto-cms/app/shared/events"
+)
+
+// State tells how a lookup was answered
+type State string
+
+const (
+	Miss  State = "MISS"  // Not cached: render the page
+	Hit   State = "HIT"   // Fresh: serve the entry
+	Stale State = "STALE" // Outdated: serve the entry and render it again in the background
+)
+
+// Entry is a rendered page
+type Entry struct {
+	PageID       int
+	Body         []byte
+	ETag         string
+	LastModified time.Time
+
+	storedAt   time.Time
+	staleUntil time.Time // Set once the entry is invalidated or expired
+}
+
+// NewEntry wraps a rendered page, deriving its ETag from the body
+func NewEntry(pageID int, body []byte) *Entry {
+	sum := sha256.Sum256(body)
+	return &Entry{
+		PageID:       pageID,
+		Body:         body,
+		ETag:         `"` + hex.EncodeToString(sum[:16]) + `"`,
+		LastModified: time.Now().UTC().Truncate(time.Second),
+	}
+}
+
+// Options configures a cache
+type Options struct {
+	TTL        time.Duration // How long an entry is served without rendering it again
+	Stale      time.Duration // How long an outdated entry is still served while it is rendered again; zero to render at once
+	MaxEntries int           // The oldest entries are evicted beyond this
+}
+
+// Stats counts how requests were answered since the server started
+type Stats struct {
+	Enabled       bool    `json:"enabled"`
+	Entries       int     `json:"entries"`
+	Hits          uint64  `json:"hits"`
+	StaleHits     uint64  `json:"stale_hits"`
+	Misses        uint64  `json:"misses"`
+	HitRate       float64 `json:"hit_rate"` // Share of lookups answered from the cache, stale ones included
+	Invalidations uint64  `json:"invalidations"`
+	Evictions     uint64  `json:"evictions"`
+}
+
+// Cache holds rendered public pages in memory, keyed by path and variant.
+// Content changes published on the event bus invalidate the pages they
+// affect; changes made by other processes (artisan) show after the TTL.
+// A nil Cache stores nothing, so every lookup is a miss.
+type Cache struct {
+	opts Options
+
+	mu         sync.Mutex
+	entries    map[string]*Entry
+	refreshing map[string]bool
+	generation uint64 // Bumped by every invalidation
+	stats      Stats
+}
+
+// New creates an empty cache
+func New(opts Options) *Cache {
+	return &Cache{
+		opts:       opts,
+		entries:    make(map[string]*Entry),
+		refreshing: make(map[string]bool),
+		stats:      Stats{Enabled: true},
+	}
+}
+
+// Subscribe invalidates cached pages when content changes: a page change
+// drops that page, and a component or settings change drops every page
+// since they may appear anywhere
+func (c *Cache) Subscribe(bus *events.Bus) {
+	bus.Subscribe(func(e events.Event) {
+		if e.Kind == events.PageChanged {
+			c.InvalidatePage(e.ID)
+			return
+		}
+		c.Purge()
+	})
+}
+
+// Get looks a key up
+func (c *Cache) Get(key string) (*Entry, State) {
+	if c == nil {
+		return nil, Miss
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	e, ok := c.entries[key]
+	if ok && e.staleUntil.IsZero() && now.Sub(e.storedAt) >= c.opts.TTL {
+		c.expire(e, e.storedAt.Add(c.opts.TTL))
+		if e.staleUntil.IsZero() {
+			delete(c.entries, key)
+			ok = false
+		}
+	}
+	if ok && !e.staleUntil.IsZero() && !now.Before(e.staleUntil) {
+		delete(c.entries, key)
+		ok = false
+	}
+
+	switch {
+	case !ok:
+		c.stats.Misses++
+		return nil, Miss
+	case e.staleUntil.IsZero():
+		c.stats.Hits++
+		return e, Hit
+	}
+
+	c.stats.StaleHits++
+	return e, Stale
+}
+
+// Claim reports whether the caller should render a stale entry again: only
+// the first caller does until Store or Release
+func (c *Cache) Claim(key string) bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.refreshing[key] {
+		return false
+	}
+	c.refreshing[key] = true
+	return true
+}
+
+// Generation returns a token to pass to Store, taken before the page is read
+func (c *Cache) Generation() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.generation
+}
+
+// Store caches a rendered page unless content changed since generation was
+// taken: the page may have been rendered from the old content. An entry
+// whose body didn't change keeps its Last-Modified time.
+func (c *Cache) Store(key string, e *Entry, generation uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.refreshing, key)
+	if generation != c.generation {
+		return
+	}
+	if old, ok := c.entries[key]; ok && old.ETag == e.ETag {
+		e.LastModified = old.LastModified
+	}
+
+	e.storedAt = time.Now()
+	if _, ok := c.entries[key]; !ok && c.opts.MaxEntries > 0 && len(c.entries) >= c.opts.MaxEntries {
+		c.evictOldest()
+	}
+	c.entries[key] = e
+}
+
+// Release gives up a claim, e.g. after a failed render
+func (c *Cache) Release(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.refreshing, key)
+	c.mu.Unlock()
+}
+
+// Remove drops an entry, e.g. of a page that no longer exists
+func (c *Cache) Remove(key string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	delete(c.entries, key)
+	delete(c.refreshing, key)
+	c.mu.Unlock()
+}
+
+// CacheControl returns the Cache-Control header of cached pages. Browsers
+// and proxies revalidate on every request, which the ETag makes cheap; with
+// a stale period proxies may serve the old page while they do.
+func (c *Cache) CacheControl() string {
+	if c == nil || c.opts.Stale <= 0 {
+		return "public, no-cache"
+	}
+	return fmt.Sprintf("public, max-age=0, stale-while-revalidate=%d", int(c.opts.Stale.Seconds()))
+}
+
+// InvalidatePage drops the cached variants of a page
+func (c *Cache) InvalidatePage(pageID int) {
+	c.invalidate(func(e *Entry) bool { return e.PageID == pageID })
+}
+
+// Purge drops every cached page
+func (c *Cache) Purge() {
+	c.invalidate(func(*Entry) bool { return true })
+}
+
+// Stats returns the counters and the current number of entries
+func (c *Cache) Stats() Stats {
+	if c == nil {
+		return Stats{}
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	stats := c.stats
+	stats.Entries = len(c.entries)
+	if lookups := stats.Hits + stats.StaleHits + stats.Misses; lookups > 0 {
+		stats.HitRate = float64(stats.Hits+stats.StaleHits) / float64(lookups)
+	}
+	return stats
+}
+
+// invalidate drops the matching entries, or with a stale period marks
+// them stale so they are served while they are rendered again
+func (c *Cache) invalidate(match func(*Entry) bool) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	c.stats.Invalidations++
+	now := time.Now()
+	for key, e := range c.entries {
+		if !match(e) || !e.staleUntil.IsZero() {
+			continue
+		}
+		c.expire(e, now)
+		if e.staleUntil.IsZero() {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// expire marks an entry outdated as of a time, to be served until the stale
+// period ends. Without a stale period it is left as is and the caller drops it.
+func (c *Cache) expire(e *Entry, at time.Time) {
+	if c.opts.Stale > 0 {
+		e.staleUntil = at.Add(c.opts.Stale)
+	}
+}
+
+// evictOldest drops the entry stored longest ago
+func (c *Cache) evictOldest() {
+	var oldestKey string
+	var oldest time.Time
+	for key, e := range c.entries {
+		if oldestKey == "" || e.storedAt.Before(oldest) {
+			oldestKey, oldest = key, e.storedAt
+		}
+	}
+	delete(c.entries, oldestKey)
+	c.stats.Evictions++
+}
@@ -1,18 +1,25 @@
 package seo
 
+import "context"
+
+// SiteInfo provides the site-wide values pages fall back to
+type SiteInfo interface {
+	SiteName(ctx context.Context) string
+	SiteDescription(ctx context.Context) string
+}
+
 // Manager provides SEO management utilities
 type Manager struct {
 	baseURL string
-	siteName string
-	siteDescription string
+	site    SiteInfo
 }
 
-// NewManager creates a new SEO manager
-func NewManager(baseURL, siteName, siteDescription string) *Manager {
+// NewManager creates a new SEO manager reading the site name and
+// description from site, so changes to them apply without a restart
+func NewManager(baseURL string, site SiteInfo) *Manager {
 	return &Manager{
 		baseURL: baseURL,
-		siteName: siteName,
-		siteDescription: siteDescription,
+		site:    site,
 	}
 }
 
@@ -38,14 +45,17 @@ func (m *Manager) ForPage(title, description, keywords, ogImage, slug string) *M
 }
 
 // ForHomePage creates SEO meta for home page
-func (m *Manager) ForHomePage() *Meta {
-	title := m.siteName
-	if m.siteName == "" {
+func (m *Manager) ForHomePage(ctx context.Context) *Meta {
+	siteName := m.site.SiteName(ctx)
+	siteDescription := m.site.SiteDescription(ctx)
+
+	title := siteName
+	if siteName == "" {
 		title = "Cacto CMS - Performans Odaklı Web Sitesi"
 	}
 	
-	description := m.siteDescription
-	if m.siteDescription == "" {
+	description := siteDescription
+	if siteDescription == "" {
 		description = "Go ve Templ ile geliştirilmiş yüksek performanslı kurumsal CMS"
 	}
 	
@@ -53,24 +63,24 @@ func (m *Manager) ForHomePage() *Meta {
 		WithKeywords("go, cms, performans, web").
 		WithCanonical(m.baseURL).
 		WithJsonLd(GenerateWebsiteSchema(
-			m.siteName,
+			siteName,
 			m.baseURL,
-			m.siteDescription,
+			siteDescription,
 		))
 	
 	return meta
 }
 
 // ForPageWithDefaults creates SEO meta with defaults if values are empty
-func (m *Manager) ForPageWithDefaults(pageTitle, pageDescription, pageKeywords, pageOGImage, slug string) *Meta {
+func (m *Manager) ForPageWithDefaults(ctx context.Context, pageTitle, pageDescription, pageKeywords, pageOGImage, slug string) *Meta {
 	title := pageTitle
 	if title == "" {
-		title = m.siteName
+		title = m.site.SiteName(ctx)
 	}
 	
 	description := pageDescription
 	if description == "" {
-		description = m.siteDescription
+		description = m.site.SiteDescription(ctx)
 	}
 	
 	return m.ForPage(title, description, pageKeywords, pageOGImage, slug)
@@ -1,14 +1,33 @@
 package sitemap
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"log"
+	"net/http"
 	"os"
+	"sync"
 	"time"
 
 	"cacto-cms/app/domain/page"
 )
 
+// DefaultPath is where the sitemap is written, served as /sitemap.xml
+const DefaultPath = "./web/static/sitemap.xml"
+
+// ErrDisabled is returned by Generate when the sitemap_enabled setting is off
+var ErrDisabled = errors.New("the sitemap is disabled in the site settings")
+
+// Settings tells the generator whether to publish a sitemap and keeps
+// track of when it was last written
+type Settings interface {
+	SitemapEnabled(ctx context.Context) bool
+	SitemapLastGenerated(ctx context.Context) time.Time
+	MarkSitemapGenerated(ctx context.Context, at time.Time) error
+}
+
 // URLSet represents the root element of a sitemap
 type URLSet struct {
 	XMLName xml.Name `xml:"urlset"`
@@ -29,24 +48,81 @@ type Generator struct {
 	baseURL    string
 	outputPath string
 	repo       page.Repository
+	settings   Settings
+
+	mu sync.Mutex // Serializes writes to the file
 }
 
 // NewGenerator creates a new sitemap generator
-func NewGenerator(baseURL, outputPath string, repo page.Repository) *Generator {
+func NewGenerator(baseURL, outputPath string, repo page.Repository, settings Settings) *Generator {
 	return &Generator{
 		baseURL:    baseURL,
 		outputPath: outputPath,
 		repo:       repo,
+		settings:   settings,
 	}
 }
 
-// Generate generates the sitemap XML file
-func (g *Generator) Generate() error {
-	pages, err := g.repo.FindPublished()
+// Generate generates the sitemap XML file and records when it did. When the
+// sitemap is disabled, an existing file is removed, so /sitemap.xml is no
+// longer served, and ErrDisabled is returned.
+func (g *Generator) Generate(ctx context.Context) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if !g.settings.SitemapEnabled(ctx) {
+		if err := os.Remove(g.outputPath); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove disabled sitemap: %w", err)
+		}
+		return ErrDisabled
+	}
+
+	pages, err := g.repo.FindPublished(ctx)
 	if err != nil {
 		return fmt.Errorf("failed to fetch pages: %w", err)
 	}
 
+	xmlContent, err := Build(g.baseURL, pages)
+	if err != nil {
+		return err
+	}
+
+	// Write to file
+	if err := os.WriteFile(g.outputPath, xmlContent, 0644); err != nil {
+		return fmt.Errorf("failed to write sitemap: %w", err)
+	}
+
+	if err := g.settings.MarkSitemapGenerated(ctx, time.Now()); err != nil {
+		return fmt.Errorf("failed to record sitemap generation: %w", err)
+	}
+	return nil
+}
+
+// ServeHTTP serves the sitemap. The setting is checked on every request, so
+// a sitemap turned off elsewhere (e.g. by an import) is no longer served
+// even while its file is still there; a missing file is written on demand.
+func (g *Generator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	if !g.settings.SitemapEnabled(ctx) {
+		http.NotFound(w, r)
+		return
+	}
+
+	if _, err := os.Stat(g.outputPath); os.IsNotExist(err) {
+		if err := g.Generate(ctx); err != nil {
+			if !errors.Is(err, ErrDisabled) {
+				log.Printf("⚠️  Sitemap generation failed: %v", err)
+			}
+			http.NotFound(w, r)
+			return
+		}
+	}
+	http.ServeFile(w, r, g.outputPath)
+}
+
+// Build returns the sitemap XML listing the home page and the given pages
+// under baseURL
+func Build(baseURL string, pages []*page.Page) ([]byte, error) {
 	urlset := URLSet{
 		Xmlns: "http://www.sitemaps.org/schemas/sitemap/0.9",
 		URLs:  make([]URL, 0, len(pages)+1),
@@ -54,16 +130,19 @@ func (g *Generator) Generate() error {
 
 	// Add homepage
 	urlset.URLs = append(urlset.URLs, URL{
-		Loc:        g.baseURL,
+		Loc:        baseURL,
 		LastMod:    time.Now().Format("2006-01-02"),
 		ChangeFreq: "daily",
 		Priority:   1.0,
 	})
 
-	// Add pages
+	// Add pages (the home page is listed above)
 	for _, p := range pages {
+		if p.Slug == "" {
+			continue
+		}
 		urlset.URLs = append(urlset.URLs, URL{
-			Loc:        fmt.Sprintf("%s/%s", g.baseURL, p.Slug),
+			Loc:        fmt.Sprintf("%s/%s", baseURL, p.Slug),
 			LastMod:    p.UpdatedAt.Format("2006-01-02"),
 			ChangeFreq: "weekly",
 			Priority:   0.8,
@@ -73,34 +152,45 @@ func (g *Generator) Generate() error {
 	// Generate XML
 	output, err := xml.MarshalIndent(urlset, "", "  ")
 	if err != nil {
-		return fmt.Errorf("failed to marshal XML: %w", err)
+		return nil, fmt.Errorf("failed to marshal XML: %w", err)
 	}
-
-	// Write to file
-	xmlContent := []byte(xml.Header + string(output))
-	if err := os.WriteFile(g.outputPath, xmlContent, 0644); err != nil {
-		return fmt.Errorf("failed to write sitemap: %w", err)
-	}
-
-	return nil
+	return []byte(xml.Header + string(output)), nil
 }
 
-// ScheduleDaily runs sitemap generation every 24 hours
+// ScheduleDaily regenerates the sitemap every 24 hours, counted from when
+// it was last generated: a restart doesn't regenerate a fresh sitemap
 func (g *Generator) ScheduleDaily() {
-	ticker := time.NewTicker(24 * time.Hour)
 	go func() {
-		// Generate immediately on start
-		if err := g.Generate(); err != nil {
-			fmt.Printf("Sitemap generation failed: %v\n", err)
-		}
+		for {
+			time.Sleep(g.untilDue(context.Background()))
+
+			err := g.Generate(context.Background())
+			switch {
+			case errors.Is(err, ErrDisabled):
+				// Checked again tomorrow, in case it was switched back on
+			case err != nil:
+				log.Printf("⚠️  Sitemap generation failed: %v", err)
+			default:
+				log.Println("📍 Sitemap regenerated")
+			}
 
-		// Then generate daily
-		for range ticker.C {
-			if err := g.Generate(); err != nil {
-				fmt.Printf("Sitemap generation failed: %v\n", err)
-			} else {
-				fmt.Println("✓ Sitemap regenerated")
+			// A failed or disabled run is retried a day later
+			if err != nil {
+				time.Sleep(24 * time.Hour)
 			}
 		}
 	}()
 }
+
+// untilDue returns how long until the sitemap is a day old; zero when it is
+// older, was never generated or its file is missing
+func (g *Generator) untilDue(ctx context.Context) time.Duration {
+	last := g.settings.SitemapLastGenerated(ctx)
+	if _, err := os.Stat(g.outputPath); err != nil || last.IsZero() {
+		return 0
+	}
+	if wait := time.Until(last.Add(24 * time.Hour)); wait > 0 {
+		return wait
+	}
+	return 0
+}
@@ -0,0 +1,503 @@
+// Package webauthn implements the relying-party side of WebAuthn (FIDO2
+// passkeys): registration and authentication ceremonies, client data and
+// authenticator data checks, and signature verification for ES256, EdDSA
+// and RS256 credentials.
+//
+// Registration requests no attestation ("none"), so attestation statements
+// are not verified; credentials are trusted because the signed-in user
+// registered them.
+package webauthn
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout is how long the browser waits for the authenticator
+const DefaultTimeout = 5 * time.Minute
+
+// User verification requirements
+const (
+	VerificationRequired  = "required"
+	VerificationPreferred = "preferred"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent    = 0x01
+	flagUserVerified   = 0x04
+	flagBackupEligible = 0x08
+	flagBackedUp       = 0x10
+	flagAttestedData   = 0x40
+)
+
+// Config holds the relying party settings
+type Config struct {
+	RPID             string        // Effective domain, e.g. "cms.example.com"
+	RPName           string        // Shown by the authenticator
+	Origins          []string      // Allowed origins, e.g. "https://cms.example.com"
+	Timeout          time.Duration // Default 5 minutes
+	UserVerification string        // "required" (default) or "preferred"
+}
+
+// RelyingParty runs WebAuthn ceremonies for one site
+type RelyingParty struct {
+	config Config
+}
+
+// NewRelyingParty creates a new relying party
+func NewRelyingParty(cfg Config) *RelyingParty {
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = DefaultTimeout
+	}
+	if cfg.UserVerification == "" {
+		cfg.UserVerification = VerificationRequired
+	}
+	if cfg.RPName == "" {
+		cfg.RPName = cfg.RPID
+	}
+	return &RelyingParty{config: cfg}
+}
+
+// RPID returns the relying party ID
+func (rp *RelyingParty) RPID() string {
+	return rp.config.RPID
+}
+
+// Timeout returns how long a ceremony may take
+func (rp *RelyingParty) Timeout() time.Duration {
+	return rp.config.Timeout
+}
+
+// User is the account a credential is registered for. ID is the opaque
+// user handle stored by the authenticator and must not contain personal data.
+type User struct {
+	ID          []byte
+	Name        string
+	DisplayName string
+}
+
+// CredentialDescriptor identifies a credential in options sent to the browser
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"` // base64url
+	Transports []string `json:"transports,omitempty"`
+}
+
+// NewCredentialDescriptor describes a stored credential
+func NewCredentialDescriptor(id []byte, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{Type: "public-key", ID: Encode(id), Transports: transports}
+}
+
+// CreationOptions are the PublicKeyCredentialCreationOptions passed to
+// navigator.credentials.create, with binary values base64url encoded
+type CreationOptions struct {
+	Challenge string `json:"challenge"`
+	RP        struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+	} `json:"rp"`
+	User struct {
+		ID          string `json:"id"`
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+	PubKeyCredParams []struct {
+		Type string `json:"type"`
+		Alg  int    `json:"alg"`
+	} `json:"pubKeyCredParams"`
+	Timeout                int                    `json:"timeout"` // milliseconds
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials"`
+	AuthenticatorSelection struct {
+		ResidentKey        string `json:"residentKey"`
+		RequireResidentKey bool   `json:"requireResidentKey"`
+		UserVerification   string `json:"userVerification"`
+	} `json:"authenticatorSelection"`
+	Attestation string `json:"attestation"`
+}
+
+// RequestOptions are the PublicKeyCredentialRequestOptions passed to
+// navigator.credentials.get, with binary values base64url encoded
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	RPID             string                 `json:"rpId"`
+	Timeout          int                    `json:"timeout"` // milliseconds
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// RegistrationResponse is the credential returned by navigator.credentials.create
+type RegistrationResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string   `json:"clientDataJSON"`
+		AttestationObject string   `json:"attestationObject"`
+		Transports        []string `json:"transports,omitempty"`
+	} `json:"response"`
+}
+
+// AssertionResponse is the credential returned by navigator.credentials.get
+type AssertionResponse struct {
+	ID       string `json:"id"`
+	RawID    string `json:"rawId"`
+	Type     string `json:"type"`
+	Response struct {
+		ClientDataJSON    string `json:"clientDataJSON"`
+		AuthenticatorData string `json:"authenticatorData"`
+		Signature         string `json:"signature"`
+		UserHandle        string `json:"userHandle,omitempty"`
+	} `json:"response"`
+}
+
+// Credential is a verified new credential, ready to be stored
+type Credential struct {
+	ID             []byte
+	PublicKey      []byte // COSE_Key
+	Algorithm      int
+	SignCount      uint32
+	AAGUID         []byte
+	Transports     []string
+	BackupEligible bool
+	BackedUp       bool
+}
+
+// Assertion is the result of a verified authentication
+type Assertion struct {
+	CredentialID []byte
+	UserHandle   []byte
+	SignCount    uint32
+	BackedUp     bool
+	UserVerified bool
+}
+
+// BeginRegistration creates the options for registering a new credential.
+// Passkeys (discoverable credentials) are required so users can sign in
+// without typing their email.
+func (rp *RelyingParty) BeginRegistration(u User, exclude []CredentialDescriptor) (*CreationOptions, error) {
+	challenge, err := NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := &CreationOptions{
+		Challenge:          challenge,
+		Timeout:            int(rp.config.Timeout / time.Millisecond),
+		ExcludeCredentials: exclude,
+		Attestation:        "none",
+	}
+	if opts.ExcludeCredentials == nil {
+		opts.ExcludeCredentials = []CredentialDescriptor{}
+	}
+	opts.RP.ID = rp.config.RPID
+	opts.RP.Name = rp.config.RPName
+	opts.User.ID = Encode(u.ID)
+	opts.User.Name = u.Name
+	opts.User.DisplayName = u.DisplayName
+	for _, alg := range SupportedAlgorithms {
+		opts.PubKeyCredParams = append(opts.PubKeyCredParams, struct {
+			Type string `json:"type"`
+			Alg  int    `json:"alg"`
+		}{Type: "public-key", Alg: alg})
+	}
+	opts.AuthenticatorSelection.ResidentKey = "required"
+	opts.AuthenticatorSelection.RequireResidentKey = true
+	opts.AuthenticatorSelection.UserVerification = rp.config.UserVerification
+
+	return opts, nil
+}
+
+// BeginLogin creates the options for signing in. An empty allow list lets
+// the browser offer any passkey stored for this site.
+func (rp *RelyingParty) BeginLogin(allow []CredentialDescriptor) (*RequestOptions, error) {
+	challenge, err := NewChallenge()
+	if err != nil {
+		return nil, err
+	}
+
+	if allow == nil {
+		allow = []CredentialDescriptor{}
+	}
+	return &RequestOptions{
+		Challenge:        challenge,
+		RPID:             rp.config.RPID,
+		Timeout:          int(rp.config.Timeout / time.Millisecond),
+		AllowCredentials: allow,
+		UserVerification: rp.config.UserVerification,
+	}, nil
+}
+
+// VerifyRegistration checks a registration response against the challenge
+// that was issued and returns the new credential
+func (rp *RelyingParty) VerifyRegistration(resp *RegistrationResponse, challenge string) (*Credential, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("unexpected credential type %q", resp.Type)
+	}
+
+	if _, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.create", challenge); err != nil {
+		return nil, err
+	}
+
+	rawAttestation, err := Decode(resp.Response.AttestationObject)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation object encoding")
+	}
+	v, _, err := decodeCBOR(rawAttestation)
+	if err != nil {
+		return nil, fmt.Errorf("invalid attestation object: %w", err)
+	}
+	attestation, ok := v.(map[interface{}]interface{})
+	if !ok {
+		return nil, fmt.Errorf("invalid attestation object")
+	}
+	rawAuthData, ok := attestation["authData"].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("attestation object has no authenticator data")
+	}
+
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if authData.flags&flagAttestedData == 0 || len(authData.credentialID) == 0 {
+		return nil, fmt.Errorf("authenticator data has no credential")
+	}
+
+	if rawID, err := Decode(resp.RawID); err != nil || !bytes.Equal(rawID, authData.credentialID) {
+		return nil, fmt.Errorf("credential ID does not match authenticator data")
+	}
+
+	key, err := parsePublicKey(authData.publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:             authData.credentialID,
+		PublicKey:      authData.publicKey,
+		Algorithm:      int(key.alg),
+		SignCount:      authData.signCount,
+		AAGUID:         authData.aaguid,
+		Transports:     resp.Response.Transports,
+		BackupEligible: authData.flags&flagBackupEligible != 0,
+		BackedUp:       authData.flags&flagBackedUp != 0,
+	}, nil
+}
+
+// VerifyAssertion checks an authentication response against the challenge
+// that was issued and the stored credential. A signature counter that does
+// not increase (when the authenticator keeps one) indicates a cloned
+// authenticator and is rejected.
+func (rp *RelyingParty) VerifyAssertion(resp *AssertionResponse, challenge string, publicKey []byte, storedSignCount uint32) (*Assertion, error) {
+	if resp.Type != "public-key" {
+		return nil, fmt.Errorf("unexpected credential type %q", resp.Type)
+	}
+
+	clientData, err := rp.verifyClientData(resp.Response.ClientDataJSON, "webauthn.get", challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := Decode(resp.Response.AuthenticatorData)
+	if err != nil {
+		return nil, fmt.Errorf("invalid authenticator data encoding")
+	}
+	authData, err := rp.verifyAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+
+	signature, err := Decode(resp.Response.Signature)
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature encoding")
+	}
+
+	key, err := parsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	clientDataHash := sha256.Sum256(clientData)
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash[:]...)
+	if !key.verify(signed, signature) {
+		return nil, fmt.Errorf("invalid signature")
+	}
+
+	if (authData.signCount != 0 || storedSignCount != 0) && authData.signCount <= storedSignCount {
+		return nil, fmt.Errorf("signature counter did not increase (%d <= %d), the authenticator may be cloned", authData.signCount, storedSignCount)
+	}
+
+	credentialID, err := Decode(resp.RawID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid credential ID encoding")
+	}
+	var userHandle []byte
+	if resp.Response.UserHandle != "" {
+		if userHandle, err = Decode(resp.Response.UserHandle); err != nil {
+			return nil, fmt.Errorf("invalid user handle encoding")
+		}
+	}
+
+	return &Assertion{
+		CredentialID: credentialID,
+		UserHandle:   userHandle,
+		SignCount:    authData.signCount,
+		BackedUp:     authData.flags&flagBackedUp != 0,
+		UserVerified: authData.flags&flagUserVerified != 0,
+	}, nil
+}
+
+// clientData is the subset of CollectedClientData we check
+type clientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// verifyClientData checks the ceremony type, challenge and origin and
+// returns the raw client data (its hash is part of the signed data)
+func (rp *RelyingParty) verifyClientData(encoded, ceremony, challenge string) ([]byte, error) {
+	raw, err := Decode(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("invalid client data encoding")
+	}
+
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return nil, fmt.Errorf("invalid client data: %w", err)
+	}
+
+	if cd.Type != ceremony {
+		return nil, fmt.Errorf("unexpected ceremony %q", cd.Type)
+	}
+	if challenge == "" || strings.TrimRight(cd.Challenge, "=") != challenge {
+		return nil, fmt.Errorf("challenge mismatch")
+	}
+	if cd.CrossOrigin {
+		return nil, fmt.Errorf("cross-origin requests are not allowed")
+	}
+
+	allowed := false
+	for _, origin := range rp.config.Origins {
+		if cd.Origin == origin {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return nil, fmt.Errorf("origin %q is not allowed", cd.Origin)
+	}
+
+	return raw, nil
+}
+
+// authenticatorData is parsed authenticator data
+type authenticatorData struct {
+	rpIDHash     []byte
+	flags        byte
+	signCount    uint32
+	aaguid       []byte
+	credentialID []byte
+	publicKey    []byte
+}
+
+// verifyAuthenticatorData parses authenticator data and checks the RP ID
+// hash and the user presence and verification flags
+func (rp *RelyingParty) verifyAuthenticatorData(raw []byte) (*authenticatorData, error) {
+	if len(raw) < 37 {
+		return nil, fmt.Errorf("authenticator data too short")
+	}
+
+	data := &authenticatorData{
+		rpIDHash:  raw[:32],
+		flags:     raw[32],
+		signCount: binary.BigEndian.Uint32(raw[33:37]),
+	}
+
+	expected := sha256.Sum256([]byte(rp.config.RPID))
+	if !bytes.Equal(data.rpIDHash, expected[:]) {
+		return nil, fmt.Errorf("credential belongs to another relying party")
+	}
+	if data.flags&flagUserPresent == 0 {
+		return nil, fmt.Errorf("user presence was not confirmed")
+	}
+	if rp.config.UserVerification == VerificationRequired && data.flags&flagUserVerified == 0 {
+		return nil, fmt.Errorf("user verification is required")
+	}
+
+	if data.flags&flagAttestedData != 0 {
+		rest := raw[37:]
+		if len(rest) < 18 {
+			return nil, fmt.Errorf("attested credential data too short")
+		}
+		data.aaguid = rest[:16]
+		idLength := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLength == 0 || idLength > 1023 || len(rest) < idLength {
+			return nil, fmt.Errorf("invalid credential ID length")
+		}
+		data.credentialID = rest[:idLength]
+		rest = rest[idLength:]
+
+		_, n, err := decodeCBOR(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid credential public key: %w", err)
+		}
+		data.publicKey = rest[:n]
+	}
+
+	return data, nil
+}
+
+// Challenge returns the challenge echoed in a registration response, used
+// to find the ceremony it answers
+func (r *RegistrationResponse) Challenge() string {
+	return echoedChallenge(r.Response.ClientDataJSON)
+}
+
+// Challenge returns the challenge echoed in an assertion response, used
+// to find the ceremony it answers
+func (r *AssertionResponse) Challenge() string {
+	return echoedChallenge(r.Response.ClientDataJSON)
+}
+
+// echoedChallenge extracts the challenge from client data without verifying it
+func echoedChallenge(encoded string) string {
+	raw, err := Decode(encoded)
+	if err != nil {
+		return ""
+	}
+	var cd clientData
+	if err := json.Unmarshal(raw, &cd); err != nil {
+		return ""
+	}
+	return strings.TrimRight(cd.Challenge, "=")
+}
+
+// NewChallenge returns a random base64url challenge
+func NewChallenge() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return Encode(b), nil
+}
+
+// Encode encodes binary values as unpadded base64url, as used in options and responses
+func Encode(b []byte) string {
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode decodes base64url values, with or without padding
+func Decode(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
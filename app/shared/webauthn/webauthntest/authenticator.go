@@ -0,0 +1,247 @@
+// Package webauthntest provides a software authenticator for exercising the
+// passkey ceremonies end to end without a browser or security key.
+//
+//	authn := webauthntest.NewAuthenticator("https://cms.example.com")
+//	credential, _ := authn.Register(creationOptions)
+//	assertion, _ := authn.Login(requestOptions)
+//
+// Credentials are ES256 keys held in memory, created as discoverable
+// passkeys with user presence and verification.
+package webauthntest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"cacto-cms/app/shared/webauthn"
+)
+
+// credential is a passkey stored in the authenticator
+type credential struct {
+	id         []byte
+	rpID       string
+	userHandle []byte
+	key        *ecdsa.PrivateKey
+	signCount  uint32
+}
+
+// Authenticator is an in-memory WebAuthn authenticator
+type Authenticator struct {
+	Origin       string // Origin reported in client data
+	UserVerified bool   // Whether to report user verification (default true)
+	KeepCounter  bool   // Whether to increment the signature counter (default true)
+
+	mu          sync.Mutex
+	credentials []*credential
+}
+
+// NewAuthenticator creates an authenticator acting for a browser at origin
+func NewAuthenticator(origin string) *Authenticator {
+	return &Authenticator{Origin: origin, UserVerified: true, KeepCounter: true}
+}
+
+// Register creates a credential for the given creation options
+func (a *Authenticator) Register(opts *webauthn.CreationOptions) (*webauthn.RegistrationResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	for _, excluded := range opts.ExcludeCredentials {
+		if c := a.find(opts.RP.ID, excluded.ID); c != nil {
+			return nil, fmt.Errorf("webauthntest: authenticator already holds an excluded credential")
+		}
+	}
+
+	userHandle, err := webauthn.Decode(opts.User.ID)
+	if err != nil {
+		return nil, fmt.Errorf("webauthntest: invalid user ID: %w", err)
+	}
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, err
+	}
+
+	c := &credential{id: id, rpID: opts.RP.ID, userHandle: userHandle, key: key}
+	a.credentials = append(a.credentials, c)
+
+	clientData, err := a.clientData("webauthn.create", opts.Challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	authData := a.authenticatorData(c, 0x40)
+	authData = append(authData, make([]byte, 16)...) // AAGUID
+	authData = binary.BigEndian.AppendUint16(authData, uint16(len(id)))
+	authData = append(authData, id...)
+	authData = append(authData, coseKey(&key.PublicKey)...)
+
+	var attestation []byte
+	attestation = appendHead(attestation, 5, 3)
+	attestation = appendText(attestation, "fmt")
+	attestation = appendText(attestation, "none")
+	attestation = appendText(attestation, "attStmt")
+	attestation = appendHead(attestation, 5, 0)
+	attestation = appendText(attestation, "authData")
+	attestation = appendBytes(attestation, authData)
+
+	resp := &webauthn.RegistrationResponse{
+		ID:    webauthn.Encode(id),
+		RawID: webauthn.Encode(id),
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = webauthn.Encode(clientData)
+	resp.Response.AttestationObject = webauthn.Encode(attestation)
+	resp.Response.Transports = []string{"internal"}
+	return resp, nil
+}
+
+// Login signs the challenge of the request options with a matching
+// credential. With an empty allow list the most recently created passkey
+// for the relying party is used, as a browser would offer it first.
+func (a *Authenticator) Login(opts *webauthn.RequestOptions) (*webauthn.AssertionResponse, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	var c *credential
+	if len(opts.AllowCredentials) == 0 {
+		for i := len(a.credentials) - 1; i >= 0; i-- {
+			if a.credentials[i].rpID == opts.RPID {
+				c = a.credentials[i]
+				break
+			}
+		}
+	} else {
+		for _, allowed := range opts.AllowCredentials {
+			if c = a.find(opts.RPID, allowed.ID); c != nil {
+				break
+			}
+		}
+	}
+	if c == nil {
+		return nil, fmt.Errorf("webauthntest: no credential for %s", opts.RPID)
+	}
+
+	if a.KeepCounter {
+		c.signCount++
+	}
+
+	clientData, err := a.clientData("webauthn.get", opts.Challenge)
+	if err != nil {
+		return nil, err
+	}
+	authData := a.authenticatorData(c, 0)
+
+	clientDataHash := sha256.Sum256(clientData)
+	digest := sha256.Sum256(append(append([]byte{}, authData...), clientDataHash[:]...))
+	signature, err := ecdsa.SignASN1(rand.Reader, c.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &webauthn.AssertionResponse{
+		ID:    webauthn.Encode(c.id),
+		RawID: webauthn.Encode(c.id),
+		Type:  "public-key",
+	}
+	resp.Response.ClientDataJSON = webauthn.Encode(clientData)
+	resp.Response.AuthenticatorData = webauthn.Encode(authData)
+	resp.Response.Signature = webauthn.Encode(signature)
+	resp.Response.UserHandle = webauthn.Encode(c.userHandle)
+	return resp, nil
+}
+
+// find looks up a credential by relying party and base64url ID
+func (a *Authenticator) find(rpID, encodedID string) *credential {
+	for _, c := range a.credentials {
+		if c.rpID == rpID && webauthn.Encode(c.id) == encodedID {
+			return c
+		}
+	}
+	return nil
+}
+
+// clientData builds the CollectedClientData JSON the browser would send
+func (a *Authenticator) clientData(ceremony, challenge string) ([]byte, error) {
+	return json.Marshal(map[string]interface{}{
+		"type":        ceremony,
+		"challenge":   challenge,
+		"origin":      a.Origin,
+		"crossOrigin": false,
+	})
+}
+
+// authenticatorData builds the RP ID hash, flags and counter
+func (a *Authenticator) authenticatorData(c *credential, extraFlags byte) []byte {
+	rpIDHash := sha256.Sum256([]byte(c.rpID))
+	flags := byte(0x01) | extraFlags // user present
+	if a.UserVerified {
+		flags |= 0x04
+	}
+
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	return binary.BigEndian.AppendUint32(data, c.signCount)
+}
+
+// coseKey encodes a P-256 public key as a COSE_Key
+func coseKey(key *ecdsa.PublicKey) []byte {
+	x := make([]byte, 32)
+	y := make([]byte, 32)
+	key.X.FillBytes(x)
+	key.Y.FillBytes(y)
+
+	var out []byte
+	out = appendHead(out, 5, 5)
+	out = appendInt(out, 1) // kty: EC2
+	out = appendInt(out, 2)
+	out = appendInt(out, 3) // alg: ES256
+	out = appendInt(out, webauthn.AlgES256)
+	out = appendInt(out, -1) // crv: P-256
+	out = appendInt(out, 1)
+	out = appendInt(out, -2) // x
+	out = appendBytes(out, x)
+	out = appendInt(out, -3) // y
+	out = appendBytes(out, y)
+	return out
+}
+
+// appendHead appends a CBOR initial byte and argument
+func appendHead(out []byte, major byte, n uint64) []byte {
+	switch {
+	case n < 24:
+		return append(out, major<<5|byte(n))
+	case n <= 0xff:
+		return append(out, major<<5|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(out, major<<5|25), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(out, major<<5|26), uint32(n))
+	}
+}
+
+// appendInt appends a CBOR integer
+func appendInt(out []byte, n int) []byte {
+	if n < 0 {
+		return appendHead(out, 1, uint64(-1-n))
+	}
+	return appendHead(out, 0, uint64(n))
+}
+
+// appendBytes appends a CBOR byte string
+func appendBytes(out, b []byte) []byte {
+	return append(appendHead(out, 2, uint64(len(b))), b...)
+}
+
+// appendText appends a CBOR text string
+func appendText(out []byte, s string) []byte {
+	return append(appendHead(out, 3, uint64(len(s))), s...)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"cacto-cms/app/interfaces/console"
+	"cacto-cms/app/shared/assets"
+	"cacto-cms/app/shared/compress"
+)
+
+// assetsCommands prepare the static assets for serving
+func assetsCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "assets:build",
+			Description: "Fingerprint the static assets and write the asset manifest",
+			Help: "Each file (CSS, vendored JavaScript, images, ...) is copied to assets/ under a\n" +
+				"name carrying a hash of its content, e.g. assets/css/output.3f2a9c1b.css, and\n" +
+				"assets/manifest.json maps the logical names to the copies and their Subresource\n" +
+				"Integrity hashes. Pages link the copies, which are served with immutable cache\n" +
+				"headers; a running server picks up a new manifest by itself. Copies of the\n" +
+				"previous build are kept for pages rendered before it. Run assets:compress\n" +
+				"afterwards; `make build` runs both.",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("dir", "./web/static", "Directory of the assets")
+			},
+			Run: func(ctx *console.Context) error {
+				result, err := assets.Build(ctx.String("dir"))
+				if err != nil {
+					return fmt.Errorf("failed to build the assets of %s: %w", ctx.String("dir"), err)
+				}
+				log.Printf("🔖 Fingerprinted %s: %d asset(s), %d written, %d removed",
+					ctx.String("dir"), result.Assets, result.Written, result.Removed)
+
+				table := &console.Table{Headers: []string{"Assets", "Written", "Removed"}}
+				table.AddRow(strconv.Itoa(result.Assets), strconv.Itoa(result.Written), strconv.Itoa(result.Removed))
+				return ctx.Render(result, table)
+			},
+		},
+		{
+			Name:        "assets:compress",
+			Description: "Write Brotli and gzip copies of the static assets",
+			Help: "Each compressible file (CSS, JavaScript, SVG, ...) gets .br and .gz siblings,\n" +
+				"which the server sends instead of the file to clients that accept them. Run it\n" +
+				"after assets:build; `make build` does. Up-to-date siblings are kept and\n" +
+				"siblings of removed files are deleted. The server ignores siblings older than\n" +
+				"their file, so an asset edited later is served uncompressed until the next run.",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("dir", "./web/static", "Directory of the assets")
+				fs.Int("min-size", k.config.CompressionMinSize, "Smallest file to compress, in bytes")
+			},
+			Run: func(ctx *console.Context) error {
+				result, err := compress.Precompress(ctx.String("dir"), int64(ctx.Int("min-size")))
+				if err != nil {
+					return fmt.Errorf("failed to compress %s: %w", ctx.String("dir"), err)
+				}
+				log.Printf("🗜️  Compressed %s: %d written, %d unchanged, %d removed",
+					ctx.String("dir"), result.Written, result.Unchanged, result.Removed)
+
+				table := &console.Table{Headers: []string{"Written", "Unchanged", "Removed", "Original", "Brotli", "Gzip"}}
+				table.AddRow(strconv.Itoa(result.Written), strconv.Itoa(result.Unchanged), strconv.Itoa(result.Removed),
+					strconv.FormatInt(result.Original, 10), strconv.FormatInt(result.Brotli, 10), strconv.FormatInt(result.Gzip, 10))
+				return ctx.Render(result, table)
+			},
+		},
+	}
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"cacto-cms/app/infrastructure/backup"
+	"cacto-cms/app/infrastructure/database"
+	"cacto-cms/app/interfaces/console"
+)
+
+// backupCommands take, check and restore snapshots of the database and uploads
+func backupCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "backup:run",
+			Description: "Back up the database and uploads, then prune old backups",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("prune", true, "Apply the retention rules afterwards")
+			},
+			Run: func(ctx *console.Context) error {
+				manager, err := k.backups(true)
+				if err != nil {
+					return err
+				}
+
+				snapshot, manifest, err := manager.Create()
+				if err != nil {
+					return err
+				}
+				log.Printf("💾 Backup created: %s", snapshot.Path)
+
+				if ctx.Bool("prune") {
+					deleted, err := manager.Prune()
+					if err != nil {
+						return err
+					}
+					if len(deleted) > 0 {
+						log.Printf("💾 Pruned %d old backup(s)", len(deleted))
+					}
+				}
+
+				return ctx.Render(map[string]interface{}{"snapshot": snapshot, "manifest": manifest}, snapshotTable(snapshot, manifest))
+			},
+		},
+		{
+			Name:        "backup:list",
+			Description: "List backups, newest first",
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				manager, err := k.backups(false)
+				if err != nil {
+					return err
+				}
+				snapshots, err := manager.List()
+				if err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Name", "Created", "Size"}}
+				for _, s := range snapshots {
+					table.AddRow(s.Name, s.CreatedAt.Local().Format("2006-01-02 15:04:05"), formatSize(s.Size))
+				}
+				return ctx.Render(snapshots, table)
+			},
+		},
+		{
+			Name:        "backup:verify",
+			Description: "Check a backup's checksum and database integrity",
+			Arguments:   "<name|path>",
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				manager, err := k.backups(false)
+				if err != nil {
+					return err
+				}
+				path, err := manager.Find(ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				manifest, err := backup.Verify(path)
+				if err != nil {
+					return fmt.Errorf("%s is not usable: %w", path, err)
+				}
+				log.Printf("✅ %s is intact", path)
+				return ctx.Render(manifest, manifestTable(manifest))
+			},
+		},
+		{
+			Name:        "backup:prune",
+			Description: "Delete backups the retention rules no longer keep",
+			Help:        "Keeps the newest backup of each of the last BACKUP_KEEP_HOURLY hours,\nBACKUP_KEEP_DAILY days and BACKUP_KEEP_WEEKLY weeks.",
+			Destructive: true,
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				manager, err := k.backups(false)
+				if err != nil {
+					return err
+				}
+				deleted, err := manager.Prune()
+				if err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Deleted", "Created"}}
+				for _, s := range deleted {
+					table.AddRow(s.Name, s.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+				}
+				return ctx.Render(deleted, table)
+			},
+		},
+		{
+			Name:        "backup:restore",
+			Description: "Replace the database and uploads with a backup",
+			Arguments:   "<name|path>",
+			Help:        "Refuses to run while the server has the database open; stop it first.\nThe current state is backed up before it is replaced, unless --snapshot=false.",
+			Destructive: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("snapshot", true, "Back up the current database and uploads first")
+			},
+			Run: func(ctx *console.Context) error {
+				// Held until the restore is done, so the server can't start in between
+				lock, err := database.AcquireServerLock(k.dbPath())
+				if err != nil {
+					return fmt.Errorf("%w; stop it before restoring", err)
+				}
+				defer lock.Release()
+
+				manager, err := k.backups(false)
+				if err != nil {
+					return err
+				}
+				path, err := manager.Find(ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+				manifest, err := backup.Verify(path)
+				if err != nil {
+					return fmt.Errorf("%s is not usable: %w", path, err)
+				}
+
+				if !ctx.Bool("force") && !ctx.Config.IsProduction() {
+					question := fmt.Sprintf("Replace %s and %s with the backup from %s?",
+						k.dbPath(), k.config.UploadDir, manifest.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+					if !ctx.Confirm(question) {
+						return fmt.Errorf("restore cancelled")
+					}
+				}
+
+				if _, err := os.Stat(k.dbPath()); err == nil && ctx.Bool("snapshot") {
+					current, err := k.backups(true)
+					if err != nil {
+						return err
+					}
+					snapshot, _, err := current.Create()
+					if err != nil {
+						return fmt.Errorf("failed to back up the current state: %w", err)
+					}
+					log.Printf("💾 Current state saved as %s", snapshot.Name)
+				}
+
+				// The database must not be open while its file is replaced
+				k.close()
+
+				if _, err := manager.Restore(path, lock); err != nil {
+					return err
+				}
+				log.Printf("✅ Restored %s (schema version %d)", path, manifest.SchemaVersion)
+				log.Println("   Run ./artisan migrate:status to check for pending migrations")
+				return nil
+			},
+		},
+	}
+}
+
+// backups creates a backup manager, with a database connection when
+// snapshots are to be taken
+func (k *kernel) backups(withDB bool) (*backup.Manager, error) {
+	cfg := backup.Config{
+		Dir:       k.config.BackupDir,
+		DBPath:    k.dbPath(),
+		UploadDir: k.config.UploadDir,
+		Retention: backup.Retention{
+			Hourly: k.config.BackupKeepHourly,
+			Daily:  k.config.BackupKeepDaily,
+			Weekly: k.config.BackupKeepWeekly,
+		},
+	}
+	if !withDB {
+		return backup.NewManager(nil, cfg), nil
+	}
+
+	db, err := k.database()
+	if err != nil {
+		return nil, err
+	}
+	return backup.NewManager(db.DB, cfg), nil
+}
+
+// snapshotTable shows a new snapshot
+func snapshotTable(s *backup.Snapshot, m *backup.Manifest) *console.Table {
+	table := &console.Table{Headers: []string{"Name", "Size", "Database", "Uploads", "Schema"}}
+	table.AddRow(s.Name, formatSize(s.Size), formatSize(m.DatabaseSize),
+		fmt.Sprintf("%d files, %s", m.Uploads, formatSize(m.UploadsSize)), strconv.Itoa(m.SchemaVersion))
+	return table
+}
+
+// manifestTable shows the contents of a snapshot
+func manifestTable(m *backup.Manifest) *console.Table {
+	table := &console.Table{Headers: []string{"Created", "Database", "Uploads", "Schema", "SHA-256"}}
+	table.AddRow(m.CreatedAt.Local().Format("2006-01-02 15:04:05"), formatSize(m.DatabaseSize),
+		fmt.Sprintf("%d files, %s", m.Uploads, formatSize(m.UploadsSize)), strconv.Itoa(m.SchemaVersion), m.DatabaseSHA256[:16]+"…")
+	return table
+}
+
+// formatSize formats a byte count for humans
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d B", n)
+	}
+}
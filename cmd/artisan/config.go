@@ -0,0 +1,37 @@
+package main
+
+import (
+	"log"
+
+	"cacto-cms/app/interfaces/console"
+)
+
+// configCommands inspect the configuration
+func configCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "config:show",
+			Description: "Print the effective configuration with secrets redacted",
+			Help: "Each setting is shown with where its value came from: the environment,\n" +
+				"the config file CONFIG_FILE names, or the default. Exits with an error when\n" +
+				"the server would refuse to start with this configuration.",
+			Output: true,
+			Run: func(ctx *console.Context) error {
+				entries := k.config.Entries()
+				table := &console.Table{Headers: []string{"Setting", "Value", "Source"}}
+				for _, e := range entries {
+					table.AddRow(e.Key, e.Value, e.Source)
+				}
+				if err := ctx.Render(entries, table); err != nil {
+					return err
+				}
+
+				if err := k.config.Validate(); err != nil {
+					return err
+				}
+				log.Println("✅ Configuration is valid")
+				return nil
+			},
+		},
+	}
+}
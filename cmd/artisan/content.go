@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"cacto-cms/app/infrastructure/bundle"
+	"cacto-cms/app/interfaces/console"
+)
+
+// contentCommands move pages, components, media and settings between
+// installations as bundles
+func contentCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "content:export",
+			Description: "Export pages, components, media and settings to a bundle",
+			Arguments:   "[path]",
+			Help:        "Writes a ZIP archive, or a single JSON file when the path ends in .json.\nThe default path is ./content-YYYYMMDD-HHMMSS.zip.",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("pages", "", "Comma-separated slugs to export, / for the home page (default: all pages)")
+				fs.Bool("media", true, "Include media files")
+				fs.Bool("settings", true, "Include site settings")
+			},
+			Run: func(ctx *console.Context) error {
+				manager, err := k.bundles()
+				if err != nil {
+					return err
+				}
+
+				opts := bundle.ExportOptions{Media: ctx.Bool("media"), Settings: ctx.Bool("settings")}
+				for _, slug := range strings.Split(ctx.String("pages"), ",") {
+					// "/" is the home page, whose slug is empty
+					if slug = strings.TrimSpace(slug); slug != "" {
+						opts.Pages = append(opts.Pages, strings.Trim(slug, "/"))
+					}
+				}
+
+				b, err := manager.Export(ctx.Context(), opts)
+				if err != nil {
+					return err
+				}
+
+				path := ctx.Arg(0)
+				if path == "" {
+					path = "./content-" + time.Now().Format("20060102-150405") + ".zip"
+				}
+				if err := manager.WriteFile(path, b); err != nil {
+					return fmt.Errorf("failed to write %s: %w", path, err)
+				}
+				log.Printf("📦 Content exported to %s", path)
+
+				summary := b.Summary()
+				if svc, err := k.services(); err == nil {
+					svc.audit.Record(actionContext(ctx, "content:export"), "content.exported", "", nil, nil, summary)
+				}
+
+				table := &console.Table{Headers: []string{"Path", "Pages", "Components", "Media", "Settings"}}
+				table.AddRow(path, strconv.Itoa(summary["pages"]), strconv.Itoa(summary["components"]),
+					strconv.Itoa(summary["media"]), strconv.Itoa(summary["settings"]))
+				return ctx.Render(map[string]interface{}{"path": path, "format": b.Format, "counts": summary}, table)
+			},
+		},
+		{
+			Name:        "content:import",
+			Description: "Import a content bundle",
+			Arguments:   "<path>",
+			Help: "Pages are matched by slug, components by type and name and media by file name.\n" +
+				"--on-conflict decides what happens when they differ from what is here:\n" +
+				"  fail       import nothing and list the conflicts (default)\n" +
+				"  skip       keep the existing content\n" +
+				"  rename     import under a free slug, name or file name (about-2)\n" +
+				"  overwrite  replace the existing content\n" +
+				"Settings that differ are only replaced with overwrite.\n" +
+				"Use --dry-run to see what would change without changing anything.",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("on-conflict", string(bundle.StrategyFail), "fail, skip, rename or overwrite")
+				fs.Bool("dry-run", false, "Report what would change without changing anything")
+				fs.Bool("settings", true, "Apply the bundle's settings")
+				fs.Bool("sitemap", true, "Regenerate the sitemap afterwards")
+				fs.Bool("force", false, "Run without confirmation in production")
+			},
+			Run: func(ctx *console.Context) error {
+				strategy, err := bundle.ParseStrategy(ctx.String("on-conflict"))
+				if err != nil {
+					return err
+				}
+				if ctx.Arg(0) == "" {
+					return fmt.Errorf("a bundle path is required")
+				}
+				b, err := bundle.Open(ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				dryRun := ctx.Bool("dry-run")
+				if !dryRun && ctx.Config.IsProduction() && !ctx.Bool("force") {
+					if !ctx.Confirm(fmt.Sprintf("Import %s into production with --on-conflict=%s?", ctx.Arg(0), strategy)) {
+						return fmt.Errorf("import cancelled")
+					}
+				}
+
+				manager, err := k.bundles()
+				if err != nil {
+					return err
+				}
+				report, importErr := manager.Import(actionContext(ctx, "content:import"), b, bundle.ImportOptions{
+					Strategy: strategy,
+					DryRun:   dryRun,
+					Settings: ctx.Bool("settings"),
+				})
+				if report == nil {
+					return importErr
+				}
+
+				if importErr == nil && !dryRun {
+					svc, err := k.services()
+					if err != nil {
+						return err
+					}
+					svc.audit.Record(actionContext(ctx, "content:import"), "content.imported", "", nil, nil, map[string]interface{}{
+						"source":   b.Source,
+						"strategy": strategy,
+						"created":  report.Count(bundle.ActionCreate) + report.Count(bundle.ActionRename),
+						"updated":  report.Count(bundle.ActionUpdate),
+						"skipped":  report.Count(bundle.ActionSkip),
+					})
+
+					if ctx.Bool("sitemap") {
+						if err := regenerateSitemap(ctx, svc); err != nil {
+							return err
+						}
+					}
+				}
+
+				table := &console.Table{Headers: []string{"Kind", "Key", "Action", "Bundle ID", "ID", "Detail"}}
+				for _, c := range report.Changes {
+					table.AddRow(c.Kind, c.Key, string(c.Action), optionalID(c.SourceID), optionalID(c.TargetID), c.Detail)
+				}
+				if err := ctx.Render(report, table); err != nil {
+					return err
+				}
+				if importErr != nil {
+					return importErr
+				}
+
+				if dryRun {
+					log.Printf("🔍 Dry run: %d to create, %d to update, %d to skip, %d conflict(s); nothing was changed",
+						report.Count(bundle.ActionCreate)+report.Count(bundle.ActionRename), report.Count(bundle.ActionUpdate),
+						report.Count(bundle.ActionSkip), report.Count(bundle.ActionConflict))
+				} else {
+					log.Printf("✅ Imported %s: %d created, %d updated, %d skipped", ctx.Arg(0),
+						report.Count(bundle.ActionCreate)+report.Count(bundle.ActionRename), report.Count(bundle.ActionUpdate),
+						report.Count(bundle.ActionSkip))
+				}
+				return nil
+			},
+		},
+	}
+}
+
+// bundles creates a bundle manager on the database
+func (k *kernel) bundles() (*bundle.Manager, error) {
+	db, err := k.database()
+	if err != nil {
+		return nil, err
+	}
+	svc, err := k.services()
+	if err != nil {
+		return nil, err
+	}
+	manager := bundle.NewManager(db.DB, bundle.Config{UploadDir: k.config.UploadDir, Source: k.config.BaseURL})
+	manager.SetAudit(svc.audit)
+	return manager, nil
+}
+
+// optionalID formats an ID, leaving zero blank
+func optionalID(id int) string {
+	if id == 0 {
+		return ""
+	}
+	return strconv.Itoa(id)
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+
+	"cacto-cms/app/infrastructure/database/seeds"
+	"cacto-cms/app/interfaces/console"
+)
+
+// dbCommands work on the contents of the database
+func dbCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "db:seed",
+			Description: "Fill the database from the seed files",
+			Help: "Seeders are JSON or YAML files: the built-in ones plus those in SEED_DIR.\n" +
+				"Only seeders tagged for the environment run (dev, demo, test, prod; default\n" +
+				"from ENV). A seeder that has run is skipped until its file changes, and\n" +
+				"records that already exist are never duplicated.",
+			Destructive: true,
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("only", "", "Comma-separated seeders to run, e.g. pages,users")
+				fs.String("env", "", "Environment tag to seed for (default: from ENV)")
+				fs.Bool("rerun", false, "Run seeders again even if their file is unchanged")
+			},
+			Run: func(ctx *console.Context) error {
+				opts := k.seedOptions(ctx.String("env"))
+				for _, name := range strings.Split(ctx.String("only"), ",") {
+					if name = strings.TrimSpace(name); name != "" {
+						opts.Only = append(opts.Only, name)
+					}
+				}
+				opts.Rerun = ctx.Bool("rerun")
+
+				results, err := k.seed(ctx.Context(), opts)
+				if err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Seeder", "Status", "Created", "Existing", "Detail"}}
+				for _, r := range results {
+					table.AddRow(r.Name, r.Status, strconv.Itoa(r.Created), strconv.Itoa(r.Existed), r.Detail)
+				}
+				return ctx.Render(results, table)
+			},
+		},
+		{
+			Name:        "db:seeders",
+			Description: "List the seeders, their environments and when they last ran",
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				db, err := k.database()
+				if err != nil {
+					return err
+				}
+				seeder := seeds.NewSeeder(db.DB, k.config.SeedDir)
+				fixtures, err := seeder.Fixtures()
+				if err != nil {
+					return err
+				}
+				runs, err := seeder.Runs(ctx.Context())
+				if err != nil {
+					return err
+				}
+
+				type seederInfo struct {
+					*seeds.Fixture
+					State   string     `json:"state"`
+					LastRun *seeds.Run `json:"last_run,omitempty"`
+				}
+				infos := make([]seederInfo, 0, len(fixtures))
+				table := &console.Table{Headers: []string{"Seeder", "Environments", "Records", "State", "Last run", "File"}}
+				for _, f := range fixtures {
+					info := seederInfo{Fixture: f, State: "pending", LastRun: runs[f.Name]}
+					lastRun := ""
+					if info.LastRun != nil {
+						info.State = "ran"
+						if info.LastRun.Checksum != f.Checksum {
+							info.State = "changed"
+						}
+						lastRun = info.LastRun.RanAt.Local().Format("2006-01-02 15:04") + " (" + info.LastRun.Environment + ")"
+					}
+					envs := strings.Join(f.Environments, ", ")
+					if envs == "" {
+						envs = "all"
+					}
+					infos = append(infos, info)
+					table.AddRow(f.Name, envs, strconv.Itoa(f.Records()), info.State, lastRun, f.Path)
+				}
+				return ctx.Render(infos, table)
+			},
+		},
+	}
+}
+
+// seedOptions selects the seeders of an environment, by default the one ENV names
+func (k *kernel) seedOptions(env string) seeds.Options {
+	if env == "" {
+		env = seeds.EnvironmentTag(k.config.Environment)
+	}
+	return seeds.Options{Environment: env}
+}
+
+// seed runs the seeders opts select
+func (k *kernel) seed(ctx context.Context, opts seeds.Options) ([]seeds.Result, error) {
+	db, err := k.database()
+	if err != nil {
+		return nil, err
+	}
+
+	log.Println("🌱 Running seeders...")
+	results, err := seeds.NewSeeder(db.DB, k.config.SeedDir).Run(ctx, opts)
+	if err != nil {
+		return results, fmt.Errorf("failed to seed database: %w", err)
+	}
+	log.Println("✅ Seeding completed")
+	return results, nil
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/domain/setting"
+	"cacto-cms/app/infrastructure/staticsite"
+	"cacto-cms/app/interfaces/console"
+	"cacto-cms/app/interfaces/http/controller"
+	"cacto-cms/app/shared/seo"
+	"cacto-cms/app/shared/sitemap"
+)
+
+// exportCommands write the site as static files
+func exportCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "export:static",
+			Description: "Render the published pages to plain HTML for static hosting",
+			Help: "Pages are written as <slug>/index.html with the static assets, the media\n" +
+				"and a sitemap. Links to pages end in a slash and get the path of --base-url.\n" +
+				"Only pages that changed since the last export into the directory are rendered\n" +
+				"again; use --full after upgrading the CMS, since templates may have changed.",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("output", "./dist", "Directory to write the site to")
+				fs.String("base-url", "", "URL the exported site is served from (default: BASE_URL)")
+				fs.Bool("full", false, "Render every page, not only the changed ones")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				baseURL := ctx.String("base-url")
+				if baseURL == "" {
+					baseURL = k.config.BaseURL
+				}
+
+				published, err := svc.pages.GetPublishedPages(ctx.Context())
+				if err != nil {
+					return fmt.Errorf("failed to load pages: %w", err)
+				}
+				pages := make([]*page.Page, 0, len(published))
+				for _, p := range published {
+					withComponents, err := svc.pages.GetPageBySlug(ctx.Context(), p.Slug)
+					if err != nil {
+						return fmt.Errorf("failed to load /%s: %w", p.Slug, err)
+					}
+					pages = append(pages, withComponents)
+				}
+
+				var sitemapXML []byte
+				if svc.settings.SitemapEnabled(ctx.Context()) {
+					if sitemapXML, err = sitemap.Build(baseURL, published); err != nil {
+						return err
+					}
+				}
+
+				// Pages are rendered by the controller that serves them, with
+				// canonical URLs on the exported site
+				renderer := controller.NewPageController(baseURL, svc.pages, svc.components, seo.NewManager(baseURL, svc.settings), nil)
+				exporter, err := staticsite.NewExporter(renderer, staticsite.Config{
+					OutputDir: ctx.String("output"),
+					BaseURL:   baseURL,
+					SourceURL: k.config.BaseURL,
+					StaticDir: "./web/static",
+					UploadDir: k.config.UploadDir,
+					Full:      ctx.Bool("full"),
+				})
+				if err != nil {
+					return err
+				}
+
+				result, err := exporter.Export(ctx.Context(), pages, map[string]string{
+					setting.KeySiteName:        svc.settings.SiteName(ctx.Context()),
+					setting.KeySiteDescription: svc.settings.SiteDescription(ctx.Context()),
+				}, sitemapXML)
+				if err != nil {
+					return err
+				}
+				log.Printf("📦 Exported %d page(s) to %s: %d rendered, %d unchanged, %d removed",
+					len(pages), ctx.String("output"), len(result.Rendered), result.Unchanged, len(result.Removed))
+
+				table := &console.Table{Headers: []string{"Rendered", "Unchanged", "Removed", "Files copied", "Files removed", "Sitemap"}}
+				table.AddRow(strconv.Itoa(len(result.Rendered)), strconv.Itoa(result.Unchanged), strconv.Itoa(len(result.Removed)),
+					strconv.Itoa(result.FilesCopied), strconv.Itoa(result.FilesRemoved), strconv.FormatBool(result.Sitemap))
+				return ctx.Render(result, table)
+			},
+		},
+	}
+}
@@ -1,94 +1,80 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"os"
-	"path/filepath"
 
 	"cacto-cms/app/infrastructure/database"
-	"cacto-cms/app/infrastructure/database/seeds"
+	"cacto-cms/app/interfaces/console"
 	"cacto-cms/config"
 )
 
-func main() {
-	// Check for "migrate:fresh" command
-	var migrateFresh bool
-	var seed bool
-
-	for _, arg := range os.Args[1:] {
-		if arg == "migrate:fresh" {
-			migrateFresh = true
-		}
-		if arg == "--seed" {
-			seed = true
-		}
-	}
-
-	if !migrateFresh {
-		fmt.Println("Usage: artisan migrate:fresh [--seed]")
-		fmt.Println("  migrate:fresh  - Drop all tables and re-run all migrations")
-		fmt.Println("  --seed        - Run seeders after migration")
-		os.Exit(1)
-	}
-
-	// Load config
-	cfg := config.Load()
-
-	fmt.Println(`
-╔═════════════════════════════════════════╗
-║         Cacto CMS Artisan CLI           ║
-╚═════════════════════════════════════════╝
-	`)
-
-	// Get database path
-	dbPath := cfg.DBPath
-	if dbPath == "" {
-		dbPath = "./cacto.db"
-	}
-
-	// If migrate:fresh, remove existing database file
-	if migrateFresh {
-		if _, err := os.Stat(dbPath); err == nil {
-			log.Printf("🗑️  Removing existing database: %s", dbPath)
-			if err := os.Remove(dbPath); err != nil {
-				log.Fatalf("Failed to remove database: %v", err)
-			}
+// kernel holds what commands share, opening the database on first use
+type kernel struct {
+	config *config.Config
+	db     *database.Database
+	svc    *services
+}
 
-			// Also remove WAL and SHM files
-			walPath := dbPath + "-wal"
-			shmPath := dbPath + "-shm"
-			os.Remove(walPath)
-			os.Remove(shmPath)
-		}
+func main() {
+	// Load config. It isn't validated: commands still run with settings the
+	// server would refuse, so they can be inspected (config:show) and fixed.
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("%v", err)
 	}
 
-	// Create database and run migrations
-	log.Printf("📂 Database path: %s", dbPath)
+	k := &kernel{config: cfg}
+	defer k.close()
+
+	app := console.New("artisan", cfg)
+	app.Register(migrateCommands(k)...)
+	app.Register(dbCommands(k)...)
+	app.Register(makeCommands(k)...)
+	app.Register(userCommands(k)...)
+	app.Register(pageCommands(k)...)
+	app.Register(sitemapCommands(k)...)
+	app.Register(backupCommands(k)...)
+	app.Register(contentCommands(k)...)
+	app.Register(exportCommands(k)...)
+	app.Register(assetsCommands(k)...)
+	app.Register(trashCommands(k)...)
+	app.Register(configCommands(k)...)
+
+	code := app.Run(os.Args[1:])
+	k.close()
+	os.Exit(code)
+}
 
-	// Ensure database directory exists
-	dir := filepath.Dir(dbPath)
-	if err := os.MkdirAll(dir, 0755); err != nil {
-		log.Fatalf("Failed to create database directory: %v", err)
+// database opens the database without migrating it
+func (k *kernel) database() (*database.Database, error) {
+	if k.db != nil {
+		return k.db, nil
 	}
 
-	db, err := database.New(dbPath)
+	log.Printf("📂 Database path: %s", k.dbPath())
+	db, err := database.New(k.dbPath())
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		return nil, err
 	}
-	defer db.Close()
-
-	log.Println("✅ Migrations completed")
+	database.SetQueryTimeout(k.config.DBQueryTimeout)
+	k.db = db
+	return db, nil
+}
 
-	// Run seeders if requested
-	if seed {
-		log.Println("\n🌱 Running seeders...")
-		seeder := seeds.NewSeeder(db.DB)
-		if err := seeder.SeedAll(); err != nil {
-			log.Fatalf("Failed to seed database: %v", err)
-		}
-		log.Println("✅ Seeding completed")
+// dbPath returns the configured database file
+func (k *kernel) dbPath() string {
+	if k.config.DBPath == "" {
+		return "./cacto.db"
 	}
+	return k.config.DBPath
+}
 
-	fmt.Println("\n✅ Done!")
+// close closes the database if it was opened
+func (k *kernel) close() {
+	if k.db != nil {
+		k.db.Close()
+		k.db = nil
+		k.svc = nil
+	}
 }
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+
+	"cacto-cms/app/infrastructure/database"
+	"cacto-cms/app/interfaces/console"
+)
+
+// migrationName matches the name part of a migration file
+var migrationName = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// makeCommands generate source files
+func makeCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "make:migration",
+			Description: "Create a new up/down migration pair",
+			Arguments:   "<name>",
+			Help:        "Creates NNN_<name>.up.sql and NNN_<name>.down.sql with the next free version.\nThe name must be snake_case, e.g. add_page_tags.",
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("path", "app/infrastructure/database/migrations", "Migrations directory")
+			},
+			Run: func(ctx *console.Context) error {
+				name := ctx.Arg(0)
+				if !migrationName.MatchString(name) {
+					return fmt.Errorf("migration name must be snake_case (a-z, 0-9, _), got %q", name)
+				}
+
+				dir := ctx.String("path")
+				migrations, err := database.LoadMigrations(os.DirFS(dir))
+				if err != nil {
+					return fmt.Errorf("failed to read %s: %w", dir, err)
+				}
+
+				version := 1
+				for _, m := range migrations {
+					if m.Name == name {
+						return fmt.Errorf("migration %s already exists", m.ID())
+					}
+					if m.Version >= version {
+						version = m.Version + 1
+					}
+				}
+
+				id := fmt.Sprintf("%03d_%s", version, name)
+				created := time.Now().Format("2006-01-02")
+				files := map[string]string{
+					id + ".up.sql":   fmt.Sprintf("-- Migration: %s\n-- Created: %s\n\n", name, created),
+					id + ".down.sql": fmt.Sprintf("-- Reverts %s.up.sql\n\n", id),
+				}
+				for file, content := range files {
+					path := filepath.Join(dir, file)
+					if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+						return fmt.Errorf("failed to write %s: %w", path, err)
+					}
+				}
+
+				ctx.Printf("✅ Created migration:\n  %s\n  %s\n",
+					filepath.Join(dir, id+".up.sql"), filepath.Join(dir, id+".down.sql"))
+				return nil
+			},
+		},
+	}
+}
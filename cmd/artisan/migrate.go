@@ -0,0 +1,167 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"cacto-cms/app/interfaces/console"
+)
+
+// migrateCommands apply, inspect and revert database migrations
+func migrateCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "migrate",
+			Description: "Run pending migrations",
+			Destructive: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("seed", false, "Run seeders after migrating")
+			},
+			Run: func(ctx *console.Context) error {
+				db, err := k.database()
+				if err != nil {
+					return err
+				}
+				if err := db.Migrate(); err != nil {
+					return err
+				}
+				if ctx.Bool("seed") {
+					_, err := k.seed(ctx.Context(), k.seedOptions(""))
+					return err
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "migrate:status",
+			Description: "Show which migrations have been applied",
+			Run: func(ctx *console.Context) error {
+				db, err := k.database()
+				if err != nil {
+					return err
+				}
+				migrator, err := db.Migrator()
+				if err != nil {
+					return fmt.Errorf("failed to load migrations: %w", err)
+				}
+				statuses, err := migrator.Status()
+				if err != nil {
+					return fmt.Errorf("failed to read migration status: %w", err)
+				}
+
+				w := tabwriter.NewWriter(ctx.Stdout, 0, 0, 2, ' ', 0)
+				fmt.Fprintln(w, "MIGRATION\tSTATUS\tBATCH\tAPPLIED AT")
+				pending := 0
+				for _, s := range statuses {
+					state, batch, appliedAt := "Pending", "", ""
+					if s.Applied {
+						state = "Applied"
+						batch = strconv.Itoa(s.Batch)
+						appliedAt = s.AppliedAt.Local().Format("2006-01-02 15:04:05")
+					} else {
+						pending++
+					}
+					if s.Modified {
+						state = "Modified"
+					}
+					if s.Missing {
+						state = "Missing file"
+					}
+					fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", s.ID(), state, batch, appliedAt)
+				}
+				w.Flush()
+
+				ctx.Printf("\n%d migration(s), %d pending\n", len(statuses), pending)
+				return nil
+			},
+		},
+		{
+			Name:        "migrate:rollback",
+			Description: "Roll back the last batch of migrations",
+			Help:        "Without --step the migrations of the last batch are reverted.\nWith --step=N the last N migrations are reverted, whatever their batch.",
+			Destructive: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Int("step", 0, "Number of migrations to roll back")
+			},
+			Run: func(ctx *console.Context) error {
+				if ctx.IsSet("step") && ctx.Int("step") < 1 {
+					return fmt.Errorf("--step must be at least 1")
+				}
+
+				db, err := k.database()
+				if err != nil {
+					return err
+				}
+				migrator, err := db.Migrator()
+				if err != nil {
+					return fmt.Errorf("failed to load migrations: %w", err)
+				}
+
+				reverted, err := migrator.Rollback(ctx.Int("step"))
+				if err != nil {
+					return fmt.Errorf("rollback failed: %w", err)
+				}
+				if len(reverted) == 0 {
+					log.Println("✅ Nothing to roll back")
+					return nil
+				}
+				log.Printf("✅ Rolled back %d migration(s)", len(reverted))
+				return nil
+			},
+		},
+		{
+			Name:        "migrate:fresh",
+			Description: "Drop the database and re-run all migrations",
+			Destructive: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("seed", false, "Run seeders after migrating")
+			},
+			Run: func(ctx *console.Context) error {
+				ctx.Printf(`
+╔═════════════════════════════════════════╗
+║         Cacto CMS Artisan CLI           ║
+╚═════════════════════════════════════════╝
+	` + "\n")
+				k.close()
+				if err := removeDatabase(k.dbPath()); err != nil {
+					return err
+				}
+
+				db, err := k.database()
+				if err != nil {
+					return err
+				}
+				if err := db.Migrate(); err != nil {
+					return err
+				}
+				if ctx.Bool("seed") {
+					if _, err := k.seed(ctx.Context(), k.seedOptions("")); err != nil {
+						return err
+					}
+				}
+
+				ctx.Printf("\n✅ Done!\n")
+				return nil
+			},
+		},
+	}
+}
+
+// removeDatabase deletes the database file with its WAL and SHM files
+func removeDatabase(dbPath string) error {
+	if _, err := os.Stat(dbPath); err != nil {
+		return nil
+	}
+
+	log.Printf("🗑️  Removing existing database: %s", dbPath)
+	if err := os.Remove(dbPath); err != nil {
+		return fmt.Errorf("failed to remove database: %w", err)
+	}
+	os.Remove(dbPath + "-wal")
+	os.Remove(dbPath + "-shm")
+	return nil
+}
@@ -0,0 +1,439 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"cacto-cms/app/domain/component"
+	"cacto-cms/app/domain/page"
+	"cacto-cms/app/interfaces/console"
+)
+
+// pageCommands list, copy, import and delete pages and change their
+// publication status and layout
+func pageCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "page:list",
+			Description: "List pages",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("status", "", "Only pages with this status (draft, published, archived)")
+			},
+			Run: func(ctx *console.Context) error {
+				status := page.Status(ctx.String("status"))
+				switch status {
+				case "", page.StatusDraft, page.StatusPublished, page.StatusArchived:
+				default:
+					return fmt.Errorf("unknown status %q", status)
+				}
+
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				pages, err := svc.pages.GetAllPages(ctx.Context())
+				if err != nil {
+					return fmt.Errorf("failed to load pages: %w", err)
+				}
+
+				listed := make([]*page.Page, 0, len(pages))
+				table := &console.Table{Headers: []string{"ID", "Slug", "Title", "Status", "Updated"}}
+				for _, p := range pages {
+					if status != "" && p.Status != status {
+						continue
+					}
+					listed = append(listed, p)
+					table.AddRow(strconv.Itoa(p.ID), p.Slug, p.Title, string(p.Status), p.UpdatedAt.Local().Format("2006-01-02 15:04"))
+				}
+				return ctx.Render(listed, table)
+			},
+		},
+		{
+			Name:        "page:publish",
+			Description: "Publish a page",
+			Arguments:   "<slug|id>",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sitemap", true, "Regenerate the sitemap afterwards")
+			},
+			Run: func(ctx *console.Context) error {
+				return setPageStatus(k, ctx, "page:publish", page.StatusPublished)
+			},
+		},
+		{
+			Name:        "page:unpublish",
+			Description: "Move a published page back to draft",
+			Arguments:   "<slug|id>",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sitemap", true, "Regenerate the sitemap afterwards")
+			},
+			Run: func(ctx *console.Context) error {
+				return setPageStatus(k, ctx, "page:unpublish", page.StatusDraft)
+			},
+		},
+		{
+			Name:        "page:delete",
+			Description: "Move a page to the trash",
+			Arguments:   "<slug|id>",
+			Help:        "The page can be restored with trash:restore until it is purged.",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sitemap", true, "Regenerate the sitemap afterwards")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				p, err := findPage(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				if err := svc.pages.DeletePage(actionContext(ctx, "page:delete"), p.ID); err != nil {
+					return err
+				}
+				log.Printf("🗑️  Moved /%s to the trash", p.Slug)
+
+				if ctx.Bool("sitemap") {
+					if err := regenerateSitemap(ctx, svc); err != nil {
+						return err
+					}
+				}
+				return ctx.Render(p, pageTable(p))
+			},
+		},
+		{
+			Name:        "page:clone",
+			Description: "Copy a page and its components under a new slug",
+			Arguments:   "<slug|id> <new-slug>",
+			Help:        "The copy is a draft and places the same components in the same order.",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("title", "", "Title of the copy (default: the original title)")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				source, err := findPage(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+				slug := strings.Trim(strings.TrimSpace(ctx.Arg(1)), "/")
+				if slug == "" {
+					return fmt.Errorf("a slug for the copy is required")
+				}
+
+				clone, err := svc.pages.ClonePage(actionContext(ctx, "page:clone"), source.ID, slug, ctx.String("title"))
+				if err != nil {
+					return err
+				}
+				log.Printf("📄 Copied /%s to /%s with %d component(s)", source.Slug, clone.Slug, len(clone.Components))
+				return ctx.Render(clone, pageTable(clone))
+			},
+		},
+		{
+			Name:        "page:layout",
+			Description: "Show or replace the components of a page",
+			Arguments:   "<slug|id> [component...]",
+			Help: "Without components, lists the page's layout. With components (names or IDs),\n" +
+				"replaces the layout with them in the given order. Components that stay on the\n" +
+				"page keep their overrides (see page:override).",
+			Output: true,
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				p, err := findPage(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				if len(ctx.Args) > 1 {
+					ids := make([]int, 0, len(ctx.Args)-1)
+					for _, ref := range ctx.Args[1:] {
+						id, err := findComponentID(ctx.Context(), svc, ref)
+						if err != nil {
+							return err
+						}
+						ids = append(ids, id)
+					}
+					if err := svc.pages.ReplaceLayout(actionContext(ctx, "page:layout"), p.ID, ids); err != nil {
+						return err
+					}
+					log.Printf("📄 Layout of /%s replaced with %d component(s)", p.Slug, len(ids))
+				}
+
+				return renderLayout(ctx, svc, p.Slug)
+			},
+		},
+		{
+			Name:        "page:override",
+			Description: "Change a component on one page without changing the shared component",
+			Arguments:   "<slug|id> <placement>",
+			Help: "The placement is the ID page:layout lists. Each field flag given overrides\n" +
+				"that field of the component on this page; an empty value blanks it. --reset\n" +
+				"drops overrides, showing the shared component's values again:\n" +
+				"  page:override about 12 --title \"About us\" --link-text \"\"\n" +
+				"  page:override about 12 --reset=title      (or --reset=all)",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				for _, field := range overrideFields {
+					fs.String(field.flag, "", "Value of the "+field.name+" field on this page")
+				}
+				fs.String("reset", "", "Comma-separated fields to stop overriding, or all")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				p, err := findPage(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+				placementID, err := strconv.Atoi(ctx.Arg(1))
+				if err != nil {
+					return fmt.Errorf("a placement ID is required (see page:layout %s)", ctx.Arg(0))
+				}
+
+				var placement *page.Placement
+				for i := range p.Components {
+					if p.Components[i].PlacementID == placementID {
+						placement = &p.Components[i]
+					}
+				}
+				if placement == nil {
+					return fmt.Errorf("placement %d is not on /%s", placementID, p.Slug)
+				}
+
+				overrides := component.Overrides{}
+				if placement.Overrides != nil {
+					overrides = *placement.Overrides
+				}
+				changed := false
+				for _, name := range strings.Split(ctx.String("reset"), ",") {
+					name = strings.TrimSpace(name)
+					if name == "" {
+						continue
+					}
+					if name == "all" {
+						overrides, changed = component.Overrides{}, true
+						continue
+					}
+					field := findOverrideField(name)
+					if field == nil {
+						return fmt.Errorf("unknown field %q", name)
+					}
+					*field.value(&overrides), changed = nil, true
+				}
+				for _, field := range overrideFields {
+					if ctx.IsSet(field.flag) {
+						value := ctx.String(field.flag)
+						*field.value(&overrides), changed = &value, true
+					}
+				}
+				if !changed {
+					return fmt.Errorf("name a field to override, or pass --reset")
+				}
+
+				if err := svc.pages.OverrideComponent(actionContext(ctx, "page:override"), p.ID, placementID, &overrides); err != nil {
+					return err
+				}
+				log.Printf("📄 Placement %d of /%s now overrides %d field(s)", placementID, p.Slug, len(overrides.Fields()))
+				return renderLayout(ctx, svc, p.Slug)
+			},
+		},
+		{
+			Name:        "page:import",
+			Description: "Create or update a page and its components from a JSON file",
+			Arguments:   "<path>",
+			Help: "The file holds the page fields and its components in layout order:\n" +
+				"  {\"slug\": \"pricing\", \"title\": \"Pricing\", \"status\": \"draft\",\n" +
+				"   \"components\": [{\"type\": \"hero\", \"name\": \"pricing-hero\", \"title\": \"Plans\"}]}\n" +
+				"Pages are matched by slug and components by name; existing ones are updated.\n" +
+				"Nothing is changed unless the page, its components and its layout all succeed.",
+			Output: true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sitemap", true, "Regenerate the sitemap afterwards")
+			},
+			Run: func(ctx *console.Context) error {
+				if ctx.Arg(0) == "" {
+					return fmt.Errorf("a JSON file is required")
+				}
+				data, err := os.ReadFile(ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+				var file pageFile
+				if err := json.Unmarshal(data, &file); err != nil {
+					return fmt.Errorf("failed to read %s: %w", ctx.Arg(0), err)
+				}
+				if file.Page == nil || file.Title == "" {
+					return fmt.Errorf("%s has no page title", ctx.Arg(0))
+				}
+				file.Slug = strings.Trim(file.Slug, "/")
+
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+
+				// Components and page share one transaction, so a failing
+				// page leaves no orphaned components behind
+				actx := actionContext(ctx, "page:import")
+				err = svc.uow.WithTx(actx, func(actx context.Context) error {
+					if err := svc.components.ImportComponents(actx, file.Layout); err != nil {
+						return err
+					}
+					ids := make([]int, 0, len(file.Layout))
+					for _, c := range file.Layout {
+						ids = append(ids, c.ID)
+					}
+					return svc.pages.ImportPage(actx, file.Page, ids)
+				})
+				if err != nil {
+					return err
+				}
+				log.Printf("📄 Imported /%s with %d component(s)", file.Slug, len(file.Layout))
+
+				if ctx.Bool("sitemap") {
+					if err := regenerateSitemap(ctx, svc); err != nil {
+						return err
+					}
+				}
+				return ctx.Render(file.Page, pageTable(file.Page))
+			},
+		},
+	}
+}
+
+// setPageStatus changes the status of the page named by the first argument
+func setPageStatus(k *kernel, ctx *console.Context, command string, status page.Status) error {
+	svc, err := k.services()
+	if err != nil {
+		return err
+	}
+	p, err := findPage(ctx.Context(), svc, ctx.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	if p.Status != status {
+		p.Status = status
+		if err := svc.pages.UpdatePage(actionContext(ctx, command), p); err != nil {
+			return fmt.Errorf("failed to update page: %w", err)
+		}
+
+		if ctx.Bool("sitemap") {
+			if err := regenerateSitemap(ctx, svc); err != nil {
+				return err
+			}
+		}
+	}
+
+	return ctx.Render(p, pageTable(p))
+}
+
+// pageFile is the JSON read by page:import
+type pageFile struct {
+	*page.Page
+	Layout []*component.Component `json:"components"`
+}
+
+// pageTable shows a page as a single table row
+func pageTable(p *page.Page) *console.Table {
+	table := &console.Table{Headers: []string{"ID", "Slug", "Title", "Status", "Updated"}}
+	table.AddRow(strconv.Itoa(p.ID), p.Slug, p.Title, string(p.Status), p.UpdatedAt.Local().Format("2006-01-02 15:04"))
+	return table
+}
+
+// overrideField is a component field page:override sets
+type overrideField struct {
+	flag, name string
+	value      func(*component.Overrides) **string
+}
+
+// overrideFields are the fields page:override sets, by flag
+var overrideFields = []overrideField{
+	{"title", "title", func(o *component.Overrides) **string { return &o.Title }},
+	{"subtitle", "subtitle", func(o *component.Overrides) **string { return &o.Subtitle }},
+	{"content", "content", func(o *component.Overrides) **string { return &o.Content }},
+	{"image-url", "image URL", func(o *component.Overrides) **string { return &o.ImageURL }},
+	{"link-url", "link URL", func(o *component.Overrides) **string { return &o.LinkURL }},
+	{"link-text", "link text", func(o *component.Overrides) **string { return &o.LinkText }},
+	{"data-json", "data JSON", func(o *component.Overrides) **string { return &o.DataJSON }},
+}
+
+// findOverrideField returns the override field with a flag name
+func findOverrideField(name string) *overrideField {
+	for i := range overrideFields {
+		if overrideFields[i].flag == name {
+			return &overrideFields[i]
+		}
+	}
+	return nil
+}
+
+// renderLayout prints the placements of a page with their overridden fields
+func renderLayout(ctx *console.Context, svc *services, slug string) error {
+	p, err := svc.pages.GetPageBySlug(ctx.Context(), slug)
+	if err != nil {
+		return err
+	}
+	table := &console.Table{Headers: []string{"Placement", "Position", "ID", "Type", "Name", "Overrides"}}
+	for _, c := range p.Components {
+		overrides := strings.Join(c.Overrides.Fields(), ", ")
+		if overrides == "" {
+			overrides = "-"
+		}
+		table.AddRow(strconv.Itoa(c.PlacementID), strconv.Itoa(c.Position), strconv.Itoa(c.ID), string(c.Type), c.Name, overrides)
+	}
+	return ctx.Render(p.Components, table)
+}
+
+// findComponentID looks a component up by ID or name
+func findComponentID(ctx context.Context, svc *services, ref string) (int, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		if _, err := svc.components.GetComponentByID(ctx, id); err != nil {
+			return 0, fmt.Errorf("component %d not found", id)
+		}
+		return id, nil
+	}
+	c, err := svc.components.GetComponentByName(ctx, ref)
+	if err != nil {
+		return 0, fmt.Errorf("component %q not found", ref)
+	}
+	return c.ID, nil
+}
+
+// findPage looks a page up by ID or slug
+func findPage(ctx context.Context, svc *services, ref string) (*page.Page, error) {
+	ref = strings.Trim(strings.TrimSpace(ref), "/")
+	if ref == "" {
+		return nil, fmt.Errorf("a page slug or ID is required")
+	}
+
+	var p *page.Page
+	var err error
+	if id, convErr := strconv.Atoi(ref); convErr == nil {
+		p, err = svc.pages.GetPageByID(ctx, id)
+	} else {
+		p, err = svc.pages.GetPageBySlug(ctx, ref)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("page %q not found", ref)
+	}
+	return p, nil
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"context"
+
+	auditservice "cacto-cms/app/application/audit"
+	authservice "cacto-cms/app/application/auth"
+	"cacto-cms/app/application/component"
+	mediaservice "cacto-cms/app/application/media"
+	"cacto-cms/app/application/page"
+	roleservice "cacto-cms/app/application/role"
+	settingservice "cacto-cms/app/application/setting"
+	trashservice "cacto-cms/app/application/trash"
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/audit"
+	pagedomain "cacto-cms/app/domain/page"
+	"cacto-cms/app/infrastructure/database"
+	auditpersistence "cacto-cms/app/infrastructure/persistence/audit"
+	componentpersistence "cacto-cms/app/infrastructure/persistence/component"
+	mediapersistence "cacto-cms/app/infrastructure/persistence/media"
+	pagepersistence "cacto-cms/app/infrastructure/persistence/page"
+	rolepersistence "cacto-cms/app/infrastructure/persistence/role"
+	settingpersistence "cacto-cms/app/infrastructure/persistence/setting"
+	userpersistence "cacto-cms/app/infrastructure/persistence/user"
+	"cacto-cms/app/interfaces/console"
+	"cacto-cms/app/shared/auth"
+	"cacto-cms/app/shared/sitemap"
+)
+
+// services are the application services commands call, wired as in the server
+type services struct {
+	users      *userservice.Service
+	roles      *roleservice.Service
+	pages      *page.Service
+	components *component.Service
+	media      *mediaservice.Service
+	uow        *database.UnitOfWork
+	auth       *authservice.Service
+	audit      *auditservice.Service
+	settings   *settingservice.Service
+	trash      *trashservice.Service
+	sitemap    *sitemap.Generator
+
+	pageRepo pagedomain.Repository // For sitemaps written elsewhere
+}
+
+// services opens the database and builds the application services once
+func (k *kernel) services() (*services, error) {
+	if k.svc != nil {
+		return k.svc, nil
+	}
+
+	db, err := k.database()
+	if err != nil {
+		return nil, err
+	}
+	cfg := k.config
+
+	pageRepo := pagepersistence.NewRepository(db.DB)
+	componentRepo := componentpersistence.NewRepository(db.DB)
+	mediaRepo := mediapersistence.NewRepository(db.DB)
+	unitOfWork := database.NewUnitOfWork(db.DB)
+	auditService := auditservice.NewService(auditpersistence.NewRepository(db.DB))
+	roleService := roleservice.NewService(rolepersistence.NewRepository(db.DB), auditService)
+	settingService := settingservice.NewService(settingpersistence.NewRepository(db.DB), auditService, settingservice.Defaults{
+		SiteName:        cfg.SiteName,
+		SiteDescription: cfg.SiteDescription,
+	})
+	userService := userservice.NewService(userpersistence.NewRepository(db.DB), userpersistence.NewPasswordHistoryRepository(db.DB), unitOfWork, roleService, auditService)
+
+	passwordPolicy := auth.DefaultPasswordPolicy()
+	passwordPolicy.MinLength = cfg.PasswordMinLength
+	passwordPolicy.HistorySize = cfg.PasswordHistory
+	passwordPolicy.CheckCommon = cfg.PasswordCheckCommon
+	if cfg.PasswordBlocklistFile != "" {
+		if err := passwordPolicy.LoadBlocklist(cfg.PasswordBlocklistFile); err != nil {
+			return nil, err
+		}
+	}
+	userService.SetPasswordPolicy(passwordPolicy)
+
+	authService := authservice.NewService(userService, roleService, auditService,
+		userpersistence.NewLockoutRepository(db.DB), userpersistence.NewSessionRepository(db.DB),
+		cfg.JWTSecret, cfg.JWTExpiration)
+	authService.SetLockoutPolicy(authservice.LockoutPolicy{
+		FreeAttempts:     cfg.LoginFreeAttempts,
+		BaseDelay:        cfg.LoginBaseDelay,
+		MaxDelay:         cfg.LoginMaxDelay,
+		LockoutThreshold: cfg.LoginLockoutThreshold,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		Window:           cfg.LoginAttemptWindow,
+	})
+
+	mediaService := mediaservice.NewService(mediaRepo, auditService)
+	mediaService.SetMaxFileSize(cfg.MaxUploadSize)
+
+	k.svc = &services{
+		users:      userService,
+		roles:      roleService,
+		pages:      page.NewService(pageRepo, unitOfWork, auditService),
+		components: component.NewService(componentRepo, unitOfWork, auditService),
+		media:      mediaService,
+		uow:        unitOfWork,
+		auth:       authService,
+		audit:      auditService,
+		settings:   settingService,
+		trash:      trashservice.NewService(pageRepo, componentRepo, mediaRepo, cfg.UploadDir, auditService, cfg.TrashRetention),
+		sitemap:    sitemap.NewGenerator(cfg.BaseURL, sitemap.DefaultPath, pageRepo, settingService),
+
+		pageRepo: pageRepo,
+	}
+	return k.svc, nil
+}
+
+// actionContext attributes audit entries to the command that made the change.
+// There is no signed-in user, so they are recorded as system actions.
+func actionContext(ctx *console.Context, command string) context.Context {
+	return audit.WithActor(ctx.Context(), audit.Actor{UserAgent: "artisan " + command})
+}
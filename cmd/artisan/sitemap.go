@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"log"
+	"strconv"
+
+	"cacto-cms/app/interfaces/console"
+	"cacto-cms/app/shared/sitemap"
+)
+
+// sitemapCommands rebuild the sitemap outside the daily schedule
+func sitemapCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "sitemap:generate",
+			Description: "Regenerate sitemap.xml from the published pages",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("path", sitemap.DefaultPath, "Output file")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				pages, err := svc.pages.GetPublishedPages(ctx.Context())
+				if err != nil {
+					return err
+				}
+
+				path := ctx.String("path")
+				if err := sitemap.NewGenerator(k.config.BaseURL, path, svc.pageRepo, svc.settings).Generate(ctx.Context()); err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Path", "URLs"}}
+				table.AddRow(path, strconv.Itoa(len(pages)+1))
+				return ctx.Render(map[string]interface{}{"path": path, "urls": len(pages) + 1}, table)
+			},
+		},
+	}
+}
+
+// regenerateSitemap rewrites the sitemap after a content change. A sitemap
+// disabled in the site settings is not an error.
+func regenerateSitemap(ctx *console.Context, svc *services) error {
+	err := svc.sitemap.Generate(ctx.Context())
+	if errors.Is(err, sitemap.ErrDisabled) {
+		log.Println("📍 Sitemap is disabled in the site settings, not regenerated")
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	log.Println("📍 Sitemap regenerated")
+	return nil
+}
@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"cacto-cms/app/domain/media"
+	"cacto-cms/app/interfaces/console"
+)
+
+// trashCommands delete components and media and list, restore and purge the
+// trash
+func trashCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "component:delete",
+			Description: "Move a component to the trash",
+			Arguments:   "<name|id>",
+			Help: "Pages keep the component's place in their layout: restoring it with\n" +
+				"trash:restore puts it back where it was.",
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				id, err := findComponentID(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				if err := svc.components.DeleteComponent(actionContext(ctx, "component:delete"), id); err != nil {
+					return err
+				}
+				log.Printf("🗑️  Moved component %s to the trash", ctx.Arg(0))
+				return nil
+			},
+		},
+		{
+			Name:        "media:delete",
+			Description: "Move a media file to the trash",
+			Arguments:   "<filename|id>",
+			Help: "The file stays in the uploads directory until the trash is purged, so\n" +
+				"pages linking it keep working until then and trash:restore brings it back.",
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				m, err := findMedia(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				if err := svc.media.DeleteMedia(actionContext(ctx, "media:delete"), m.ID); err != nil {
+					return err
+				}
+				log.Printf("🗑️  Moved media %s to the trash", m.Filename)
+				return nil
+			},
+		},
+		{
+			Name:        "trash:list",
+			Description: "List deleted pages, components and media",
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				contents, err := svc.trash.Contents(ctx.Context())
+				if err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Kind", "ID", "Name", "Deleted", "Purged after"}}
+				for _, p := range contents.Pages {
+					table.AddRow("page", strconv.Itoa(p.ID), "/"+p.Slug, trashTime(p.DeletedAt, 0), trashTime(p.DeletedAt, contents.Retention))
+				}
+				for _, c := range contents.Components {
+					table.AddRow("component", strconv.Itoa(c.ID), c.Name, trashTime(c.DeletedAt, 0), trashTime(c.DeletedAt, contents.Retention))
+				}
+				for _, m := range contents.Media {
+					table.AddRow("media", strconv.Itoa(m.ID), m.Filename, trashTime(m.DeletedAt, 0), trashTime(m.DeletedAt, contents.Retention))
+				}
+				return ctx.Render(contents, table)
+			},
+		},
+		{
+			Name:        "trash:restore",
+			Description: "Take a page, component or media file out of the trash",
+			Arguments:   "<page|component|media> <id>",
+			Help:        "A restored component returns to the pages and positions it had.",
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("sitemap", true, "Regenerate the sitemap after restoring a page")
+			},
+			Run: func(ctx *console.Context) error {
+				id, err := strconv.Atoi(ctx.Arg(1))
+				if err != nil {
+					return fmt.Errorf("an ID from trash:list is required")
+				}
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+
+				actx := actionContext(ctx, "trash:restore")
+				switch ctx.Arg(0) {
+				case "page":
+					p, err := svc.trash.RestorePage(actx, id)
+					if err != nil {
+						return err
+					}
+					log.Printf("♻️  Restored /%s", p.Slug)
+					if ctx.Bool("sitemap") {
+						return regenerateSitemap(ctx, svc)
+					}
+				case "component":
+					c, err := svc.trash.RestoreComponent(actx, id)
+					if err != nil {
+						return err
+					}
+					log.Printf("♻️  Restored component %s", c.Name)
+				case "media":
+					m, err := svc.trash.RestoreMedia(actx, id)
+					if err != nil {
+						return err
+					}
+					log.Printf("♻️  Restored media %s", m.Filename)
+				default:
+					return fmt.Errorf("restore a page, a component or media, not %q", ctx.Arg(0))
+				}
+				return nil
+			},
+		},
+		{
+			Name:        "trash:purge",
+			Description: "Permanently delete items that have outlived the trash retention",
+			Help: "Items are purged once they have been in the trash for TRASH_RETENTION;\n" +
+				"the server does this every hour. Purged media lose their file in the uploads\n" +
+				"directory. --all empties the trash.",
+			Destructive: true,
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.Bool("all", false, "Purge everything in the trash, however recent")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+
+				before := time.Now().Add(-svc.trash.Retention())
+				if ctx.Bool("all") {
+					before = time.Now()
+				}
+				purged, err := svc.trash.PurgeDeletedBefore(actionContext(ctx, "trash:purge"), before)
+				if err != nil {
+					return err
+				}
+
+				table := &console.Table{Headers: []string{"Pages", "Components", "Media"}}
+				table.AddRow(strconv.Itoa(purged.Pages), strconv.Itoa(purged.Components), strconv.Itoa(purged.Media))
+				return ctx.Render(purged, table)
+			},
+		},
+	}
+}
+
+// trashTime formats when an item was deleted, plus an optional offset
+func trashTime(deletedAt *time.Time, offset time.Duration) string {
+	if deletedAt == nil {
+		return ""
+	}
+	return deletedAt.Add(offset).Local().Format("2006-01-02 15:04")
+}
+
+// findMedia looks a media file up by ID or file name
+func findMedia(ctx context.Context, svc *services, ref string) (*media.Media, error) {
+	if id, err := strconv.Atoi(ref); err == nil {
+		m, err := svc.media.GetMediaByID(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("media %d not found", id)
+		}
+		return m, nil
+	}
+	m, err := svc.media.GetMediaByFilename(ctx, ref)
+	if err != nil {
+		return nil, fmt.Errorf("media %q not found", ref)
+	}
+	return m, nil
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/interfaces/console"
+	"cacto-cms/app/shared/validation"
+)
+
+// userCommands manage accounts from the shell, e.g. when every admin is
+// locked out of the panel
+func userCommands(k *kernel) []*console.Command {
+	return []*console.Command{
+		{
+			Name:        "user:create",
+			Description: "Create an active user",
+			Arguments:   "<email>",
+			Help:        "Without --password a temporary password is generated and printed once.",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("name", "", "Display name")
+				fs.String("role", string(user.RoleViewer), "Role name")
+				fs.String("password", "", "Password (checked against the password policy)")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+
+				req := &userservice.InviteUserRequest{
+					Email:    ctx.Arg(0),
+					Name:     ctx.String("name"),
+					Role:     ctx.String("role"),
+					Password: ctx.String("password"),
+				}
+				if err := validation.ValidateStruct(req); err != nil {
+					return err
+				}
+
+				u, temporary, err := svc.users.InviteUser(actionContext(ctx, "user:create"), req)
+				if err != nil {
+					return err
+				}
+
+				table := userTable(u)
+				if temporary != "" {
+					table.Headers = append(table.Headers, "Temporary password")
+					table.Rows[0] = append(table.Rows[0], temporary)
+				}
+				return ctx.Render(userResult{User: u, TemporaryPassword: temporary}, table)
+			},
+		},
+		{
+			Name:        "user:set-role",
+			Description: "Change the role of a user",
+			Arguments:   "<email|id> <role>",
+			Help:        "The last active admin cannot be demoted.",
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				if len(ctx.Args) != 2 {
+					return fmt.Errorf("expected a user and a role")
+				}
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				u, err := findUser(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				u, err = svc.users.ChangeRole(actionContext(ctx, "user:set-role"), u.ID, ctx.Arg(1))
+				if err != nil {
+					return err
+				}
+				return ctx.Render(userResult{User: u}, userTable(u))
+			},
+		},
+		{
+			Name:        "user:reset-password",
+			Description: "Set a new password and lift any login lockout",
+			Arguments:   "<email|id>",
+			Help:        "Without --password a temporary password is generated and printed once.\nThe account is also unlocked unless --unlock=false is given.",
+			Output:      true,
+			Flags: func(fs *flag.FlagSet) {
+				fs.String("password", "", "New password (checked against the password policy)")
+				fs.Bool("unlock", true, "Lift an active login lockout")
+			},
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				u, err := findUser(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				actx := actionContext(ctx, "user:reset-password")
+				temporary, err := svc.users.ResetPassword(actx, u.ID, ctx.String("password"))
+				if err != nil {
+					return err
+				}
+				if ctx.Bool("unlock") {
+					if err := svc.auth.UnlockAccount(actx, u.Email, 0); err != nil {
+						return err
+					}
+				}
+
+				table := userTable(u)
+				if temporary != "" {
+					table.Headers = append(table.Headers, "Temporary password")
+					table.Rows[0] = append(table.Rows[0], temporary)
+				}
+				return ctx.Render(userResult{User: u, TemporaryPassword: temporary}, table)
+			},
+		},
+		{
+			Name:        "user:deactivate",
+			Description: "Deactivate a user so they can no longer sign in",
+			Arguments:   "<email|id>",
+			Help:        "The user's sessions and API tokens stop working at once.\nThe last active admin cannot be deactivated.",
+			Destructive: true,
+			Output:      true,
+			Run: func(ctx *console.Context) error {
+				svc, err := k.services()
+				if err != nil {
+					return err
+				}
+				u, err := findUser(ctx.Context(), svc, ctx.Arg(0))
+				if err != nil {
+					return err
+				}
+
+				u, err = svc.users.SetActive(actionContext(ctx, "user:deactivate"), u.ID, false)
+				if err != nil {
+					return err
+				}
+				return ctx.Render(userResult{User: u}, userTable(u))
+			},
+		},
+	}
+}
+
+// userResult is the JSON output of the user commands
+type userResult struct {
+	User              *user.User `json:"user"`
+	TemporaryPassword string     `json:"temporary_password,omitempty"`
+}
+
+// findUser looks a user up by ID or email
+func findUser(ctx context.Context, svc *services, ref string) (*user.User, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, fmt.Errorf("a user email or ID is required")
+	}
+	if id, err := strconv.Atoi(ref); err == nil {
+		return svc.users.GetUserByID(ctx, id)
+	}
+	return svc.users.GetUserByEmail(ctx, ref)
+}
+
+// userTable shows a user as a single table row
+func userTable(u *user.User) *console.Table {
+	lastLogin := "never"
+	if u.LastLoginAt != nil {
+		lastLogin = u.LastLoginAt.Local().Format("2006-01-02 15:04")
+	}
+
+	table := &console.Table{Headers: []string{"ID", "Email", "Name", "Role", "Active", "Last login"}}
+	table.AddRow(strconv.Itoa(u.ID), u.Email, u.Name, string(u.Role), strconv.FormatBool(u.IsActive), lastLogin)
+	return table
+}
@@ -1,30 +1,67 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
 
+	tokenservice "cacto-cms/app/application/apitoken"
+	auditservice "cacto-cms/app/application/audit"
 	authservice "cacto-cms/app/application/auth"
 	"cacto-cms/app/application/component"
 	"cacto-cms/app/application/page"
+	roleservice "cacto-cms/app/application/role"
+	settingservice "cacto-cms/app/application/setting"
+	trashservice "cacto-cms/app/application/trash"
 	userservice "cacto-cms/app/application/user"
+	"cacto-cms/app/infrastructure/backup"
+	"cacto-cms/app/infrastructure/bundle"
 	"cacto-cms/app/infrastructure/database"
+	tokenpersistence "cacto-cms/app/infrastructure/persistence/apitoken"
+	auditpersistence "cacto-cms/app/infrastructure/persistence/audit"
 	componentpersistence "cacto-cms/app/infrastructure/persistence/component"
+	mediapersistence "cacto-cms/app/infrastructure/persistence/media"
 	pagepersistence "cacto-cms/app/infrastructure/persistence/page"
+	rolepersistence "cacto-cms/app/infrastructure/persistence/role"
+	settingpersistence "cacto-cms/app/infrastructure/persistence/setting"
 	userpersistence "cacto-cms/app/infrastructure/persistence/user"
 	httphandlers "cacto-cms/app/interfaces/http"
 	"cacto-cms/app/interfaces/http/controller"
+	"cacto-cms/app/domain/user"
+	"cacto-cms/app/shared/assets"
 	"cacto-cms/app/shared/auth"
+	"cacto-cms/app/shared/events"
+	"cacto-cms/app/shared/oidc"
+	"cacto-cms/app/shared/pagecache"
 	"cacto-cms/app/shared/seo"
 	"cacto-cms/app/shared/sitemap"
+	"cacto-cms/app/shared/webauthn"
 	"cacto-cms/config"
 )
 
 func main() {
-	// Load config
-	cfg := config.Load()
+	// Load config and refuse to start with an invalid or insecure one
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("%v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("%v", err)
+	}
+	if cfg.ConfigFile != "" {
+		log.Printf("⚙️  Config file: %s", cfg.ConfigFile)
+	}
+
+	// Hold the server lock so offline maintenance (backup:restore) refuses
+	// to run against a database in use, and the server refuses to start
+	// during one
+	serverLock, err := database.AcquireServerLock(cfg.DBPath)
+	if err != nil {
+		log.Fatalf("Failed to lock database: %v", err)
+	}
+	defer serverLock.Release()
 
 	// Initialize database
 	db, err := database.New(cfg.DBPath)
@@ -32,45 +69,196 @@ func main() {
 		log.Fatalf("Failed to initialize database: %v", err)
 	}
 	defer db.Close()
+	database.SetQueryTimeout(cfg.DBQueryTimeout)
+
+	// Migrations are run via artisan CLI (go run ./cmd/artisan migrate),
+	// or on boot when AUTO_MIGRATE is enabled
+	if cfg.AutoMigrate {
+		if err := db.Migrate(); err != nil {
+			log.Fatalf("Failed to migrate database: %v", err)
+		}
+	} else if err := checkMigrations(db); err != nil {
+		log.Fatalf("%v", err)
+	}
 
 	// Seeders are now run via artisan CLI: go run ./cmd/artisan migrate:fresh --seed
 
 	// Initialize repositories
 	pageRepo := pagepersistence.NewRepository(db.DB)
 	componentRepo := componentpersistence.NewRepository(db.DB)
+	mediaRepo := mediapersistence.NewRepository(db.DB)
 	userRepo := userpersistence.NewRepository(db.DB)
+	lockoutRepo := userpersistence.NewLockoutRepository(db.DB)
+	sessionRepo := userpersistence.NewSessionRepository(db.DB)
+	passwordHistoryRepo := userpersistence.NewPasswordHistoryRepository(db.DB)
+	roleRepo := rolepersistence.NewRepository(db.DB)
+	tokenRepo := tokenpersistence.NewRepository(db.DB)
+	auditRepo := auditpersistence.NewRepository(db.DB)
+	settingRepo := settingpersistence.NewRepository(db.DB)
+	unitOfWork := database.NewUnitOfWork(db.DB)
 
 	// Initialize services
-	pageService := page.NewService(pageRepo)
-	componentService := component.NewService(componentRepo)
-	userService := userservice.NewService(userRepo)
+	auditService := auditservice.NewService(auditRepo)
+	pageService := page.NewService(pageRepo, unitOfWork, auditService)
+	componentService := component.NewService(componentRepo, unitOfWork, auditService)
+	roleService := roleservice.NewService(roleRepo, auditService)
+	userService := userservice.NewService(userRepo, passwordHistoryRepo, unitOfWork, roleService, auditService)
+
+	// Site settings: values saved in the admin override the ones from the environment
+	settingService := settingservice.NewService(settingRepo, auditService, settingservice.Defaults{
+		SiteName:        cfg.SiteName,
+		SiteDescription: cfg.SiteDescription,
+	})
+
+	passwordPolicy := auth.DefaultPasswordPolicy()
+	passwordPolicy.MinLength = cfg.PasswordMinLength
+	passwordPolicy.HistorySize = cfg.PasswordHistory
+	passwordPolicy.CheckCommon = cfg.PasswordCheckCommon
+	if cfg.PasswordBlocklistFile != "" {
+		if err := passwordPolicy.LoadBlocklist(cfg.PasswordBlocklistFile); err != nil {
+			log.Fatalf("Failed to load password blocklist: %v", err)
+		}
+	}
+	userService.SetPasswordPolicy(passwordPolicy)
+	tokenService := tokenservice.NewService(tokenRepo, userService, roleService, auditService)
 
 	// Initialize auth
 	jwtManager := auth.NewJWTManager(cfg.JWTSecret, cfg.JWTExpiration)
-	authService := authservice.NewService(userService, cfg.JWTSecret, cfg.JWTExpiration)
+	authService := authservice.NewService(userService, roleService, auditService, lockoutRepo, sessionRepo, cfg.JWTSecret, cfg.JWTExpiration)
+	authService.SetImpersonationDuration(cfg.ImpersonationDuration)
+	authService.SetLockoutPolicy(authservice.LockoutPolicy{
+		FreeAttempts:     cfg.LoginFreeAttempts,
+		BaseDelay:        cfg.LoginBaseDelay,
+		MaxDelay:         cfg.LoginMaxDelay,
+		LockoutThreshold: cfg.LoginLockoutThreshold,
+		LockoutDuration:  cfg.LoginLockoutDuration,
+		Window:           cfg.LoginAttemptWindow,
+	})
+	authService.ScheduleAttemptPruning()
+
+	// Initialize single sign-on (OpenID Connect)
+	if cfg.SSOEnabled() {
+		mappings, err := authservice.ParseRoleMappings(cfg.OIDCRoleMapping)
+		if err != nil {
+			log.Fatalf("Invalid OIDC_ROLE_MAPPING: %v", err)
+		}
+		for _, m := range append(mappings, authservice.RoleMapping{Role: user.Role(cfg.OIDCDefaultRole)}) {
+			if m.Role != "" && !roleService.RoleExists(context.Background(), string(m.Role)) {
+				log.Fatalf("Unknown role in SSO configuration: %s", m.Role)
+			}
+		}
+
+		provider := oidc.NewProvider(oidc.Config{
+			Issuer:       cfg.OIDCIssuer,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+			Scopes:       cfg.OIDCScopes,
+		})
+		authService.EnableSSO(provider, userpersistence.NewIdentityRepository(db.DB), authservice.SSOConfig{
+			ProviderName:         cfg.OIDCProviderName,
+			RoleClaim:            cfg.OIDCRoleClaim,
+			RoleMappings:         mappings,
+			DefaultRole:          user.Role(cfg.OIDCDefaultRole),
+			AutoProvision:        cfg.OIDCAutoProvision,
+			LinkByEmail:          cfg.OIDCLinkByEmail,
+			DisablePasswordLogin: cfg.OIDCDisablePasswordLogin,
+		})
+		log.Printf("🔐 Single sign-on enabled (%s)", cfg.OIDCIssuer)
+	}
+
+	// Initialize passkeys (WebAuthn)
+	if cfg.PasskeysEnabled {
+		authService.EnablePasskeys(webauthn.NewRelyingParty(webauthn.Config{
+			RPID:    cfg.WebAuthnRPID,
+			RPName:  cfg.SiteName,
+			Origins: cfg.WebAuthnOrigins,
+		}), userpersistence.NewPasskeyRepository(db.DB))
+		log.Printf("🔑 Passkeys enabled for %s", cfg.WebAuthnRPID)
+	}
 
 	// Initialize SEO manager
-	seoManager := seo.NewManager(cfg.BaseURL, cfg.SiteName, cfg.SiteDescription)
+	seoManager := seo.NewManager(cfg.BaseURL, settingService)
 
 	// Initialize sitemap generator
-	sitemapPath := "./web/static/sitemap.xml"
-	sitemapGen := sitemap.NewGenerator(cfg.BaseURL, sitemapPath, pageRepo)
+	sitemapGen := sitemap.NewGenerator(cfg.BaseURL, sitemap.DefaultPath, pageRepo, settingService)
 	sitemapGen.ScheduleDaily()
 	log.Println("📍 Sitemap generator scheduled")
 
+	// Trash: deleted pages, components and media are purged after the retention period
+	trashService := trashservice.NewService(pageRepo, componentRepo, mediaRepo, cfg.UploadDir, auditService, cfg.TrashRetention)
+	trashService.Schedule()
+	log.Printf("🗑️  Trash retention: %s", cfg.TrashRetention)
+
+	// Page cache: content changes are published on the bus and drop the pages they affect
+	contentEvents := events.NewBus()
+	pageService.SetEvents(contentEvents)
+	componentService.SetEvents(contentEvents)
+	settingService.SetEvents(contentEvents)
+	trashService.SetEvents(contentEvents)
+
+	var pageCache *pagecache.Cache
+	if cfg.PageCacheEnabled {
+		pageCache = pagecache.New(pagecache.Options{
+			TTL:        cfg.PageCacheTTL,
+			Stale:      cfg.PageCacheStale,
+			MaxEntries: cfg.PageCacheMaxEntries,
+		})
+		pageCache.Subscribe(contentEvents)
+		log.Printf("⚡ Page cache enabled (TTL %s)", cfg.PageCacheTTL)
+	}
+
+	// Initialize scheduled backups
+	if cfg.BackupEnabled {
+		backups := backup.NewManager(db.DB, backup.Config{
+			Dir:       cfg.BackupDir,
+			DBPath:    cfg.DBPath,
+			UploadDir: cfg.UploadDir,
+			Retention: backup.Retention{
+				Hourly: cfg.BackupKeepHourly,
+				Daily:  cfg.BackupKeepDaily,
+				Weekly: cfg.BackupKeepWeekly,
+			},
+		})
+		backups.Schedule(cfg.BackupInterval)
+		log.Printf("💾 Backups scheduled every %s to %s", cfg.BackupInterval, cfg.BackupDir)
+	}
+
 	// Initialize controllers
 	pageController := controller.NewPageController(
 		cfg.BaseURL,
 		pageService,
 		componentService,
 		seoManager,
+		pageCache,
 	)
 	
 	authController := controller.NewAuthController(authService, cfg)
-	adminController := controller.NewAdminController(authService, cfg.BaseURL, cfg)
+	adminController := controller.NewAdminController(authService, roleService, cfg.BaseURL, cfg)
+	roleController := controller.NewRoleController(roleService, cfg)
+	userController := controller.NewUserController(userService, roleService, cfg)
+	tokenController := controller.NewTokenController(tokenService, cfg)
+	auditController := controller.NewAuditController(auditService, roleService, cfg)
+	sessionController := controller.NewSessionController(authService, roleService, cfg)
+	passkeyController := controller.NewPasskeyController(authService, roleService, cfg)
+	bundles := bundle.NewManager(db.DB, bundle.Config{UploadDir: cfg.UploadDir, Source: cfg.BaseURL})
+	bundles.SetAudit(auditService)
+	bundles.SetEvents(contentEvents)
+	contentController := controller.NewContentController(
+		bundles,
+		auditService,
+		cfg,
+	)
+	settingsController := controller.NewSettingsController(settingService, sitemapGen, pageCache, roleService, cfg)
+	trashController := controller.NewTrashController(trashService, sitemapGen, roleService, cfg)
 
 	// Setup router
-	router := httphandlers.NewRouter(pageController, authController, adminController, jwtManager, cfg)
+	router := httphandlers.NewRouter(pageController, authController, adminController, roleController, userController, tokenController, auditController, sessionController, passkeyController, contentController, settingsController, trashController, sitemapGen, roleService, jwtManager, authService, tokenService, cfg)
+
+	// Every page loads the vendored scripts: refuse to serve without them
+	if err := assets.CheckVendored("./web/static"); err != nil {
+		log.Fatalf("❌ %v", err)
+	}
 
 	// Start server
 	addr := ":" + cfg.ServerPort
@@ -106,3 +294,33 @@ func init() {
 	╚═════════════════════════════════════════╝
 	`)
 }
+
+// checkMigrations refuses to start on a database with pending migrations
+// and warns when applied migrations no longer match their files
+func checkMigrations(db *database.Database) error {
+	migrator, err := db.Migrator()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	statuses, err := migrator.Status()
+	if err != nil {
+		return fmt.Errorf("failed to read migration status: %w", err)
+	}
+
+	pending := 0
+	for _, s := range statuses {
+		switch {
+		case !s.Applied:
+			pending++
+		case s.Modified:
+			log.Printf("⚠️  Migration %s was modified after it was applied", s.ID())
+		case s.Missing:
+			log.Printf("⚠️  Migration %s is applied but unknown to this build", s.ID())
+		}
+	}
+	if pending > 0 {
+		return fmt.Errorf("database has %d pending migration(s): run ./artisan migrate or set AUTO_MIGRATE=true", pending)
+	}
+	return nil
+}
@@ -1,13 +1,18 @@
 package config
 
 import (
+	"fmt"
+	"net/url"
 	"os"
+	"sort"
 	"strings"
 	"time"
 )
 
 // Config holds application configuration
 type Config struct {
+	ConfigFile string // YAML or TOML file the settings were read from, if any
+
 	// Server
 	ServerPort string
 	BaseURL    string
@@ -15,56 +20,197 @@ type Config struct {
 	UseHTTPS   bool   // Whether HTTPS is enabled
 
 	// Database
-	DBPath string
+	DBPath      string
+	AutoMigrate bool // Apply pending migrations when the server starts
+	DBQueryTimeout time.Duration // Deadline for a single database query
+	SeedDir        string        // Seed files that add to or replace the built-in ones
+
+	// Trash
+	TrashRetention time.Duration // How long deleted pages and components can be restored
+
+	// Page cache
+	PageCacheEnabled    bool          // Keep rendered public pages in memory
+	PageCacheTTL        time.Duration // Longest a page is served without rendering it again
+	PageCacheStale      time.Duration // Serve outdated pages this long while they are rendered again; 0 to turn off
+	PageCacheMaxEntries int
+
+	// Compression
+	CompressionEnabled bool // gzip or Brotli for dynamic responses the client accepts
+	CompressionMinSize int  // Smaller responses are sent as they are
 
 	// File Storage
 	UploadDir string
 	MaxUploadSize int64 // in bytes
 
+	// Backups
+	BackupEnabled    bool          // Take scheduled backups while the server runs
+	BackupDir        string
+	BackupInterval   time.Duration
+	BackupKeepHourly int           // Newest backup of each of the last N hours
+	BackupKeepDaily  int           // Newest backup of each of the last N days
+	BackupKeepWeekly int           // Newest backup of each of the last N weeks
+
 	// JWT
 	JWTSecret     string
 	JWTExpiration time.Duration
 
+	// Impersonation
+	ImpersonationDuration time.Duration // Lifetime of an admin impersonation session
+
 	// Site
 	SiteName        string
 	SiteDescription string
 
 	// Security
 	AllowedOrigins []string // CORS allowed origins
+
+	// Passkeys (WebAuthn)
+	PasskeysEnabled  bool
+	WebAuthnRPID     string   // Domain passkeys are bound to (default: BASE_URL host)
+	WebAuthnOrigins  []string // Origins allowed to use passkeys (default: BASE_URL)
+
+	// Brute-force protection
+	LoginFreeAttempts     int           // Failed logins allowed before delays kick in
+	LoginBaseDelay        time.Duration // First delay, doubled on each further failure
+	LoginMaxDelay         time.Duration // Upper bound for the delay
+	LoginLockoutThreshold int           // Failed logins that lock the account
+	LoginLockoutDuration  time.Duration // How long a lockout lasts
+	LoginAttemptWindow    time.Duration // Failures older than this are forgotten
+
+	// Password policy
+	PasswordMinLength     int
+	PasswordHistory       int    // Recent passwords that cannot be reused
+	PasswordCheckCommon   bool   // Reject common/breached passwords
+	PasswordBlocklistFile string // Extra breached password list, one per line
+
+	// Single sign-on (OpenID Connect)
+	OIDCIssuer               string
+	OIDCClientID             string
+	OIDCClientSecret         string
+	OIDCRedirectURL          string
+	OIDCScopes               []string
+	OIDCProviderName         string // Shown on the login button
+	OIDCRoleClaim            string // Claim holding groups/roles
+	OIDCRoleMapping          string // "group=role,group=role", first match wins
+	OIDCDefaultRole          string // Role when no mapping matches; empty denies access
+	OIDCAutoProvision        bool   // Create users on first sign-in
+	OIDCLinkByEmail          bool   // Link existing users by verified email
+	OIDCDisablePasswordLogin bool   // Only allow SSO sign-in
+
+	entries []Entry // Every setting and its source, for config:show
 }
 
-// Load loads configuration from environment variables with defaults
-func Load() *Config {
-	env := getEnv("ENV", "development")
-	baseURL := getEnv("BASE_URL", "http://localhost:8080")
-	useHTTPS := getEnvBool("USE_HTTPS", false)
-	
+// Load loads configuration from environment variables, the optional config
+// file CONFIG_FILE names (YAML or TOML) and defaults, in that order of
+// precedence. It fails on unreadable files and values it can't parse;
+// Validate checks the values themselves.
+func Load() (*Config, error) {
+	file := map[string]string{}
+	configFile := os.Getenv("CONFIG_FILE")
+	if configFile != "" {
+		var err error
+		if file, err = readFile(configFile); err != nil {
+			return nil, err
+		}
+	}
+	l := newLoader(file)
+
+	env := l.getEnv("ENV", "development")
+	baseURL := l.getEnv("BASE_URL", "http://localhost:8080")
+	useHTTPS := l.getEnvBool("USE_HTTPS", false)
+
 	// If USE_HTTPS is not set, try to detect from BASE_URL
 	if !useHTTPS {
 		useHTTPS = strings.HasPrefix(baseURL, "https://")
 	}
-	
+
 	cfg := &Config{
-		ServerPort:     getEnv("PORT", "8080"),
-		DBPath:          getEnv("DB_PATH", "./cacto.db"),
-		BaseURL:         baseURL,
-		UploadDir:       getEnv("UPLOAD_DIR", "./web/uploads"),
-		MaxUploadSize:   10 * 1024 * 1024, // 10MB
-		Environment:     env,
-		UseHTTPS:        useHTTPS,
-		JWTSecret:       getEnv("JWT_SECRET", generateDefaultSecret()),
-		JWTExpiration:   24 * time.Hour,
-		SiteName:        getEnv("SITE_NAME", "Cacto CMS"),
-		SiteDescription: getEnv("SITE_DESCRIPTION", "Performance-focused enterprise CMS"),
-		AllowedOrigins:  getAllowedOrigins(env, baseURL),
+		ConfigFile:       configFile,
+		ServerPort:       l.getEnv("PORT", "8080"),
+		DBPath:           l.getEnv("DB_PATH", "./cacto.db"),
+		AutoMigrate:      l.getEnvBool("AUTO_MIGRATE", false),
+		DBQueryTimeout:   l.getEnvDuration("DB_QUERY_TIMEOUT", 5*time.Second),
+		SeedDir:          l.getEnv("SEED_DIR", "./seeds"),
+		BaseURL:          baseURL,
+		TrashRetention:   l.getEnvDuration("TRASH_RETENTION", 30*24*time.Hour),
+		PageCacheEnabled:    l.getEnvBool("PAGE_CACHE", true),
+		PageCacheTTL:        l.getEnvDuration("PAGE_CACHE_TTL", 5*time.Minute),
+		PageCacheStale:      l.getEnvOptionalDuration("PAGE_CACHE_STALE", 0),
+		PageCacheMaxEntries: l.getEnvInt("PAGE_CACHE_MAX_ENTRIES", 1000),
+		CompressionEnabled: l.getEnvBool("COMPRESSION", true),
+		CompressionMinSize: l.getEnvInt("COMPRESSION_MIN_SIZE", 1024),
+		UploadDir:        l.getEnv("UPLOAD_DIR", "./web/uploads"),
+		MaxUploadSize:    l.getEnvSize("MAX_UPLOAD_SIZE", 10*1024*1024), // 10MB
+		BackupEnabled:    l.getEnvBool("BACKUP_ENABLED", false),
+		BackupDir:        l.getEnv("BACKUP_DIR", "./backups"),
+		BackupInterval:   l.getEnvDuration("BACKUP_INTERVAL", time.Hour),
+		BackupKeepHourly: l.getEnvInt("BACKUP_KEEP_HOURLY", 24),
+		BackupKeepDaily:  l.getEnvInt("BACKUP_KEEP_DAILY", 7),
+		BackupKeepWeekly: l.getEnvInt("BACKUP_KEEP_WEEKLY", 4),
+		Environment:      env,
+		UseHTTPS:         useHTTPS,
+		JWTSecret:        l.getSecret("JWT_SECRET", generateDefaultSecret()),
+		JWTExpiration:    l.getEnvDuration("JWT_EXPIRATION", 24*time.Hour),
+		SiteName:         l.getEnv("SITE_NAME", "Cacto CMS"),
+		SiteDescription:  l.getEnv("SITE_DESCRIPTION", "Performance-focused enterprise CMS"),
+		AllowedOrigins:   getAllowedOrigins(env, baseURL),
+
+		ImpersonationDuration: l.getEnvDuration("IMPERSONATION_DURATION", 30*time.Minute),
+
+		PasskeysEnabled: l.getEnvBool("PASSKEYS_ENABLED", true),
+		WebAuthnRPID:    l.getEnv("WEBAUTHN_RP_ID", hostOf(baseURL)),
+		WebAuthnOrigins: l.getEnvList("WEBAUTHN_ORIGINS", strings.TrimSuffix(baseURL, "/")),
+
+		LoginFreeAttempts:     l.getEnvInt("LOGIN_FREE_ATTEMPTS", 3),
+		LoginBaseDelay:        l.getEnvDuration("LOGIN_BASE_DELAY", time.Second),
+		LoginMaxDelay:         l.getEnvDuration("LOGIN_MAX_DELAY", 30*time.Second),
+		LoginLockoutThreshold: l.getEnvInt("LOGIN_LOCKOUT_THRESHOLD", 10),
+		LoginLockoutDuration:  l.getEnvDuration("LOGIN_LOCKOUT_DURATION", 15*time.Minute),
+		LoginAttemptWindow:    l.getEnvDuration("LOGIN_ATTEMPT_WINDOW", time.Hour),
+
+		PasswordMinLength:     l.getEnvInt("PASSWORD_MIN_LENGTH", 10),
+		PasswordHistory:       l.getEnvInt("PASSWORD_HISTORY", 5),
+		PasswordCheckCommon:   l.getEnvBool("PASSWORD_CHECK_COMMON", true),
+		PasswordBlocklistFile: l.getEnv("PASSWORD_BLOCKLIST_FILE", ""),
+
+		OIDCIssuer:               l.getEnv("OIDC_ISSUER", ""),
+		OIDCClientID:             l.getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:         l.getSecret("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:          l.getEnv("OIDC_REDIRECT_URL", strings.TrimSuffix(baseURL, "/")+"/admin/sso/callback"),
+		OIDCScopes:               l.getEnvList("OIDC_SCOPES", "openid email profile"),
+		OIDCProviderName:         l.getEnv("OIDC_PROVIDER_NAME", "SSO"),
+		OIDCRoleClaim:            l.getEnv("OIDC_ROLE_CLAIM", "groups"),
+		OIDCRoleMapping:          l.getEnv("OIDC_ROLE_MAPPING", ""),
+		OIDCDefaultRole:          l.getEnv("OIDC_DEFAULT_ROLE", ""),
+		OIDCAutoProvision:        l.getEnvBool("OIDC_AUTO_PROVISION", false),
+		OIDCLinkByEmail:          l.getEnvBool("OIDC_LINK_BY_EMAIL", true),
+		OIDCDisablePasswordLogin: l.getEnvBool("OIDC_DISABLE_PASSWORD_LOGIN", false),
 	}
+	cfg.entries = l.entries
 
-	// Warn if using default secret in production
-	if cfg.IsProduction() && cfg.JWTSecret == generateDefaultSecret() {
-		// Log warning (will be handled by logger)
+	problems := l.problems
+	if unknown := l.unknownFileKeys(); len(unknown) > 0 {
+		sort.Strings(unknown)
+		problems = append(problems, fmt.Sprintf("%s: unknown setting(s) %s", configFile, strings.Join(unknown, ", ")))
+	}
+	if len(problems) > 0 {
+		return nil, problemsError("invalid configuration", problems)
 	}
 
-	return cfg
+	return cfg, nil
+}
+
+// Entries returns every setting with its effective value and where it came
+// from, in the order Load reads them. Secrets that are set are redacted.
+func (c *Config) Entries() []Entry {
+	entries := make([]Entry, len(c.entries))
+	copy(entries, c.entries)
+	for i := range entries {
+		if entries[i].Secret && entries[i].Value != "" {
+			entries[i].Value = redacted
+		}
+	}
+	return entries
 }
 
 // getAllowedOrigins returns allowed CORS origins based on environment
@@ -90,25 +236,23 @@ func (c *Config) IsProduction() bool {
 	return c.Environment == "production" || c.Environment == "prod"
 }
 
+// SSOEnabled checks if OpenID Connect sign-in is configured
+func (c *Config) SSOEnabled() bool {
+	return c.OIDCIssuer != "" && c.OIDCClientID != ""
+}
+
 // GetCookieSecure returns whether cookies should be Secure (HTTPS only)
 func (c *Config) GetCookieSecure() bool {
 	return c.UseHTTPS || c.IsProduction()
 }
 
-func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
-		return value
-	}
-	return defaultValue
-}
-
-// getEnvBool gets boolean environment variable
-func getEnvBool(key string, defaultValue bool) bool {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// hostOf returns the host name of a URL without the port
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
 	}
-	return strings.ToLower(value) == "true" || value == "1"
+	return u.Hostname()
 }
 
 // generateDefaultSecret generates a default secret (should be overridden in production)
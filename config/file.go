@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// readFile reads a YAML or TOML config file into upper-case keys named like
+// the environment variables. Nested keys are joined with an underscore, so
+// these are all BACKUP_DIR:
+//
+//	backup_dir: ./backups      # YAML
+//	backup:
+//	  dir: ./backups
+//
+//	[backup]                   # TOML
+//	dir = "./backups"
+//
+// Lists become comma-separated values.
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	var tree map[string]interface{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	if err := flatten(values, "", tree); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// flatten copies a decoded tree into values, joining nested keys
+func flatten(values map[string]string, prefix string, tree map[string]interface{}) error {
+	for key, value := range tree {
+		key = strings.ToUpper(prefix + key)
+		if nested, ok := value.(map[string]interface{}); ok {
+			if err := flatten(values, key+"_", nested); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if _, dup := values[key]; dup {
+			return fmt.Errorf("%s is set twice", key)
+		}
+		switch v := value.(type) {
+		case []interface{}:
+			items := make([]string, 0, len(v))
+			for _, item := range v {
+				items = append(items, fmt.Sprint(item))
+			}
+			values[key] = strings.Join(items, ",")
+		case nil:
+			values[key] = ""
+		default:
+			values[key] = fmt.Sprint(v)
+		}
+	}
+	return nil
+}
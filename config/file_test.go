@@ -0,0 +1,157 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFile(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		want    map[string]string
+		wantErr string
+	}{
+		{
+			name: "yaml with flat and nested keys",
+			file: "cacto.yaml",
+			content: `
+port: 9090
+base_url: https://cms.example.com   # comment
+backup:
+  enabled: true
+  keep:
+    daily: 14
+`,
+			want: map[string]string{
+				"PORT":              "9090",
+				"BASE_URL":          "https://cms.example.com",
+				"BACKUP_ENABLED":    "true",
+				"BACKUP_KEEP_DAILY": "14",
+			},
+		},
+		{
+			name: "yaml lists are comma-separated",
+			file: "cacto.yml",
+			content: `
+oidc:
+  scopes: [openid, email, profile]
+`,
+			want: map[string]string{"OIDC_SCOPES": "openid,email,profile"},
+		},
+		{
+			name: "yaml empty value",
+			file: "cacto.yaml",
+			content: `
+oidc_issuer:
+`,
+			want: map[string]string{"OIDC_ISSUER": ""},
+		},
+		{
+			name: "toml tables, arrays and comments",
+			file: "cacto.toml",
+			content: `
+port = "9090"
+site_name = "Cacto # CMS" # the hash in the string stays
+max_upload_size = 10_485_760
+
+[backup]
+enabled = true
+interval = '30m'
+
+[backup.keep]
+daily = 14
+
+[oidc]
+scopes = [
+  "openid",
+  "email", # trailing comma allowed
+]
+`,
+			want: map[string]string{
+				"PORT":              "9090",
+				"SITE_NAME":         "Cacto # CMS",
+				"MAX_UPLOAD_SIZE":   "10485760",
+				"BACKUP_ENABLED":    "true",
+				"BACKUP_INTERVAL":   "30m",
+				"BACKUP_KEEP_DAILY": "14",
+				"OIDC_SCOPES":       "openid,email",
+			},
+		},
+		{
+			name: "toml inline table",
+			file: "cacto.toml",
+			content: `
+backup = { dir = "./backups", keep = { weekly = 8 } }
+`,
+			want: map[string]string{"BACKUP_DIR": "./backups", "BACKUP_KEEP_WEEKLY": "8"},
+		},
+		{
+			name: "a key set flat and nested",
+			file: "cacto.yaml",
+			content: `
+backup_dir: ./a
+backup:
+  dir: ./b
+`,
+			wantErr: "set twice",
+		},
+		{
+			name:    "invalid toml",
+			file:    "cacto.toml",
+			content: "port = 9090\nport = 9091\n",
+			wantErr: "invalid config file",
+		},
+		{
+			name:    "unquoted toml string",
+			file:    "cacto.toml",
+			content: "base_url = https://cms.example.com\n",
+			wantErr: "invalid config file",
+		},
+		{
+			name:    "invalid yaml",
+			file:    "cacto.yaml",
+			content: "port: [9090\n",
+			wantErr: "invalid config file",
+		},
+		{
+			name:    "unsupported format",
+			file:    "cacto.json",
+			content: `{"port": 9090}`,
+			wantErr: "unsupported format",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := readFile(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("readFile() error = %v, want one containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("readFile() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readFile() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadFileMissing(t *testing.T) {
+	if _, err := readFile(filepath.Join(t.TempDir(), "missing.yaml")); err == nil {
+		t.Error("readFile() of a missing file succeeded")
+	}
+}
@@ -0,0 +1,234 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Entry is one setting as config:show prints it
+type Entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"` // env, file or default, with the *_FILE variable a secret came from
+	Secret bool   `json:"secret,omitempty"`
+}
+
+// redacted replaces the value of secrets that are set
+const redacted = "[redacted]"
+
+// loader resolves each setting from, in order, the environment, the config
+// file and the default. Secrets may instead name a file holding the value
+// in a *_FILE variable. It records where every value came from and which
+// values it couldn't parse.
+type loader struct {
+	file     map[string]string // Config file values by upper-case key
+	used     map[string]bool   // Keys read, to reject unknown ones in the file
+	entries  []Entry
+	problems []string
+}
+
+// newLoader creates a loader over the values of a config file
+func newLoader(file map[string]string) *loader {
+	return &loader{file: file, used: make(map[string]bool)}
+}
+
+// lookup returns the raw value of a key and where it was set
+func (l *loader) lookup(key string) (value, source string, ok bool) {
+	l.used[key] = true
+	if value := os.Getenv(key); value != "" {
+		return value, "env", true
+	}
+	if value := l.file[key]; value != "" {
+		return value, "file", true
+	}
+	return "", "", false
+}
+
+// record notes the effective value of a key
+func (l *loader) record(key, value, source string, secret bool) {
+	l.entries = append(l.entries, Entry{Key: key, Value: value, Source: source, Secret: secret})
+}
+
+// invalid notes a value that couldn't be parsed; the default is used
+func (l *loader) invalid(key, value, want string) {
+	l.problems = append(l.problems, fmt.Sprintf("%s: %q is not a valid %s", key, value, want))
+}
+
+// getEnv returns a string setting
+func (l *loader) getEnv(key, defaultValue string) string {
+	value, source, ok := l.lookup(key)
+	if !ok {
+		value, source = defaultValue, "default"
+	}
+	l.record(key, value, source, false)
+	return value
+}
+
+// getSecret returns a secret setting, read from the file KEY_FILE names
+// when that is set (Docker and Kubernetes secrets). Surrounding whitespace
+// in the file is ignored.
+func (l *loader) getSecret(key, defaultValue string) string {
+	value, source, ok := l.lookup(key)
+	path, pathSource, fromFile := l.lookup(key + "_FILE")
+
+	switch {
+	case ok && fromFile:
+		l.problems = append(l.problems, fmt.Sprintf("%s: set either %s or %s_FILE, not both", key, key, key))
+	case fromFile:
+		data, err := os.ReadFile(path)
+		if err != nil {
+			l.problems = append(l.problems, fmt.Sprintf("%s_FILE: %v", key, err))
+			break
+		}
+		value, source, ok = strings.TrimSpace(string(data)), pathSource+" ("+key+"_FILE)", true
+	}
+
+	if !ok {
+		value, source = defaultValue, "default"
+	}
+	l.record(key, value, source, true)
+	return value
+}
+
+// getEnvBool returns a boolean setting (true/false, 1/0, yes/no)
+func (l *loader) getEnvBool(key string, defaultValue bool) bool {
+	raw, source, ok := l.lookup(key)
+	value := defaultValue
+	if ok {
+		switch strings.ToLower(raw) {
+		case "true", "1", "yes", "on":
+			value = true
+		case "false", "0", "no", "off":
+			value = false
+		default:
+			l.invalid(key, raw, "boolean")
+			ok = false
+		}
+	}
+	if !ok {
+		source = "default"
+	}
+	l.record(key, strconv.FormatBool(value), source, false)
+	return value
+}
+
+// getEnvInt returns an integer setting
+func (l *loader) getEnvInt(key string, defaultValue int) int {
+	raw, source, ok := l.lookup(key)
+	value := defaultValue
+	if ok {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil {
+			l.invalid(key, raw, "number")
+			ok = false
+		} else {
+			value = parsed
+		}
+	}
+	if !ok {
+		source = "default"
+	}
+	l.record(key, strconv.Itoa(value), source, false)
+	return value
+}
+
+// getEnvDuration returns a positive duration setting (e.g. "30m", "2h")
+func (l *loader) getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	raw, source, ok := l.lookup(key)
+	value := defaultValue
+	if ok {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed <= 0 {
+			l.invalid(key, raw, "duration")
+			ok = false
+		} else {
+			value = parsed
+		}
+	}
+	if !ok {
+		source = "default"
+	}
+	l.record(key, value.String(), source, false)
+	return value
+}
+
+// getEnvOptionalDuration returns a duration setting that may be 0 to turn
+// a feature off
+func (l *loader) getEnvOptionalDuration(key string, defaultValue time.Duration) time.Duration {
+	raw, source, ok := l.lookup(key)
+	value := defaultValue
+	if ok {
+		parsed, err := time.ParseDuration(raw)
+		if err != nil || parsed < 0 {
+			l.invalid(key, raw, "duration")
+			ok = false
+		} else {
+			value = parsed
+		}
+	}
+	if !ok {
+		source = "default"
+	}
+	l.record(key, value.String(), source, false)
+	return value
+}
+
+// getEnvSize returns a size in bytes, given as a number of bytes or with a
+// KB, MB or GB suffix (powers of 1024)
+func (l *loader) getEnvSize(key string, defaultValue int64) int64 {
+	raw, source, ok := l.lookup(key)
+	value := defaultValue
+	if ok {
+		parsed, err := parseSize(raw)
+		if err != nil || parsed <= 0 {
+			l.invalid(key, raw, "size")
+			ok = false
+		} else {
+			value = parsed
+		}
+	}
+	if !ok {
+		source = "default"
+	}
+	l.record(key, strconv.FormatInt(value, 10), source, false)
+	return value
+}
+
+// getEnvList returns a list setting, separated by commas or spaces
+func (l *loader) getEnvList(key, defaultValue string) []string {
+	return strings.Fields(strings.ReplaceAll(l.getEnv(key, defaultValue), ",", " "))
+}
+
+// unknownFileKeys returns the config file keys no setting reads
+func (l *loader) unknownFileKeys() []string {
+	var unknown []string
+	for key := range l.file {
+		if !l.used[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	return unknown
+}
+
+// parseSize parses "10485760", "512KB", "10MB" or "1GB"
+func parseSize(s string) (int64, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	multiplier := int64(1)
+	for _, unit := range []struct {
+		suffix string
+		size   int64
+	}{{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10}, {"B", 1}} {
+		if strings.HasSuffix(s, unit.suffix) {
+			s, multiplier = strings.TrimSpace(strings.TrimSuffix(s, unit.suffix)), unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	return n * multiplier, nil
+}
@@ -0,0 +1,79 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// minSecretLength is the shortest JWT secret accepted in production
+const minSecretLength = 32
+
+// Validate checks that the settings make sense together. In production it
+// also rejects insecure settings: the server refuses to start with them.
+func (c *Config) Validate() error {
+	var problems []string
+	add := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	if port, err := strconv.Atoi(c.ServerPort); err != nil || port < 1 || port > 65535 {
+		add("PORT: %q is not a valid port", c.ServerPort)
+	}
+	if u, err := url.Parse(c.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		add("BASE_URL: %q must be an absolute http(s) URL", c.BaseURL)
+	}
+	if c.PasswordMinLength < 1 {
+		add("PASSWORD_MIN_LENGTH: must be at least 1")
+	}
+	if c.LoginFreeAttempts < 0 {
+		add("LOGIN_FREE_ATTEMPTS: must not be negative")
+	}
+	if c.LoginLockoutThreshold < 1 {
+		add("LOGIN_LOCKOUT_THRESHOLD: must be at least 1")
+	}
+	if c.LoginBaseDelay <= 0 || c.LoginMaxDelay < c.LoginBaseDelay {
+		add("LOGIN_BASE_DELAY, LOGIN_MAX_DELAY: must be positive, the maximum at least the base delay")
+	}
+	if c.LoginLockoutDuration <= 0 || c.LoginAttemptWindow <= 0 {
+		add("LOGIN_LOCKOUT_DURATION, LOGIN_ATTEMPT_WINDOW: must be positive")
+	}
+	if c.BackupKeepHourly < 0 || c.BackupKeepDaily < 0 || c.BackupKeepWeekly < 0 {
+		add("BACKUP_KEEP_*: must not be negative")
+	}
+	if c.PageCacheEnabled && c.PageCacheMaxEntries < 1 {
+		add("PAGE_CACHE_MAX_ENTRIES: must be at least 1")
+	}
+	if c.CompressionMinSize < 0 {
+		add("COMPRESSION_MIN_SIZE: must not be negative")
+	}
+	if c.OIDCIssuer != "" && c.OIDCClientID == "" {
+		add("OIDC_CLIENT_ID: required when OIDC_ISSUER is set")
+	}
+
+	if c.IsProduction() {
+		switch {
+		case c.JWTSecret == generateDefaultSecret():
+			add("JWT_SECRET: the default secret can't be used in production (set JWT_SECRET or JWT_SECRET_FILE)")
+		case len(c.JWTSecret) < minSecretLength:
+			add("JWT_SECRET: must be at least %d characters in production", minSecretLength)
+		}
+		if !c.UseHTTPS {
+			add("BASE_URL: must use https in production (cookies are Secure), or set USE_HTTPS=true behind a TLS proxy")
+		}
+		if c.PasswordMinLength < 8 {
+			add("PASSWORD_MIN_LENGTH: must be at least 8 in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return problemsError("insecure or invalid configuration", problems)
+	}
+	return nil
+}
+
+// problemsError lists every problem found, one per line
+func problemsError(summary string, problems []string) error {
+	return fmt.Errorf("%s:\n  - %s", summary, strings.Join(problems, "\n  - "))
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig returns the defaults Load uses in development
+func validConfig() *Config {
+	return &Config{
+		ServerPort:            "8080",
+		BaseURL:               "http://localhost:8080",
+		Environment:           "development",
+		JWTSecret:             generateDefaultSecret(),
+		PageCacheEnabled:      true,
+		PageCacheMaxEntries:   1000,
+		CompressionMinSize:    1024,
+		BackupKeepHourly:      24,
+		BackupKeepDaily:       7,
+		BackupKeepWeekly:      4,
+		LoginFreeAttempts:     3,
+		LoginBaseDelay:        time.Second,
+		LoginMaxDelay:         30 * time.Second,
+		LoginLockoutThreshold: 10,
+		LoginLockoutDuration:  15 * time.Minute,
+		LoginAttemptWindow:    time.Hour,
+		PasswordMinLength:     10,
+	}
+}
+
+// production turns a config into a secure production one
+func production(c *Config) {
+	c.Environment = "production"
+	c.BaseURL = "https://cms.example.com"
+	c.UseHTTPS = true
+	c.JWTSecret = strings.Repeat("s", minSecretLength)
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr []string // Problems the error must name; none when valid
+	}{
+		{name: "development defaults", change: func(c *Config) {}},
+		{name: "secure production", change: production},
+		{
+			name:    "port out of range",
+			change:  func(c *Config) { c.ServerPort = "70000" },
+			wantErr: []string{"PORT"},
+		},
+		{
+			name:    "port not a number",
+			change:  func(c *Config) { c.ServerPort = "http" },
+			wantErr: []string{"PORT"},
+		},
+		{
+			name:    "relative base URL",
+			change:  func(c *Config) { c.BaseURL = "cms.example.com" },
+			wantErr: []string{"BASE_URL"},
+		},
+		{
+			name:    "max delay below the base delay",
+			change:  func(c *Config) { c.LoginMaxDelay = c.LoginBaseDelay / 2 },
+			wantErr: []string{"LOGIN_MAX_DELAY"},
+		},
+		{
+			name:    "no attempt window",
+			change:  func(c *Config) { c.LoginAttemptWindow = 0 },
+			wantErr: []string{"LOGIN_ATTEMPT_WINDOW"},
+		},
+		{
+			name:    "lockout threshold zero",
+			change:  func(c *Config) { c.LoginLockoutThreshold = 0 },
+			wantErr: []string{"LOGIN_LOCKOUT_THRESHOLD"},
+		},
+		{
+			name:    "negative backup retention",
+			change:  func(c *Config) { c.BackupKeepDaily = -1 },
+			wantErr: []string{"BACKUP_KEEP_*"},
+		},
+		{
+			name:    "page cache without entries",
+			change:  func(c *Config) { c.PageCacheMaxEntries = 0 },
+			wantErr: []string{"PAGE_CACHE_MAX_ENTRIES"},
+		},
+		{
+			name:   "page cache disabled without entries",
+			change: func(c *Config) { c.PageCacheEnabled, c.PageCacheMaxEntries = false, 0 },
+		},
+		{
+			name:    "OIDC issuer without client",
+			change:  func(c *Config) { c.OIDCIssuer = "https://id.example.com" },
+			wantErr: []string{"OIDC_CLIENT_ID"},
+		},
+		{
+			name: "every problem is reported",
+			change: func(c *Config) {
+				c.ServerPort = "0"
+				c.PasswordMinLength = 0
+				c.CompressionMinSize = -1
+			},
+			wantErr: []string{"PORT", "PASSWORD_MIN_LENGTH", "COMPRESSION_MIN_SIZE"},
+		},
+		{
+			name: "default secret in production",
+			change: func(c *Config) {
+				production(c)
+				c.JWTSecret = generateDefaultSecret()
+			},
+			wantErr: []string{"JWT_SECRET: the default secret"},
+		},
+		{
+			name: "short secret in production",
+			change: func(c *Config) {
+				production(c)
+				c.JWTSecret = "short"
+			},
+			wantErr: []string{"JWT_SECRET: must be at least"},
+		},
+		{
+			name: "plain http in production",
+			change: func(c *Config) {
+				production(c)
+				c.BaseURL = "http://cms.example.com"
+				c.UseHTTPS = false
+			},
+			wantErr: []string{"BASE_URL: must use https"},
+		},
+		{
+			name: "weak passwords in production",
+			change: func(c *Config) {
+				production(c)
+				c.PasswordMinLength = 6
+			},
+			wantErr: []string{"PASSWORD_MIN_LENGTH: must be at least 8"},
+		},
+		{
+			name:   "weak passwords in development",
+			change: func(c *Config) { c.PasswordMinLength = 6 },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := validConfig()
+			tt.change(c)
+
+			err := c.Validate()
+			if len(tt.wantErr) == 0 {
+				if err != nil {
+					t.Fatalf("Validate() error = %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatalf("Validate() succeeded, want problems with %v", tt.wantErr)
+			}
+			for _, want := range tt.wantErr {
+				if !strings.Contains(err.Error(), want) {
+					t.Errorf("Validate() error = %v, want it to name %q", err, want)
+				}
+			}
+		})
+	}
+}